@@ -0,0 +1,154 @@
+package cqrs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultCheckpointTable 默认检查点表名.
+const DefaultCheckpointTable = "projection_checkpoints"
+
+// CheckpointStore 投影检查点存储接口.
+//
+// 检查点按 (投影, 来源) 保存已处理的最大位置，投影重启后从检查点之后继续处理.
+type CheckpointStore interface {
+	// Load 加载检查点，不存在时返回 0.
+	Load(ctx context.Context, projection, source string) (int64, error)
+
+	// Save 保存检查点.
+	Save(ctx context.Context, projection, source string, position int64) error
+
+	// Reset 删除投影在所有来源的检查点.
+	Reset(ctx context.Context, projection string) error
+}
+
+// MemoryCheckpointStore 内存检查点存储.
+//
+// 适用于测试和每次启动都重建的投影，进程重启后检查点丢失.
+type MemoryCheckpointStore struct {
+	mu        sync.RWMutex
+	positions map[string]map[string]int64
+}
+
+// NewMemoryCheckpointStore 创建内存检查点存储.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{positions: make(map[string]map[string]int64)}
+}
+
+// Load 加载检查点.
+func (s *MemoryCheckpointStore) Load(ctx context.Context, projection, source string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.positions[projection][source], nil
+}
+
+// Save 保存检查点.
+func (s *MemoryCheckpointStore) Save(ctx context.Context, projection, source string, position int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sources, ok := s.positions[projection]
+	if !ok {
+		sources = make(map[string]int64)
+		s.positions[projection] = sources
+	}
+	sources[source] = position
+	return nil
+}
+
+// Reset 删除投影的检查点.
+func (s *MemoryCheckpointStore) Reset(ctx context.Context, projection string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.positions, projection)
+	return nil
+}
+
+// Checkpoint 检查点记录.
+type Checkpoint struct {
+	// Projection 投影名称
+	Projection string `gorm:"column:projection;primaryKey;size:128"`
+
+	// Source 事件来源
+	Source string `gorm:"column:source;primaryKey;size:255"`
+
+	// Position 已处理的最大位置
+	Position int64 `gorm:"column:position;not null"`
+
+	// UpdatedTime 更新时间
+	UpdatedTime time.Time `gorm:"column:updated_time"`
+}
+
+// GORMCheckpointOption GORM 检查点存储配置选项.
+type GORMCheckpointOption func(*GORMCheckpointStore)
+
+// WithCheckpointTable 设置检查点表名.
+func WithCheckpointTable(table string) GORMCheckpointOption {
+	return func(s *GORMCheckpointStore) {
+		s.table = table
+	}
+}
+
+// GORMCheckpointStore 基于 GORM 的检查点存储.
+//
+// 读模型与检查点使用同一个数据库时，可以在处理事件的事务中更新读模型，
+// 检查点在处理成功后保存，投影处理器仍需幂等.
+type GORMCheckpointStore struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewGORMCheckpointStore 创建基于 GORM 的检查点存储.
+func NewGORMCheckpointStore(db *gorm.DB, opts ...GORMCheckpointOption) *GORMCheckpointStore {
+	if db == nil {
+		panic("cqrs: db 不能为空")
+	}
+	s := &GORMCheckpointStore{db: db, table: DefaultCheckpointTable}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// AutoMigrate 创建或更新检查点表结构.
+func (s *GORMCheckpointStore) AutoMigrate(ctx context.Context) error {
+	return s.db.WithContext(ctx).Table(s.table).AutoMigrate(&Checkpoint{})
+}
+
+// Load 加载检查点.
+func (s *GORMCheckpointStore) Load(ctx context.Context, projection, source string) (int64, error) {
+	var positions []int64
+	err := s.db.WithContext(ctx).Table(s.table).
+		Where("projection = ? AND source = ?", projection, source).
+		Limit(1).
+		Pluck("position", &positions).Error
+	if err != nil || len(positions) == 0 {
+		return 0, err
+	}
+	return positions[0], nil
+}
+
+// Save 保存检查点.
+func (s *GORMCheckpointStore) Save(ctx context.Context, projection, source string, position int64) error {
+	return s.db.WithContext(ctx).Table(s.table).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "projection"}, {Name: "source"}},
+			DoUpdates: clause.AssignmentColumns([]string{"position", "updated_time"}),
+		}).
+		Create(&Checkpoint{
+			Projection:  projection,
+			Source:      source,
+			Position:    position,
+			UpdatedTime: time.Now(),
+		}).Error
+}
+
+// Reset 删除投影的检查点.
+func (s *GORMCheckpointStore) Reset(ctx context.Context, projection string) error {
+	return s.db.WithContext(ctx).Table(s.table).
+		Where("projection = ?", projection).
+		Delete(&Checkpoint{}).Error
+}
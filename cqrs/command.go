@@ -1,4 +1,4 @@
-// Package cqrs 实现CQRS模式的命令和查询处理.
+// Package cqrs 实现CQRS模式的命令和查询处理，以及维护读模型的投影.
 package cqrs
 
 import "context"
@@ -0,0 +1,15 @@
+package cqrs
+
+import "errors"
+
+// 预定义错误.
+var (
+	// ErrProjectionNotFound 投影未注册.
+	ErrProjectionNotFound = errors.New("cqrs: 投影未注册")
+
+	// ErrEventStoreRequired 未配置事件存储.
+	ErrEventStoreRequired = errors.New("cqrs: 未配置事件存储")
+
+	// ErrRegistryRequired 未配置事件注册表.
+	ErrRegistryRequired = errors.New("cqrs: 未配置事件注册表")
+)
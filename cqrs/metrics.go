@@ -0,0 +1,56 @@
+package cqrs
+
+import (
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/observability/metrics"
+)
+
+// 投影处理结果.
+const (
+	statusSuccess = "success"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// projectionMetrics 投影指标记录器.
+//
+// 封装 metrics.PrometheusCollector，未设置收集器时不记录.
+type projectionMetrics struct {
+	collector *metrics.PrometheusCollector
+}
+
+// newProjectionMetrics 创建投影指标记录器.
+func newProjectionMetrics(collector *metrics.PrometheusCollector) *projectionMetrics {
+	if collector == nil {
+		return nil
+	}
+	return &projectionMetrics{collector: collector}
+}
+
+// RecordHandle 记录事件处理结果和耗时.
+func (m *projectionMetrics) RecordHandle(projection, status string, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.collector.Counter("projection_events_total", map[string]string{"projection": projection, "status": status})
+	if status != statusSkipped {
+		m.collector.Histogram("projection_handle_duration_seconds", latency.Seconds(), map[string]string{"projection": projection})
+	}
+}
+
+// RecordPosition 记录投影在来源中的检查点位置.
+func (m *projectionMetrics) RecordPosition(projection, source string, position int64) {
+	if m == nil {
+		return
+	}
+	m.collector.Gauge("projection_position", float64(position), map[string]string{"projection": projection, "source": source})
+}
+
+// RecordLag 记录投影延迟，即最近处理的事件发生至今的时间.
+func (m *projectionMetrics) RecordLag(projection string, lag time.Duration) {
+	if m == nil {
+		return
+	}
+	m.collector.Gauge("projection_lag_seconds", lag.Seconds(), map[string]string{"projection": projection})
+}
@@ -0,0 +1,102 @@
+package cqrs
+
+import (
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/domain"
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/observability/metrics"
+)
+
+// RunnerOption ProjectionRunner 配置选项.
+type RunnerOption func(*runnerOptions)
+
+// runnerOptions ProjectionRunner 配置.
+type runnerOptions struct {
+	registry     *domain.EventRegistry
+	store        domain.EventStore
+	collector    *metrics.PrometheusCollector
+	logger       logger.Logger
+	pollInterval time.Duration
+	batchSize    int
+	gapTimeout   time.Duration
+}
+
+// applyRunnerOptions 应用 ProjectionRunner 配置选项.
+func applyRunnerOptions(opts []RunnerOption) *runnerOptions {
+	o := &runnerOptions{
+		pollInterval: time.Second,
+		batchSize:    100,
+		gapTimeout:   10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithEventRegistry 设置事件注册表，用于反序列化事件存储和消息队列中的事件.
+func WithEventRegistry(registry *domain.EventRegistry) RunnerOption {
+	return func(o *runnerOptions) {
+		o.registry = registry
+	}
+}
+
+// WithEventStore 设置事件存储.
+//
+// 设置后 Start 按全局位置轮询事件存储，Rebuild 从事件存储重放事件，需要同时设置 WithEventRegistry.
+func WithEventStore(store domain.EventStore) RunnerOption {
+	return func(o *runnerOptions) {
+		o.store = store
+	}
+}
+
+// WithMetrics 设置指标收集器，记录投影的处理数量、耗时、位置和延迟.
+func WithMetrics(collector *metrics.PrometheusCollector) RunnerOption {
+	return func(o *runnerOptions) {
+		o.collector = collector
+	}
+}
+
+// WithLogger 设置日志记录器.
+func WithLogger(log logger.Logger) RunnerOption {
+	return func(o *runnerOptions) {
+		o.logger = log
+	}
+}
+
+// WithPollInterval 设置事件存储的轮询间隔.
+//
+// 默认: 1 秒.
+func WithPollInterval(d time.Duration) RunnerOption {
+	return func(o *runnerOptions) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// WithBatchSize 设置每次从事件存储读取的事件数量.
+//
+// 默认: 100.
+func WithBatchSize(n int) RunnerOption {
+	return func(o *runnerOptions) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithGapTimeout 设置等待事件存储中空洞位置被填补的最长时间.
+//
+// 并发追加的事务可能乱序提交，较小的位置稍后才可见. 超时后空洞视为回滚留下的永久空洞，
+// 检查点越过空洞继续推进. 超时时间应大于追加事件的事务的最长执行时间.
+//
+// 默认: 10 秒.
+func WithGapTimeout(d time.Duration) RunnerOption {
+	return func(o *runnerOptions) {
+		if d > 0 {
+			o.gapTimeout = d
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package cqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/domain"
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+	"github.com/Tsukikage7/microservice-kit/outbox"
+)
+
+// 内置事件来源.
+const (
+	// SourceEventStore 事件存储，位置为事件的全局位置
+	SourceEventStore = "event-store"
+
+	// SourceEventBus 进程内事件总线，没有位置，不保存检查点
+	SourceEventBus = "event-bus"
+)
+
+// EventEnvelope 投影处理的事件及其来源位置.
+type EventEnvelope struct {
+	// Event 领域事件
+	Event domain.DomainEvent
+
+	// Source 事件来源，如 SourceEventStore 或消息队列的 "topic/partition"
+	Source string
+
+	// Position 事件在来源中的位置，同一来源内递增. 0 表示来源没有位置，不做去重和检查点
+	Position int64
+
+	// StreamID 事件流标识，仅事件存储来源设置
+	StreamID string
+
+	// OccurredTime 事件发生时间，用于计算投影延迟
+	OccurredTime time.Time
+}
+
+// Projection 投影，将领域事件应用到读模型.
+//
+// 检查点在 Handle 成功后保存，进程崩溃或消息重投时事件可能被重复处理，Handle 需要幂等.
+type Projection interface {
+	// Name 投影名称，作为检查点的键，需要唯一且稳定.
+	Name() string
+
+	// Handle 处理事件，不关心的事件直接返回 nil.
+	Handle(ctx context.Context, env *EventEnvelope) error
+
+	// Reset 清空读模型，重建时调用.
+	Reset(ctx context.Context) error
+}
+
+// funcProjection 基于函数的投影.
+type funcProjection struct {
+	name   string
+	handle func(ctx context.Context, env *EventEnvelope) error
+	reset  func(ctx context.Context) error
+}
+
+// NewProjection 基于函数创建投影，reset 为空时重建不清空读模型.
+func NewProjection(name string, handle func(ctx context.Context, env *EventEnvelope) error, reset func(ctx context.Context) error) Projection {
+	if name == "" {
+		panic("cqrs: 投影名称不能为空")
+	}
+	if handle == nil {
+		panic("cqrs: handle 不能为空")
+	}
+	return &funcProjection{name: name, handle: handle, reset: reset}
+}
+
+func (p *funcProjection) Name() string { return p.name }
+
+func (p *funcProjection) Handle(ctx context.Context, env *EventEnvelope) error {
+	return p.handle(ctx, env)
+}
+
+func (p *funcProjection) Reset(ctx context.Context) error {
+	if p.reset == nil {
+		return nil
+	}
+	return p.reset(ctx)
+}
+
+// projectionState 已注册投影的运行状态.
+type projectionState struct {
+	projection Projection
+
+	// mu 串行化同一个投影的事件处理和重建
+	mu sync.Mutex
+
+	// positions 按来源缓存的检查点
+	positions map[string]int64
+
+	// gapPosition 事件存储中等待填补的空洞位置，gapSince 为首次发现的时间
+	gapPosition int64
+	gapSince    time.Time
+}
+
+// ProjectionRunner 投影运行器，将领域事件分发给已注册的投影并维护检查点.
+//
+// 事件来源:
+//   - SubscribeTo: 订阅 domain.EventBus，事件没有位置，不保存检查点
+//   - MessageHandler: 消费 outbox 发布到消息队列的事件，按 topic/partition 保存偏移量检查点
+//   - Start: 配置 WithEventStore 时按全局位置轮询事件存储
+//
+// 同一个投影通常只使用一种来源，多种来源的事件会分别处理.
+// Rebuild 清空读模型和检查点后从事件存储重放全部事件.
+//
+// 事件存储的全局位置可能存在空洞（见 domain.EventStore.ReadAll），读到空洞时检查点停在空洞之前，
+// 等待较小位置的事件提交. 空洞持续超过 WithGapTimeout 或空洞之后的事件发生时间早于超时时间时，
+// 视为回滚留下的永久空洞并跳过.
+//
+// ProjectionRunner 实现 app.Server，可直接注册到 app.Application.
+//
+// 示例:
+//
+//	runner := cqrs.NewProjectionRunner(checkpoints,
+//	    cqrs.WithEventStore(store),
+//	    cqrs.WithEventRegistry(registry),
+//	    cqrs.WithMetrics(collector),
+//	)
+//	runner.Register(cqrs.NewProjection("order-summary", handle, reset))
+//	application.Use(runner)
+type ProjectionRunner struct {
+	checkpoints CheckpointStore
+	opts        *runnerOptions
+	metrics     *projectionMetrics
+
+	mu          sync.RWMutex
+	projections []*projectionState
+	names       map[string]*projectionState
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+// NewProjectionRunner 创建投影运行器.
+func NewProjectionRunner(checkpoints CheckpointStore, opts ...RunnerOption) *ProjectionRunner {
+	if checkpoints == nil {
+		panic("cqrs: checkpoints 不能为空")
+	}
+	o := applyRunnerOptions(opts)
+	return &ProjectionRunner{
+		checkpoints: checkpoints,
+		opts:        o,
+		metrics:     newProjectionMetrics(o.collector),
+		names:       make(map[string]*projectionState),
+		stopCh:      make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Register 注册投影，名称重复时 panic.
+func (r *ProjectionRunner) Register(projections ...Projection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range projections {
+		if p == nil {
+			panic("cqrs: projection 不能为空")
+		}
+		if _, ok := r.names[p.Name()]; ok {
+			panic(fmt.Sprintf("cqrs: 投影 %s 重复注册", p.Name()))
+		}
+		st := &projectionState{projection: p, positions: make(map[string]int64)}
+		r.projections = append(r.projections, st)
+		r.names[p.Name()] = st
+	}
+}
+
+// Dispatch 将事件分发给所有投影.
+//
+// 位置不大于投影检查点的事件视为已处理，直接跳过. 某个投影处理失败不影响其他投影，
+// 返回所有失败投影的错误.
+func (r *ProjectionRunner) Dispatch(ctx context.Context, env *EventEnvelope) error {
+	var errs []error
+	for _, st := range r.states() {
+		if err := r.handle(ctx, st, env); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SubscribeTo 订阅事件总线上的所有事件.
+func (r *ProjectionRunner) SubscribeTo(bus *domain.EventBus) {
+	bus.SubscribeAll(func(ctx context.Context, event domain.DomainEvent) error {
+		return r.Dispatch(ctx, &EventEnvelope{
+			Event:        event,
+			Source:       SourceEventBus,
+			OccurredTime: event.OccurredTime(),
+		})
+	})
+}
+
+// MessageHandler 返回消费 outbox 发布事件的消息处理器，需要配置 WithEventRegistry.
+//
+// 按 outbox.HeaderEventName 反序列化事件，未注册的事件直接确认. 来源为 "topic/partition"，
+// 位置为偏移量加 1，因此消息重投时已处理的事件会被跳过.
+func (r *ProjectionRunner) MessageHandler(ctx context.Context) messaging.MessageHandler {
+	if r.opts.registry == nil {
+		panic("cqrs: MessageHandler 需要配置 WithEventRegistry")
+	}
+	return func(msg *messaging.Message) error {
+		name := msg.Headers[outbox.HeaderEventName]
+		if !r.opts.registry.Registered(name) {
+			r.logDebug("[Projection] 忽略未注册的事件", name, msg.Topic)
+			return nil
+		}
+
+		occurred := msg.Timestamp
+		if t, err := time.Parse(time.RFC3339Nano, msg.Headers[outbox.HeaderOccurredTime]); err == nil {
+			occurred = t
+		}
+		event, err := r.opts.registry.Decode(&domain.StoredEvent{
+			EventName:    name,
+			Payload:      msg.Value,
+			OccurredTime: occurred,
+		})
+		if err != nil {
+			return err
+		}
+
+		return r.Dispatch(ctx, &EventEnvelope{
+			Event:        event,
+			Source:       fmt.Sprintf("%s/%d", msg.Topic, msg.Partition),
+			Position:     msg.Offset + 1,
+			OccurredTime: occurred,
+		})
+	}
+}
+
+// CatchUp 从事件存储处理所有投影检查点之后的事件，返回处理的事件数量.
+func (r *ProjectionRunner) CatchUp(ctx context.Context) (int, error) {
+	if err := r.requireStore(); err != nil {
+		return 0, err
+	}
+
+	var (
+		total int
+		errs  []error
+	)
+	for _, st := range r.states() {
+		st.mu.Lock()
+		n, err := r.catchUp(ctx, st)
+		st.mu.Unlock()
+		total += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// Rebuild 重建投影：清空读模型和检查点，从事件存储的起点重放全部事件.
+//
+// 重建期间该投影的其他事件等待重建完成后处理.
+func (r *ProjectionRunner) Rebuild(ctx context.Context, name string) error {
+	if err := r.requireStore(); err != nil {
+		return err
+	}
+	r.mu.RLock()
+	st, ok := r.names[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrProjectionNotFound, name)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	start := time.Now()
+	if err := st.projection.Reset(ctx); err != nil {
+		return fmt.Errorf("cqrs: 重置投影 %s: %w", name, err)
+	}
+	if err := r.checkpoints.Reset(ctx, name); err != nil {
+		return err
+	}
+	clear(st.positions)
+	st.gapPosition, st.gapSince = 0, time.Time{}
+
+	n, err := r.catchUp(ctx, st)
+	if err != nil {
+		return err
+	}
+	if r.opts.logger != nil {
+		r.opts.logger.With(
+			logger.String("projection", name),
+			logger.Int("events", n),
+			logger.Duration("elapsed", time.Since(start)),
+		).Info("[Projection] 投影重建完成")
+	}
+	return nil
+}
+
+// Start 轮询事件存储，阻塞直到 ctx 取消或调用 Stop.
+//
+// 未配置事件存储时只等待停止，事件由 SubscribeTo 或 MessageHandler 投递.
+func (r *ProjectionRunner) Start(ctx context.Context) error {
+	if !r.started.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer close(r.done)
+
+	if r.opts.logger != nil {
+		r.opts.logger.Info("[Projection] 投影运行器启动")
+	}
+
+	ticker := time.NewTicker(r.opts.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if r.opts.store != nil {
+			if _, err := r.CatchUp(ctx); err != nil && ctx.Err() == nil && r.opts.logger != nil {
+				r.opts.logger.With(logger.Err(err)).Error("[Projection] 处理事件存储失败")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.stopCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop 停止轮询，等待正在处理的批次完成.
+func (r *ProjectionRunner) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+	if !r.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-r.done:
+		if r.opts.logger != nil {
+			r.opts.logger.Info("[Projection] 投影运行器停止")
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name 返回服务名称.
+func (r *ProjectionRunner) Name() string { return "projection-runner" }
+
+// Addr 返回服务地址（无监听地址）.
+func (r *ProjectionRunner) Addr() string { return "" }
+
+// states 返回已注册投影的快照.
+func (r *ProjectionRunner) states() []*projectionState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*projectionState(nil), r.projections...)
+}
+
+// requireStore 检查重放事件存储所需的配置.
+func (r *ProjectionRunner) requireStore() error {
+	if r.opts.store == nil {
+		return ErrEventStoreRequired
+	}
+	if r.opts.registry == nil {
+		return ErrRegistryRequired
+	}
+	return nil
+}
+
+// handle 由单个投影处理事件.
+func (r *ProjectionRunner) handle(ctx context.Context, st *projectionState, env *EventEnvelope) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return r.handleLocked(ctx, st, env)
+}
+
+// handleLocked 由单个投影处理事件，调用方持有 st.mu.
+func (r *ProjectionRunner) handleLocked(ctx context.Context, st *projectionState, env *EventEnvelope) error {
+	name := st.projection.Name()
+	if env.Position > 0 {
+		checkpoint, err := r.checkpoint(ctx, st, env.Source)
+		if err != nil {
+			return err
+		}
+		if env.Position <= checkpoint {
+			r.metrics.RecordHandle(name, statusSkipped, 0)
+			return nil
+		}
+	}
+
+	start := time.Now()
+	if err := st.projection.Handle(ctx, env); err != nil {
+		r.metrics.RecordHandle(name, statusFailed, time.Since(start))
+		return fmt.Errorf("cqrs: 投影 %s 处理事件 %s: %w", name, env.Event.EventName(), err)
+	}
+	r.metrics.RecordHandle(name, statusSuccess, time.Since(start))
+	if !env.OccurredTime.IsZero() {
+		r.metrics.RecordLag(name, time.Since(env.OccurredTime))
+	}
+
+	if env.Position > 0 {
+		return r.commit(ctx, st, env.Source, env.Position)
+	}
+	return nil
+}
+
+// catchUp 按批读取事件存储直到没有新事件或遇到未超时的空洞，调用方持有 st.mu.
+func (r *ProjectionRunner) catchUp(ctx context.Context, st *projectionState) (int, error) {
+	name := st.projection.Name()
+	var handled int
+	for {
+		if err := ctx.Err(); err != nil {
+			return handled, err
+		}
+
+		after, err := r.checkpoint(ctx, st, SourceEventStore)
+		if err != nil {
+			return handled, err
+		}
+		events, err := r.opts.store.ReadAll(ctx, after, r.opts.batchSize)
+		if err != nil {
+			return handled, err
+		}
+		if len(events) == 0 {
+			r.metrics.RecordLag(name, 0)
+			return handled, nil
+		}
+
+		last, blocked := after, false
+		for _, stored := range events {
+			if stored.Position > last+1 && !r.skipGap(st, last+1, stored) {
+				blocked = true
+				break
+			}
+			last = stored.Position
+
+			// 未注册的事件不关心，只推进检查点
+			if !r.opts.registry.Registered(stored.EventName) {
+				continue
+			}
+			event, err := r.opts.registry.Decode(stored)
+			if err != nil {
+				return handled, err
+			}
+			err = r.handleLocked(ctx, st, &EventEnvelope{
+				Event:        event,
+				Source:       SourceEventStore,
+				Position:     stored.Position,
+				StreamID:     stored.StreamID,
+				OccurredTime: stored.OccurredTime,
+			})
+			if err != nil {
+				return handled, err
+			}
+			handled++
+		}
+
+		if last > st.positions[SourceEventStore] {
+			if err := r.commit(ctx, st, SourceEventStore, last); err != nil {
+				return handled, err
+			}
+		}
+		if blocked || len(events) < r.opts.batchSize {
+			return handled, nil
+		}
+	}
+}
+
+// skipGap 判断是否跳过从 missing 到 next 之前的空洞.
+//
+// 空洞首次发现后开始计时，持续超过 gapTimeout，或 next 的发生时间早于 gapTimeout 之前时跳过.
+// 后者用于重建时快速越过历史上回滚留下的空洞.
+func (r *ProjectionRunner) skipGap(st *projectionState, missing int64, next *domain.StoredEvent) bool {
+	now := time.Now()
+	if st.gapPosition != missing {
+		st.gapPosition, st.gapSince = missing, now
+	}
+	if now.Sub(st.gapSince) < r.opts.gapTimeout &&
+		(next.OccurredTime.IsZero() || now.Sub(next.OccurredTime) < r.opts.gapTimeout) {
+		return false
+	}
+
+	if r.opts.logger != nil {
+		r.opts.logger.With(
+			logger.String("projection", st.projection.Name()),
+			logger.Int64("from", missing),
+			logger.Int64("to", next.Position-1),
+		).Warn("[Projection] 跳过事件存储中的空洞位置")
+	}
+	st.gapPosition, st.gapSince = 0, time.Time{}
+	return true
+}
+
+// checkpoint 返回投影在来源中的检查点，首次访问时从 CheckpointStore 加载.
+func (r *ProjectionRunner) checkpoint(ctx context.Context, st *projectionState, source string) (int64, error) {
+	if position, ok := st.positions[source]; ok {
+		return position, nil
+	}
+	position, err := r.checkpoints.Load(ctx, st.projection.Name(), source)
+	if err != nil {
+		return 0, err
+	}
+	st.positions[source] = position
+	return position, nil
+}
+
+// commit 保存检查点.
+func (r *ProjectionRunner) commit(ctx context.Context, st *projectionState, source string, position int64) error {
+	name := st.projection.Name()
+	if err := r.checkpoints.Save(ctx, name, source, position); err != nil {
+		return err
+	}
+	st.positions[source] = position
+	r.metrics.RecordPosition(name, source, position)
+	return nil
+}
+
+// logDebug 记录调试日志.
+func (r *ProjectionRunner) logDebug(msg, eventName, topic string) {
+	if r.opts.logger == nil {
+		return
+	}
+	r.opts.logger.With(
+		logger.String("event", eventName),
+		logger.String("topic", topic),
+	).Debug(msg)
+}
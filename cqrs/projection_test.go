@@ -0,0 +1,489 @@
+package cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/Tsukikage7/microservice-kit/domain"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+	"github.com/Tsukikage7/microservice-kit/outbox"
+)
+
+// orderPlaced 测试用领域事件.
+type orderPlaced struct {
+	domain.BaseEvent
+	OrderID string `json:"order_id"`
+	Amount  int64  `json:"amount"`
+}
+
+func newOrderPlaced(orderID string, amount int64) orderPlaced {
+	return orderPlaced{BaseEvent: domain.NewBaseEvent("OrderPlaced"), OrderID: orderID, Amount: amount}
+}
+
+// orderTotals 测试用读模型，按订单汇总金额.
+type orderTotals struct {
+	mu      sync.Mutex
+	totals  map[string]int64
+	handled int
+	failOn  string
+}
+
+func newOrderTotals() *orderTotals {
+	return &orderTotals{totals: make(map[string]int64)}
+}
+
+func (m *orderTotals) projection() Projection {
+	return NewProjection("order-totals",
+		func(_ context.Context, env *EventEnvelope) error {
+			e, ok := env.Event.(orderPlaced)
+			if !ok {
+				return nil
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if e.OrderID == m.failOn {
+				return errors.New("read model unavailable")
+			}
+			m.totals[e.OrderID] += e.Amount
+			m.handled++
+			return nil
+		},
+		func(context.Context) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.totals = make(map[string]int64)
+			return nil
+		},
+	)
+}
+
+func (m *orderTotals) total(orderID string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.totals[orderID]
+}
+
+func newTestRegistry() *domain.EventRegistry {
+	registry := domain.NewEventRegistry()
+	registry.Register(newOrderPlaced("", 0))
+	return registry
+}
+
+func appendEvents(t *testing.T, store domain.EventStore, registry *domain.EventRegistry, streamID string, events ...domain.DomainEvent) {
+	t.Helper()
+	ctx := context.Background()
+	current, err := store.Load(ctx, streamID, 0)
+	require.NoError(t, err)
+
+	stored := make([]*domain.StoredEvent, len(events))
+	for i, event := range events {
+		stored[i], err = registry.Encode(event)
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Append(ctx, streamID, int64(len(current)), stored...))
+}
+
+func newTestGORMCheckpointStore(t *testing.T) *GORMCheckpointStore {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store := NewGORMCheckpointStore(db)
+	require.NoError(t, store.AutoMigrate(context.Background()))
+	return store
+}
+
+func TestCheckpointStore(t *testing.T) {
+	stores := map[string]CheckpointStore{
+		"memory": NewMemoryCheckpointStore(),
+		"gorm":   newTestGORMCheckpointStore(t),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			position, err := store.Load(ctx, "p1", SourceEventStore)
+			require.NoError(t, err)
+			assert.Zero(t, position)
+
+			require.NoError(t, store.Save(ctx, "p1", SourceEventStore, 5))
+			require.NoError(t, store.Save(ctx, "p1", SourceEventStore, 8))
+			require.NoError(t, store.Save(ctx, "p1", "orders/0", 3))
+			require.NoError(t, store.Save(ctx, "p2", SourceEventStore, 2))
+
+			position, err = store.Load(ctx, "p1", SourceEventStore)
+			require.NoError(t, err)
+			assert.Equal(t, int64(8), position)
+
+			require.NoError(t, store.Reset(ctx, "p1"))
+			position, err = store.Load(ctx, "p1", "orders/0")
+			require.NoError(t, err)
+			assert.Zero(t, position)
+			position, err = store.Load(ctx, "p2", SourceEventStore)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), position, "其他投影的检查点不受影响")
+		})
+	}
+}
+
+func TestProjectionRunner_EventStore(t *testing.T) {
+	ctx := context.Background()
+	store := domain.NewMemoryEventStore()
+	registry := newTestRegistry()
+	checkpoints := newTestGORMCheckpointStore(t)
+
+	appendEvents(t, store, registry, "order-1", newOrderPlaced("1", 100), newOrderPlaced("1", 50))
+	appendEvents(t, store, registry, "order-2", newOrderPlaced("2", 30))
+	// 未注册的事件只推进检查点
+	require.NoError(t, store.Append(ctx, "audit", 0, &domain.StoredEvent{EventName: "Audited", Payload: []byte(`{}`)}))
+
+	model := newOrderTotals()
+	runner := NewProjectionRunner(checkpoints, WithEventStore(store), WithEventRegistry(registry), WithBatchSize(2))
+	runner.Register(model.projection())
+
+	n, err := runner.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, int64(150), model.total("1"))
+	assert.Equal(t, int64(30), model.total("2"))
+
+	position, err := checkpoints.Load(ctx, "order-totals", SourceEventStore)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), position)
+
+	// 新的运行器从检查点继续
+	appendEvents(t, store, registry, "order-2", newOrderPlaced("2", 20))
+	runner = NewProjectionRunner(checkpoints, WithEventStore(store), WithEventRegistry(registry))
+	runner.Register(model.projection())
+	n, err = runner.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int64(50), model.total("2"))
+
+	// 处理失败时检查点停在失败事件之前，修复后重试
+	appendEvents(t, store, registry, "order-3", newOrderPlaced("3", 10))
+	model.failOn = "3"
+	_, err = runner.CatchUp(ctx)
+	assert.Error(t, err)
+	model.failOn = ""
+	n, err = runner.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int64(10), model.total("3"))
+}
+
+func TestProjectionRunner_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	store := domain.NewMemoryEventStore()
+	registry := newTestRegistry()
+	appendEvents(t, store, registry, "order-1", newOrderPlaced("1", 100), newOrderPlaced("1", 50))
+
+	model := newOrderTotals()
+	runner := NewProjectionRunner(NewMemoryCheckpointStore(), WithEventStore(store), WithEventRegistry(registry))
+	runner.Register(model.projection())
+
+	_, err := runner.CatchUp(ctx)
+	require.NoError(t, err)
+
+	// 读模型损坏后重建
+	model.mu.Lock()
+	model.totals["1"] = 999
+	model.mu.Unlock()
+	require.NoError(t, runner.Rebuild(ctx, "order-totals"))
+	assert.Equal(t, int64(150), model.total("1"))
+	assert.Equal(t, 4, model.handled)
+
+	assert.ErrorIs(t, runner.Rebuild(ctx, "missing"), ErrProjectionNotFound)
+
+	noStore := NewProjectionRunner(NewMemoryCheckpointStore())
+	noStore.Register(newOrderTotals().projection())
+	assert.ErrorIs(t, noStore.Rebuild(ctx, "order-totals"), ErrEventStoreRequired)
+}
+
+// gapEventStore 模拟数据库自增位置的事件存储：位置在追加时分配，提交后才可见.
+type gapEventStore struct {
+	mu     sync.Mutex
+	events []*domain.StoredEvent
+	hidden map[int64]bool
+
+	// commitDelay 分配位置到提交之间的延迟
+	commitDelay func()
+}
+
+func newGapEventStore() *gapEventStore {
+	return &gapEventStore{hidden: make(map[int64]bool)}
+}
+
+func (s *gapEventStore) Append(_ context.Context, streamID string, expectedVersion int64, events ...*domain.StoredEvent) error {
+	s.mu.Lock()
+	for i, event := range events {
+		event.StreamID = streamID
+		event.Version = expectedVersion + int64(i) + 1
+		event.Position = int64(len(s.events)) + 1
+		s.events = append(s.events, event)
+		s.hidden[event.Position] = true
+	}
+	s.mu.Unlock()
+
+	if s.commitDelay != nil {
+		s.commitDelay()
+	}
+	for _, event := range events {
+		s.setHidden(event.Position, false)
+	}
+	return nil
+}
+
+func (s *gapEventStore) Load(_ context.Context, streamID string, afterVersion int64) ([]*domain.StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []*domain.StoredEvent
+	for _, event := range s.events {
+		if event.StreamID == streamID && event.Version > afterVersion && !s.hidden[event.Position] {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *gapEventStore) ReadAll(_ context.Context, afterPosition int64, limit int) ([]*domain.StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []*domain.StoredEvent
+	for _, event := range s.events {
+		if event.Position > afterPosition && !s.hidden[event.Position] {
+			events = append(events, event)
+			if limit > 0 && len(events) == limit {
+				break
+			}
+		}
+	}
+	return events, nil
+}
+
+// setHidden 设置位置是否可见，模拟事务未提交或已回滚.
+func (s *gapEventStore) setHidden(position int64, hidden bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hidden[position] = hidden
+}
+
+func TestProjectionRunner_EventStoreGap(t *testing.T) {
+	ctx := context.Background()
+	store := newGapEventStore()
+	registry := newTestRegistry()
+	checkpoints := NewMemoryCheckpointStore()
+	model := newOrderTotals()
+	runner := NewProjectionRunner(checkpoints,
+		WithEventStore(store),
+		WithEventRegistry(registry),
+		WithGapTimeout(100*time.Millisecond),
+	)
+	runner.Register(model.projection())
+
+	appendEvents(t, store, registry, "order-1", newOrderPlaced("1", 100))
+	appendEvents(t, store, registry, "order-2", newOrderPlaced("2", 30))
+	appendEvents(t, store, registry, "order-3", newOrderPlaced("3", 10))
+
+	// 位置 2 尚未提交，检查点停在空洞之前
+	store.setHidden(2, true)
+	n, err := runner.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	position, err := checkpoints.Load(ctx, "order-totals", SourceEventStore)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), position)
+
+	// 位置 2 提交后继续处理
+	store.setHidden(2, false)
+	n, err = runner.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(30), model.total("2"))
+	assert.Equal(t, int64(10), model.total("3"))
+
+	// 位置 4 回滚，超时后越过空洞
+	appendEvents(t, store, registry, "order-4", newOrderPlaced("4", 5))
+	appendEvents(t, store, registry, "order-5", newOrderPlaced("5", 7))
+	store.setHidden(4, true)
+	n, err = runner.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	time.Sleep(150 * time.Millisecond)
+	n, err = runner.CatchUp(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int64(7), model.total("5"))
+	position, err = checkpoints.Load(ctx, "order-totals", SourceEventStore)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), position)
+
+	// 重建时空洞之后的历史事件早于超时时间，直接越过
+	slow := NewProjectionRunner(NewMemoryCheckpointStore(),
+		WithEventStore(store),
+		WithEventRegistry(registry),
+		WithGapTimeout(time.Hour),
+	)
+	rebuilt := newOrderTotals()
+	slow.Register(rebuilt.projection())
+	store.mu.Lock()
+	for _, event := range store.events {
+		event.OccurredTime = event.OccurredTime.Add(-2 * time.Hour)
+	}
+	store.mu.Unlock()
+	require.NoError(t, slow.Rebuild(ctx, "order-totals"))
+	assert.Equal(t, 4, rebuilt.handled)
+}
+
+func TestProjectionRunner_ConcurrentAppends(t *testing.T) {
+	ctx := context.Background()
+	store := newGapEventStore()
+	// 追加的事务乱序提交
+	var delay atomic.Int64
+	store.commitDelay = func() {
+		if delay.Add(1)%3 == 0 {
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+	registry := newTestRegistry()
+	model := newOrderTotals()
+	runner := NewProjectionRunner(NewMemoryCheckpointStore(), WithEventStore(store), WithEventRegistry(registry))
+	runner.Register(model.projection())
+
+	const perStream = 100
+	var wg sync.WaitGroup
+	for _, orderID := range []string{"1", "2"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perStream {
+				stored, err := registry.Encode(newOrderPlaced(orderID, 1))
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.NoError(t, store.Append(ctx, "order-"+orderID, int64(i), stored))
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		_, err := runner.CatchUp(ctx)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(perStream), model.total("1"))
+	assert.Equal(t, int64(perStream), model.total("2"))
+	assert.Equal(t, 2*perStream, model.handled)
+}
+
+func TestProjectionRunner_EventBus(t *testing.T) {
+	ctx := context.Background()
+	bus := domain.NewEventBus()
+	model := newOrderTotals()
+	runner := NewProjectionRunner(NewMemoryCheckpointStore())
+	runner.Register(model.projection())
+	runner.SubscribeTo(bus)
+
+	require.NoError(t, bus.Publish(ctx, newOrderPlaced("1", 100)))
+	require.NoError(t, bus.Publish(ctx, newOrderPlaced("1", 20)))
+	assert.Equal(t, int64(120), model.total("1"))
+
+	model.failOn = "2"
+	assert.Error(t, bus.Publish(ctx, newOrderPlaced("2", 10)))
+}
+
+func TestProjectionRunner_MessageHandler(t *testing.T) {
+	ctx := context.Background()
+	checkpoints := NewMemoryCheckpointStore()
+	model := newOrderTotals()
+	runner := NewProjectionRunner(checkpoints, WithEventRegistry(newTestRegistry()))
+	runner.Register(model.projection())
+	handler := runner.MessageHandler(ctx)
+
+	newMessage := func(offset int64, event orderPlaced) *messaging.Message {
+		value, err := json.Marshal(event)
+		require.NoError(t, err)
+		return &messaging.Message{
+			Topic:     "orders",
+			Partition: 1,
+			Offset:    offset,
+			Value:     value,
+			Headers: map[string]string{
+				outbox.HeaderEventName:    event.EventName(),
+				outbox.HeaderOccurredTime: event.OccurredTime().Format(time.RFC3339Nano),
+			},
+		}
+	}
+
+	require.NoError(t, handler(newMessage(0, newOrderPlaced("1", 100))))
+	require.NoError(t, handler(newMessage(1, newOrderPlaced("1", 50))))
+	// 重投的消息按偏移量跳过
+	require.NoError(t, handler(newMessage(1, newOrderPlaced("1", 50))))
+	assert.Equal(t, int64(150), model.total("1"))
+
+	position, err := checkpoints.Load(ctx, "order-totals", "orders/1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), position)
+
+	// 未注册的事件直接确认
+	require.NoError(t, handler(&messaging.Message{Topic: "orders", Offset: 2, Headers: map[string]string{outbox.HeaderEventName: "Unknown"}}))
+
+	assert.Panics(t, func() { NewProjectionRunner(checkpoints).MessageHandler(ctx) })
+}
+
+func TestProjectionRunner_Start(t *testing.T) {
+	store := domain.NewMemoryEventStore()
+	registry := newTestRegistry()
+	model := newOrderTotals()
+	runner := NewProjectionRunner(NewMemoryCheckpointStore(),
+		WithEventStore(store),
+		WithEventRegistry(registry),
+		WithPollInterval(10*time.Millisecond),
+	)
+	runner.Register(model.projection())
+
+	done := make(chan error, 1)
+	go func() { done <- runner.Start(context.Background()) }()
+
+	appendEvents(t, store, registry, "order-1", newOrderPlaced("1", 100))
+	assert.Eventually(t, func() bool { return model.total("1") == 100 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, runner.Stop(context.Background()))
+	require.NoError(t, <-done)
+}
+
+func TestProjectionRunner_RegisterDuplicate(t *testing.T) {
+	runner := NewProjectionRunner(NewMemoryCheckpointStore())
+	runner.Register(newOrderTotals().projection())
+	assert.Panics(t, func() { runner.Register(newOrderTotals().projection()) })
+	assert.Panics(t, func() { NewProjectionRunner(nil) })
+}
@@ -1,7 +1,18 @@
 package discovery
 
-// TypeConsul 表示 Consul 服务发现类型.
-const TypeConsul = "consul"
+import "time"
+
+// 服务发现类型常量.
+const (
+	// TypeConsul 表示 Consul 服务发现类型.
+	TypeConsul = "consul"
+	// TypeMemory 表示进程内服务发现类型.
+	TypeMemory = "memory"
+	// TypeFile 表示静态文件服务发现类型.
+	TypeFile = "file"
+	// TypeEtcd 表示 etcd 服务发现类型.
+	TypeEtcd = "etcd"
+)
 
 // 协议类型常量.
 const (
@@ -22,10 +33,28 @@ const (
 // DefaultVersion 是默认服务版本.
 const DefaultVersion = "1.0.0"
 
+// DefaultTTL 是进程内服务发现的默认实例过期时间，也用作 etcd 租约 TTL.
+const DefaultTTL = 30 * time.Second
+
+// etcd 默认值.
+const (
+	// DefaultEtcdAddr 是默认 etcd 地址.
+	DefaultEtcdAddr = "127.0.0.1:2379"
+	// DefaultEtcdPrefix 是默认服务实例键前缀.
+	DefaultEtcdPrefix = "/services"
+)
+
 // Config 表示服务发现配置.
+//
+// Addr 对 Consul 为 agent 地址，对 etcd 为逗号分隔的节点地址，对进程内实现为注册表命名空间.
+// Path 为文件实现的 YAML 文件路径，Prefix 为 etcd 实例键前缀.
+// TTL 为进程内实现的实例过期时间和 etcd 的租约 TTL.
 type Config struct {
 	Type     string        `json:"type" toml:"type" yaml:"type" mapstructure:"type"`
 	Addr     string        `json:"addr" toml:"addr" yaml:"addr" mapstructure:"addr"`
+	Path     string        `json:"path" toml:"path" yaml:"path" mapstructure:"path"`
+	Prefix   string        `json:"prefix" toml:"prefix" yaml:"prefix" mapstructure:"prefix"`
+	TTL      time.Duration `json:"ttl" toml:"ttl" yaml:"ttl" mapstructure:"ttl"`
 	Services ServiceConfig `json:"services" toml:"services" yaml:"services" mapstructure:"services"`
 }
 
@@ -50,14 +79,33 @@ func (c *Config) Validate() error {
 	if c.Type == "" {
 		return ErrEmptyType
 	}
-	if c.Type != TypeConsul {
+	switch c.Type {
+	case TypeConsul, TypeMemory, TypeEtcd:
+		return nil
+	case TypeFile:
+		if c.Path == "" {
+			return ErrEmptyPath
+		}
+		return nil
+	default:
 		return ErrUnsupportedType
 	}
-	return nil
 }
 
 // SetDefaults 设置配置的默认值.
 func (c *Config) SetDefaults() {
+	if c.TTL <= 0 {
+		c.TTL = DefaultTTL
+	}
+	if c.Type == TypeEtcd {
+		if c.Addr == "" {
+			c.Addr = DefaultEtcdAddr
+		}
+		if c.Prefix == "" {
+			c.Prefix = DefaultEtcdPrefix
+		}
+	}
+
 	if c.Services.HTTP.Version == "" {
 		c.Services.HTTP.Version = DefaultVersion
 	}
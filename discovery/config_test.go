@@ -38,6 +38,26 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name:    "valid memory config",
+			config:  &Config{Type: TypeMemory},
+			wantErr: nil,
+		},
+		{
+			name:    "file config without path",
+			config:  &Config{Type: TypeFile},
+			wantErr: ErrEmptyPath,
+		},
+		{
+			name:    "valid etcd config",
+			config:  &Config{Type: TypeEtcd},
+			wantErr: nil,
+		},
+		{
+			name:    "valid file config",
+			config:  &Config{Type: TypeFile, Path: "services.yaml"},
+			wantErr: nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -68,6 +88,18 @@ func TestConfig_SetDefaults(t *testing.T) {
 	assert.Equal(t, DefaultVersion, config.Services.GRPC.Version)
 	assert.Equal(t, ProtocolGRPC, config.Services.GRPC.Protocol)
 	assert.Equal(t, []string{"grpc", "v1"}, config.Services.GRPC.Tags)
+
+	assert.Equal(t, DefaultTTL, config.TTL)
+	assert.Empty(t, config.Prefix)
+}
+
+func TestConfig_SetDefaults_Etcd(t *testing.T) {
+	config := &Config{Type: TypeEtcd}
+	config.SetDefaults()
+
+	assert.Equal(t, DefaultEtcdAddr, config.Addr)
+	assert.Equal(t, DefaultEtcdPrefix, config.Prefix)
+	assert.Equal(t, DefaultTTL, config.TTL)
 }
 
 func TestConfig_SetDefaults_PreserveExisting(t *testing.T) {
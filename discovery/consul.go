@@ -5,12 +5,20 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/Tsukikage7/microservice-kit/logger"
 	"github.com/Tsukikage7/microservice-kit/transport"
 	"github.com/hashicorp/consul/api"
 )
 
+// Watch 阻塞查询参数.
+const (
+	watchWaitTime   = 5 * time.Minute
+	watchMinBackoff = time.Second
+	watchMaxBackoff = 30 * time.Second
+)
+
 // consulDiscovery 是 Consul 服务发现实现.
 type consulDiscovery struct {
 	client *api.Client
@@ -347,6 +355,104 @@ func (c *consulDiscovery) Discover(ctx context.Context, serviceName string) ([]s
 	return addresses, nil
 }
 
+// Instances 返回服务的全部实例，包含不健康实例.
+func (c *consulDiscovery) Instances(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+
+	queryOpts := (&api.QueryOptions{}).WithContext(ctx)
+	entries, _, err := c.client.Health().Service(serviceName, "", false, queryOpts)
+	if err != nil {
+		c.logger.With(
+			logger.String("serviceName", serviceName),
+			logger.Err(err),
+		).Error("[Discovery] consul服务发现失败")
+		return nil, ErrDiscover
+	}
+
+	return toServiceInstances(entries), nil
+}
+
+// Watch 基于 Consul 阻塞查询监听服务实例变化.
+// 查询失败时按指数退避重试，不会关闭通道.
+func (c *consulDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+
+	ch := make(chan []*ServiceInstance, 1)
+	go c.watch(ctx, serviceName, ch)
+	return ch, nil
+}
+
+// watch 阻塞查询循环.
+func (c *consulDiscovery) watch(ctx context.Context, serviceName string, ch chan<- []*ServiceInstance) {
+	defer close(ch)
+
+	var (
+		lastIndex uint64
+		last      []*ServiceInstance
+		sent      bool
+		backoff   time.Duration
+	)
+
+	for {
+		queryOpts := (&api.QueryOptions{
+			WaitIndex: lastIndex,
+			WaitTime:  watchWaitTime,
+		}).WithContext(ctx)
+
+		entries, meta, err := c.client.Health().Service(serviceName, "", false, queryOpts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = min(max(backoff*2, watchMinBackoff), watchMaxBackoff)
+			c.logger.With(
+				logger.String("serviceName", serviceName),
+				logger.Duration("backoff", backoff),
+				logger.Err(err),
+			).Warn("[Discovery] consul监听服务失败，稍后重试")
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		// 索引回退时重置，索引必须大于 0，避免阻塞查询退化为忙轮询
+		switch {
+		case meta.LastIndex < lastIndex:
+			lastIndex = 0
+		case meta.LastIndex < 1:
+			lastIndex = 1
+		default:
+			lastIndex = meta.LastIndex
+		}
+
+		instances := toServiceInstances(entries)
+		if sent && instancesEqual(last, instances) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case ch <- instances:
+		}
+		last, sent = instances, true
+
+		c.logger.With(
+			logger.String("serviceName", serviceName),
+			logger.Int("instances", len(instances)),
+		).Debug("[Discovery] 服务实例变更")
+	}
+}
+
 // Close 关闭服务发现连接.
 func (c *consulDiscovery) Close() error {
 	c.logger.Debug("[Discovery] consul服务发现连接已关闭")
@@ -368,6 +474,31 @@ func parseAddress(address string) (string, int, error) {
 	return host, port, nil
 }
 
+// toServiceInstances 将 Consul 健康查询结果转换为服务实例.
+func toServiceInstances(entries []*api.ServiceEntry) []*ServiceInstance {
+	instances := make([]*ServiceInstance, 0, len(entries))
+	for _, entry := range entries {
+		svc := entry.Service
+		address := svc.Address
+		if address == "" && entry.Node != nil {
+			address = entry.Node.Address
+		}
+		instances = append(instances, &ServiceInstance{
+			ID:       svc.ID,
+			Name:     svc.Service,
+			Address:  address,
+			Port:     svc.Port,
+			Protocol: svc.Meta["protocol"],
+			Version:  svc.Meta["version"],
+			Tags:     svc.Tags,
+			Metadata: svc.Meta,
+			Healthy:  entry.Checks.AggregatedStatus() == api.HealthPassing,
+		})
+	}
+	sortInstances(instances)
+	return instances
+}
+
 // contains 检查字符串切片是否包含指定字符串.
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -2,9 +2,16 @@ package discovery
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -546,3 +553,141 @@ func TestConsulDiscovery_Register_NoConsul(t *testing.T) {
 		assert.ErrorIs(t, err, ErrRegister)
 	}
 }
+
+// fakeConsul 模拟 Consul 健康查询接口，支持阻塞查询.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	entries []*api.ServiceEntry
+	changed chan struct{}
+}
+
+func newFakeConsul() *fakeConsul {
+	return &fakeConsul{index: 1, changed: make(chan struct{})}
+}
+
+func (f *fakeConsul) set(entries ...*api.ServiceEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = entries
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	waitIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+
+	f.mu.Lock()
+	index, changed := f.index, f.changed
+	f.mu.Unlock()
+
+	if waitIndex >= index {
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(f.entries)
+}
+
+func consulEntry(id string, port int, status string) *api.ServiceEntry {
+	return &api.ServiceEntry{
+		Node: &api.Node{Address: "10.0.0.1"},
+		Service: &api.AgentService{
+			ID:      id,
+			Service: "test-service",
+			Address: "127.0.0.1",
+			Port:    port,
+			Tags:    []string{"grpc"},
+			Meta:    map[string]string{"protocol": ProtocolGRPC, "version": "1.0.0"},
+		},
+		Checks: api.HealthChecks{{Status: status}},
+	}
+}
+
+func newFakeConsulDiscovery(t *testing.T, fc *fakeConsul) *consulDiscovery {
+	t.Helper()
+	srv := httptest.NewServer(fc)
+	t.Cleanup(srv.Close)
+
+	config := &Config{Type: TypeConsul, Addr: strings.TrimPrefix(srv.URL, "http://")}
+	config.SetDefaults()
+	d, err := newConsulDiscovery(config, &mockLogger{})
+	require.NoError(t, err)
+	return d.(*consulDiscovery)
+}
+
+func TestConsulDiscovery_Instances(t *testing.T) {
+	fc := newFakeConsul()
+	fc.set(
+		consulEntry("svc-2", 9092, api.HealthCritical),
+		consulEntry("svc-1", 9091, api.HealthPassing),
+	)
+	d := newFakeConsulDiscovery(t, fc)
+
+	instances, err := d.Instances(context.Background(), "test-service")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	assert.Equal(t, "svc-1", instances[0].ID)
+	assert.Equal(t, "127.0.0.1:9091", instances[0].Endpoint())
+	assert.Equal(t, ProtocolGRPC, instances[0].Protocol)
+	assert.Equal(t, "1.0.0", instances[0].Version)
+	assert.True(t, instances[0].Healthy)
+	assert.False(t, instances[1].Healthy)
+
+	healthy := FilterInstances(instances, ProtocolGRPC)
+	require.Len(t, healthy, 1)
+	assert.Equal(t, "svc-1", healthy[0].ID)
+	assert.Empty(t, FilterInstances(instances, ProtocolHTTP))
+
+	_, err = d.Instances(context.Background(), "")
+	assert.ErrorIs(t, err, ErrEmptyName)
+}
+
+func TestConsulDiscovery_Watch(t *testing.T) {
+	fc := newFakeConsul()
+	fc.set(consulEntry("svc-1", 9091, api.HealthPassing))
+	d := newFakeConsulDiscovery(t, fc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := d.Watch(ctx, "test-service")
+	require.NoError(t, err)
+
+	recv := func() []*ServiceInstance {
+		select {
+		case instances := <-ch:
+			return instances
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for watch update")
+			return nil
+		}
+	}
+
+	first := recv()
+	require.Len(t, first, 1)
+	assert.Equal(t, "svc-1", first[0].ID)
+
+	fc.set(
+		consulEntry("svc-1", 9091, api.HealthPassing),
+		consulEntry("svc-2", 9092, api.HealthPassing),
+	)
+	second := recv()
+	require.Len(t, second, 2)
+	assert.Equal(t, "svc-2", second[1].ID)
+
+	cancel()
+	for range ch {
+	}
+
+	_, err = d.Watch(context.Background(), "")
+	assert.ErrorIs(t, err, ErrEmptyName)
+}
@@ -25,6 +25,13 @@ type Discovery interface {
 	// Discover 发现服务实例.
 	Discover(ctx context.Context, serviceName string) ([]string, error)
 
+	// Instances 返回服务的全部实例，包含不健康实例.
+	Instances(ctx context.Context, serviceName string) ([]*ServiceInstance, error)
+
+	// Watch 监听服务实例变化.
+	// 首次推送当前实例列表，之后每次变化推送完整列表，ctx 取消后关闭通道.
+	Watch(ctx context.Context, serviceName string) (<-chan []*ServiceInstance, error)
+
 	// Close 关闭服务发现连接.
 	Close() error
 }
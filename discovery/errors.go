@@ -22,6 +22,9 @@ var (
 	// ErrEmptyServiceID 服务ID为空.
 	ErrEmptyServiceID = errors.New("服务ID为空")
 
+	// ErrEmptyPath 服务发现文件路径为空.
+	ErrEmptyPath = errors.New("服务发现文件路径为空")
+
 	// ErrEmptyType 服务发现类型为空.
 	ErrEmptyType = errors.New("服务发现类型为空")
 
@@ -51,4 +54,13 @@ var (
 
 	// ErrDiscover 发现服务失败.
 	ErrDiscover = errors.New("发现服务失败")
+
+	// ErrLoadFile 加载服务发现文件失败.
+	ErrLoadFile = errors.New("加载服务发现文件失败")
+
+	// ErrDuplicateInstance 服务实例ID重复.
+	ErrDuplicateInstance = errors.New("服务实例ID重复")
+
+	// ErrClosed 服务发现已关闭.
+	ErrClosed = errors.New("服务发现已关闭")
 )
@@ -0,0 +1,463 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/transport"
+)
+
+// etcd 客户端参数.
+const (
+	etcdDialTimeout    = 5 * time.Second
+	etcdRequestTimeout = 5 * time.Second
+)
+
+// etcdRegistration 本实例注册的服务及其租约.
+type etcdRegistration struct {
+	key     string
+	leaseID clientv3.LeaseID
+	cancel  context.CancelFunc
+}
+
+// etcdDiscovery 是 etcd 服务发现实现.
+//
+// 实例以 JSON 写入 <Prefix>/<serviceName>/<serviceID>，绑定 TTL 租约并后台续约.
+// 续约中断（租约过期或连接丢失）时实例随租约删除，Close 后停止续约.
+type etcdDiscovery struct {
+	client *clientv3.Client
+	config *Config
+	logger logger.Logger
+
+	mu            sync.Mutex
+	registrations map[string]*etcdRegistration // serviceID -> registration
+	closed        bool
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newEtcdDiscovery 创建 etcd 服务发现实例，Addr 支持逗号分隔的多个地址.
+func newEtcdDiscovery(config *Config, log logger.Logger) (Discovery, error) {
+	endpoints := strings.Split(config.Addr, ",")
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: etcdDialTimeout,
+	})
+	if err != nil {
+		log.With(
+			logger.String("addr", config.Addr),
+			logger.Err(err),
+		).Error("[Discovery] 创建etcd客户端失败")
+		return nil, ErrClientCreate
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &etcdDiscovery{
+		client:        client,
+		config:        config,
+		logger:        log,
+		registrations: make(map[string]*etcdRegistration),
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
+}
+
+// Register 注册服务实例，返回服务ID. Close 后返回 ErrClosed.
+func (e *etcdDiscovery) Register(ctx context.Context, serviceName, address string) (string, error) {
+	return e.register(ctx, serviceName, address, "")
+}
+
+// RegisterWithProtocol 根据协议注册服务实例，返回服务ID.
+func (e *etcdDiscovery) RegisterWithProtocol(ctx context.Context, serviceName, address, protocol string) (string, error) {
+	return e.register(ctx, serviceName, address, protocol)
+}
+
+// RegisterWithHealthEndpoint 注册服务实例，etcd 实现以租约续约代替健康检查，忽略 healthEndpoint.
+func (e *etcdDiscovery) RegisterWithHealthEndpoint(ctx context.Context, serviceName, address, protocol string, healthEndpoint *transport.HealthEndpoint) (string, error) {
+	return e.register(ctx, serviceName, address, protocol)
+}
+
+func (e *etcdDiscovery) register(ctx context.Context, serviceName, address, protocol string) (string, error) {
+	if err := e.acquire(); err != nil {
+		return "", err
+	}
+	defer e.wg.Done()
+
+	instance, err := newServiceInstance(e.config, serviceName, address, protocol)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := json.Marshal(instance)
+	if err != nil {
+		return "", ErrRegister
+	}
+
+	// 租约 TTL 以秒为单位，不足 1 秒按 1 秒处理
+	ttl := int64(e.config.TTL / time.Second)
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	lease, err := e.client.Grant(ctx, ttl)
+	if err != nil {
+		e.logger.With(
+			logger.String("serviceName", serviceName),
+			logger.Err(err),
+		).Error("[Discovery] etcd创建租约失败")
+		return "", ErrRegister
+	}
+
+	key := e.instanceKey(serviceName, instance.ID)
+	if _, err := e.client.Put(ctx, key, string(value), clientv3.WithLease(lease.ID)); err != nil {
+		e.revoke(lease.ID)
+		e.logger.With(
+			logger.String("serviceName", serviceName),
+			logger.String("key", key),
+			logger.Err(err),
+		).Error("[Discovery] etcd服务注册失败")
+		return "", ErrRegister
+	}
+
+	keepCtx, cancel := context.WithCancel(e.ctx)
+	keepAlive, err := e.client.KeepAlive(keepCtx, lease.ID)
+	if err != nil {
+		cancel()
+		e.revoke(lease.ID)
+		e.logger.With(
+			logger.String("serviceName", serviceName),
+			logger.Err(err),
+		).Error("[Discovery] etcd租约续约失败")
+		return "", ErrRegister
+	}
+
+	reg := &etcdRegistration{key: key, leaseID: lease.ID, cancel: cancel}
+	e.mu.Lock()
+	if e.closed {
+		// 注册期间调用了 Close，撤销租约避免留下无人续约的实例
+		e.mu.Unlock()
+		cancel()
+		e.revoke(lease.ID)
+		return "", ErrClosed
+	}
+	e.registrations[instance.ID] = reg
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go e.keepAlive(keepCtx, instance.ID, reg, keepAlive)
+
+	e.logger.With(
+		logger.String("serviceName", serviceName),
+		logger.String("serviceID", instance.ID),
+		logger.String("addr", instance.Endpoint()),
+		logger.Int64("ttl", ttl),
+	).Debug("[Discovery] 服务注册成功")
+
+	return instance.ID, nil
+}
+
+// keepAlive 消费续约响应，通道关闭说明租约已丢失.
+func (e *etcdDiscovery) keepAlive(ctx context.Context, serviceID string, reg *etcdRegistration, ch <-chan *clientv3.LeaseKeepAliveResponse) {
+	defer e.wg.Done()
+
+	for range ch {
+	}
+
+	// 主动注销或关闭时不视为租约丢失
+	if ctx.Err() != nil {
+		return
+	}
+
+	e.mu.Lock()
+	if e.registrations[serviceID] == reg {
+		delete(e.registrations, serviceID)
+	}
+	e.mu.Unlock()
+
+	// 租约可能因连接中断而未过期，主动删除避免残留
+	delCtx, cancel := context.WithTimeout(e.ctx, etcdRequestTimeout)
+	defer cancel()
+	_, _ = e.client.Delete(delCtx, reg.key)
+
+	e.logger.With(
+		logger.String("serviceID", serviceID),
+		logger.String("key", reg.key),
+	).Warn("[Discovery] etcd租约已丢失，服务实例已注销")
+}
+
+// Unregister 注销服务实例.
+func (e *etcdDiscovery) Unregister(ctx context.Context, serviceID string) error {
+	if serviceID == "" {
+		return ErrEmptyServiceID
+	}
+
+	e.mu.Lock()
+	reg, ok := e.registrations[serviceID]
+	delete(e.registrations, serviceID)
+	e.mu.Unlock()
+
+	// 非本实例注册或租约已丢失，视为注销成功
+	if !ok {
+		return nil
+	}
+	reg.cancel()
+
+	// 撤销租约会同时删除绑定的键
+	if _, err := e.client.Revoke(ctx, reg.leaseID); err != nil {
+		e.logger.With(
+			logger.String("serviceID", serviceID),
+			logger.Err(err),
+		).Error("[Discovery] etcd服务注销失败")
+		return ErrUnregister
+	}
+
+	e.logger.With(logger.String("serviceID", serviceID)).Debug("[Discovery] 服务注销成功")
+	return nil
+}
+
+// Discover 发现健康的 gRPC 服务实例.
+func (e *etcdDiscovery) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	instances, err := e.Instances(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := endpoints(instances)
+	if len(addrs) == 0 {
+		e.logger.With(logger.String("serviceName", serviceName)).Warn("[Discovery] 未发现任何服务实例")
+	}
+	return addrs, nil
+}
+
+// Instances 返回服务的全部实例.
+func (e *etcdDiscovery) Instances(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+
+	instances, _, err := e.list(ctx, serviceName)
+	if err != nil {
+		e.logger.With(
+			logger.String("serviceName", serviceName),
+			logger.Err(err),
+		).Error("[Discovery] etcd服务发现失败")
+		return nil, ErrDiscover
+	}
+
+	result := make([]*ServiceInstance, 0, len(instances))
+	for _, inst := range instances {
+		result = append(result, inst)
+	}
+	sortInstances(result)
+	return result, nil
+}
+
+// Watch 基于 etcd Watch 监听服务实例变化.
+// 监听中断时按指数退避重新全量拉取，不会关闭通道. Close 后返回 ErrClosed.
+func (e *etcdDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+
+	if err := e.acquire(); err != nil {
+		return nil, err
+	}
+	ch := make(chan []*ServiceInstance, 1)
+	go e.watch(ctx, serviceName, ch)
+	return ch, nil
+}
+
+// watch 全量拉取后增量监听，出错时重新拉取.
+func (e *etcdDiscovery) watch(ctx context.Context, serviceName string, ch chan<- []*ServiceInstance) {
+	defer e.wg.Done()
+	defer close(ch)
+
+	// Close 时同样退出
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(e.ctx, cancel)
+	defer stop()
+
+	var (
+		last    []*ServiceInstance
+		sent    bool
+		backoff time.Duration
+	)
+
+	send := func(instances map[string]*ServiceInstance) bool {
+		list := make([]*ServiceInstance, 0, len(instances))
+		for _, inst := range instances {
+			list = append(list, inst.clone())
+		}
+		sortInstances(list)
+		if sent && instancesEqual(last, list) {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case ch <- list:
+		}
+		last, sent = list, true
+		return true
+	}
+
+	retry := func(err error) bool {
+		backoff = min(max(backoff*2, watchMinBackoff), watchMaxBackoff)
+		e.logger.With(
+			logger.String("serviceName", serviceName),
+			logger.Duration("backoff", backoff),
+			logger.Err(err),
+		).Warn("[Discovery] etcd监听服务失败，稍后重试")
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+			return true
+		}
+	}
+
+	prefix := e.servicePrefix(serviceName)
+	for {
+		instances, rev, err := e.list(ctx, serviceName)
+		if err != nil {
+			if ctx.Err() != nil || !retry(err) {
+				return
+			}
+			continue
+		}
+		backoff = 0
+		if !send(instances) {
+			return
+		}
+
+		watchCh := e.client.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+		for resp := range watchCh {
+			if err = resp.Err(); err != nil {
+				break
+			}
+			for _, ev := range resp.Events {
+				id := path.Base(string(ev.Kv.Key))
+				switch ev.Type {
+				case clientv3.EventTypePut:
+					inst, decodeErr := decodeInstance(ev.Kv.Value)
+					if decodeErr != nil {
+						e.logger.With(
+							logger.String("key", string(ev.Kv.Key)),
+							logger.Err(decodeErr),
+						).Warn("[Discovery] 忽略无法解析的服务实例")
+						continue
+					}
+					instances[id] = inst
+				case clientv3.EventTypeDelete:
+					delete(instances, id)
+				}
+			}
+			if len(resp.Events) > 0 && !send(instances) {
+				return
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+		// 监听通道关闭（如版本被压缩、失去 leader），退避后重新拉取
+		if err == nil {
+			err = ErrDiscover
+		}
+		if !retry(err) {
+			return
+		}
+	}
+}
+
+// list 拉取服务实例，返回以服务 ID 为键的实例和当前版本.
+func (e *etcdDiscovery) list(ctx context.Context, serviceName string) (map[string]*ServiceInstance, int64, error) {
+	resp, err := e.client.Get(ctx, e.servicePrefix(serviceName), clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	instances := make(map[string]*ServiceInstance, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		inst, err := decodeInstance(kv.Value)
+		if err != nil {
+			e.logger.With(
+				logger.String("key", string(kv.Key)),
+				logger.Err(err),
+			).Warn("[Discovery] 忽略无法解析的服务实例")
+			continue
+		}
+		instances[path.Base(string(kv.Key))] = inst
+	}
+	return instances, resp.Header.Revision, nil
+}
+
+// Close 停止续约和监听并关闭客户端，已注册的实例在租约到期后移除.
+//
+// 重复调用返回首次关闭的结果，关闭后 Register 和 Watch 返回 ErrClosed.
+func (e *etcdDiscovery) Close() error {
+	e.closeOnce.Do(func() {
+		e.mu.Lock()
+		e.closed = true
+		e.mu.Unlock()
+
+		// 等待进行中的注册和监听退出后再关闭客户端
+		e.cancel()
+		e.wg.Wait()
+		if e.closeErr = e.client.Close(); e.closeErr == nil {
+			e.logger.Debug("[Discovery] etcd服务发现连接已关闭")
+		}
+	})
+	return e.closeErr
+}
+
+// acquire 登记进行中的注册或监听，已关闭时返回 ErrClosed.
+func (e *etcdDiscovery) acquire() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return ErrClosed
+	}
+	e.wg.Add(1)
+	return nil
+}
+
+// revoke 尽力撤销租约.
+func (e *etcdDiscovery) revoke(leaseID clientv3.LeaseID) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+	_, _ = e.client.Revoke(ctx, leaseID)
+}
+
+// servicePrefix 返回服务实例键前缀，以 / 结尾避免匹配同前缀的其他服务.
+func (e *etcdDiscovery) servicePrefix(serviceName string) string {
+	return path.Join(e.config.Prefix, serviceName) + "/"
+}
+
+func (e *etcdDiscovery) instanceKey(serviceName, serviceID string) string {
+	return e.servicePrefix(serviceName) + serviceID
+}
+
+// decodeInstance 解析 etcd 中存储的实例，租约存在即视为健康.
+func decodeInstance(value []byte) (*ServiceInstance, error) {
+	var inst ServiceInstance
+	if err := json.Unmarshal(value, &inst); err != nil {
+		return nil, err
+	}
+	inst.Healthy = true
+	return &inst, nil
+}
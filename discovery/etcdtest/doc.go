@@ -0,0 +1,9 @@
+// Package etcdtest 使用嵌入式 etcd 测试 discovery 的 etcd 实现.
+//
+// 嵌入式 etcd 依赖完整的 etcd 服务端，放在独立模块中，
+// 避免库的使用者引入 go.etcd.io/etcd/server/v3.
+//
+// 运行测试:
+//
+//	cd discovery/etcdtest && go test ./...
+package etcdtest
@@ -0,0 +1,199 @@
+package etcdtest
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"github.com/Tsukikage7/microservice-kit/discovery"
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// freeURL 返回本地可用端口的 URL.
+func freeURL(t *testing.T) url.URL {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return url.URL{Scheme: "http", Host: l.Addr().String()}
+}
+
+// startEmbeddedEtcd 启动嵌入式 etcd，返回客户端地址.
+func startEmbeddedEtcd(t *testing.T) string {
+	t.Helper()
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientURL, peerURL := freeURL(t), freeURL(t)
+	cfg.ListenClientUrls = []url.URL{clientURL}
+	cfg.AdvertiseClientUrls = []url.URL{clientURL}
+	cfg.ListenPeerUrls = []url.URL{peerURL}
+	cfg.AdvertisePeerUrls = []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	e, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	t.Cleanup(e.Close)
+
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		e.Server.Stop()
+		t.Fatal("embedded etcd not ready")
+	}
+	return clientURL.Host
+}
+
+func newTestEtcdDiscovery(t *testing.T, addr string, ttl time.Duration) discovery.Discovery {
+	t.Helper()
+	log, err := logger.NewLogger(logger.DefaultConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	d, err := discovery.NewDiscovery(&discovery.Config{
+		Type:   discovery.TypeEtcd,
+		Addr:   addr,
+		TTL:    ttl,
+		Prefix: "/test",
+	}, log)
+	require.NoError(t, err)
+	return d
+}
+
+// recvInstances 等待 Watch 推送的下一组实例.
+func recvInstances(t *testing.T, ch <-chan []*discovery.ServiceInstance) []*discovery.ServiceInstance {
+	t.Helper()
+	select {
+	case instances, ok := <-ch:
+		require.True(t, ok, "watch channel closed")
+		return instances
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for watch update")
+		return nil
+	}
+}
+
+func TestEtcdDiscovery_RegisterAndDiscover(t *testing.T) {
+	ctx := context.Background()
+	addr := startEmbeddedEtcd(t)
+	d := newTestEtcdDiscovery(t, addr, 5*time.Second)
+	defer d.Close()
+
+	grpcID, err := d.RegisterWithProtocol(ctx, "test-service", "0.0.0.0:9090", discovery.ProtocolGRPC)
+	require.NoError(t, err)
+	_, err = d.RegisterWithProtocol(ctx, "test-service", "127.0.0.1:8080", discovery.ProtocolHTTP)
+	require.NoError(t, err)
+	// 同前缀的其他服务不应被发现
+	_, err = d.Register(ctx, "test-service-v2", "127.0.0.1:9091")
+	require.NoError(t, err)
+
+	addrs, err := d.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:9090"}, addrs)
+
+	instances, err := d.Instances(ctx, "test-service")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	for _, inst := range instances {
+		assert.True(t, inst.Healthy)
+		assert.Equal(t, "test-service", inst.Name)
+	}
+
+	require.NoError(t, d.Unregister(ctx, grpcID))
+	addrs, err = d.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Empty(t, addrs)
+
+	assert.NoError(t, d.Unregister(ctx, grpcID))
+	assert.ErrorIs(t, d.Unregister(ctx, ""), discovery.ErrEmptyServiceID)
+}
+
+func TestEtcdDiscovery_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	addr := startEmbeddedEtcd(t)
+	d := newTestEtcdDiscovery(t, addr, 5*time.Second)
+	defer d.Close()
+
+	ch, err := d.Watch(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Empty(t, recvInstances(t, ch))
+
+	id, err := d.Register(ctx, "test-service", "127.0.0.1:9090")
+	require.NoError(t, err)
+	instances := recvInstances(t, ch)
+	require.Len(t, instances, 1)
+	assert.Equal(t, id, instances[0].ID)
+
+	require.NoError(t, d.Unregister(ctx, id))
+	assert.Empty(t, recvInstances(t, ch))
+
+	cancel()
+	for range ch {
+	}
+
+	_, err = d.Watch(ctx, "")
+	assert.ErrorIs(t, err, discovery.ErrEmptyName)
+}
+
+func TestEtcdDiscovery_LeaseExpiresAfterClose(t *testing.T) {
+	ctx := context.Background()
+	addr := startEmbeddedEtcd(t)
+
+	owner := newTestEtcdDiscovery(t, addr, time.Second)
+	observer := newTestEtcdDiscovery(t, addr, time.Second)
+	defer observer.Close()
+
+	ch, err := observer.Watch(ctx, "test-service")
+	require.NoError(t, err)
+	recvInstances(t, ch)
+
+	_, err = owner.Register(ctx, "test-service", "127.0.0.1:9090")
+	require.NoError(t, err)
+	require.Len(t, recvInstances(t, ch), 1)
+
+	// 续约期间实例保持存在
+	time.Sleep(3 * time.Second)
+	addrs, err := observer.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Len(t, addrs, 1)
+
+	// 关闭后停止续约，租约到期后实例被删除
+	require.NoError(t, owner.Close())
+	select {
+	case instances := <-ch:
+		assert.Empty(t, instances)
+	case <-time.After(10 * time.Second):
+		t.Fatal("instance not removed after lease expiry")
+	}
+}
+
+func TestEtcdDiscovery_Close(t *testing.T) {
+	ctx := context.Background()
+	addr := startEmbeddedEtcd(t)
+	d := newTestEtcdDiscovery(t, addr, 5*time.Second)
+
+	ch, err := d.Watch(ctx, "test-service")
+	require.NoError(t, err)
+	recvInstances(t, ch)
+
+	require.NoError(t, d.Close())
+	require.NoError(t, d.Close(), "重复关闭")
+
+	// 关闭时结束监听
+	for range ch {
+	}
+
+	_, err = d.Register(ctx, "test-service", "127.0.0.1:9090")
+	assert.ErrorIs(t, err, discovery.ErrClosed)
+	_, err = d.RegisterWithProtocol(ctx, "test-service", "127.0.0.1:9090", "grpc")
+	assert.ErrorIs(t, err, discovery.ErrClosed)
+	_, err = d.Watch(ctx, "test-service")
+	assert.ErrorIs(t, err, discovery.ErrClosed)
+}
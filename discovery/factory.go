@@ -28,6 +28,12 @@ func NewDiscovery(config *Config, log logger.Logger) (Discovery, error) {
 	switch config.Type {
 	case TypeConsul:
 		return newConsulDiscovery(config, log)
+	case TypeMemory:
+		return newMemoryDiscovery(config, log)
+	case TypeFile:
+		return newFileDiscovery(config, log)
+	case TypeEtcd:
+		return newEtcdDiscovery(config, log)
 	default:
 		return nil, ErrUnsupportedType
 	}
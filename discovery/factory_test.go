@@ -64,7 +64,7 @@ func TestNewDiscovery(t *testing.T) {
 		{
 			name: "unsupported type",
 			config: &Config{
-				Type: "etcd",
+				Type: "zookeeper",
 			},
 			logger:  log,
 			wantErr: ErrUnsupportedType,
@@ -78,6 +78,23 @@ func TestNewDiscovery(t *testing.T) {
 			logger:  log,
 			wantErr: nil,
 		},
+		{
+			name: "valid memory config",
+			config: &Config{
+				Type: TypeMemory,
+			},
+			logger:  log,
+			wantErr: nil,
+		},
+		{
+			name: "file not found",
+			config: &Config{
+				Type: TypeFile,
+				Path: "/nonexistent/services.yaml",
+			},
+			logger:  log,
+			wantErr: ErrLoadFile,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,351 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/transport"
+)
+
+// fileReloadDelay 文件最后一次变化到重新加载的等待时间.
+//
+// 编辑器保存时通常先截断文件再写入，合并连续的写事件，避免加载写到一半的文件.
+const fileReloadDelay = 100 * time.Millisecond
+
+// fileServices 静态服务发现文件格式，必须包含 services 字段，没有服务时写为 services: [].
+//
+//	services:
+//	  - name: user-service
+//	    instances:
+//	      - address: 127.0.0.1:9090
+//	        metadata:
+//	          weight: "5"
+//	      - id: user-service-http
+//	        address: 127.0.0.1:8080
+//	        protocol: http
+type fileServices struct {
+	Services []fileService `yaml:"services"`
+}
+
+// fileService 单个服务的静态配置.
+type fileService struct {
+	Name      string         `yaml:"name"`
+	Instances []fileInstance `yaml:"instances"`
+}
+
+// fileInstance 单个实例的静态配置.
+type fileInstance struct {
+	ID       string            `yaml:"id"`
+	Address  string            `yaml:"address"`
+	Protocol string            `yaml:"protocol"`
+	Version  string            `yaml:"version"`
+	Tags     []string          `yaml:"tags"`
+	Metadata map[string]string `yaml:"metadata"`
+}
+
+// fileDiscovery 是基于静态 YAML 文件的服务发现实现.
+//
+// 文件变化后等待 fileReloadDelay 再重新加载，加载失败时保留上一次的实例列表.
+// 实例列表由文件维护，Register 系列方法只生成服务 ID，不修改文件.
+type fileDiscovery struct {
+	config *Config
+	logger logger.Logger
+
+	mu       sync.Mutex
+	services map[string][]*ServiceInstance
+	hub      *watchHub
+
+	watcher   *fsnotify.Watcher
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newFileDiscovery 创建文件服务发现实例.
+func newFileDiscovery(config *Config, log logger.Logger) (Discovery, error) {
+	path, err := filepath.Abs(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLoadFile, err)
+	}
+
+	services, err := loadServicesFile(path)
+	if err != nil {
+		log.With(
+			logger.String("path", path),
+			logger.Err(err),
+		).Error("[Discovery] 加载服务发现文件失败")
+		return nil, err
+	}
+
+	// 监听所在目录，编辑器通过重命名替换文件时仍能收到事件
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrClientCreate, err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("%w: %v", ErrClientCreate, err)
+	}
+
+	d := &fileDiscovery{
+		config:   config,
+		logger:   log,
+		services: services,
+		hub:      newWatchHub(),
+		watcher:  watcher,
+		done:     make(chan struct{}),
+	}
+
+	d.wg.Add(1)
+	go d.watchFile(path)
+
+	return d, nil
+}
+
+// Register 文件实现不支持动态注册，仅返回服务 ID.
+func (d *fileDiscovery) Register(ctx context.Context, serviceName, address string) (string, error) {
+	return d.register(serviceName, address, "")
+}
+
+// RegisterWithProtocol 文件实现不支持动态注册，仅返回服务 ID.
+func (d *fileDiscovery) RegisterWithProtocol(ctx context.Context, serviceName, address, protocol string) (string, error) {
+	return d.register(serviceName, address, protocol)
+}
+
+// RegisterWithHealthEndpoint 文件实现不支持动态注册，仅返回服务 ID.
+func (d *fileDiscovery) RegisterWithHealthEndpoint(ctx context.Context, serviceName, address, protocol string, healthEndpoint *transport.HealthEndpoint) (string, error) {
+	return d.register(serviceName, address, protocol)
+}
+
+func (d *fileDiscovery) register(serviceName, address, protocol string) (string, error) {
+	instance, err := newServiceInstance(d.config, serviceName, address, protocol)
+	if err != nil {
+		return "", err
+	}
+
+	d.logger.With(
+		logger.String("serviceName", serviceName),
+		logger.String("addr", instance.Endpoint()),
+	).Debug("[Discovery] 文件服务发现忽略注册，实例需写入服务发现文件")
+
+	return instance.ID, nil
+}
+
+// Unregister 文件实现不支持动态注销.
+func (d *fileDiscovery) Unregister(ctx context.Context, serviceID string) error {
+	if serviceID == "" {
+		return ErrEmptyServiceID
+	}
+	return nil
+}
+
+// Discover 发现健康的 gRPC 服务实例.
+func (d *fileDiscovery) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+
+	addrs := endpoints(d.snapshot(serviceName))
+	if len(addrs) == 0 {
+		d.logger.With(logger.String("serviceName", serviceName)).Warn("[Discovery] 未发现任何服务实例")
+	}
+	return addrs, nil
+}
+
+// Instances 返回服务的全部实例.
+func (d *fileDiscovery) Instances(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+	return d.snapshot(serviceName), nil
+}
+
+// Watch 监听服务实例变化，文件重新加载后推送.
+func (d *fileDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.hub.subscribe(ctx, d.done, serviceName, cloneInstances(d.services[serviceName])), nil
+}
+
+// Close 停止监听文件并关闭所有监听通道.
+func (d *fileDiscovery) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		close(d.done)
+		err = d.watcher.Close()
+		d.wg.Wait()
+		d.logger.Debug("[Discovery] 文件服务发现已关闭")
+	})
+	return err
+}
+
+func (d *fileDiscovery) snapshot(serviceName string) []*ServiceInstance {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return cloneInstances(d.services[serviceName])
+}
+
+// watchFile 处理文件变化事件，连续的变化合并为一次重新加载.
+func (d *fileDiscovery) watchFile(path string) {
+	defer d.wg.Done()
+
+	timer := time.NewTimer(fileReloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case event, ok := <-d.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+			timer.Reset(fileReloadDelay)
+		case <-timer.C:
+			d.reload(path)
+		case err, ok := <-d.watcher.Errors:
+			if !ok {
+				return
+			}
+			d.logger.With(
+				logger.String("path", path),
+				logger.Err(err),
+			).Warn("[Discovery] 监听服务发现文件失败")
+		}
+	}
+}
+
+// reload 重新加载文件，向实例有变化的服务推送.
+func (d *fileDiscovery) reload(path string) {
+	services, err := loadServicesFile(path)
+	if err != nil {
+		d.logger.With(
+			logger.String("path", path),
+			logger.Err(err),
+		).Warn("[Discovery] 重新加载服务发现文件失败，保留原有实例")
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	old := d.services
+	d.services = services
+
+	for name, instances := range services {
+		if !instancesEqual(old[name], instances) {
+			d.hub.publish(name, instances)
+		}
+	}
+	for name := range old {
+		if _, ok := services[name]; !ok {
+			d.hub.publish(name, []*ServiceInstance{})
+		}
+	}
+
+	d.logger.With(
+		logger.String("path", path),
+		logger.Int("services", len(services)),
+	).Debug("[Discovery] 服务发现文件已重新加载")
+}
+
+// loadServicesFile 读取并解析服务发现文件.
+func loadServicesFile(path string) (map[string][]*ServiceInstance, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLoadFile, err)
+	}
+
+	var file fileServices
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLoadFile, err)
+	}
+	// 空文件或缺少 services 字段通常是文件正在被写入，不视为删除全部服务
+	if file.Services == nil {
+		return nil, fmt.Errorf("%w: 缺少 services 字段", ErrLoadFile)
+	}
+
+	services := make(map[string][]*ServiceInstance, len(file.Services))
+	for _, svc := range file.Services {
+		if svc.Name == "" {
+			return nil, fmt.Errorf("%w: %v", ErrLoadFile, ErrEmptyName)
+		}
+		for _, fi := range svc.Instances {
+			instance, err := fi.toServiceInstance(svc.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 服务 %s: %v", ErrLoadFile, svc.Name, err)
+			}
+			if slices.ContainsFunc(services[svc.Name], func(si *ServiceInstance) bool { return si.ID == instance.ID }) {
+				return nil, fmt.Errorf("%w: 服务 %s: %w %s", ErrLoadFile, svc.Name, ErrDuplicateInstance, instance.ID)
+			}
+			services[svc.Name] = append(services[svc.Name], instance)
+		}
+		sortInstances(services[svc.Name])
+	}
+	return services, nil
+}
+
+// toServiceInstance 转换为服务实例，协议默认 gRPC，ID 默认为服务名和地址.
+func (fi fileInstance) toServiceInstance(serviceName string) (*ServiceInstance, error) {
+	if fi.Address == "" {
+		return nil, ErrEmptyAddress
+	}
+	host, port, err := parseAddress(fi.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	protocol := fi.Protocol
+	if protocol == "" {
+		protocol = ProtocolGRPC
+	}
+	version := fi.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+	id := fi.ID
+	if id == "" {
+		id = serviceName + "-" + fi.Address
+	}
+
+	tags := fi.Tags
+	if !contains(tags, protocol) {
+		tags = append(tags, protocol)
+	}
+
+	metadata := make(map[string]string, len(fi.Metadata)+2)
+	for k, v := range fi.Metadata {
+		metadata[k] = v
+	}
+	metadata["version"] = version
+	metadata["protocol"] = protocol
+
+	return &ServiceInstance{
+		ID:       id,
+		Name:     serviceName,
+		Address:  host,
+		Port:     port,
+		Protocol: protocol,
+		Version:  version,
+		Tags:     tags,
+		Metadata: metadata,
+		Healthy:  true,
+	}, nil
+}
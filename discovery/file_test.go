@@ -0,0 +1,194 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServicesYAML = `
+services:
+  - name: user-service
+    instances:
+      - address: 127.0.0.1:9090
+        metadata:
+          weight: "5"
+      - id: user-service-http
+        address: 127.0.0.1:8080
+        protocol: http
+`
+
+// writeServicesFile 通过重命名原子替换文件，模拟配置发布.
+func writeServicesFile(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte(content), 0o644))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func newTestFileDiscovery(t *testing.T, content string) (Discovery, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeServicesFile(t, path, content)
+
+	d, err := NewDiscovery(&Config{Type: TypeFile, Path: path}, &mockLogger{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+	return d, path
+}
+
+func TestFileDiscovery_Discover(t *testing.T) {
+	ctx := context.Background()
+	d, _ := newTestFileDiscovery(t, testServicesYAML)
+
+	addrs, err := d.Discover(ctx, "user-service")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:9090"}, addrs)
+
+	instances, err := d.Instances(ctx, "user-service")
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	grpcInst := instances[0]
+	assert.Equal(t, "user-service-127.0.0.1:9090", grpcInst.ID)
+	assert.Equal(t, ProtocolGRPC, grpcInst.Protocol)
+	assert.Equal(t, DefaultVersion, grpcInst.Version)
+	assert.Equal(t, "5", grpcInst.Metadata["weight"])
+	assert.Contains(t, grpcInst.Tags, ProtocolGRPC)
+	assert.Equal(t, "user-service-http", instances[1].ID)
+	assert.Equal(t, ProtocolHTTP, instances[1].Protocol)
+
+	addrs, err = d.Discover(ctx, "unknown-service")
+	require.NoError(t, err)
+	assert.Empty(t, addrs)
+}
+
+func TestFileDiscovery_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid yaml", content: "services: [}"},
+		{name: "missing service name", content: "services:\n  - instances:\n      - address: 127.0.0.1:9090\n"},
+		{name: "invalid address", content: "services:\n  - name: svc\n    instances:\n      - address: invalid\n"},
+		{name: "empty file", content: ""},
+		{name: "missing services", content: "# services: []\n"},
+		{name: "duplicate instance id", content: "services:\n  - name: svc\n    instances:\n      - address: 127.0.0.1:9090\n      - address: 127.0.0.1:9090\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "services.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			_, err := NewDiscovery(&Config{Type: TypeFile, Path: path}, &mockLogger{})
+			assert.ErrorIs(t, err, ErrLoadFile)
+		})
+	}
+}
+
+func TestFileDiscovery_RegisterIsNoop(t *testing.T) {
+	ctx := context.Background()
+	d, _ := newTestFileDiscovery(t, testServicesYAML)
+
+	id, err := d.Register(ctx, "order-service", "127.0.0.1:9091")
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+	assert.NoError(t, d.Unregister(ctx, id))
+
+	addrs, err := d.Discover(ctx, "order-service")
+	require.NoError(t, err)
+	assert.Empty(t, addrs)
+}
+
+func TestFileDiscovery_HotReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, path := newTestFileDiscovery(t, testServicesYAML)
+
+	ch, err := d.Watch(ctx, "user-service")
+	require.NoError(t, err)
+	require.Len(t, recvInstances(t, ch), 2)
+
+	writeServicesFile(t, path, `
+services:
+  - name: user-service
+    instances:
+      - address: 127.0.0.1:9090
+      - address: 127.0.0.1:9091
+`)
+	instances := recvInstances(t, ch)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "127.0.0.1:9091", instances[1].Endpoint())
+
+	// 加载失败时保留原有实例
+	writeServicesFile(t, path, "services: [}")
+	time.Sleep(100 * time.Millisecond)
+	addrs, err := d.Discover(ctx, "user-service")
+	require.NoError(t, err)
+	assert.Len(t, addrs, 2)
+
+	// 服务被删除时推送空列表
+	writeServicesFile(t, path, "services: []\n")
+	assert.Empty(t, recvInstances(t, ch))
+}
+
+func TestFileDiscovery_TruncateThenRewrite(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, path := newTestFileDiscovery(t, testServicesYAML)
+
+	ch, err := d.Watch(ctx, "user-service")
+	require.NoError(t, err)
+	require.Len(t, recvInstances(t, ch), 2)
+
+	// 编辑器先截断文件，空文件不会清空实例
+	require.NoError(t, os.Truncate(path, 0))
+	select {
+	case instances := <-ch:
+		t.Fatalf("unexpected push after truncate: %v", instances)
+	case <-time.After(3 * fileReloadDelay):
+	}
+	addrs, err := d.Discover(ctx, "user-service")
+	require.NoError(t, err)
+	assert.Len(t, addrs, 1)
+
+	// 分两次写入新内容，只推送写完后的实例
+	content := testServicesYAML + "      - address: 127.0.0.1:9091\n"
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(content[:len(content)/2])
+	require.NoError(t, err)
+	time.Sleep(fileReloadDelay / 5)
+	_, err = f.WriteString(content[len(content)/2:])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	instances := recvInstances(t, ch)
+	require.Len(t, instances, 3)
+	assert.Equal(t, "127.0.0.1:9091", instances[1].Endpoint())
+}
+
+func TestFileDiscovery_CloseStopsWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	writeServicesFile(t, path, testServicesYAML)
+	d, err := NewDiscovery(&Config{Type: TypeFile, Path: path}, &mockLogger{})
+	require.NoError(t, err)
+
+	ch, err := d.Watch(context.Background(), "user-service")
+	require.NoError(t, err)
+	recvInstances(t, ch)
+
+	require.NoError(t, d.Close())
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("watch channel not closed")
+	}
+}
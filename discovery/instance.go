@@ -0,0 +1,144 @@
+package discovery
+
+import (
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ServiceInstance 表示一个服务实例.
+type ServiceInstance struct {
+	// ID 实例唯一标识
+	ID string `json:"id"`
+	// Name 服务名称
+	Name string `json:"name"`
+	// Address 实例主机地址
+	Address string `json:"address"`
+	// Port 实例端口
+	Port int `json:"port"`
+	// Protocol 协议类型（http/grpc）
+	Protocol string `json:"protocol"`
+	// Version 服务版本
+	Version string `json:"version"`
+	// Tags 标签
+	Tags []string `json:"tags,omitempty"`
+	// Metadata 元数据
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Healthy 健康检查是否通过
+	Healthy bool `json:"healthy"`
+}
+
+// Endpoint 返回 host:port 形式的地址.
+func (i *ServiceInstance) Endpoint() string {
+	return net.JoinHostPort(i.Address, strconv.Itoa(i.Port))
+}
+
+// FilterInstances 返回健康且协议匹配的实例，protocol 为空时不按协议过滤.
+func FilterInstances(instances []*ServiceInstance, protocol string) []*ServiceInstance {
+	result := make([]*ServiceInstance, 0, len(instances))
+	for _, inst := range instances {
+		if !inst.Healthy {
+			continue
+		}
+		if protocol != "" && inst.Protocol != protocol && !contains(inst.Tags, protocol) {
+			continue
+		}
+		result = append(result, inst)
+	}
+	return result
+}
+
+// sortInstances 按 ID 排序实例，保证输出顺序稳定.
+func sortInstances(instances []*ServiceInstance) {
+	slices.SortFunc(instances, func(a, b *ServiceInstance) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// instancesEqual 判断两组已排序的实例是否相同.
+func instancesEqual(a, b []*ServiceInstance) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// newServiceInstance 根据协议配置构建待注册的服务实例.
+// protocol 为空时使用 gRPC 的元数据配置，与 Register 的行为一致.
+func newServiceInstance(config *Config, serviceName, address, protocol string) (*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+	if address == "" {
+		return nil, ErrEmptyAddress
+	}
+
+	idPrefix := serviceName
+	meta := config.GetServiceConfig(ProtocolGRPC)
+	if protocol != "" {
+		meta = config.GetServiceConfig(protocol)
+		if meta.Protocol == "" {
+			return nil, ErrUnsupportedProtocol
+		}
+		idPrefix = serviceName + "-" + protocol
+	}
+
+	host, port, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+
+	tags := slices.Clone(meta.Tags)
+	if !contains(tags, meta.Protocol) {
+		tags = append(tags, meta.Protocol)
+	}
+
+	return &ServiceInstance{
+		ID:       GenerateServiceID(idPrefix),
+		Name:     serviceName,
+		Address:  host,
+		Port:     port,
+		Protocol: meta.Protocol,
+		Version:  meta.Version,
+		Tags:     tags,
+		Metadata: map[string]string{
+			"version":  meta.Version,
+			"protocol": meta.Protocol,
+		},
+		Healthy: true,
+	}, nil
+}
+
+// clone 返回实例的深拷贝，避免调用方修改内部状态.
+func (i *ServiceInstance) clone() *ServiceInstance {
+	c := *i
+	c.Tags = slices.Clone(i.Tags)
+	if i.Metadata != nil {
+		c.Metadata = make(map[string]string, len(i.Metadata))
+		for k, v := range i.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return &c
+}
+
+// cloneInstances 深拷贝实例列表.
+func cloneInstances(instances []*ServiceInstance) []*ServiceInstance {
+	result := make([]*ServiceInstance, 0, len(instances))
+	for _, inst := range instances {
+		result = append(result, inst.clone())
+	}
+	return result
+}
+
+// endpoints 返回健康 gRPC 实例的地址列表，与 Discover 的过滤规则一致.
+func endpoints(instances []*ServiceInstance) []string {
+	filtered := FilterInstances(instances, ProtocolGRPC)
+	addrs := make([]string, 0, len(filtered))
+	for _, inst := range filtered {
+		addrs = append(addrs, inst.Endpoint())
+	}
+	return addrs
+}
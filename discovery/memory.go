@@ -0,0 +1,323 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/transport"
+)
+
+// memoryStores 进程内按命名空间共享的注册表.
+var memoryStores = struct {
+	mu     sync.Mutex
+	stores map[string]*memoryStore
+}{stores: make(map[string]*memoryStore)}
+
+// sharedMemoryStore 返回命名空间对应的注册表，不存在时创建.
+func sharedMemoryStore(namespace string) *memoryStore {
+	memoryStores.mu.Lock()
+	defer memoryStores.mu.Unlock()
+
+	store, ok := memoryStores.stores[namespace]
+	if !ok {
+		store = &memoryStore{
+			services: make(map[string]map[string]*memoryEntry),
+			owners:   make(map[string]string),
+			hub:      newWatchHub(),
+		}
+		memoryStores.stores[namespace] = store
+	}
+	return store
+}
+
+// memoryEntry 注册表中的实例及其过期时间.
+type memoryEntry struct {
+	instance *ServiceInstance
+	ttl      time.Duration
+	expires  time.Time
+	timer    *time.Timer
+}
+
+// memoryStore 进程内注册表，实例在 TTL 内未续约时自动移除.
+type memoryStore struct {
+	mu       sync.Mutex
+	services map[string]map[string]*memoryEntry // serviceName -> serviceID -> entry
+	owners   map[string]string                  // serviceID -> serviceName
+	hub      *watchHub
+}
+
+// add 添加实例并启动过期计时.
+func (s *memoryStore) add(instance *ServiceInstance, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryEntry{
+		instance: instance,
+		ttl:      ttl,
+		expires:  time.Now().Add(ttl),
+	}
+	entry.timer = time.AfterFunc(ttl, func() { s.expire(instance.ID) })
+
+	if s.services[instance.Name] == nil {
+		s.services[instance.Name] = make(map[string]*memoryEntry)
+	}
+	s.services[instance.Name][instance.ID] = entry
+	s.owners[instance.ID] = instance.Name
+	s.hub.publish(instance.Name, s.snapshotLocked(instance.Name))
+}
+
+// remove 移除实例，实例不存在时返回 false.
+func (s *memoryStore) remove(serviceID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeLocked(serviceID)
+}
+
+// renew 续约实例，返回仍存在的实例 ID.
+func (s *memoryStore) renew(serviceIDs []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alive := make([]string, 0, len(serviceIDs))
+	for _, id := range serviceIDs {
+		entry := s.lookupLocked(id)
+		if entry == nil {
+			continue
+		}
+		entry.expires = time.Now().Add(entry.ttl)
+		entry.timer.Reset(entry.ttl)
+		alive = append(alive, id)
+	}
+	return alive
+}
+
+// expire 计时器回调，未续约的实例被移除.
+func (s *memoryStore) expire(serviceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.lookupLocked(serviceID)
+	// 回调等待锁期间实例可能已被续约
+	if entry == nil || time.Now().Before(entry.expires) {
+		return
+	}
+	s.removeLocked(serviceID)
+}
+
+// instances 返回服务的实例快照.
+func (s *memoryStore) instances(serviceName string) []*ServiceInstance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked(serviceName)
+}
+
+// watch 订阅服务实例变化.
+func (s *memoryStore) watch(ctx context.Context, done <-chan struct{}, serviceName string) <-chan []*ServiceInstance {
+	// 持有注册表锁订阅，保证首次推送与后续变更之间不遗漏
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hub.subscribe(ctx, done, serviceName, s.snapshotLocked(serviceName))
+}
+
+func (s *memoryStore) lookupLocked(serviceID string) *memoryEntry {
+	name, ok := s.owners[serviceID]
+	if !ok {
+		return nil
+	}
+	return s.services[name][serviceID]
+}
+
+func (s *memoryStore) removeLocked(serviceID string) bool {
+	entry := s.lookupLocked(serviceID)
+	if entry == nil {
+		return false
+	}
+	entry.timer.Stop()
+
+	name := entry.instance.Name
+	delete(s.owners, serviceID)
+	delete(s.services[name], serviceID)
+	if len(s.services[name]) == 0 {
+		delete(s.services, name)
+	}
+	s.hub.publish(name, s.snapshotLocked(name))
+	return true
+}
+
+func (s *memoryStore) snapshotLocked(serviceName string) []*ServiceInstance {
+	entries := s.services[serviceName]
+	instances := make([]*ServiceInstance, 0, len(entries))
+	for _, entry := range entries {
+		instances = append(instances, entry.instance.clone())
+	}
+	sortInstances(instances)
+	return instances
+}
+
+// memoryDiscovery 是进程内服务发现实现.
+//
+// 相同 Addr（命名空间）的实例共享同一注册表，适用于测试和本地开发.
+// 注册的实例由后台心跳续约，Close 后停止续约，实例在 TTL 到期后被移除.
+type memoryDiscovery struct {
+	store  *memoryStore
+	config *Config
+	logger logger.Logger
+
+	mu         sync.Mutex
+	serviceIDs map[string]struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newMemoryDiscovery 创建进程内服务发现实例.
+func newMemoryDiscovery(config *Config, log logger.Logger) (Discovery, error) {
+	d := &memoryDiscovery{
+		store:      sharedMemoryStore(config.Addr),
+		config:     config,
+		logger:     log,
+		serviceIDs: make(map[string]struct{}),
+		done:       make(chan struct{}),
+	}
+
+	d.wg.Add(1)
+	go d.heartbeat()
+
+	return d, nil
+}
+
+// Register 注册服务实例，返回服务ID.
+func (d *memoryDiscovery) Register(ctx context.Context, serviceName, address string) (string, error) {
+	return d.register(serviceName, address, "")
+}
+
+// RegisterWithProtocol 根据协议注册服务实例，返回服务ID.
+func (d *memoryDiscovery) RegisterWithProtocol(ctx context.Context, serviceName, address, protocol string) (string, error) {
+	return d.register(serviceName, address, protocol)
+}
+
+// RegisterWithHealthEndpoint 注册服务实例，进程内实现以心跳代替健康检查，忽略 healthEndpoint.
+func (d *memoryDiscovery) RegisterWithHealthEndpoint(ctx context.Context, serviceName, address, protocol string, healthEndpoint *transport.HealthEndpoint) (string, error) {
+	return d.register(serviceName, address, protocol)
+}
+
+func (d *memoryDiscovery) register(serviceName, address, protocol string) (string, error) {
+	instance, err := newServiceInstance(d.config, serviceName, address, protocol)
+	if err != nil {
+		return "", err
+	}
+
+	d.store.add(instance, d.config.TTL)
+
+	d.mu.Lock()
+	d.serviceIDs[instance.ID] = struct{}{}
+	d.mu.Unlock()
+
+	d.logger.With(
+		logger.String("serviceName", serviceName),
+		logger.String("serviceID", instance.ID),
+		logger.String("addr", instance.Endpoint()),
+	).Debug("[Discovery] 服务注册成功")
+
+	return instance.ID, nil
+}
+
+// Unregister 注销服务实例.
+func (d *memoryDiscovery) Unregister(ctx context.Context, serviceID string) error {
+	if serviceID == "" {
+		return ErrEmptyServiceID
+	}
+
+	d.mu.Lock()
+	delete(d.serviceIDs, serviceID)
+	d.mu.Unlock()
+
+	// 实例可能已过期移除，视为注销成功
+	if d.store.remove(serviceID) {
+		d.logger.With(logger.String("serviceID", serviceID)).Debug("[Discovery] 服务注销成功")
+	}
+	return nil
+}
+
+// Discover 发现健康的 gRPC 服务实例.
+func (d *memoryDiscovery) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+
+	addrs := endpoints(d.store.instances(serviceName))
+	if len(addrs) == 0 {
+		d.logger.With(logger.String("serviceName", serviceName)).Warn("[Discovery] 未发现任何服务实例")
+	}
+	return addrs, nil
+}
+
+// Instances 返回服务的全部实例.
+func (d *memoryDiscovery) Instances(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+	return d.store.instances(serviceName), nil
+}
+
+// Watch 监听服务实例变化.
+func (d *memoryDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*ServiceInstance, error) {
+	if serviceName == "" {
+		return nil, ErrEmptyName
+	}
+	return d.store.watch(ctx, d.done, serviceName), nil
+}
+
+// Close 停止心跳并关闭所有监听，已注册的实例在 TTL 到期后移除.
+func (d *memoryDiscovery) Close() error {
+	d.closeOnce.Do(func() {
+		close(d.done)
+		d.wg.Wait()
+		d.logger.Debug("[Discovery] 内存服务发现已关闭")
+	})
+	return nil
+}
+
+// heartbeat 按 TTL 的三分之一周期续约本实例注册的服务.
+func (d *memoryDiscovery) heartbeat() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.config.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+		}
+
+		d.mu.Lock()
+		ids := make([]string, 0, len(d.serviceIDs))
+		for id := range d.serviceIDs {
+			ids = append(ids, id)
+		}
+		d.mu.Unlock()
+
+		if len(ids) == 0 {
+			continue
+		}
+		alive := d.store.renew(ids)
+		if len(alive) == len(ids) {
+			continue
+		}
+
+		// 心跳中断期间已过期的实例不再续约
+		d.mu.Lock()
+		for _, id := range ids {
+			if !contains(alive, id) {
+				delete(d.serviceIDs, id)
+				d.logger.With(logger.String("serviceID", id)).Warn("[Discovery] 服务实例已过期")
+			}
+		}
+		d.mu.Unlock()
+	}
+}
@@ -0,0 +1,171 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMemoryDiscovery 创建使用独立命名空间的内存服务发现.
+func newTestMemoryDiscovery(t *testing.T, ttl time.Duration) Discovery {
+	t.Helper()
+	d, err := NewDiscovery(&Config{Type: TypeMemory, Addr: t.Name(), TTL: ttl}, &mockLogger{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = d.Close() })
+	return d
+}
+
+// recvInstances 等待 Watch 推送.
+func recvInstances(t *testing.T, ch <-chan []*ServiceInstance) []*ServiceInstance {
+	t.Helper()
+	select {
+	case instances, ok := <-ch:
+		require.True(t, ok, "watch channel closed")
+		return instances
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for watch update")
+		return nil
+	}
+}
+
+func TestMemoryDiscovery_RegisterAndDiscover(t *testing.T) {
+	ctx := context.Background()
+	d := newTestMemoryDiscovery(t, time.Minute)
+
+	grpcID, err := d.RegisterWithProtocol(ctx, "test-service", "0.0.0.0:9090", ProtocolGRPC)
+	require.NoError(t, err)
+	_, err = d.RegisterWithProtocol(ctx, "test-service", "127.0.0.1:8080", ProtocolHTTP)
+	require.NoError(t, err)
+
+	addrs, err := d.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:9090"}, addrs)
+
+	instances, err := d.Instances(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Len(t, instances, 2)
+
+	require.NoError(t, d.Unregister(ctx, grpcID))
+	addrs, err = d.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Empty(t, addrs)
+
+	// 重复注销不报错
+	assert.NoError(t, d.Unregister(ctx, grpcID))
+}
+
+func TestMemoryDiscovery_Validation(t *testing.T) {
+	ctx := context.Background()
+	d := newTestMemoryDiscovery(t, time.Minute)
+
+	_, err := d.Register(ctx, "", "127.0.0.1:9090")
+	assert.ErrorIs(t, err, ErrEmptyName)
+	_, err = d.Register(ctx, "svc", "")
+	assert.ErrorIs(t, err, ErrEmptyAddress)
+	_, err = d.Register(ctx, "svc", "invalid")
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+	_, err = d.RegisterWithProtocol(ctx, "svc", "127.0.0.1:9090", "websocket")
+	assert.ErrorIs(t, err, ErrUnsupportedProtocol)
+	assert.ErrorIs(t, d.Unregister(ctx, ""), ErrEmptyServiceID)
+	_, err = d.Discover(ctx, "")
+	assert.ErrorIs(t, err, ErrEmptyName)
+	_, err = d.Watch(ctx, "")
+	assert.ErrorIs(t, err, ErrEmptyName)
+}
+
+func TestMemoryDiscovery_SharedAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{Type: TypeMemory, Addr: t.Name()}
+
+	server, err := NewDiscovery(config, &mockLogger{})
+	require.NoError(t, err)
+	defer server.Close()
+	client, err := NewDiscovery(&Config{Type: TypeMemory, Addr: t.Name()}, &mockLogger{})
+	require.NoError(t, err)
+	defer client.Close()
+	other, err := NewDiscovery(&Config{Type: TypeMemory, Addr: t.Name() + "-other"}, &mockLogger{})
+	require.NoError(t, err)
+	defer other.Close()
+
+	_, err = server.Register(ctx, "test-service", "127.0.0.1:9090")
+	require.NoError(t, err)
+
+	addrs, err := client.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:9090"}, addrs)
+
+	// 不同命名空间互相隔离
+	addrs, err = other.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Empty(t, addrs)
+}
+
+func TestMemoryDiscovery_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := newTestMemoryDiscovery(t, time.Minute)
+
+	ch, err := d.Watch(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Empty(t, recvInstances(t, ch))
+
+	id, err := d.Register(ctx, "test-service", "127.0.0.1:9090")
+	require.NoError(t, err)
+	instances := recvInstances(t, ch)
+	require.Len(t, instances, 1)
+	assert.Equal(t, id, instances[0].ID)
+	assert.True(t, instances[0].Healthy)
+
+	require.NoError(t, d.Unregister(ctx, id))
+	assert.Empty(t, recvInstances(t, ch))
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestMemoryDiscovery_TTL(t *testing.T) {
+	ctx := context.Background()
+	const ttl = 150 * time.Millisecond
+
+	owner, err := NewDiscovery(&Config{Type: TypeMemory, Addr: t.Name(), TTL: ttl}, &mockLogger{})
+	require.NoError(t, err)
+	observer := newTestMemoryDiscovery(t, ttl)
+
+	ch, err := observer.Watch(ctx, "test-service")
+	require.NoError(t, err)
+	recvInstances(t, ch)
+
+	_, err = owner.Register(ctx, "test-service", "127.0.0.1:9090")
+	require.NoError(t, err)
+	require.Len(t, recvInstances(t, ch), 1)
+
+	// 心跳续约期间实例保持存在
+	time.Sleep(3 * ttl)
+	addrs, err := observer.Discover(ctx, "test-service")
+	require.NoError(t, err)
+	assert.Len(t, addrs, 1)
+
+	// 关闭后停止心跳，实例过期移除
+	require.NoError(t, owner.Close())
+	assert.Empty(t, recvInstances(t, ch))
+}
+
+func TestMemoryDiscovery_CloseStopsWatch(t *testing.T) {
+	d, err := NewDiscovery(&Config{Type: TypeMemory, Addr: t.Name()}, &mockLogger{})
+	require.NoError(t, err)
+
+	ch, err := d.Watch(context.Background(), "test-service")
+	require.NoError(t, err)
+	recvInstances(t, ch)
+
+	require.NoError(t, d.Close())
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("watch channel not closed")
+	}
+}
@@ -53,6 +53,20 @@ func (m *mockDiscovery) Discover(ctx context.Context, serviceName string) ([]str
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *mockDiscovery) Instances(ctx context.Context, serviceName string) ([]*ServiceInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	args := m.Called(ctx, serviceName)
+	return args.Get(0).([]*ServiceInstance), args.Error(1)
+}
+
+func (m *mockDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*ServiceInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	args := m.Called(ctx, serviceName)
+	return args.Get(0).(<-chan []*ServiceInstance), args.Error(1)
+}
+
 func (m *mockDiscovery) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
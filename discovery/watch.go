@@ -0,0 +1,66 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+)
+
+// watchHub 管理服务实例变化的订阅者，供进程内实现复用.
+//
+// 每个订阅通道容量为 1，只保留最新的实例列表，发布方不会被慢消费者阻塞.
+type watchHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan []*ServiceInstance]struct{}
+}
+
+// newWatchHub 创建订阅管理器.
+func newWatchHub() *watchHub {
+	return &watchHub{subs: make(map[string]map[chan []*ServiceInstance]struct{})}
+}
+
+// subscribe 订阅服务实例变化并立即推送 current.
+// ctx 取消或 done 关闭后移除订阅并关闭通道.
+// 调用方需保证 current 与后续 publish 之间不存在遗漏的变更.
+func (h *watchHub) subscribe(ctx context.Context, done <-chan struct{}, serviceName string, current []*ServiceInstance) <-chan []*ServiceInstance {
+	ch := make(chan []*ServiceInstance, 1)
+	ch <- current
+
+	h.mu.Lock()
+	if h.subs[serviceName] == nil {
+		h.subs[serviceName] = make(map[chan []*ServiceInstance]struct{})
+	}
+	h.subs[serviceName][ch] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[serviceName], ch)
+		if len(h.subs[serviceName]) == 0 {
+			delete(h.subs, serviceName)
+		}
+		close(ch)
+	}()
+
+	return ch
+}
+
+// publish 向服务的所有订阅者推送最新实例列表.
+func (h *watchHub) publish(serviceName string, instances []*ServiceInstance) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[serviceName] {
+		// 丢弃未消费的旧列表，只保留最新值
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- cloneInstances(instances)
+	}
+}
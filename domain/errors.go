@@ -8,4 +8,8 @@ var (
 	ErrNotFound = errors.New("未找到")
 	// ErrConcurrencyConflict 并发冲突错误.
 	ErrConcurrencyConflict = errors.New("并发冲突")
+	// ErrEventNotRegistered 事件类型未注册.
+	ErrEventNotRegistered = errors.New("事件类型未注册")
+	// ErrNoApplyHandler 事件没有注册 Apply 处理器.
+	ErrNoApplyHandler = errors.New("事件没有注册 Apply 处理器")
 )
@@ -21,3 +21,9 @@ func NewBaseEvent(name string) BaseEvent {
 
 func (e BaseEvent) EventName() string       { return e.name }
 func (e BaseEvent) OccurredTime() time.Time { return e.occurredTime }
+
+// restore 反序列化后恢复名称和发生时间，见 EventRegistry.Decode.
+func (e *BaseEvent) restore(name string, occurredTime time.Time) {
+	e.name = name
+	e.occurredTime = occurredTime
+}
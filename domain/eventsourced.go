@@ -0,0 +1,288 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// EventSourced 事件溯源聚合根基类.
+//
+// 聚合通过 Apply 产生事件并更新状态，加载时由 Repository 重放事件流重建状态.
+// 状态只在 Apply 处理器中修改，处理器通过 On 按事件类型注册.
+//
+// 示例:
+//
+//	type Order struct {
+//	    domain.EventSourced[string]
+//	    Status string
+//	}
+//
+//	func NewOrder(id string) *Order {
+//	    o := &Order{EventSourced: domain.NewEventSourced(id)}
+//	    domain.On(&o.EventSourced, func(e OrderCreated) { o.Status = "created" })
+//	    return o
+//	}
+//
+//	func (o *Order) Create(userID string) error {
+//	    return o.Apply(OrderCreated{UserID: userID})
+//	}
+type EventSourced[ID any] struct {
+	AggregateRoot[ID]
+	version  int64
+	handlers map[reflect.Type]func(DomainEvent)
+}
+
+// NewEventSourced 创建事件溯源聚合根.
+func NewEventSourced[ID any](id ID) EventSourced[ID] {
+	return EventSourced[ID]{
+		AggregateRoot: NewAggregateRoot(id),
+		handlers:      make(map[reflect.Type]func(DomainEvent)),
+	}
+}
+
+// On 注册事件的 Apply 处理器，按事件的具体类型匹配.
+func On[ID any, E DomainEvent](a *EventSourced[ID], apply func(E)) {
+	if a.handlers == nil {
+		a.handlers = make(map[reflect.Type]func(DomainEvent))
+	}
+	a.handlers[reflect.TypeFor[E]()] = func(event DomainEvent) { apply(event.(E)) }
+}
+
+// StreamID 返回事件流标识，默认为聚合 ID.
+func (a *EventSourced[ID]) StreamID() string { return fmt.Sprint(a.ID()) }
+
+// Version 返回已持久化的流版本，不包括未保存的事件.
+func (a *EventSourced[ID]) Version() int64 { return a.version }
+
+// Apply 应用新事件更新状态，并记录为待保存的领域事件.
+func (a *EventSourced[ID]) Apply(event DomainEvent) error {
+	if err := a.handle(event); err != nil {
+		return err
+	}
+	a.RaiseEvent(event)
+	return nil
+}
+
+// replay 重放已持久化的事件.
+func (a *EventSourced[ID]) replay(event DomainEvent) error {
+	if err := a.handle(event); err != nil {
+		return err
+	}
+	a.version++
+	return nil
+}
+
+// committed 保存成功后更新版本并清除待保存的事件.
+func (a *EventSourced[ID]) committed(version int64) {
+	a.version = version
+	a.ClearDomainEvents()
+}
+
+// handle 调用事件的 Apply 处理器.
+func (a *EventSourced[ID]) handle(event DomainEvent) error {
+	apply, ok := a.handlers[reflect.TypeOf(event)]
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrNoApplyHandler, event)
+	}
+	apply(event)
+	return nil
+}
+
+// Aggregate 事件溯源聚合接口，嵌入 EventSourced 的聚合指针满足该接口.
+type Aggregate interface {
+	StreamID() string
+	Version() int64
+	DomainEvents() []DomainEvent
+	replay(event DomainEvent) error
+	committed(version int64)
+}
+
+// Snapshotter 支持快照的聚合.
+//
+// 实现后 Repository 按 WithSnapshots 的间隔保存快照，加载时从快照恢复后只重放之后的事件.
+type Snapshotter interface {
+	// SnapshotState 序列化聚合状态.
+	SnapshotState() ([]byte, error)
+
+	// RestoreSnapshot 从快照恢复聚合状态.
+	RestoreSnapshot(state []byte) error
+}
+
+// RepositoryOption 事件溯源仓储配置选项.
+type RepositoryOption func(*repositoryOptions)
+
+// repositoryOptions 事件溯源仓储配置.
+type repositoryOptions struct {
+	streamPrefix  string
+	snapshots     SnapshotStore
+	snapshotEvery int64
+	bus           *EventBus
+}
+
+// WithStreamPrefix 设置事件流前缀，用于区分不同聚合类型的流，如 "order-".
+func WithStreamPrefix(prefix string) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.streamPrefix = prefix
+	}
+}
+
+// WithSnapshots 设置快照存储，流版本每增加 every 个事件保存一次快照.
+//
+// 聚合需要实现 Snapshotter. 快照只用于加速加载，保存失败不影响事件的保存.
+func WithSnapshots(store SnapshotStore, every int) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.snapshots = store
+		o.snapshotEvery = int64(every)
+	}
+}
+
+// WithEventBus 设置事件总线，事件保存成功后发布.
+func WithEventBus(bus *EventBus) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.bus = bus
+	}
+}
+
+// Repository 事件溯源仓储，负责保存聚合的新事件和重放事件流加载聚合.
+type Repository[T Aggregate] struct {
+	store    EventStore
+	registry *EventRegistry
+	factory  func(id string) T
+	opts     *repositoryOptions
+}
+
+// NewRepository 创建事件溯源仓储.
+//
+// factory 创建指定 ID 的空聚合并注册 Apply 处理器.
+func NewRepository[T Aggregate](store EventStore, registry *EventRegistry, factory func(id string) T, opts ...RepositoryOption) *Repository[T] {
+	if store == nil {
+		panic("domain: store 不能为空")
+	}
+	if registry == nil {
+		panic("domain: registry 不能为空")
+	}
+	if factory == nil {
+		panic("domain: factory 不能为空")
+	}
+
+	o := &repositoryOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Repository[T]{store: store, registry: registry, factory: factory, opts: o}
+}
+
+// Load 加载聚合，流不存在时返回 ErrNotFound.
+func (r *Repository[T]) Load(ctx context.Context, id string) (T, error) {
+	var zero T
+	agg := r.factory(id)
+	streamID := r.streamID(agg)
+
+	after, err := r.restoreSnapshot(ctx, agg, streamID)
+	if err != nil {
+		return zero, err
+	}
+
+	events, err := r.store.Load(ctx, streamID, after)
+	if err != nil {
+		return zero, err
+	}
+	if after == 0 && len(events) == 0 {
+		return zero, ErrNotFound
+	}
+
+	for _, stored := range events {
+		event, err := r.registry.Decode(stored)
+		if err != nil {
+			return zero, err
+		}
+		if err := agg.replay(event); err != nil {
+			return zero, err
+		}
+	}
+	return agg, nil
+}
+
+// Save 追加聚合的新事件，流版本已被其他写入更新时返回 ErrConcurrencyConflict.
+func (r *Repository[T]) Save(ctx context.Context, agg T) error {
+	pending := agg.DomainEvents()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	events := make([]*StoredEvent, len(pending))
+	for i, event := range pending {
+		stored, err := r.registry.Encode(event)
+		if err != nil {
+			return err
+		}
+		events[i] = stored
+	}
+
+	expected := agg.Version()
+	if err := r.store.Append(ctx, r.streamID(agg), expected, events...); err != nil {
+		return err
+	}
+	version := expected + int64(len(events))
+	agg.committed(version)
+
+	if r.opts.snapshotEvery > 0 && version/r.opts.snapshotEvery > expected/r.opts.snapshotEvery {
+		r.saveSnapshot(ctx, agg, version)
+	}
+
+	if r.opts.bus != nil {
+		for _, event := range pending {
+			if err := r.opts.bus.Publish(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreSnapshot 从快照恢复聚合，返回快照对应的版本，没有快照时返回 0.
+func (r *Repository[T]) restoreSnapshot(ctx context.Context, agg T, streamID string) (int64, error) {
+	snapshotter, ok := any(agg).(Snapshotter)
+	if r.opts.snapshots == nil || !ok {
+		return 0, nil
+	}
+
+	snapshot, err := r.opts.snapshots.LoadSnapshot(ctx, streamID)
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := snapshotter.RestoreSnapshot(snapshot.State); err != nil {
+		return 0, err
+	}
+	agg.committed(snapshot.Version)
+	return snapshot.Version, nil
+}
+
+// saveSnapshot 保存聚合快照，失败时忽略，下次达到间隔时重新保存.
+func (r *Repository[T]) saveSnapshot(ctx context.Context, agg T, version int64) {
+	snapshotter, ok := any(agg).(Snapshotter)
+	if r.opts.snapshots == nil || !ok {
+		return
+	}
+	state, err := snapshotter.SnapshotState()
+	if err != nil {
+		return
+	}
+	_ = r.opts.snapshots.SaveSnapshot(ctx, &Snapshot{
+		StreamID:    r.streamID(agg),
+		Version:     version,
+		State:       state,
+		CreatedTime: time.Now(),
+	})
+}
+
+// streamID 返回聚合的事件流标识.
+func (r *Repository[T]) streamID(agg T) string {
+	return r.opts.streamPrefix + agg.StreamID()
+}
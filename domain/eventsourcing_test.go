@@ -0,0 +1,271 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// orderCreated 嵌入 BaseEvent 的测试事件.
+type orderCreated struct {
+	BaseEvent
+	UserID string `json:"user_id"`
+}
+
+func newOrderCreated(userID string) orderCreated {
+	return orderCreated{BaseEvent: NewBaseEvent("OrderCreated"), UserID: userID}
+}
+
+// itemAdded 自行实现 DomainEvent 的测试事件.
+type itemAdded struct {
+	SKU      string    `json:"sku"`
+	Quantity int       `json:"quantity"`
+	At       time.Time `json:"at"`
+}
+
+func (e *itemAdded) EventName() string       { return "ItemAdded" }
+func (e *itemAdded) OccurredTime() time.Time { return e.At }
+
+// testOrder 事件溯源测试聚合.
+type testOrder struct {
+	EventSourced[string]
+	UserID  string
+	Items   int
+	replays int
+}
+
+func newTestOrder(id string) *testOrder {
+	o := &testOrder{EventSourced: NewEventSourced(id)}
+	On(&o.EventSourced, func(e orderCreated) { o.UserID = e.UserID; o.replays++ })
+	On(&o.EventSourced, func(e *itemAdded) { o.Items += e.Quantity; o.replays++ })
+	return o
+}
+
+func (o *testOrder) SnapshotState() ([]byte, error) {
+	return json.Marshal(map[string]any{"user_id": o.UserID, "items": o.Items})
+}
+
+func (o *testOrder) RestoreSnapshot(state []byte) error {
+	var s struct {
+		UserID string `json:"user_id"`
+		Items  int    `json:"items"`
+	}
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+	o.UserID, o.Items = s.UserID, s.Items
+	return nil
+}
+
+func newTestRegistry() *EventRegistry {
+	registry := NewEventRegistry()
+	registry.Register(newOrderCreated(""), &itemAdded{})
+	return registry
+}
+
+func newTestGORMEventStore(t *testing.T) *GORMEventStore {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store := NewGORMEventStore(db)
+	require.NoError(t, store.AutoMigrate(context.Background()))
+	return store
+}
+
+func TestEventRegistry(t *testing.T) {
+	registry := newTestRegistry()
+	assert.Panics(t, func() { registry.Register(NewBaseEvent("OrderCreated")) }, "同名不同类型")
+	assert.Panics(t, func() { registry.Register(BaseEvent{}) }, "名称为空")
+
+	created := newOrderCreated("user-1")
+	stored, err := registry.Encode(created)
+	require.NoError(t, err)
+	assert.Equal(t, "OrderCreated", stored.EventName)
+	assert.Equal(t, created.OccurredTime(), stored.OccurredTime)
+
+	// 嵌入 BaseEvent 的事件恢复名称和发生时间
+	event, err := registry.Decode(stored)
+	require.NoError(t, err)
+	decoded, ok := event.(orderCreated)
+	require.True(t, ok)
+	assert.Equal(t, "user-1", decoded.UserID)
+	assert.Equal(t, "OrderCreated", decoded.EventName())
+	assert.True(t, created.OccurredTime().Equal(decoded.OccurredTime()))
+
+	// 指针类型按指针返回
+	stored, err = registry.Encode(&itemAdded{SKU: "sku-1", Quantity: 2})
+	require.NoError(t, err)
+	event, err = registry.Decode(stored)
+	require.NoError(t, err)
+	assert.Equal(t, 2, event.(*itemAdded).Quantity)
+
+	_, err = registry.Encode(NewBaseEvent("Unknown"))
+	assert.ErrorIs(t, err, ErrEventNotRegistered)
+	_, err = registry.Decode(&StoredEvent{EventName: "Unknown"})
+	assert.ErrorIs(t, err, ErrEventNotRegistered)
+}
+
+func TestEventStore(t *testing.T) {
+	stores := map[string]interface {
+		EventStore
+		SnapshotStore
+	}{
+		"memory": NewMemoryEventStore(),
+		"gorm":   newTestGORMEventStore(t),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			newEvent := func(name string) *StoredEvent {
+				return &StoredEvent{EventName: name, Payload: []byte(`{}`), OccurredTime: time.Now()}
+			}
+
+			first := []*StoredEvent{newEvent("OrderCreated"), newEvent("ItemAdded")}
+			require.NoError(t, store.Append(ctx, "order-1", 0, first...))
+			assert.Equal(t, int64(2), first[1].Version)
+			assert.Equal(t, "order-1", first[1].StreamID)
+			require.NoError(t, store.Append(ctx, "order-2", 0, newEvent("OrderCreated")))
+
+			// 基于旧版本追加返回冲突，不写入任何事件
+			err := store.Append(ctx, "order-1", 1, newEvent("ItemAdded"))
+			assert.ErrorIs(t, err, ErrConcurrencyConflict)
+			require.NoError(t, store.Append(ctx, "order-1", 2, newEvent("ItemAdded")))
+
+			events, err := store.Load(ctx, "order-1", 0)
+			require.NoError(t, err)
+			require.Len(t, events, 3)
+			for i, event := range events {
+				assert.Equal(t, int64(i+1), event.Version)
+			}
+			assert.Equal(t, "OrderCreated", events[0].EventName)
+
+			events, err = store.Load(ctx, "order-1", 2)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, int64(3), events[0].Version)
+
+			events, err = store.Load(ctx, "missing", 0)
+			require.NoError(t, err)
+			assert.Empty(t, events)
+
+			// 全局位置跨流递增
+			all, err := store.ReadAll(ctx, 0, 0)
+			require.NoError(t, err)
+			require.Len(t, all, 4)
+			assert.Equal(t, []string{"order-1", "order-1", "order-2", "order-1"},
+				[]string{all[0].StreamID, all[1].StreamID, all[2].StreamID, all[3].StreamID})
+			page, err := store.ReadAll(ctx, all[1].Position, 1)
+			require.NoError(t, err)
+			require.Len(t, page, 1)
+			assert.Equal(t, all[2].Position, page[0].Position)
+
+			_, err = store.LoadSnapshot(ctx, "order-1")
+			assert.ErrorIs(t, err, ErrNotFound)
+			require.NoError(t, store.SaveSnapshot(ctx, &Snapshot{StreamID: "order-1", Version: 2, State: []byte("v2")}))
+			require.NoError(t, store.SaveSnapshot(ctx, &Snapshot{StreamID: "order-1", Version: 3, State: []byte("v3")}))
+			snapshot, err := store.LoadSnapshot(ctx, "order-1")
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), snapshot.Version)
+			assert.Equal(t, []byte("v3"), snapshot.State)
+		})
+	}
+}
+
+func TestEventSourced_Apply(t *testing.T) {
+	order := newTestOrder("order-1")
+	require.NoError(t, order.Apply(newOrderCreated("user-1")))
+	assert.Equal(t, "user-1", order.UserID)
+	assert.Len(t, order.DomainEvents(), 1)
+	assert.Zero(t, order.Version())
+
+	err := order.Apply(NewBaseEvent("Unknown"))
+	assert.ErrorIs(t, err, ErrNoApplyHandler)
+	assert.Len(t, order.DomainEvents(), 1, "没有处理器的事件不记录")
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	bus := NewEventBus()
+	var published []string
+	bus.SubscribeAll(func(_ context.Context, event DomainEvent) error {
+		published = append(published, event.EventName())
+		return nil
+	})
+	repo := NewRepository(store, newTestRegistry(), newTestOrder, WithStreamPrefix("order-"), WithEventBus(bus))
+
+	_, err := repo.Load(ctx, "1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	order := newTestOrder("1")
+	require.NoError(t, order.Apply(newOrderCreated("user-1")))
+	require.NoError(t, order.Apply(&itemAdded{SKU: "sku-1", Quantity: 2}))
+	require.NoError(t, repo.Save(ctx, order))
+	assert.Equal(t, int64(2), order.Version())
+	assert.Empty(t, order.DomainEvents())
+	assert.Equal(t, []string{"OrderCreated", "ItemAdded"}, published)
+
+	events, err := store.Load(ctx, "order-1", 0)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	loaded, err := repo.Load(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", loaded.UserID)
+	assert.Equal(t, 2, loaded.Items)
+	assert.Equal(t, int64(2), loaded.Version())
+
+	// 两个副本并发修改，后保存的冲突
+	stale, err := repo.Load(ctx, "1")
+	require.NoError(t, err)
+	require.NoError(t, loaded.Apply(&itemAdded{SKU: "sku-2", Quantity: 1}))
+	require.NoError(t, repo.Save(ctx, loaded))
+	require.NoError(t, stale.Apply(&itemAdded{SKU: "sku-3", Quantity: 1}))
+	assert.ErrorIs(t, repo.Save(ctx, stale), ErrConcurrencyConflict)
+}
+
+func TestRepository_Snapshots(t *testing.T) {
+	ctx := context.Background()
+	store := newTestGORMEventStore(t)
+	repo := NewRepository(store, newTestRegistry(), newTestOrder, WithSnapshots(store, 3))
+
+	order := newTestOrder("order-1")
+	require.NoError(t, order.Apply(newOrderCreated("user-1")))
+	require.NoError(t, order.Apply(&itemAdded{SKU: "sku-1", Quantity: 1}))
+	require.NoError(t, repo.Save(ctx, order))
+
+	_, err := store.LoadSnapshot(ctx, "order-1")
+	assert.ErrorIs(t, err, ErrNotFound, "未达到快照间隔")
+
+	for range 2 {
+		require.NoError(t, order.Apply(&itemAdded{SKU: "sku-1", Quantity: 1}))
+		require.NoError(t, repo.Save(ctx, order))
+	}
+	snapshot, err := store.LoadSnapshot(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), snapshot.Version)
+
+	// 从快照恢复后只重放之后的事件
+	loaded, err := repo.Load(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", loaded.UserID)
+	assert.Equal(t, 3, loaded.Items)
+	assert.Equal(t, int64(4), loaded.Version())
+	assert.Equal(t, 1, loaded.replays)
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// StoredEvent 事件存储中的事件.
+//
+// 由调用方填充 EventName、Payload、Metadata 和 OccurredTime，
+// StreamID、Version 和 Position 在追加时由 EventStore 设置.
+type StoredEvent struct {
+	// Position 全局位置，所有流共享递增序列，用于投影按顺序读取，可能不连续
+	Position int64 `gorm:"column:position;primaryKey;autoIncrement"`
+
+	// StreamID 事件流标识，通常为聚合类型和 ID
+	StreamID string `gorm:"column:stream_id;size:255;not null;uniqueIndex:idx_event_stream_version,priority:1"`
+
+	// Version 流内版本，从 1 开始连续递增
+	Version int64 `gorm:"column:version;not null;uniqueIndex:idx_event_stream_version,priority:2"`
+
+	// EventName 事件名称，通过 EventRegistry 反序列化
+	EventName string `gorm:"column:event_name;size:255;not null"`
+
+	// Payload 序列化后的事件
+	Payload []byte `gorm:"column:payload"`
+
+	// Metadata 元数据，如关联 ID、操作人
+	Metadata map[string]string `gorm:"column:metadata;serializer:json;type:text"`
+
+	// OccurredTime 事件发生时间
+	OccurredTime time.Time `gorm:"column:occurred_time"`
+}
+
+// Snapshot 聚合快照.
+type Snapshot struct {
+	// StreamID 事件流标识
+	StreamID string `gorm:"column:stream_id;primaryKey;size:255"`
+
+	// Version 快照对应的流版本，加载时从该版本之后重放事件
+	Version int64 `gorm:"column:version;not null"`
+
+	// State 序列化后的聚合状态
+	State []byte `gorm:"column:state"`
+
+	// CreatedTime 创建时间
+	CreatedTime time.Time `gorm:"column:created_time"`
+}
+
+// EventStore 事件存储接口.
+type EventStore interface {
+	// Append 追加事件到流.
+	//
+	// expectedVersion 为调用方读取到的流版本（新流为 0），与当前版本不一致时返回 ErrConcurrencyConflict.
+	// 成功后设置每个事件的 StreamID、Version 和 Position.
+	Append(ctx context.Context, streamID string, expectedVersion int64, events ...*StoredEvent) error
+
+	// Load 按版本顺序加载流中版本大于 afterVersion 的事件，流不存在时返回空列表.
+	Load(ctx context.Context, streamID string, afterVersion int64) ([]*StoredEvent, error)
+
+	// ReadAll 按全局位置顺序读取位置大于 afterPosition 的事件，limit <= 0 时不限制数量.
+	//
+	// 返回的事件按位置升序排列，但位置之间可能存在空洞:
+	//   - 追加失败回滚的事务会永久占用位置
+	//   - 并发追加的事务提交顺序可能与位置分配顺序不同，较小的位置可能在较大的位置之后才可见
+	//
+	// 因此读到位置 N 后，小于 N 的空洞位置仍可能在之后出现. 依赖完整顺序的消费方
+	// 不能直接把检查点推进到最后一个事件，应在空洞处等待一段时间，超时后再视为永久空洞.
+	ReadAll(ctx context.Context, afterPosition int64, limit int) ([]*StoredEvent, error)
+}
+
+// SnapshotStore 快照存储接口.
+type SnapshotStore interface {
+	// SaveSnapshot 保存快照，覆盖同一个流的旧快照.
+	SaveSnapshot(ctx context.Context, snapshot *Snapshot) error
+
+	// LoadSnapshot 加载流的最新快照，不存在时返回 ErrNotFound.
+	LoadSnapshot(ctx context.Context, streamID string) (*Snapshot, error)
+}
+
+// MemoryEventStore 内存事件存储，同时实现 EventStore 和 SnapshotStore.
+//
+// 适用于测试和单实例场景，进程重启后数据丢失.
+type MemoryEventStore struct {
+	mu        sync.RWMutex
+	events    []*StoredEvent
+	streams   map[string][]*StoredEvent
+	snapshots map[string]*Snapshot
+}
+
+// NewMemoryEventStore 创建内存事件存储.
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{
+		streams:   make(map[string][]*StoredEvent),
+		snapshots: make(map[string]*Snapshot),
+	}
+}
+
+// Append 追加事件到流.
+func (s *MemoryEventStore) Append(ctx context.Context, streamID string, expectedVersion int64, events ...*StoredEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := s.streams[streamID]
+	if int64(len(stream)) != expectedVersion {
+		return ErrConcurrencyConflict
+	}
+
+	for i, event := range events {
+		event.StreamID = streamID
+		event.Version = expectedVersion + int64(i) + 1
+		event.Position = int64(len(s.events)) + 1
+
+		stored := *event
+		s.events = append(s.events, &stored)
+		stream = append(stream, &stored)
+	}
+	s.streams[streamID] = stream
+	return nil
+}
+
+// Load 加载流中版本大于 afterVersion 的事件.
+func (s *MemoryEventStore) Load(ctx context.Context, streamID string, afterVersion int64) ([]*StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stream := s.streams[streamID]
+	if afterVersion < 0 {
+		afterVersion = 0
+	}
+	if afterVersion >= int64(len(stream)) {
+		return nil, nil
+	}
+	return copyEvents(stream[afterVersion:]), nil
+}
+
+// ReadAll 按全局位置读取位置大于 afterPosition 的事件.
+func (s *MemoryEventStore) ReadAll(ctx context.Context, afterPosition int64, limit int) ([]*StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if afterPosition < 0 {
+		afterPosition = 0
+	}
+	if afterPosition >= int64(len(s.events)) {
+		return nil, nil
+	}
+	events := s.events[afterPosition:]
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return copyEvents(events), nil
+}
+
+// SaveSnapshot 保存快照.
+func (s *MemoryEventStore) SaveSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *snapshot
+	s.snapshots[snapshot.StreamID] = &stored
+	return nil
+}
+
+// LoadSnapshot 加载流的最新快照.
+func (s *MemoryEventStore) LoadSnapshot(ctx context.Context, streamID string) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, ok := s.snapshots[streamID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	loaded := *snapshot
+	return &loaded, nil
+}
+
+// copyEvents 复制事件，避免调用方修改存储中的数据.
+func copyEvents(events []*StoredEvent) []*StoredEvent {
+	copied := make([]*StoredEvent, len(events))
+	for i, event := range events {
+		e := *event
+		copied[i] = &e
+	}
+	return copied
+}
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 默认表名.
+const (
+	// DefaultEventTable 默认事件表名
+	DefaultEventTable = "domain_events"
+	// DefaultSnapshotTable 默认快照表名
+	DefaultSnapshotTable = "domain_snapshots"
+)
+
+// GORMEventStoreOption GORM 事件存储配置选项.
+type GORMEventStoreOption func(*GORMEventStore)
+
+// WithEventTable 设置事件表名.
+func WithEventTable(table string) GORMEventStoreOption {
+	return func(s *GORMEventStore) {
+		s.eventTable = table
+	}
+}
+
+// WithSnapshotTable 设置快照表名.
+func WithSnapshotTable(table string) GORMEventStoreOption {
+	return func(s *GORMEventStore) {
+		s.snapshotTable = table
+	}
+}
+
+// GORMEventStore 基于 GORM 的事件存储，同时实现 EventStore 和 SnapshotStore.
+//
+// (stream_id, version) 上的唯一索引保证并发追加同一个流时只有一个成功.
+// 全局位置由数据库自增列分配，分配发生在插入时而可见发生在提交时，
+// 并发追加不同的流时 ReadAll 可能先读到较大的位置，见 EventStore.ReadAll.
+type GORMEventStore struct {
+	db            *gorm.DB
+	eventTable    string
+	snapshotTable string
+}
+
+// NewGORMEventStore 创建基于 GORM 的事件存储.
+func NewGORMEventStore(db *gorm.DB, opts ...GORMEventStoreOption) *GORMEventStore {
+	if db == nil {
+		panic("domain: db 不能为空")
+	}
+	s := &GORMEventStore{
+		db:            db,
+		eventTable:    DefaultEventTable,
+		snapshotTable: DefaultSnapshotTable,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// AutoMigrate 创建或更新事件表和快照表结构.
+func (s *GORMEventStore) AutoMigrate(ctx context.Context) error {
+	if err := s.db.WithContext(ctx).Table(s.eventTable).AutoMigrate(&StoredEvent{}); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Table(s.snapshotTable).AutoMigrate(&Snapshot{})
+}
+
+// Append 追加事件到流.
+func (s *GORMEventStore) Append(ctx context.Context, streamID string, expectedVersion int64, events ...*StoredEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	for i, event := range events {
+		event.Position = 0
+		event.StreamID = streamID
+		event.Version = expectedVersion + int64(i) + 1
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := s.streamVersion(tx, streamID)
+		if err != nil {
+			return err
+		}
+		if current != expectedVersion {
+			return ErrConcurrencyConflict
+		}
+		return tx.Table(s.eventTable).Create(events).Error
+	})
+	if err == nil || errors.Is(err, ErrConcurrencyConflict) {
+		return err
+	}
+
+	// 并发追加时唯一索引冲突，错误类型与驱动有关，以当前版本判断
+	if current, verr := s.streamVersion(s.db.WithContext(ctx), streamID); verr == nil && current != expectedVersion {
+		return ErrConcurrencyConflict
+	}
+	return err
+}
+
+// Load 加载流中版本大于 afterVersion 的事件.
+func (s *GORMEventStore) Load(ctx context.Context, streamID string, afterVersion int64) ([]*StoredEvent, error) {
+	var events []*StoredEvent
+	err := s.db.WithContext(ctx).Table(s.eventTable).
+		Where("stream_id = ? AND version > ?", streamID, afterVersion).
+		Order("version ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// ReadAll 按全局位置读取位置大于 afterPosition 的已提交事件，结果可能包含空洞.
+func (s *GORMEventStore) ReadAll(ctx context.Context, afterPosition int64, limit int) ([]*StoredEvent, error) {
+	query := s.db.WithContext(ctx).Table(s.eventTable).
+		Where("position > ?", afterPosition).
+		Order("position ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var events []*StoredEvent
+	err := query.Find(&events).Error
+	return events, err
+}
+
+// SaveSnapshot 保存快照，覆盖同一个流的旧快照.
+func (s *GORMEventStore) SaveSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	if snapshot.CreatedTime.IsZero() {
+		snapshot.CreatedTime = time.Now()
+	}
+	return s.db.WithContext(ctx).Table(s.snapshotTable).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(snapshot).Error
+}
+
+// LoadSnapshot 加载流的最新快照.
+func (s *GORMEventStore) LoadSnapshot(ctx context.Context, streamID string) (*Snapshot, error) {
+	var snapshot Snapshot
+	err := s.db.WithContext(ctx).Table(s.snapshotTable).
+		Where("stream_id = ?", streamID).
+		Take(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// streamVersion 返回流的当前版本，流不存在时为 0.
+func (s *GORMEventStore) streamVersion(db *gorm.DB, streamID string) (int64, error) {
+	var version int64
+	err := db.Table(s.eventTable).
+		Where("stream_id = ?", streamID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
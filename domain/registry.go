@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// EventRegistry 事件类型注册表，按事件名称序列化和反序列化领域事件.
+//
+// 事件以 JSON 序列化，只保存导出字段. 嵌入 BaseEvent 的事件在反序列化后
+// 从 StoredEvent 恢复名称和发生时间.
+//
+// 示例:
+//
+//	registry := domain.NewEventRegistry()
+//	registry.Register(OrderCreated{}, ItemAdded{})
+type EventRegistry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// NewEventRegistry 创建事件类型注册表.
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{types: make(map[string]reflect.Type)}
+}
+
+// Register 按 EventName 注册事件类型，值类型和指针类型分别注册为对应的类型.
+//
+// 嵌入 BaseEvent 的事件需要传入带名称的实例，如 OrderCreated{BaseEvent: domain.NewBaseEvent("OrderCreated")}.
+// 名称为空或同名注册了不同类型时 panic.
+func (r *EventRegistry) Register(events ...DomainEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range events {
+		name := event.EventName()
+		if name == "" {
+			panic(fmt.Sprintf("domain: 事件 %T 名称为空", event))
+		}
+		typ := reflect.TypeOf(event)
+		if existing, ok := r.types[name]; ok && existing != typ {
+			panic(fmt.Sprintf("domain: 事件 %s 已注册为 %s", name, existing))
+		}
+		r.types[name] = typ
+	}
+}
+
+// Registered 返回事件名称是否已注册.
+func (r *EventRegistry) Registered(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.types[name]
+	return ok
+}
+
+// Encode 将领域事件序列化为待追加的 StoredEvent，事件类型必须已注册.
+func (r *EventRegistry) Encode(event DomainEvent) (*StoredEvent, error) {
+	name := event.EventName()
+	if !r.Registered(name) {
+		return nil, fmt.Errorf("%w: %s", ErrEventNotRegistered, name)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	occurred := event.OccurredTime()
+	if occurred.IsZero() {
+		occurred = time.Now()
+	}
+	return &StoredEvent{
+		EventName:    name,
+		Payload:      payload,
+		OccurredTime: occurred,
+	}, nil
+}
+
+// Decode 将 StoredEvent 反序列化为注册的事件类型.
+func (r *EventRegistry) Decode(stored *StoredEvent) (DomainEvent, error) {
+	r.mu.RLock()
+	typ, ok := r.types[stored.EventName]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEventNotRegistered, stored.EventName)
+	}
+
+	isPtr := typ.Kind() == reflect.Pointer
+	if isPtr {
+		typ = typ.Elem()
+	}
+	ptr := reflect.New(typ)
+	if len(stored.Payload) > 0 {
+		if err := json.Unmarshal(stored.Payload, ptr.Interface()); err != nil {
+			return nil, fmt.Errorf("domain: 反序列化事件 %s: %w", stored.EventName, err)
+		}
+	}
+	if base, ok := ptr.Interface().(baseEventRestorer); ok {
+		base.restore(stored.EventName, stored.OccurredTime)
+	}
+
+	if isPtr {
+		return ptr.Interface().(DomainEvent), nil
+	}
+	return ptr.Elem().Interface().(DomainEvent), nil
+}
+
+// baseEventRestorer 嵌入 BaseEvent 的事件，反序列化后恢复未导出的名称和发生时间.
+type baseEventRestorer interface {
+	restore(name string, occurredTime time.Time)
+}
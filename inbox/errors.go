@@ -0,0 +1,12 @@
+package inbox
+
+import "errors"
+
+// 预定义错误.
+var (
+	// ErrMissingID 无法从消息中提取 ID.
+	ErrMissingID = errors.New("inbox: 无法确定消息 ID")
+
+	// ErrInProgress 消息正在被其他消费者处理，稍后重试.
+	ErrInProgress = errors.New("inbox: 消息正在处理中")
+)
@@ -0,0 +1,133 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Tsukikage7/microservice-kit/messaging"
+)
+
+// DefaultTable 默认收件箱表名.
+const DefaultTable = "inbox_messages"
+
+// errDuplicate 消息已处理，用于回滚事务.
+var errDuplicate = errors.New("inbox: 重复消息")
+
+// Record 收件箱表记录.
+type Record struct {
+	// ID 自增主键
+	ID uint64 `gorm:"primaryKey;autoIncrement"`
+
+	// Consumer 消费者名称
+	Consumer string `gorm:"column:consumer;size:128;not null;uniqueIndex:idx_inbox_consumer_message,priority:1"`
+
+	// MessageID 消息唯一标识
+	MessageID string `gorm:"column:message_id;size:255;not null;uniqueIndex:idx_inbox_consumer_message,priority:2"`
+
+	// Topic 消息主题
+	Topic string `gorm:"column:topic;size:255"`
+
+	// ProcessedTime 处理时间
+	ProcessedTime time.Time `gorm:"column:processed_time;index"`
+}
+
+// TxHandler 在收件箱事务中执行的消息处理函数.
+//
+// 通过 tx 写入的数据与已处理记录一起提交，返回错误时一起回滚.
+type TxHandler func(ctx context.Context, tx *gorm.DB, msg *messaging.Message) error
+
+// GORMInbox 基于 GORM 的收件箱.
+//
+// 在同一个事务中先写入已处理记录再执行处理器，写入冲突说明消息已处理过，直接跳过.
+// 并发处理同一条消息时，后到的事务在唯一索引上等待先到的事务结束，
+// 先到的事务提交则后到的跳过，回滚则后到的继续处理.
+type GORMInbox struct {
+	db   *gorm.DB
+	opts *options
+}
+
+// NewGORMInbox 创建基于 GORM 的收件箱.
+func NewGORMInbox(db *gorm.DB, opts ...Option) *GORMInbox {
+	if db == nil {
+		panic("inbox: db 不能为空")
+	}
+	return &GORMInbox{db: db, opts: applyOptions(opts)}
+}
+
+// AutoMigrate 创建或更新收件箱表结构.
+func (i *GORMInbox) AutoMigrate(ctx context.Context) error {
+	return i.db.WithContext(ctx).Table(i.opts.table).AutoMigrate(&Record{})
+}
+
+// Wrap 包装消息处理器，处理器在收件箱事务中执行.
+//
+// 处理器返回错误时已处理记录回滚，消息可以重新处理.
+// 处理器的数据库副作用需要与记录原子提交时使用 WrapTx.
+func (i *GORMInbox) Wrap(handler messaging.MessageHandler) messaging.MessageHandler {
+	return i.WrapTx(func(_ context.Context, _ *gorm.DB, msg *messaging.Message) error {
+		return handler(msg)
+	})
+}
+
+// WrapTx 包装事务处理器，处理器通过 tx 写入的数据与已处理记录在同一个事务中提交.
+func (i *GORMInbox) WrapTx(handler TxHandler) messaging.MessageHandler {
+	return func(msg *messaging.Message) error {
+		id, err := i.opts.messageID(msg)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		err = i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			res := tx.Table(i.opts.table).
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&Record{
+					Consumer:      i.opts.consumer,
+					MessageID:     id,
+					Topic:         msg.Topic,
+					ProcessedTime: time.Now(),
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errDuplicate
+			}
+			return handler(ctx, tx, msg)
+		})
+
+		if errors.Is(err, errDuplicate) {
+			i.opts.logDuplicate(msg, id)
+			return nil
+		}
+		return err
+	}
+}
+
+// Processed 检查消息是否已被当前消费者处理.
+func (i *GORMInbox) Processed(ctx context.Context, msg *messaging.Message) (bool, error) {
+	id, err := i.opts.messageID(msg)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	err = i.db.WithContext(ctx).Table(i.opts.table).
+		Where("consumer = ? AND message_id = ?", i.opts.consumer, id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Cleanup 删除当前消费者早于 before 的已处理记录，返回删除的条数.
+//
+// 只应删除不会再被重复投递的记录，通常保留数天.
+func (i *GORMInbox) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	res := i.db.WithContext(ctx).Table(i.opts.table).
+		Where("consumer = ? AND processed_time < ?", i.opts.consumer, before).
+		Delete(&Record{})
+	return res.RowsAffected, res.Error
+}
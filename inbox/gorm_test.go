@@ -0,0 +1,188 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/Tsukikage7/microservice-kit/messaging"
+)
+
+// payment 测试用业务表.
+type payment struct {
+	ID      uint64 `gorm:"primaryKey"`
+	OrderID string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&payment{}))
+	return db
+}
+
+func newTestGORMInbox(t *testing.T, opts ...Option) (*GORMInbox, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	ib := NewGORMInbox(db, opts...)
+	require.NoError(t, ib.AutoMigrate(context.Background()))
+	return ib, db
+}
+
+func newMessage(id, orderID string) *messaging.Message {
+	return &messaging.Message{
+		Topic:   "orders",
+		Value:   []byte(orderID),
+		Headers: map[string]string{HeaderMessageID: id},
+	}
+}
+
+func countPayments(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	require.NoError(t, db.Model(&payment{}).Count(&n).Error)
+	return n
+}
+
+func TestGORMInbox_WrapTx(t *testing.T) {
+	ctx := context.Background()
+	ib, db := newTestGORMInbox(t, WithConsumer("payment-service"))
+
+	calls := 0
+	handler := ib.WrapTx(func(_ context.Context, tx *gorm.DB, msg *messaging.Message) error {
+		calls++
+		return tx.Create(&payment{OrderID: string(msg.Value)}).Error
+	})
+
+	msg := newMessage("msg-1", "order-1")
+	require.NoError(t, handler(msg))
+	require.NoError(t, handler(msg), "重复消息直接跳过")
+	require.NoError(t, handler(newMessage("msg-2", "order-2")))
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int64(2), countPayments(t, db))
+
+	processed, err := ib.Processed(ctx, msg)
+	require.NoError(t, err)
+	assert.True(t, processed)
+}
+
+func TestGORMInbox_RollbackOnError(t *testing.T) {
+	ctx := context.Background()
+	ib, db := newTestGORMInbox(t)
+
+	errHandler := errors.New("handler failed")
+	fail := true
+	handler := ib.WrapTx(func(_ context.Context, tx *gorm.DB, msg *messaging.Message) error {
+		if err := tx.Create(&payment{OrderID: string(msg.Value)}).Error; err != nil {
+			return err
+		}
+		if fail {
+			return errHandler
+		}
+		return nil
+	})
+
+	msg := newMessage("msg-1", "order-1")
+	require.ErrorIs(t, handler(msg), errHandler)
+	assert.Zero(t, countPayments(t, db), "副作用随记录一起回滚")
+
+	processed, err := ib.Processed(ctx, msg)
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	// 重新投递后正常处理
+	fail = false
+	require.NoError(t, handler(msg))
+	assert.Equal(t, int64(1), countPayments(t, db))
+}
+
+func TestGORMInbox_ConsumerIsolation(t *testing.T) {
+	db := newTestDB(t)
+	billing := NewGORMInbox(db, WithConsumer("billing"))
+	shipping := NewGORMInbox(db, WithConsumer("shipping"))
+	require.NoError(t, billing.AutoMigrate(context.Background()))
+
+	var calls []string
+	handlerFor := func(name string) messaging.MessageHandler {
+		return func(*messaging.Message) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	msg := newMessage("msg-1", "order-1")
+	require.NoError(t, billing.Wrap(handlerFor("billing"))(msg))
+	require.NoError(t, shipping.Wrap(handlerFor("shipping"))(msg))
+	require.NoError(t, billing.Wrap(handlerFor("billing"))(msg))
+
+	assert.Equal(t, []string{"billing", "shipping"}, calls)
+}
+
+func TestGORMInbox_MessageID(t *testing.T) {
+	ib, _ := newTestGORMInbox(t, WithTable("consumer_inbox"))
+
+	calls := 0
+	handler := ib.Wrap(func(*messaging.Message) error {
+		calls++
+		return nil
+	})
+
+	// 没有消息 ID 头时使用主题/分区/偏移量
+	msg := &messaging.Message{Topic: "orders", Partition: 1, Offset: 42, Key: []byte("k")}
+	require.NoError(t, handler(msg))
+	require.NoError(t, handler(msg))
+	require.NoError(t, handler(&messaging.Message{Topic: "orders", Partition: 1, Offset: 43}))
+	assert.Equal(t, 2, calls)
+
+	assert.ErrorIs(t, handler(&messaging.Message{}), ErrMissingID)
+	assert.ErrorIs(t, handler(nil), messaging.ErrNilMessage)
+}
+
+func TestGORMInbox_Cleanup(t *testing.T) {
+	ctx := context.Background()
+	ib, _ := newTestGORMInbox(t)
+
+	handler := ib.Wrap(func(*messaging.Message) error { return nil })
+	require.NoError(t, handler(newMessage("msg-1", "order-1")))
+	require.NoError(t, handler(newMessage("msg-2", "order-2")))
+
+	deleted, err := ib.Cleanup(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	deleted, err = ib.Cleanup(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+func TestIDFunc(t *testing.T) {
+	msg := &messaging.Message{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    7,
+		Key:       []byte("order-1"),
+		Headers:   map[string]string{"x-request-id": "req-1"},
+	}
+	assert.Equal(t, "orders/2/7/order-1", DefaultIDFunc(msg))
+	assert.Equal(t, "req-1", HeaderIDFunc("x-request-id")(msg))
+
+	msg.Headers[HeaderMessageID] = "msg-1"
+	assert.Equal(t, "msg-1", DefaultIDFunc(msg))
+}
@@ -0,0 +1,84 @@
+// Package inbox 提供消费端收件箱，实现消息的恰好一次处理.
+//
+// Kafka 和 RabbitMQ 消费者只保证至少一次投递，同一条消息可能被重复消费.
+// 收件箱记录已处理的消息 ID，重复的消息直接跳过，无需每个处理器自行实现幂等.
+//
+// 提供两种存储:
+//   - GORMInbox: 消息 ID 与处理器的数据库副作用在同一个事务中提交，实现恰好一次
+//   - KVInbox: 基于 storage/cache，处理成功后记录消息 ID，适用于没有数据库副作用的处理器
+//
+// 基本用法:
+//
+//	ib := inbox.NewGORMInbox(db, inbox.WithConsumer("order-service"))
+//	_ = ib.AutoMigrate(ctx)
+//
+//	consumer.Consume(ctx, []string{"orders"}, ib.WrapTx(
+//	    func(ctx context.Context, tx *gorm.DB, msg *messaging.Message) error {
+//	        return tx.Create(&Payment{...}).Error
+//	    },
+//	))
+package inbox
+
+import (
+	"fmt"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+	"github.com/Tsukikage7/microservice-kit/outbox"
+)
+
+// HeaderMessageID 默认读取的消息 ID 头，与 outbox 投递时写入的消息头一致.
+const HeaderMessageID = outbox.HeaderMessageID
+
+// Inbox 收件箱，包装消息处理器以跳过重复消息.
+type Inbox interface {
+	// Wrap 包装消息处理器.
+	Wrap(handler messaging.MessageHandler) messaging.MessageHandler
+}
+
+// IDFunc 从消息中提取唯一标识，返回空字符串表示无法识别.
+type IDFunc func(msg *messaging.Message) string
+
+// DefaultIDFunc 默认的消息 ID 提取函数.
+//
+// 优先使用 HeaderMessageID 消息头，不存在时使用 主题/分区/偏移量/键 组合.
+// RabbitMQ 的偏移量为投递标签，重连后会重新计数，应由生产方设置消息 ID 头.
+func DefaultIDFunc(msg *messaging.Message) string {
+	if id := msg.Headers[HeaderMessageID]; id != "" {
+		return id
+	}
+	if msg.Topic == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d/%d/%s", msg.Topic, msg.Partition, msg.Offset, msg.Key)
+}
+
+// HeaderIDFunc 返回从指定消息头读取 ID 的 IDFunc.
+func HeaderIDFunc(header string) IDFunc {
+	return func(msg *messaging.Message) string {
+		return msg.Headers[header]
+	}
+}
+
+// messageID 提取消息 ID.
+func (o *options) messageID(msg *messaging.Message) (string, error) {
+	if msg == nil {
+		return "", messaging.ErrNilMessage
+	}
+	id := o.idFunc(msg)
+	if id == "" {
+		return "", ErrMissingID
+	}
+	return id, nil
+}
+
+// logDuplicate 记录跳过的重复消息.
+func (o *options) logDuplicate(msg *messaging.Message, id string) {
+	if o.logger != nil {
+		o.logger.With(
+			logger.String("consumer", o.consumer),
+			logger.String("topic", msg.Topic),
+			logger.String("message_id", id),
+		).Debug("[Inbox] 跳过重复消息")
+	}
+}
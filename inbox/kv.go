@@ -0,0 +1,140 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/messaging"
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+// 已处理记录的状态值.
+const (
+	kvProcessing = "processing"
+	kvDone       = "done"
+)
+
+// KV 收件箱所需的键值存储接口.
+//
+// 这是 inbox 包的最小依赖接口.
+// 可以用 cache.Cache、Redis 客户端或其他存储实现.
+type KV interface {
+	// Get 获取键的值，键不存在时返回 cache.ErrNotFound.
+	Get(ctx context.Context, key string) (string, error)
+
+	// Set 设置键值对.
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+
+	// SetNX 仅在键不存在时设置.
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+
+	// Del 删除键.
+	Del(ctx context.Context, keys ...string) error
+}
+
+// KVInbox 基于 KV 存储的收件箱.
+//
+// 处理前以 SetNX 写入处理中标记，成功后改为已处理，失败时删除标记以便重新处理.
+// 已处理记录与处理器的副作用不在同一个事务中：处理成功但写入记录失败时消息会被再次处理，
+// 因此适用于副作用不在数据库中或本身可容忍极少量重复的处理器.
+type KVInbox struct {
+	kv   KV
+	opts *options
+}
+
+// NewKVInbox 创建基于 KV 存储的收件箱.
+//
+// kv: KV 存储实现（可用 CacheKV 适配 cache.Cache）
+func NewKVInbox(kv KV, opts ...Option) *KVInbox {
+	if kv == nil {
+		panic("inbox: kv 不能为空")
+	}
+	return &KVInbox{kv: kv, opts: applyOptions(opts)}
+}
+
+// Wrap 包装消息处理器.
+//
+// 消息已处理时跳过并返回 nil；正在被其他消费者处理时返回 ErrInProgress，由消费者重试.
+func (i *KVInbox) Wrap(handler messaging.MessageHandler) messaging.MessageHandler {
+	return func(msg *messaging.Message) error {
+		id, err := i.opts.messageID(msg)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		key := i.key(id)
+
+		ok, err := i.kv.SetNX(ctx, key, kvProcessing, i.opts.processingTTL)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if status, _ := i.kv.Get(ctx, key); status == kvDone {
+				i.opts.logDuplicate(msg, id)
+				return nil
+			}
+			return ErrInProgress
+		}
+
+		if err := handler(msg); err != nil {
+			_ = i.kv.Del(ctx, key)
+			return err
+		}
+		return i.kv.Set(ctx, key, kvDone, i.opts.ttl)
+	}
+}
+
+// Processed 检查消息是否已被当前消费者处理.
+func (i *KVInbox) Processed(ctx context.Context, msg *messaging.Message) (bool, error) {
+	id, err := i.opts.messageID(msg)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := i.kv.Get(ctx, i.key(id))
+	if errors.Is(err, cache.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return status == kvDone, nil
+}
+
+// key 返回消息对应的缓存键.
+func (i *KVInbox) key(id string) string {
+	return i.opts.keyPrefix + i.opts.consumer + ":" + id
+}
+
+// cacheKV 是 cache.Cache 到 KV 的适配器.
+type cacheKV struct {
+	cache cache.Cache
+}
+
+// CacheKV 将 cache.Cache 适配为 KV 接口.
+//
+// 示例:
+//
+//	redisCache, _ := cache.NewCache(cache.NewRedisConfig("localhost:6379"), log)
+//	ib := inbox.NewKVInbox(inbox.CacheKV(redisCache), inbox.WithConsumer("order-service"))
+func CacheKV(c cache.Cache) KV {
+	return &cacheKV{cache: c}
+}
+
+func (c *cacheKV) Get(ctx context.Context, key string) (string, error) {
+	return c.cache.Get(ctx, key)
+}
+
+func (c *cacheKV) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return c.cache.Set(ctx, key, value, ttl)
+}
+
+func (c *cacheKV) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return c.cache.SetNX(ctx, key, value, ttl)
+}
+
+func (c *cacheKV) Del(ctx context.Context, keys ...string) error {
+	return c.cache.Del(ctx, keys...)
+}
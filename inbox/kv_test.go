@@ -0,0 +1,155 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+// testLogger 用于测试的模拟日志器.
+type testLogger struct{}
+
+func (m *testLogger) Debug(args ...any)                             {}
+func (m *testLogger) Debugf(format string, args ...any)             {}
+func (m *testLogger) Info(args ...any)                              {}
+func (m *testLogger) Infof(format string, args ...any)              {}
+func (m *testLogger) Warn(args ...any)                              {}
+func (m *testLogger) Warnf(format string, args ...any)              {}
+func (m *testLogger) Error(args ...any)                             {}
+func (m *testLogger) Errorf(format string, args ...any)             {}
+func (m *testLogger) Fatal(args ...any)                             {}
+func (m *testLogger) Fatalf(format string, args ...any)             {}
+func (m *testLogger) Panic(args ...any)                             {}
+func (m *testLogger) Panicf(format string, args ...any)             {}
+func (m *testLogger) With(fields ...logger.Field) logger.Logger     { return m }
+func (m *testLogger) WithContext(ctx context.Context) logger.Logger { return m }
+func (m *testLogger) Sync() error                                   { return nil }
+func (m *testLogger) Close() error                                  { return nil }
+
+func newTestKVInbox(t *testing.T, opts ...Option) (*KVInbox, cache.Cache) {
+	t.Helper()
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	t.Cleanup(func() { memCache.Close() })
+
+	opts = append([]Option{WithLogger(&testLogger{})}, opts...)
+	return NewKVInbox(CacheKV(memCache), opts...), memCache
+}
+
+func TestKVInbox_Wrap(t *testing.T) {
+	ctx := context.Background()
+	ib, memCache := newTestKVInbox(t, WithConsumer("notify"), WithKeyPrefix("test:"))
+
+	calls := 0
+	handler := ib.Wrap(func(*messaging.Message) error {
+		calls++
+		return nil
+	})
+
+	msg := newMessage("msg-1", "order-1")
+	require.NoError(t, handler(msg))
+	require.NoError(t, handler(msg), "重复消息直接跳过")
+	require.NoError(t, handler(newMessage("msg-2", "order-2")))
+	assert.Equal(t, 2, calls)
+
+	status, err := memCache.Get(ctx, "test:notify:msg-1")
+	require.NoError(t, err)
+	assert.Equal(t, kvDone, status)
+
+	processed, err := ib.Processed(ctx, msg)
+	require.NoError(t, err)
+	assert.True(t, processed)
+}
+
+func TestKVInbox_RetryAfterError(t *testing.T) {
+	ctx := context.Background()
+	ib, _ := newTestKVInbox(t)
+
+	errHandler := errors.New("handler failed")
+	calls := 0
+	handler := ib.Wrap(func(*messaging.Message) error {
+		calls++
+		if calls == 1 {
+			return errHandler
+		}
+		return nil
+	})
+
+	msg := newMessage("msg-1", "order-1")
+	require.ErrorIs(t, handler(msg), errHandler)
+
+	processed, err := ib.Processed(ctx, msg)
+	require.NoError(t, err)
+	assert.False(t, processed, "失败后删除处理中标记")
+
+	require.NoError(t, handler(msg))
+	assert.Equal(t, 2, calls)
+}
+
+func TestKVInbox_InProgress(t *testing.T) {
+	ib, memCache := newTestKVInbox(t, WithProcessingTTL(time.Minute))
+
+	// 模拟其他消费者正在处理
+	require.NoError(t, memCache.Set(context.Background(), "inbox:default:msg-1", kvProcessing, time.Minute))
+
+	called := false
+	handler := ib.Wrap(func(*messaging.Message) error {
+		called = true
+		return nil
+	})
+
+	assert.ErrorIs(t, handler(newMessage("msg-1", "order-1")), ErrInProgress)
+	assert.False(t, called)
+}
+
+func TestKVInbox_TTL(t *testing.T) {
+	ctx := context.Background()
+	ib, memCache := newTestKVInbox(t, WithTTL(time.Hour))
+
+	require.NoError(t, ib.Wrap(func(*messaging.Message) error { return nil })(newMessage("msg-1", "order-1")))
+
+	ttl, err := memCache.TTL(ctx, "inbox:default:msg-1")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), ttl.Seconds(), 5)
+}
+
+// failingKV 读取总是失败的 KV.
+type failingKV struct {
+	KV
+	err error
+}
+
+func (f *failingKV) Get(ctx context.Context, key string) (string, error) {
+	return "", f.err
+}
+
+func TestKVInbox_ProcessedError(t *testing.T) {
+	ctx := context.Background()
+	msg := newMessage("msg-1", "order-1")
+
+	ib, _ := newTestKVInbox(t)
+	processed, err := ib.Processed(ctx, msg)
+	require.NoError(t, err, "未处理的消息不是错误")
+	assert.False(t, processed)
+
+	readErr := errors.New("connection refused")
+	processed, err = NewKVInbox(&failingKV{err: readErr}).Processed(ctx, msg)
+	assert.ErrorIs(t, err, readErr)
+	assert.False(t, processed)
+}
+
+func TestInbox_Interface(t *testing.T) {
+	var _ Inbox = (*GORMInbox)(nil)
+	var _ Inbox = (*KVInbox)(nil)
+
+	assert.Panics(t, func() { NewKVInbox(nil) })
+	assert.Panics(t, func() { NewGORMInbox(nil) })
+}
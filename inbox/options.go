@@ -0,0 +1,112 @@
+package inbox
+
+import (
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// Option 收件箱配置选项.
+type Option func(*options)
+
+// options 收件箱配置.
+type options struct {
+	consumer      string
+	idFunc        IDFunc
+	logger        logger.Logger
+	table         string
+	keyPrefix     string
+	ttl           time.Duration
+	processingTTL time.Duration
+}
+
+// applyOptions 应用配置选项.
+func applyOptions(opts []Option) *options {
+	o := &options{
+		consumer:      "default",
+		idFunc:        DefaultIDFunc,
+		table:         DefaultTable,
+		keyPrefix:     "inbox:",
+		ttl:           7 * 24 * time.Hour,
+		processingTTL: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithConsumer 设置消费者名称.
+//
+// 已处理记录按消费者隔离，多个服务共用同一张表或同一个缓存时，
+// 同一条消息对每个消费者各处理一次. 通常使用消费者组 ID.
+// 默认: default.
+func WithConsumer(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.consumer = name
+		}
+	}
+}
+
+// WithIDFunc 设置消息 ID 提取函数.
+//
+// 默认: DefaultIDFunc.
+func WithIDFunc(fn IDFunc) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.idFunc = fn
+		}
+	}
+}
+
+// WithLogger 设置日志记录器.
+func WithLogger(log logger.Logger) Option {
+	return func(o *options) {
+		o.logger = log
+	}
+}
+
+// WithTable 设置收件箱表名（GORMInbox 用）.
+//
+// 默认: inbox_messages.
+func WithTable(table string) Option {
+	return func(o *options) {
+		if table != "" {
+			o.table = table
+		}
+	}
+}
+
+// WithKeyPrefix 设置缓存键前缀（KVInbox 用）.
+//
+// 默认: inbox:.
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.keyPrefix = prefix
+	}
+}
+
+// WithTTL 设置已处理记录的保留时长（KVInbox 用）.
+//
+// 应大于消息可能被重复投递的时间范围.
+// 默认: 7 天.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithProcessingTTL 设置处理中标记的过期时间（KVInbox 用）.
+//
+// 消费者在处理过程中崩溃时，标记过期后消息才能被重新处理，应大于处理器的最长耗时.
+// 默认: 5 分钟.
+func WithProcessingTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.processingTTL = ttl
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package jobqueue
+
+import (
+	"context"
+	"time"
+)
+
+// Backend 任务队列存储后端.
+//
+// 任务在后端中处于以下状态之一：
+//
+//	scheduled: 等待 RunAt 到期
+//	ready:     可以执行，按优先级出队
+//	running:   已被 Worker 取出，可见性超时后计入一次失败并重新变为 ready
+//	dead:      达到最大执行次数
+type Backend interface {
+	// Enqueue 写入任务.
+	// 任务设置了唯一键且相同唯一键的任务尚未完成时返回 ErrDuplicateJob.
+	Enqueue(ctx context.Context, job *Job) error
+
+	// Dequeue 取出一个到期的最高优先级任务，并在 visibility 时长内对其他 Worker 不可见.
+	// 可见性超时未确认的任务计入一次失败，达到最大执行次数时移入死信队列并释放唯一键.
+	// 队列为空时返回 nil, nil.
+	Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error)
+
+	// Ack 确认任务完成，删除任务并释放唯一键.
+	// job 必须是 Dequeue 返回的任务，租约失效时返回 ErrLeaseLost，不做修改.
+	Ack(ctx context.Context, job *Job) error
+
+	// Retry 保存任务的失败信息，并在 runAt 时重新执行.
+	// 租约失效时返回 ErrLeaseLost，不做修改.
+	Retry(ctx context.Context, job *Job, runAt time.Time) error
+
+	// Kill 将任务移入死信队列并释放唯一键.
+	// 租约失效时返回 ErrLeaseLost，不做修改.
+	Kill(ctx context.Context, job *Job) error
+
+	// Dead 按进入时间倒序列出死信任务.
+	Dead(ctx context.Context, queue string, limit int) ([]*Job, error)
+
+	// Requeue 将死信任务重新放回队列并重新占用唯一键，任务不存在时返回 ErrJobNotFound.
+	// 唯一键已被其他任务占用时返回 ErrDuplicateJob，任务保留在死信队列中.
+	Requeue(ctx context.Context, queue, id string) error
+
+	// Delete 删除死信任务，任务不存在时返回 ErrJobNotFound.
+	Delete(ctx context.Context, queue, id string) error
+
+	// Stats 返回队列中各状态的任务数.
+	Stats(ctx context.Context, queue string) (*Stats, error)
+}
+
+// Stats 队列统计.
+type Stats struct {
+	// Scheduled 等待到期的任务数（含等待重试的任务）.
+	Scheduled int64 `json:"scheduled"`
+
+	// Ready 可以执行的任务数.
+	Ready int64 `json:"ready"`
+
+	// Running 执行中的任务数.
+	Running int64 `json:"running"`
+
+	// Dead 死信任务数.
+	Dead int64 `json:"dead"`
+}
@@ -0,0 +1,308 @@
+package jobqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+// testBackends 返回需要验证的存储后端.
+func testBackends(t *testing.T) map[string]func(t *testing.T) Backend {
+	return map[string]func(t *testing.T) Backend{
+		"Memory": func(*testing.T) Backend { return NewMemoryBackend() },
+		"Redis":  newTestRedisBackend,
+	}
+}
+
+func newTestRedisBackend(t *testing.T) Backend {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(cache.NewRedisConfig(mr.Addr()), &testLogger{})
+	require.NoError(t, err)
+	t.Cleanup(func() { redisCache.Close() })
+
+	backend, err := NewRedisBackend(redisCache, WithKeyPrefix("test"))
+	require.NoError(t, err)
+	return backend
+}
+
+func newTestJob(id string, opts ...EnqueueOption) *Job {
+	now := time.Now()
+	job := &Job{
+		ID:           id,
+		Queue:        "default",
+		Type:         "test",
+		MaxAttempts:  3,
+		RunAt:        now,
+		EnqueuedTime: now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	return job
+}
+
+func TestBackend_Priority(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(t)
+
+			require.NoError(t, b.Enqueue(ctx, newTestJob("01-low")))
+			require.NoError(t, b.Enqueue(ctx, newTestJob("02-high", WithPriority(10))))
+			require.NoError(t, b.Enqueue(ctx, newTestJob("03-low")))
+
+			var order []string
+			for {
+				job, err := b.Dequeue(ctx, "default", time.Minute)
+				require.NoError(t, err)
+				if job == nil {
+					break
+				}
+				order = append(order, job.ID)
+			}
+			assert.Equal(t, []string{"02-high", "01-low", "03-low"}, order, "高优先级优先，同优先级按入队顺序")
+		})
+	}
+}
+
+func TestBackend_Delay(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(t)
+
+			require.NoError(t, b.Enqueue(ctx, newTestJob("delayed", WithDelay(time.Hour))))
+			require.NoError(t, b.Enqueue(ctx, newTestJob("soon", WithDelay(50*time.Millisecond))))
+
+			stats, err := b.Stats(ctx, "default")
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), stats.Scheduled)
+
+			job, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			assert.Nil(t, job, "未到期的任务不可见")
+
+			time.Sleep(60 * time.Millisecond)
+			job, err = b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			require.NotNil(t, job)
+			assert.Equal(t, "soon", job.ID)
+		})
+	}
+}
+
+func TestBackend_VisibilityTimeout(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(t)
+
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-1")))
+
+			job, err := b.Dequeue(ctx, "default", 50*time.Millisecond)
+			require.NoError(t, err)
+			require.NotNil(t, job)
+
+			again, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			assert.Nil(t, again, "执行中的任务对其他 Worker 不可见")
+
+			// Worker 崩溃，超时后重新投递
+			time.Sleep(60 * time.Millisecond)
+			again, err = b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			require.NotNil(t, again)
+			assert.Equal(t, "job-1", again.ID)
+			assert.Equal(t, 1, again.Attempts, "超时计入失败次数")
+			assert.Equal(t, ErrVisibilityTimeout.Error(), again.LastError)
+
+			require.NoError(t, b.Ack(ctx, again))
+			stats, err := b.Stats(ctx, "default")
+			require.NoError(t, err)
+			assert.Equal(t, Stats{}, *stats)
+		})
+	}
+}
+
+func TestBackend_VisibilityTimeoutExhausted(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(t)
+
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-1", WithMaxAttempts(2), WithUniqueKey("k"))))
+
+			// 每次取出后都不确认，模拟导致 Worker 崩溃的任务
+			for range 2 {
+				job, err := b.Dequeue(ctx, "default", 20*time.Millisecond)
+				require.NoError(t, err)
+				require.NotNil(t, job)
+				time.Sleep(30 * time.Millisecond)
+			}
+
+			job, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			assert.Nil(t, job, "达到最大执行次数后不再投递")
+
+			dead, err := b.Dead(ctx, "default", 0)
+			require.NoError(t, err)
+			require.Len(t, dead, 1)
+			assert.Equal(t, 2, dead[0].Attempts)
+			assert.Equal(t, ErrVisibilityTimeout.Error(), dead[0].LastError)
+			assert.False(t, dead[0].FailedTime.IsZero())
+
+			stats, err := b.Stats(ctx, "default")
+			require.NoError(t, err)
+			assert.Equal(t, Stats{Dead: 1}, *stats)
+
+			// 进入死信后释放唯一键
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-2", WithUniqueKey("k"))))
+		})
+	}
+}
+
+func TestBackend_LeaseLost(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(t)
+
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-1", WithUniqueKey("k"))))
+			assert.ErrorIs(t, b.Ack(ctx, newTestJob("job-1")), ErrLeaseLost, "未出队的任务没有租约")
+
+			stale, err := b.Dequeue(ctx, "default", 20*time.Millisecond)
+			require.NoError(t, err)
+			require.NotNil(t, stale)
+
+			// 处理器忽略 ctx 超时，任务被其他 Worker 重新取出
+			time.Sleep(30 * time.Millisecond)
+			current, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			require.NotNil(t, current)
+			assert.NotEqual(t, stale.Lease, current.Lease)
+
+			stale.Attempts++
+			assert.ErrorIs(t, b.Ack(ctx, stale), ErrLeaseLost)
+			assert.ErrorIs(t, b.Retry(ctx, stale, time.Now()), ErrLeaseLost)
+			assert.ErrorIs(t, b.Kill(ctx, stale), ErrLeaseLost)
+
+			stats, err := b.Stats(ctx, "default")
+			require.NoError(t, err)
+			assert.Equal(t, Stats{Running: 1}, *stats, "过期租约不影响当前执行")
+			assert.ErrorIs(t, b.Enqueue(ctx, newTestJob("job-2", WithUniqueKey("k"))), ErrDuplicateJob)
+
+			require.NoError(t, b.Ack(ctx, current))
+			assert.ErrorIs(t, b.Ack(ctx, current), ErrLeaseLost, "重复确认")
+		})
+	}
+}
+
+func TestBackend_Unique(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(t)
+
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-1", WithUniqueKey("order-1"))))
+			assert.ErrorIs(t, b.Enqueue(ctx, newTestJob("job-2", WithUniqueKey("order-1"))), ErrDuplicateJob)
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-3", WithUniqueKey("order-2"))))
+
+			job, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			require.Equal(t, "job-1", job.ID)
+			require.NoError(t, b.Ack(ctx, job))
+
+			// 完成后释放唯一键
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-4", WithUniqueKey("order-1"))))
+		})
+	}
+}
+
+func TestBackend_RetryAndDead(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(t)
+
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-1", WithUniqueKey("k"), WithPriority(5))))
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-2")))
+
+			job, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			job.Attempts = 1
+			job.LastError = "boom"
+			require.NoError(t, b.Retry(ctx, job, time.Now()))
+
+			stats, err := b.Stats(ctx, "default")
+			require.NoError(t, err)
+			assert.Equal(t, Stats{Scheduled: 1, Ready: 1}, *stats)
+
+			job, err = b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			require.Equal(t, "job-1", job.ID)
+			assert.Equal(t, 1, job.Attempts, "保存失败信息")
+
+			job.Attempts = 3
+			require.NoError(t, b.Kill(ctx, job))
+			other, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			require.NoError(t, b.Kill(ctx, other))
+
+			dead, err := b.Dead(ctx, "default", 0)
+			require.NoError(t, err)
+			require.Len(t, dead, 2)
+			assert.Equal(t, "job-2", dead[0].ID, "按进入时间倒序")
+			assert.Equal(t, "boom", dead[1].LastError)
+
+			dead, err = b.Dead(ctx, "default", 1)
+			require.NoError(t, err)
+			assert.Len(t, dead, 1)
+
+			// 进入死信后释放唯一键
+			require.NoError(t, b.Enqueue(ctx, newTestJob("job-3", WithUniqueKey("k"))))
+
+			// 唯一键被其他任务占用时不能重新入队
+			assert.ErrorIs(t, b.Requeue(ctx, "default", "job-1"), ErrDuplicateJob)
+			holder, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			require.Equal(t, "job-3", holder.ID)
+			require.NoError(t, b.Ack(ctx, holder))
+
+			require.NoError(t, b.Requeue(ctx, "default", "job-1"))
+			assert.ErrorIs(t, b.Requeue(ctx, "default", "job-1"), ErrJobNotFound)
+			assert.ErrorIs(t, b.Enqueue(ctx, newTestJob("job-4", WithUniqueKey("k"))), ErrDuplicateJob,
+				"重新入队的任务重新占用唯一键")
+
+			requeued, err := b.Dequeue(ctx, "default", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, "job-1", requeued.ID)
+			assert.Zero(t, requeued.Attempts, "失败次数清零")
+
+			require.NoError(t, b.Delete(ctx, "default", "job-2"))
+			assert.ErrorIs(t, b.Delete(ctx, "default", "job-2"), ErrJobNotFound)
+
+			stats, err = b.Stats(ctx, "default")
+			require.NoError(t, err)
+			assert.Equal(t, Stats{Running: 1}, *stats)
+		})
+	}
+}
+
+func TestNewRedisBackend(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+
+	_, err = NewRedisBackend(memCache)
+	assert.ErrorIs(t, err, ErrUnsupportedCache)
+
+	_, err = NewRedisBackend(nil)
+	assert.ErrorIs(t, err, ErrUnsupportedCache)
+}
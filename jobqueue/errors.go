@@ -0,0 +1,27 @@
+package jobqueue
+
+import "errors"
+
+// 预定义错误.
+var (
+	// ErrEmptyType 任务类型为空.
+	ErrEmptyType = errors.New("jobqueue: 任务类型不能为空")
+
+	// ErrDuplicateJob 相同唯一键的任务尚未完成.
+	ErrDuplicateJob = errors.New("jobqueue: 相同唯一键的任务已存在")
+
+	// ErrJobNotFound 任务不存在.
+	ErrJobNotFound = errors.New("jobqueue: 任务不存在")
+
+	// ErrUnknownType 任务类型没有注册处理器.
+	ErrUnknownType = errors.New("jobqueue: 任务类型未注册处理器")
+
+	// ErrLeaseLost 任务已可见性超时并被重新投递，本次出队不再拥有该任务.
+	ErrLeaseLost = errors.New("jobqueue: 任务租约已失效")
+
+	// ErrVisibilityTimeout 任务执行超过可见性超时，Worker 没有确认，作为失败信息记录.
+	ErrVisibilityTimeout = errors.New("jobqueue: 任务执行超过可见性超时")
+
+	// ErrUnsupportedCache 缓存实现不是 Redis.
+	ErrUnsupportedCache = errors.New("jobqueue: 缓存实现不支持，需要 Redis 缓存")
+)
@@ -0,0 +1,115 @@
+package jobqueue
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job 队列中的任务.
+type Job struct {
+	// ID 任务唯一标识（UUIDv7，同优先级按入队顺序执行）.
+	ID string `json:"id"`
+
+	// Queue 所属队列名称.
+	Queue string `json:"queue"`
+
+	// Type 任务类型，用于查找处理器.
+	Type string `json:"type"`
+
+	// Payload 任务参数的 JSON 序列化结果.
+	Payload json.RawMessage `json:"payload,omitempty"`
+
+	// Priority 优先级，数值越大越先执行.
+	Priority int `json:"priority"`
+
+	// UniqueKey 唯一键，任务完成或进入死信前不允许重复入队.
+	UniqueKey string `json:"unique_key,omitempty"`
+
+	// Attempts 已失败的次数.
+	Attempts int `json:"attempts"`
+
+	// MaxAttempts 最大执行次数，达到后进入死信队列.
+	MaxAttempts int `json:"max_attempts"`
+
+	// LastError 最近一次失败的错误信息.
+	LastError string `json:"last_error,omitempty"`
+
+	// RunAt 计划执行时间.
+	RunAt time.Time `json:"run_at"`
+
+	// EnqueuedTime 入队时间.
+	EnqueuedTime time.Time `json:"enqueued_time"`
+
+	// FailedTime 最近一次失败时间.
+	FailedTime time.Time `json:"failed_time,omitzero"`
+
+	// Lease 本次出队的租约，由 Backend.Dequeue 设置（可见性截止时间），不持久化.
+	// Ack、Retry 和 Kill 校验租约，任务被重新投递后返回 ErrLeaseLost.
+	Lease int64 `json:"-"`
+}
+
+// Decode 将任务参数反序列化到 v.
+func (j *Job) Decode(v any) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(j.Payload, v)
+}
+
+// expire 记录一次可见性超时的失败，返回是否应进入死信队列.
+func (j *Job) expire(now time.Time) bool {
+	j.Attempts++
+	j.LastError = ErrVisibilityTimeout.Error()
+	j.FailedTime = now
+	return j.Attempts >= j.MaxAttempts
+}
+
+// EnqueueOption 入队选项.
+type EnqueueOption func(*Job)
+
+// WithDelay 设置延迟执行时长.
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(j *Job) {
+		if d > 0 {
+			j.RunAt = time.Now().Add(d)
+		}
+	}
+}
+
+// WithRunAt 设置计划执行时间，早于当前时间时立即执行.
+func WithRunAt(t time.Time) EnqueueOption {
+	return func(j *Job) {
+		if !t.IsZero() {
+			j.RunAt = t
+		}
+	}
+}
+
+// WithPriority 设置优先级，数值越大越先执行.
+//
+// 默认: 0.
+func WithPriority(priority int) EnqueueOption {
+	return func(j *Job) {
+		j.Priority = priority
+	}
+}
+
+// WithUniqueKey 设置唯一键.
+//
+// 相同唯一键的任务完成或进入死信队列前，再次入队返回 ErrDuplicateJob.
+func WithUniqueKey(key string) EnqueueOption {
+	return func(j *Job) {
+		j.UniqueKey = key
+	}
+}
+
+// WithMaxAttempts 设置任务的最大执行次数.
+//
+// 默认: 队列的 WithDefaultMaxAttempts.
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(j *Job) {
+		if n > 0 {
+			j.MaxAttempts = n
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package jobqueue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryQueue 单个队列的内存状态.
+type memoryQueue struct {
+	jobs      map[string]*Job
+	scheduled map[string]time.Time
+	ready     map[string]struct{}
+	running   map[string]time.Time
+	dead      map[string]time.Time
+	unique    map[string]string
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{
+		jobs:      make(map[string]*Job),
+		scheduled: make(map[string]time.Time),
+		ready:     make(map[string]struct{}),
+		running:   make(map[string]time.Time),
+		dead:      make(map[string]time.Time),
+		unique:    make(map[string]string),
+	}
+}
+
+// MemoryBackend 内存存储后端.
+//
+// 数据不持久化，适用于测试和单进程场景.
+type MemoryBackend struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+}
+
+// NewMemoryBackend 创建内存存储后端.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{queues: make(map[string]*memoryQueue)}
+}
+
+// queue 返回队列状态，不存在时创建，调用方需持有锁.
+func (b *MemoryBackend) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = newMemoryQueue()
+		b.queues[name] = q
+	}
+	return q
+}
+
+// Enqueue 写入任务.
+func (b *MemoryBackend) Enqueue(_ context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(job.Queue)
+	if job.UniqueKey != "" {
+		if _, ok := q.unique[job.UniqueKey]; ok {
+			return ErrDuplicateJob
+		}
+		q.unique[job.UniqueKey] = job.ID
+	}
+
+	q.jobs[job.ID] = cloneJob(job)
+	if job.RunAt.After(time.Now()) {
+		q.scheduled[job.ID] = job.RunAt
+	} else {
+		q.ready[job.ID] = struct{}{}
+	}
+	return nil
+}
+
+// Dequeue 取出一个到期的最高优先级任务.
+func (b *MemoryBackend) Dequeue(_ context.Context, queue string, visibility time.Duration) (*Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(queue)
+	now := time.Now()
+
+	// 可见性超时的任务计入失败次数，次数用尽时进入死信队列，否则重新入队
+	for id, deadline := range q.running {
+		if deadline.After(now) {
+			continue
+		}
+		delete(q.running, id)
+		job := q.jobs[id]
+		if job.expire(now) {
+			q.dead[id] = now
+			q.releaseUnique(job)
+		} else {
+			q.ready[id] = struct{}{}
+		}
+	}
+	// 到期的延迟任务
+	for id, runAt := range q.scheduled {
+		if !runAt.After(now) {
+			delete(q.scheduled, id)
+			q.ready[id] = struct{}{}
+		}
+	}
+
+	var next *Job
+	for id := range q.ready {
+		job := q.jobs[id]
+		if next == nil || job.Priority > next.Priority ||
+			(job.Priority == next.Priority && job.ID < next.ID) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	deadline := now.Add(visibility)
+	delete(q.ready, next.ID)
+	q.running[next.ID] = deadline
+
+	job := cloneJob(next)
+	job.Lease = deadline.UnixNano()
+	return job, nil
+}
+
+// Ack 确认任务完成.
+func (b *MemoryBackend) Ack(_ context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(job.Queue)
+	if !q.release(job) {
+		return ErrLeaseLost
+	}
+	delete(q.jobs, job.ID)
+	q.releaseUnique(job)
+	return nil
+}
+
+// Retry 保存失败信息并在 runAt 时重新执行.
+func (b *MemoryBackend) Retry(_ context.Context, job *Job, runAt time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(job.Queue)
+	if !q.release(job) {
+		return ErrLeaseLost
+	}
+	q.jobs[job.ID] = cloneJob(job)
+	q.scheduled[job.ID] = runAt
+	return nil
+}
+
+// Kill 将任务移入死信队列.
+func (b *MemoryBackend) Kill(_ context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(job.Queue)
+	if !q.release(job) {
+		return ErrLeaseLost
+	}
+	q.jobs[job.ID] = cloneJob(job)
+	q.dead[job.ID] = time.Now()
+	q.releaseUnique(job)
+	return nil
+}
+
+// Dead 按进入时间倒序列出死信任务.
+func (b *MemoryBackend) Dead(_ context.Context, queue string, limit int) ([]*Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(queue)
+	jobs := make([]*Job, 0, len(q.dead))
+	for id := range q.dead {
+		jobs = append(jobs, cloneJob(q.jobs[id]))
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		ti, tk := q.dead[jobs[i].ID], q.dead[jobs[k].ID]
+		if ti.Equal(tk) {
+			return jobs[i].ID > jobs[k].ID
+		}
+		return ti.After(tk)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// Requeue 将死信任务重新放回队列，失败次数清零并重新占用唯一键.
+func (b *MemoryBackend) Requeue(_ context.Context, queue, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(queue)
+	if _, ok := q.dead[id]; !ok {
+		return ErrJobNotFound
+	}
+	job := q.jobs[id]
+	if job.UniqueKey != "" {
+		if owner, ok := q.unique[job.UniqueKey]; ok && owner != id {
+			return ErrDuplicateJob
+		}
+		q.unique[job.UniqueKey] = id
+	}
+	delete(q.dead, id)
+	job.Attempts = 0
+	q.ready[id] = struct{}{}
+	return nil
+}
+
+// Delete 删除死信任务.
+func (b *MemoryBackend) Delete(_ context.Context, queue, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(queue)
+	if _, ok := q.dead[id]; !ok {
+		return ErrJobNotFound
+	}
+	delete(q.dead, id)
+	delete(q.jobs, id)
+	return nil
+}
+
+// Stats 返回队列统计.
+func (b *MemoryBackend) Stats(_ context.Context, queue string) (*Stats, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(queue)
+	return &Stats{
+		Scheduled: int64(len(q.scheduled)),
+		Ready:     int64(len(q.ready)),
+		Running:   int64(len(q.running)),
+		Dead:      int64(len(q.dead)),
+	}, nil
+}
+
+// release 在租约有效时将任务移出执行中集合，返回租约是否有效.
+func (q *memoryQueue) release(job *Job) bool {
+	deadline, ok := q.running[job.ID]
+	if !ok || deadline.UnixNano() != job.Lease {
+		return false
+	}
+	delete(q.running, job.ID)
+	return true
+}
+
+// releaseUnique 释放任务持有的唯一键.
+func (q *memoryQueue) releaseUnique(job *Job) {
+	if job.UniqueKey != "" && q.unique[job.UniqueKey] == job.ID {
+		delete(q.unique, job.UniqueKey)
+	}
+}
+
+// cloneJob 复制任务，避免调用方修改后端中的数据，租约不随任务保存.
+func cloneJob(job *Job) *Job {
+	c := *job
+	c.Payload = append([]byte(nil), job.Payload...)
+	c.Lease = 0
+	return &c
+}
@@ -0,0 +1,135 @@
+package jobqueue
+
+import (
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// BackoffFunc 根据已失败次数计算重试间隔.
+type BackoffFunc func(attempts int) time.Duration
+
+// DefaultBackoff 默认重试间隔：从 1 秒开始翻倍，最长 1 小时.
+func DefaultBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 12 {
+		return time.Hour
+	}
+	return min(time.Second<<(attempts-1), time.Hour)
+}
+
+// Option 队列配置选项.
+type Option func(*options)
+
+// options 队列配置.
+type options struct {
+	name              string
+	logger            logger.Logger
+	concurrency       int
+	visibilityTimeout time.Duration
+	pollInterval      time.Duration
+	maxAttempts       int
+	backoff           BackoffFunc
+	hooks             *Hooks
+}
+
+// applyOptions 应用配置选项.
+func applyOptions(opts []Option) *options {
+	o := &options{
+		name:              "default",
+		concurrency:       10,
+		visibilityTimeout: 5 * time.Minute,
+		pollInterval:      time.Second,
+		maxAttempts:       25,
+		backoff:           DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithName 设置队列名称.
+//
+// 默认: default.
+func WithName(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.name = name
+		}
+	}
+}
+
+// WithLogger 设置日志记录器.
+func WithLogger(log logger.Logger) Option {
+	return func(o *options) {
+		o.logger = log
+	}
+}
+
+// WithConcurrency 设置 Worker 数量.
+//
+// 默认: 10.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithVisibilityTimeout 设置可见性超时.
+//
+// 任务出队后在该时长内对其他 Worker 不可见，超时未确认则重新投递.
+// 同时作为处理器 context 的超时时间，应大于处理器的最长耗时.
+//
+// 默认: 5 分钟.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.visibilityTimeout = d
+		}
+	}
+}
+
+// WithPollInterval 设置队列为空时的轮询间隔.
+//
+// 默认: 1 秒.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// WithDefaultMaxAttempts 设置任务默认的最大执行次数.
+//
+// 默认: 25.
+func WithDefaultMaxAttempts(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff 设置重试间隔计算函数.
+//
+// 默认: DefaultBackoff.
+func WithBackoff(fn BackoffFunc) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.backoff = fn
+		}
+	}
+}
+
+// WithHooks 设置任务钩子.
+func WithHooks(hooks *Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
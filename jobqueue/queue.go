@@ -0,0 +1,355 @@
+// Package jobqueue 提供持久化的后台任务队列.
+//
+// 特性：
+//   - 类型化任务参数，JSON 序列化存储
+//   - 延迟执行、定时执行和优先级
+//   - 唯一键去重：相同唯一键的任务完成前不会重复入队
+//   - Worker 池 + 可见性超时，至少一次执行
+//   - 指数退避重试，超过最大次数进入死信队列
+//   - Hook 机制：BeforeJob/AfterJob/OnError/OnDead
+//   - Redis（复用 cache 包）和内存两种存储后端
+//
+// 示例：
+//
+//	backend, _ := jobqueue.NewRedisBackend(redisCache)
+//	q := jobqueue.New(backend, jobqueue.WithName("mail"), jobqueue.WithLogger(log))
+//
+//	jobqueue.Handle(q, "send-email", func(ctx context.Context, p EmailPayload) error {
+//	    return mailer.Send(ctx, p.To, p.Subject)
+//	})
+//
+//	q.Enqueue(ctx, "send-email", EmailPayload{To: "a@example.com"},
+//	    jobqueue.WithDelay(10*time.Minute),
+//	    jobqueue.WithUniqueKey("welcome:42"),
+//	)
+//
+//	application.Use(q) // Queue 实现 app.Server
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// Handler 任务处理器.
+type Handler func(ctx context.Context, job *Job) error
+
+// Handle 注册类型化的任务处理器，任务参数自动反序列化为 T.
+//
+// 参数反序列化失败的任务直接进入死信队列，不再重试.
+func Handle[T any](q *Queue, jobType string, fn func(ctx context.Context, payload T) error) {
+	q.Register(jobType, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := job.Decode(&payload); err != nil {
+			return Permanent(fmt.Errorf("jobqueue: 解析任务参数失败: %w", err))
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// permanentError 不可重试的错误.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 将错误标记为不可重试，处理器返回后任务直接进入死信队列.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 检查错误是否被标记为不可重试.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// Queue 任务队列.
+//
+// 同一个 Queue 既可以入队任务，也可以作为 app.Server 启动 Worker 池消费任务.
+// 多个实例共享同一个后端和队列名称时，任务只会被其中一个 Worker 取出.
+type Queue struct {
+	backend  Backend
+	opts     *options
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	started  atomic.Bool
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// New 创建任务队列.
+func New(backend Backend, opts ...Option) *Queue {
+	if backend == nil {
+		panic("jobqueue: 存储后端不能为空")
+	}
+	return &Queue{
+		backend:  backend,
+		opts:     applyOptions(opts),
+		handlers: make(map[string]Handler),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Register 注册任务处理器，相同类型重复注册时覆盖.
+func (q *Queue) Register(jobType string, handler Handler) {
+	if jobType == "" {
+		panic("jobqueue: 任务类型不能为空")
+	}
+	if handler == nil {
+		panic("jobqueue: 处理器不能为空")
+	}
+	q.mu.Lock()
+	q.handlers[jobType] = handler
+	q.mu.Unlock()
+}
+
+// Enqueue 入队任务，payload 以 JSON 序列化存储.
+//
+// 设置了唯一键且相同唯一键的任务尚未完成时返回 ErrDuplicateJob.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any, opts ...EnqueueOption) (*Job, error) {
+	if jobType == "" {
+		return nil, ErrEmptyType
+	}
+
+	var data json.RawMessage
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("jobqueue: 序列化任务参数失败: %w", err)
+		}
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &Job{
+		ID:           id.String(),
+		Queue:        q.opts.name,
+		Type:         jobType,
+		Payload:      data,
+		MaxAttempts:  q.opts.maxAttempts,
+		RunAt:        now,
+		EnqueuedTime: now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := q.backend.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Dead 按进入时间倒序列出死信任务，limit <= 0 时返回全部.
+func (q *Queue) Dead(ctx context.Context, limit int) ([]*Job, error) {
+	return q.backend.Dead(ctx, q.opts.name, limit)
+}
+
+// Requeue 将死信任务重新放回队列，失败次数清零.
+//
+// 任务的唯一键已被其他任务占用时返回 ErrDuplicateJob.
+func (q *Queue) Requeue(ctx context.Context, id string) error {
+	return q.backend.Requeue(ctx, q.opts.name, id)
+}
+
+// DeleteDead 删除死信任务.
+func (q *Queue) DeleteDead(ctx context.Context, id string) error {
+	return q.backend.Delete(ctx, q.opts.name, id)
+}
+
+// Stats 返回队列统计.
+func (q *Queue) Stats(ctx context.Context) (*Stats, error) {
+	return q.backend.Stats(ctx, q.opts.name)
+}
+
+// Start 启动 Worker 池，阻塞直到 ctx 取消或调用 Stop.
+func (q *Queue) Start(ctx context.Context) error {
+	if !q.started.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer close(q.done)
+
+	q.logInfo("[JobQueue] 队列启动")
+
+	var wg sync.WaitGroup
+	for range q.opts.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// Stop 停止 Worker 池，等待执行中的任务完成.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stopCh) })
+	if !q.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-q.done:
+		q.logInfo("[JobQueue] 队列停止")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name 返回队列名称.
+func (q *Queue) Name() string { return "jobqueue-" + q.opts.name }
+
+// Addr 返回队列名称.
+func (q *Queue) Addr() string { return q.opts.name }
+
+// work 单个 Worker 的循环：有任务时连续处理，队列为空时等待轮询间隔.
+func (q *Queue) work(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.stopCh:
+			return
+		case <-timer.C:
+		}
+
+		ok, err := q.Process(ctx)
+		if err != nil {
+			q.logError("[JobQueue] 处理任务失败", err)
+		}
+		if ok && err == nil {
+			timer.Reset(0)
+		} else {
+			timer.Reset(q.opts.pollInterval)
+		}
+	}
+}
+
+// Process 取出并执行一个任务，队列为空时返回 false.
+//
+// 通常由 Worker 循环调用，也可用于测试或手动触发.
+func (q *Queue) Process(ctx context.Context) (bool, error) {
+	job, err := q.backend.Dequeue(ctx, q.opts.name, q.opts.visibilityTimeout)
+	if err != nil || job == nil {
+		return false, err
+	}
+	return true, q.execute(ctx, job)
+}
+
+// execute 执行任务并根据结果确认、重试或移入死信队列.
+func (q *Queue) execute(ctx context.Context, job *Job) error {
+	jc := &JobContext{
+		Job:       job,
+		StartTime: time.Now(),
+		Attempt:   job.Attempts + 1,
+	}
+
+	err := q.opts.hooks.runBeforeHooks(ctx, jc)
+	if err == nil {
+		err = q.run(ctx, job)
+	}
+	jc.Error = err
+	jc.Duration = time.Since(jc.StartTime)
+	q.opts.hooks.runAfterHooks(ctx, jc)
+
+	// 队列停止时仍需写回任务状态
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		return q.backend.Ack(ctx, job)
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	job.FailedTime = time.Now()
+
+	if IsPermanent(err) || errors.Is(err, ErrUnknownType) || job.Attempts >= job.MaxAttempts {
+		if killErr := q.backend.Kill(ctx, job); killErr != nil {
+			return killErr
+		}
+		q.logFailure("[JobQueue] 任务进入死信队列", job, err)
+		q.opts.hooks.runDeadHooks(ctx, jc)
+		return nil
+	}
+
+	jc.NextRunAt = time.Now().Add(q.opts.backoff(job.Attempts))
+	if retryErr := q.backend.Retry(ctx, job, jc.NextRunAt); retryErr != nil {
+		return retryErr
+	}
+	q.logFailure("[JobQueue] 任务执行失败，等待重试", job, err)
+	q.opts.hooks.runErrorHooks(ctx, jc)
+	return nil
+}
+
+// run 查找处理器并在可见性超时内执行，捕获处理器 panic.
+func (q *Queue) run(ctx context.Context, job *Job) (err error) {
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, job.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.opts.visibilityTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobqueue: 任务 panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// logFailure 记录任务失败日志.
+func (q *Queue) logFailure(msg string, job *Job, err error) {
+	if q.opts.logger != nil {
+		q.opts.logger.With(
+			logger.String("queue", job.Queue),
+			logger.String("job_id", job.ID),
+			logger.String("job_type", job.Type),
+			logger.Int("attempts", job.Attempts),
+			logger.Err(err),
+		).Warn(msg)
+	}
+}
+
+// logInfo 记录信息日志.
+func (q *Queue) logInfo(msg string) {
+	if q.opts.logger != nil {
+		q.opts.logger.With(logger.String("queue", q.opts.name)).Info(msg)
+	}
+}
+
+// logError 记录错误日志.
+func (q *Queue) logError(msg string, err error) {
+	if q.opts.logger != nil {
+		q.opts.logger.With(logger.String("queue", q.opts.name), logger.Err(err)).Error(msg)
+	}
+}
@@ -0,0 +1,226 @@
+package jobqueue
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// testLogger 用于测试的模拟日志器.
+type testLogger struct{}
+
+func (m *testLogger) Debug(args ...any)                             {}
+func (m *testLogger) Debugf(format string, args ...any)             {}
+func (m *testLogger) Info(args ...any)                              {}
+func (m *testLogger) Infof(format string, args ...any)              {}
+func (m *testLogger) Warn(args ...any)                              {}
+func (m *testLogger) Warnf(format string, args ...any)              {}
+func (m *testLogger) Error(args ...any)                             {}
+func (m *testLogger) Errorf(format string, args ...any)             {}
+func (m *testLogger) Fatal(args ...any)                             {}
+func (m *testLogger) Fatalf(format string, args ...any)             {}
+func (m *testLogger) Panic(args ...any)                             {}
+func (m *testLogger) Panicf(format string, args ...any)             {}
+func (m *testLogger) With(fields ...logger.Field) logger.Logger     { return m }
+func (m *testLogger) WithContext(ctx context.Context) logger.Logger { return m }
+func (m *testLogger) Sync() error                                   { return nil }
+func (m *testLogger) Close() error                                  { return nil }
+
+type emailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+func newTestQueue(opts ...Option) *Queue {
+	opts = append([]Option{WithLogger(&testLogger{})}, opts...)
+	return New(NewMemoryBackend(), opts...)
+}
+
+func TestQueue_Handle(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue()
+
+	var got emailPayload
+	Handle(q, "send-email", func(_ context.Context, p emailPayload) error {
+		got = p
+		return nil
+	})
+
+	job, err := q.Enqueue(ctx, "send-email", emailPayload{To: "a@example.com", Subject: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "default", job.Queue)
+	assert.Equal(t, 25, job.MaxAttempts)
+
+	ok, err := q.Process(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, emailPayload{To: "a@example.com", Subject: "hi"}, got)
+
+	ok, err = q.Process(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok, "成功的任务已确认删除")
+
+	_, err = q.Enqueue(ctx, "", nil)
+	assert.ErrorIs(t, err, ErrEmptyType)
+}
+
+func TestQueue_RetryThenDead(t *testing.T) {
+	ctx := context.Background()
+
+	var before, after, failed, dead atomic.Int32
+	hooks := NewHooks().
+		BeforeJob(func(context.Context, *JobContext) error { before.Add(1); return nil }).
+		AfterJob(func(context.Context, *JobContext) { after.Add(1) }).
+		OnError(func(_ context.Context, jc *JobContext) {
+			failed.Add(1)
+			assert.False(t, jc.NextRunAt.IsZero())
+		}).
+		OnDead(func(_ context.Context, jc *JobContext) {
+			dead.Add(1)
+			assert.Equal(t, 2, jc.Attempt)
+		}).
+		Build()
+
+	q := newTestQueue(WithHooks(hooks), WithBackoff(func(int) time.Duration { return 0 }))
+	errWebhook := errors.New("webhook failed")
+	q.Register("webhook", func(context.Context, *Job) error { return errWebhook })
+
+	job, err := q.Enqueue(ctx, "webhook", nil, WithMaxAttempts(2))
+	require.NoError(t, err)
+
+	for range 2 {
+		ok, err := q.Process(ctx)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	assert.Equal(t, int32(2), before.Load())
+	assert.Equal(t, int32(2), after.Load())
+	assert.Equal(t, int32(1), failed.Load())
+	assert.Equal(t, int32(1), dead.Load())
+
+	deadJobs, err := q.Dead(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, deadJobs, 1)
+	assert.Equal(t, job.ID, deadJobs[0].ID)
+	assert.Equal(t, 2, deadJobs[0].Attempts)
+	assert.Equal(t, errWebhook.Error(), deadJobs[0].LastError)
+
+	// 人工修复后重新入队
+	q.Register("webhook", func(context.Context, *Job) error { return nil })
+	require.NoError(t, q.Requeue(ctx, job.ID))
+	ok, err := q.Process(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	stats, err := q.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{}, *stats)
+}
+
+func TestQueue_PermanentError(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue()
+
+	q.Register("permanent", func(context.Context, *Job) error {
+		return Permanent(errors.New("invalid address"))
+	})
+	Handle(q, "typed", func(context.Context, emailPayload) error { return nil })
+	q.Register("panic", func(context.Context, *Job) error { panic("boom") })
+
+	_, err := q.Enqueue(ctx, "permanent", nil)
+	require.NoError(t, err)
+	_, err = q.Enqueue(ctx, "typed", "not an object")
+	require.NoError(t, err)
+	_, err = q.Enqueue(ctx, "unknown", nil)
+	require.NoError(t, err)
+	_, err = q.Enqueue(ctx, "panic", nil)
+	require.NoError(t, err)
+
+	for range 4 {
+		_, err := q.Process(ctx)
+		require.NoError(t, err)
+	}
+
+	stats, err := q.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Dead, "不可重试的错误直接进入死信队列")
+	assert.Equal(t, int64(1), stats.Scheduled, "panic 按普通失败重试")
+
+	assert.True(t, IsPermanent(Permanent(errors.New("x"))))
+	assert.False(t, IsPermanent(errors.New("x")))
+	assert.NoError(t, Permanent(nil))
+}
+
+func TestQueue_BeforeHookError(t *testing.T) {
+	ctx := context.Background()
+	errBlocked := errors.New("blocked")
+	hooks := NewHooks().
+		BeforeJob(func(context.Context, *JobContext) error { return errBlocked }).
+		Build()
+	q := newTestQueue(WithHooks(hooks))
+
+	called := false
+	q.Register("job", func(context.Context, *Job) error { called = true; return nil })
+	_, err := q.Enqueue(ctx, "job", nil)
+	require.NoError(t, err)
+
+	_, err = q.Process(ctx)
+	require.NoError(t, err)
+	assert.False(t, called)
+
+	stats, err := q.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Scheduled)
+}
+
+func TestQueue_StartStop(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(WithName("mail"), WithConcurrency(3), WithPollInterval(10*time.Millisecond))
+	assert.Equal(t, "jobqueue-mail", q.Name())
+	assert.Equal(t, "mail", q.Addr())
+
+	var processed atomic.Int32
+	done := make(chan struct{})
+	q.Register("job", func(context.Context, *Job) error {
+		if processed.Add(1) == 5 {
+			close(done)
+		}
+		return nil
+	})
+
+	go func() { _ = q.Start(ctx) }()
+
+	for i := range 5 {
+		_, err := q.Enqueue(ctx, "job", i)
+		require.NoError(t, err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("任务未在超时时间内执行")
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, q.Stop(stopCtx))
+}
+
+func TestDefaultBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, DefaultBackoff(0))
+	assert.Equal(t, time.Second, DefaultBackoff(1))
+	assert.Equal(t, 8*time.Second, DefaultBackoff(4))
+	assert.Equal(t, time.Hour, DefaultBackoff(20))
+}
+
+func TestNew_NilBackend(t *testing.T) {
+	assert.Panics(t, func() { New(nil) })
+}
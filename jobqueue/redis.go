@@ -0,0 +1,553 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+// enqueueScript 原子地占用唯一键并写入任务.
+//
+// KEYS: jobs, priority, scheduled, ready, unique
+// ARGV: id, data, priority, run_at_ms, now_ms, has_unique
+var enqueueScript = redis.NewScript(`
+if ARGV[6] == '1' then
+	if not redis.call('SET', KEYS[5], ARGV[1], 'NX') then
+		return 0
+	end
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+if tonumber(ARGV[4]) > tonumber(ARGV[5]) then
+	redis.call('ZADD', KEYS[3], ARGV[4], ARGV[1])
+else
+	redis.call('ZADD', KEYS[4], -tonumber(ARGV[3]), ARGV[1])
+end
+return 1
+`)
+
+// expireScript 将可见性超时的任务放回就绪集合或移入死信集合.
+//
+// 任务的截止时间与读取时不同说明已被其他 Worker 处理，不做修改.
+//
+// KEYS: jobs, priority, running, ready, dead, unique
+// ARGV: id, deadline_ms, data, dead, now_ms, has_unique
+var expireScript = redis.NewScript(`
+local deadline = redis.call('ZSCORE', KEYS[3], ARGV[1])
+if not deadline or tonumber(deadline) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+if ARGV[4] == '1' then
+	redis.call('ZADD', KEYS[5], ARGV[5], ARGV[1])
+	if ARGV[6] == '1' and redis.call('GET', KEYS[6]) == ARGV[1] then
+		redis.call('DEL', KEYS[6])
+	end
+else
+	local priority = redis.call('HGET', KEYS[2], ARGV[1]) or '0'
+	redis.call('ZADD', KEYS[4], -tonumber(priority), ARGV[1])
+end
+return 1
+`)
+
+// dequeueScript 将到期的延迟任务移入就绪集合，取出优先级最高的任务并设置可见性超时.
+//
+// 就绪集合的分数为负优先级，同分数按成员（UUIDv7）字典序，即入队顺序排列.
+//
+// KEYS: jobs, priority, scheduled, ready, running
+// ARGV: now_ms, deadline_ms, move_limit
+var dequeueScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[3]))
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[3], id)
+	local priority = redis.call('HGET', KEYS[2], id)
+	if priority then
+		redis.call('ZADD', KEYS[4], -tonumber(priority), id)
+	end
+end
+
+while true do
+	local ids = redis.call('ZRANGE', KEYS[4], 0, 0)
+	if #ids == 0 then
+		return false
+	end
+	local id = ids[1]
+	redis.call('ZREM', KEYS[4], id)
+	local data = redis.call('HGET', KEYS[1], id)
+	if data then
+		redis.call('ZADD', KEYS[5], ARGV[2], id)
+		return data
+	end
+end
+`)
+
+// ackScript 删除已完成的任务并释放唯一键.
+//
+// 任务的截止时间与租约不同说明已被重新投递，返回 0 且不做修改.
+//
+// KEYS: running, jobs, priority, unique
+// ARGV: id, lease, has_unique
+var ackScript = redis.NewScript(`
+local deadline = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not deadline or tonumber(deadline) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+if ARGV[3] == '1' and redis.call('GET', KEYS[4]) == ARGV[1] then
+	redis.call('DEL', KEYS[4])
+end
+return 1
+`)
+
+// retryScript 保存失败信息并重新调度任务.
+//
+// KEYS: running, jobs, scheduled
+// ARGV: id, lease, data, run_at_ms
+var retryScript = redis.NewScript(`
+local deadline = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not deadline or tonumber(deadline) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[3], ARGV[4], ARGV[1])
+return 1
+`)
+
+// killScript 将任务移入死信集合并释放唯一键.
+//
+// KEYS: running, jobs, dead, unique
+// ARGV: id, lease, data, now_ms, has_unique
+var killScript = redis.NewScript(`
+local deadline = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not deadline or tonumber(deadline) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[3], ARGV[4], ARGV[1])
+if ARGV[5] == '1' and redis.call('GET', KEYS[4]) == ARGV[1] then
+	redis.call('DEL', KEYS[4])
+end
+return 1
+`)
+
+// requeueScript 重新占用唯一键并将死信任务放回就绪集合.
+//
+// 任务不存在时返回 0，唯一键被其他任务占用时返回 -1.
+//
+// KEYS: jobs, priority, dead, ready, unique
+// ARGV: id, data, has_unique
+var requeueScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[3], ARGV[1]) then
+	return 0
+end
+if ARGV[3] == '1' then
+	local owner = redis.call('GET', KEYS[5])
+	if owner and owner ~= ARGV[1] then
+		return -1
+	end
+	redis.call('SET', KEYS[5], ARGV[1])
+end
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+local priority = redis.call('HGET', KEYS[2], ARGV[1]) or '0'
+redis.call('ZADD', KEYS[4], -tonumber(priority), ARGV[1])
+return 1
+`)
+
+// deleteScript 删除死信任务.
+//
+// KEYS: jobs, priority, dead
+// ARGV: id
+var deleteScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[3], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+return 1
+`)
+
+// promoteLimit 每次出队最多迁移的到期任务数.
+const promoteLimit = 100
+
+// RedisBackend 基于 Redis 的存储后端.
+//
+// 每个队列使用以下键，键名带有 {queue} 哈希标签，Redis Cluster 下落在同一个槽位：
+//
+//	<prefix>:{queue}:jobs       HASH  任务 ID → 任务 JSON
+//	<prefix>:{queue}:priority   HASH  任务 ID → 优先级
+//	<prefix>:{queue}:scheduled  ZSET  等待到期的任务，分数为执行时间
+//	<prefix>:{queue}:ready      ZSET  就绪任务，分数为负优先级
+//	<prefix>:{queue}:running    ZSET  执行中的任务，分数为可见性截止时间
+//	<prefix>:{queue}:dead       ZSET  死信任务，分数为进入时间
+//	<prefix>:{queue}:unique:<key>     唯一键占用标记
+//
+// 所有状态迁移都由 Lua 脚本原子完成.
+type RedisBackend struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// RedisOption Redis 后端配置选项.
+type RedisOption func(*RedisBackend)
+
+// WithKeyPrefix 设置 Redis 键前缀.
+//
+// 默认: jobqueue.
+func WithKeyPrefix(prefix string) RedisOption {
+	return func(b *RedisBackend) {
+		if prefix != "" {
+			b.prefix = prefix
+		}
+	}
+}
+
+// NewRedisBackend 基于 Redis 缓存创建存储后端.
+//
+// c 必须是 Redis 缓存（cache.NewRedisCache 或 cache.NewCache 创建），否则返回 ErrUnsupportedCache.
+func NewRedisBackend(c cache.Cache, opts ...RedisOption) (*RedisBackend, error) {
+	if c == nil {
+		return nil, ErrUnsupportedCache
+	}
+	client, ok := c.Client().(redis.UniversalClient)
+	if !ok {
+		return nil, ErrUnsupportedCache
+	}
+	return NewRedisBackendWithClient(client, opts...), nil
+}
+
+// NewRedisBackendWithClient 基于 Redis 客户端创建存储后端.
+func NewRedisBackendWithClient(client redis.UniversalClient, opts ...RedisOption) *RedisBackend {
+	if client == nil {
+		panic("jobqueue: Redis 客户端不能为空")
+	}
+	b := &RedisBackend{client: client, prefix: "jobqueue"}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// key 返回队列的 Redis 键.
+func (b *RedisBackend) key(queue, name string) string {
+	return b.prefix + ":{" + queue + "}:" + name
+}
+
+// uniqueKey 返回唯一键对应的 Redis 键和脚本参数.
+//
+// 任务没有唯一键时仍返回同一槽位的键名，避免 Redis Cluster 下出现跨槽位错误，由标志位跳过处理.
+func (b *RedisBackend) uniqueKey(job *Job) (string, string) {
+	key := b.key(job.Queue, "unique:"+job.UniqueKey)
+	if job.UniqueKey == "" {
+		return key, "0"
+	}
+	return key, "1"
+}
+
+// Enqueue 写入任务.
+func (b *RedisBackend) Enqueue(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	uniqueKey, hasUnique := b.uniqueKey(job)
+	keys := []string{
+		b.key(job.Queue, "jobs"),
+		b.key(job.Queue, "priority"),
+		b.key(job.Queue, "scheduled"),
+		b.key(job.Queue, "ready"),
+		uniqueKey,
+	}
+	ok, err := enqueueScript.Run(ctx, b.client, keys,
+		job.ID, data, job.Priority, job.RunAt.UnixMilli(), time.Now().UnixMilli(), hasUnique,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrDuplicateJob
+	}
+	return nil
+}
+
+// Dequeue 取出一个到期的最高优先级任务.
+func (b *RedisBackend) Dequeue(ctx context.Context, queue string, visibility time.Duration) (*Job, error) {
+	now := time.Now()
+	if err := b.expire(ctx, queue, now); err != nil {
+		return nil, err
+	}
+
+	keys := []string{
+		b.key(queue, "jobs"),
+		b.key(queue, "priority"),
+		b.key(queue, "scheduled"),
+		b.key(queue, "ready"),
+		b.key(queue, "running"),
+	}
+	deadline := now.Add(visibility).UnixMilli()
+	data, err := dequeueScript.Run(ctx, b.client, keys,
+		now.UnixMilli(), deadline, promoteLimit,
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job, err := decodeJob(data)
+	if err != nil {
+		return nil, err
+	}
+	job.Lease = deadline
+	return job, nil
+}
+
+// expire 处理可见性超时的任务，计入失败次数，次数用尽时移入死信集合.
+//
+// 失败次数在 Go 中修改，避免 Lua cjson 重新编码任务参数时丢失数字精度.
+func (b *RedisBackend) expire(ctx context.Context, queue string, now time.Time) error {
+	expired, err := b.client.ZRangeByScoreWithScores(ctx, b.key(queue, "running"), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: promoteLimit,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, z := range expired {
+		id, ok := z.Member.(string)
+		if !ok {
+			continue
+		}
+		data, err := b.client.HGet(ctx, b.key(queue, "jobs"), id).Result()
+		if errors.Is(err, redis.Nil) {
+			b.client.ZRem(ctx, b.key(queue, "running"), id)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		job, err := decodeJob(data)
+		if err != nil {
+			return err
+		}
+		dead := job.expire(now)
+		updated, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+
+		uniqueKey, hasUnique := b.uniqueKey(job)
+		keys := []string{
+			b.key(queue, "jobs"),
+			b.key(queue, "priority"),
+			b.key(queue, "running"),
+			b.key(queue, "ready"),
+			b.key(queue, "dead"),
+			uniqueKey,
+		}
+		err = expireScript.Run(ctx, b.client, keys,
+			id, int64(z.Score), updated, boolArg(dead), now.UnixMilli(), hasUnique,
+		).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ack 确认任务完成.
+func (b *RedisBackend) Ack(ctx context.Context, job *Job) error {
+	uniqueKey, hasUnique := b.uniqueKey(job)
+	keys := []string{
+		b.key(job.Queue, "running"),
+		b.key(job.Queue, "jobs"),
+		b.key(job.Queue, "priority"),
+		uniqueKey,
+	}
+	return leaseResult(ackScript.Run(ctx, b.client, keys, job.ID, job.Lease, hasUnique).Int())
+}
+
+// Retry 保存失败信息并在 runAt 时重新执行.
+func (b *RedisBackend) Retry(ctx context.Context, job *Job, runAt time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	keys := []string{
+		b.key(job.Queue, "running"),
+		b.key(job.Queue, "jobs"),
+		b.key(job.Queue, "scheduled"),
+	}
+	return leaseResult(retryScript.Run(ctx, b.client, keys, job.ID, job.Lease, data, runAt.UnixMilli()).Int())
+}
+
+// Kill 将任务移入死信队列.
+func (b *RedisBackend) Kill(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	uniqueKey, hasUnique := b.uniqueKey(job)
+	keys := []string{
+		b.key(job.Queue, "running"),
+		b.key(job.Queue, "jobs"),
+		b.key(job.Queue, "dead"),
+		uniqueKey,
+	}
+	return leaseResult(killScript.Run(ctx, b.client, keys,
+		job.ID, job.Lease, data, time.Now().UnixMilli(), hasUnique,
+	).Int())
+}
+
+// Dead 按进入时间倒序列出死信任务.
+func (b *RedisBackend) Dead(ctx context.Context, queue string, limit int) ([]*Job, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit) - 1
+	}
+	ids, err := b.client.ZRevRange(ctx, b.key(queue, "dead"), 0, stop).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	values, err := b.client.HMGet(ctx, b.key(queue, "jobs"), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			continue
+		}
+		job, err := decodeJob(data)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Requeue 将死信任务重新放回队列，失败次数清零并重新占用唯一键.
+func (b *RedisBackend) Requeue(ctx context.Context, queue, id string) error {
+	data, err := b.client.HGet(ctx, b.key(queue, "jobs"), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrJobNotFound
+	}
+	if err != nil {
+		return err
+	}
+	job, err := decodeJob(data)
+	if err != nil {
+		return err
+	}
+	job.Attempts = 0
+	updated, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	uniqueKey, hasUnique := b.uniqueKey(job)
+	keys := []string{
+		b.key(queue, "jobs"),
+		b.key(queue, "priority"),
+		b.key(queue, "dead"),
+		b.key(queue, "ready"),
+		uniqueKey,
+	}
+	ok, err := requeueScript.Run(ctx, b.client, keys, id, updated, hasUnique).Int()
+	if err != nil {
+		return err
+	}
+	switch ok {
+	case 0:
+		return ErrJobNotFound
+	case -1:
+		return ErrDuplicateJob
+	}
+	return nil
+}
+
+// Delete 删除死信任务.
+func (b *RedisBackend) Delete(ctx context.Context, queue, id string) error {
+	keys := []string{
+		b.key(queue, "jobs"),
+		b.key(queue, "priority"),
+		b.key(queue, "dead"),
+	}
+	ok, err := deleteScript.Run(ctx, b.client, keys, id).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// Stats 返回队列统计.
+func (b *RedisBackend) Stats(ctx context.Context, queue string) (*Stats, error) {
+	pipe := b.client.Pipeline()
+	scheduled := pipe.ZCard(ctx, b.key(queue, "scheduled"))
+	ready := pipe.ZCard(ctx, b.key(queue, "ready"))
+	running := pipe.ZCard(ctx, b.key(queue, "running"))
+	dead := pipe.ZCard(ctx, b.key(queue, "dead"))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return &Stats{
+		Scheduled: scheduled.Val(),
+		Ready:     ready.Val(),
+		Running:   running.Val(),
+		Dead:      dead.Val(),
+	}, nil
+}
+
+// leaseResult 将校验租约的脚本结果转换为错误.
+func leaseResult(ok int, err error) error {
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// boolArg 将布尔值转换为脚本参数.
+func boolArg(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+// decodeJob 反序列化任务.
+func decodeJob(data string) (*Job, error) {
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// 确保实现了 Backend 接口.
+var (
+	_ Backend = (*RedisBackend)(nil)
+	_ Backend = (*MemoryBackend)(nil)
+)
@@ -0,0 +1,366 @@
+// Package circuitbreaker 提供熔断器中间件.
+//
+// 熔断器有三种状态:
+//   - Closed: 正常放行，在滚动窗口内统计失败率和慢调用率
+//   - Open: 失败率或慢调用率超过阈值后打开，直接拒绝请求
+//   - HalfOpen: 打开一段时间后放行少量探测请求，全部成功则关闭，否则重新打开
+//
+// 基本用法:
+//
+//	breaker := circuitbreaker.New("user-service",
+//	    circuitbreaker.WithFailureRatio(0.5),
+//	    circuitbreaker.WithSlowCall(time.Second, 0.8),
+//	)
+//	err := breaker.Execute(func() error {
+//	    return callDownstream(ctx)
+//	})
+//
+// 按方法或下游主机隔离:
+//
+//	group := circuitbreaker.NewGroup(circuitbreaker.WithOpenTimeout(10*time.Second))
+//	conn, _ := grpc.NewClient(target,
+//	    grpc.WithUnaryInterceptor(circuitbreaker.UnaryClientInterceptor(group, nil)),
+//	)
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// State 熔断器状态.
+type State int
+
+// 熔断器状态常量.
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// String 返回状态名称.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Counts 滚动窗口内的调用统计.
+type Counts struct {
+	Requests  int64 // 请求总数
+	Failures  int64 // 失败数
+	SlowCalls int64 // 慢调用数
+}
+
+// Breaker 熔断器.
+type Breaker struct {
+	name string
+	opts *options
+
+	mu       sync.Mutex
+	state    State
+	window   *rollingWindow
+	openedAt time.Time
+
+	// 半开状态下已放行和已成功的探测请求数
+	probes    int
+	successes int
+
+	// generation 每次状态切换时递增，用于丢弃旧状态下发出的请求结果
+	generation uint64
+}
+
+// New 创建熔断器，name 用于日志和指标.
+func New(name string, opts ...Option) *Breaker {
+	o := applyOptions(opts)
+	return &Breaker{
+		name:   name,
+		opts:   o,
+		state:  StateClosed,
+		window: newRollingWindow(o.window, o.buckets, time.Now()),
+	}
+}
+
+// Name 返回熔断器名称.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 返回当前状态.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshLocked(time.Now())
+	return b.state
+}
+
+// Counts 返回当前滚动窗口内的统计.
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.window.counts(time.Now())
+}
+
+// Allow 判断是否放行请求.
+//
+// 放行时返回 done 回调，调用方必须在请求结束后调用 done 报告结果.
+// 拒绝时返回 ErrOpen 或 ErrTooManyRequests.
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	now := time.Now()
+
+	b.mu.Lock()
+	b.refreshLocked(now)
+
+	switch b.state {
+	case StateOpen:
+		b.mu.Unlock()
+		b.opts.metrics.recordRejected(b.name)
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.opts.halfOpenRequests {
+			b.mu.Unlock()
+			b.opts.metrics.recordRejected(b.name)
+			return nil, ErrTooManyRequests
+		}
+		b.probes++
+	}
+	generation := b.generation
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			b.report(generation, success, time.Since(now))
+		})
+	}, nil
+}
+
+// Execute 在熔断器保护下执行 fn，按 WithIsFailure 判断结果.
+func (b *Breaker) Execute(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	done(!b.opts.isFailure(err))
+	return err
+}
+
+// report 记录请求结果并按需切换状态.
+func (b *Breaker) report(generation uint64, success bool, elapsed time.Duration) {
+	now := time.Now()
+	slow := b.opts.slowCallDuration > 0 && elapsed >= b.opts.slowCallDuration
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshLocked(now)
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		b.window.add(now, !success, slow)
+		if b.shouldTripLocked(now) {
+			b.setStateLocked(StateOpen, now)
+		}
+	case StateHalfOpen:
+		// 探测期间慢调用同样视为失败
+		if !success || slow {
+			b.setStateLocked(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.opts.halfOpenRequests {
+			b.setStateLocked(StateClosed, now)
+		}
+	}
+}
+
+// shouldTripLocked 判断滚动窗口统计是否超过阈值.
+func (b *Breaker) shouldTripLocked(now time.Time) bool {
+	c := b.window.counts(now)
+	if c.Requests < b.opts.minRequests {
+		return false
+	}
+
+	total := float64(c.Requests)
+	if float64(c.Failures)/total >= b.opts.failureRatio {
+		return true
+	}
+	return b.opts.slowCallDuration > 0 && float64(c.SlowCalls)/total >= b.opts.slowCallRatio
+}
+
+// refreshLocked 打开超时后切换到半开状态.
+func (b *Breaker) refreshLocked(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.opts.openTimeout {
+		b.setStateLocked(StateHalfOpen, now)
+	}
+}
+
+// setStateLocked 切换状态并通知.
+func (b *Breaker) setStateLocked(state State, now time.Time) {
+	from := b.state
+	if from == state {
+		return
+	}
+
+	b.state = state
+	b.generation++
+	b.probes, b.successes = 0, 0
+
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.window.reset(now)
+	}
+
+	if b.opts.logger != nil {
+		log := b.opts.logger.With(
+			logger.String("breaker", b.name),
+			logger.String("from", from.String()),
+			logger.String("to", state.String()),
+		)
+		if state == StateOpen {
+			log.Warn("[CircuitBreaker] 熔断器已打开")
+		} else {
+			log.Info("[CircuitBreaker] 熔断器状态变更")
+		}
+	}
+	b.opts.metrics.recordStateChange(b.name, from, state)
+	if b.opts.onStateChange != nil {
+		b.opts.onStateChange(b.name, from, state)
+	}
+}
+
+// bucket 滚动窗口中的单个时间桶.
+type bucket struct {
+	requests  int64
+	failures  int64
+	slowCalls int64
+}
+
+// rollingWindow 按时间分桶的滚动窗口.
+type rollingWindow struct {
+	buckets []bucket
+	width   time.Duration // 单个桶的时长
+	start   time.Time     // 当前桶的起始时间
+	current int
+}
+
+func newRollingWindow(window time.Duration, n int, now time.Time) *rollingWindow {
+	return &rollingWindow{
+		buckets: make([]bucket, n),
+		width:   window / time.Duration(n),
+		start:   now,
+	}
+}
+
+// advance 滚动到 now 所在的桶，清空过期的桶.
+func (w *rollingWindow) advance(now time.Time) {
+	n := int(now.Sub(w.start) / w.width)
+	if n <= 0 {
+		return
+	}
+	if n >= len(w.buckets) {
+		w.reset(now)
+		return
+	}
+	for i := 0; i < n; i++ {
+		w.current = (w.current + 1) % len(w.buckets)
+		w.buckets[w.current] = bucket{}
+	}
+	w.start = w.start.Add(time.Duration(n) * w.width)
+}
+
+func (w *rollingWindow) add(now time.Time, failure, slow bool) {
+	w.advance(now)
+	b := &w.buckets[w.current]
+	b.requests++
+	if failure {
+		b.failures++
+	}
+	if slow {
+		b.slowCalls++
+	}
+}
+
+func (w *rollingWindow) counts(now time.Time) Counts {
+	w.advance(now)
+	var c Counts
+	for _, b := range w.buckets {
+		c.Requests += b.requests
+		c.Failures += b.failures
+		c.SlowCalls += b.slowCalls
+	}
+	return c
+}
+
+func (w *rollingWindow) reset(now time.Time) {
+	clear(w.buckets)
+	w.current = 0
+	w.start = now
+}
+
+// Group 按键管理熔断器，例如按方法或下游主机隔离.
+type Group struct {
+	opts []Option
+
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup 创建熔断器组，组内熔断器共用 opts.
+func NewGroup(opts ...Option) *Group {
+	return &Group{
+		opts:     opts,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Get 返回键对应的熔断器，不存在时创建.
+func (g *Group) Get(key string) *Breaker {
+	g.mu.RLock()
+	b, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// 双重检查
+	if b, ok = g.breakers[key]; !ok {
+		b = New(key, g.opts...)
+		g.breakers[key] = b
+	}
+	return b
+}
+
+// States 返回组内所有熔断器的当前状态.
+func (g *Group) States() map[string]State {
+	g.mu.RLock()
+	breakers := make([]*Breaker, 0, len(g.breakers))
+	for _, b := range g.breakers {
+		breakers = append(breakers, b)
+	}
+	g.mu.RUnlock()
+
+	states := make(map[string]State, len(breakers))
+	for _, b := range breakers {
+		states[b.name] = b.State()
+	}
+	return states
+}
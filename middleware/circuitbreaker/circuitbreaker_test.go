@@ -0,0 +1,247 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errDownstream = errors.New("downstream error")
+
+// tripBreaker 连续失败直到熔断器打开.
+func tripBreaker(t *testing.T, b *Breaker, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_ = b.Execute(func() error { return errDownstream })
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("期望状态为 open，实际 %s", b.State())
+	}
+}
+
+func TestBreaker_FailureRatio(t *testing.T) {
+	t.Run("未达到最小请求数不熔断", func(t *testing.T) {
+		b := New("test", WithMinRequests(5))
+		for i := 0; i < 4; i++ {
+			_ = b.Execute(func() error { return errDownstream })
+		}
+		if b.State() != StateClosed {
+			t.Errorf("期望状态为 closed，实际 %s", b.State())
+		}
+	})
+
+	t.Run("失败率达到阈值后熔断", func(t *testing.T) {
+		b := New("test", WithMinRequests(4), WithFailureRatio(0.5))
+		_ = b.Execute(func() error { return nil })
+		_ = b.Execute(func() error { return nil })
+		_ = b.Execute(func() error { return errDownstream })
+		if b.State() != StateClosed {
+			t.Fatalf("期望状态为 closed，实际 %s", b.State())
+		}
+		_ = b.Execute(func() error { return errDownstream })
+		if b.State() != StateOpen {
+			t.Fatalf("期望状态为 open，实际 %s", b.State())
+		}
+
+		called := false
+		err := b.Execute(func() error {
+			called = true
+			return nil
+		})
+		if !errors.Is(err, ErrOpen) {
+			t.Errorf("期望 ErrOpen，实际 %v", err)
+		}
+		if called {
+			t.Error("熔断时不应调用下游")
+		}
+	})
+
+	t.Run("客户端错误不计为失败", func(t *testing.T) {
+		b := New("test", WithMinRequests(3))
+		for i := 0; i < 5; i++ {
+			_ = b.Execute(func() error { return status.Error(codes.InvalidArgument, "bad request") })
+			_ = b.Execute(func() error { return context.Canceled })
+		}
+		if b.State() != StateClosed {
+			t.Errorf("期望状态为 closed，实际 %s", b.State())
+		}
+		if c := b.Counts(); c.Requests != 10 || c.Failures != 0 {
+			t.Errorf("期望 10 次请求 0 次失败，实际 %+v", c)
+		}
+	})
+}
+
+func TestBreaker_SlowCall(t *testing.T) {
+	b := New("test",
+		WithMinRequests(2),
+		WithSlowCall(20*time.Millisecond, 0.5),
+	)
+
+	_ = b.Execute(func() error { return nil })
+	_ = b.Execute(func() error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+
+	if c := b.Counts(); c.SlowCalls != 1 || c.Failures != 0 {
+		t.Errorf("期望 1 次慢调用 0 次失败，实际 %+v", c)
+	}
+	if b.State() != StateOpen {
+		t.Errorf("期望慢调用触发熔断，实际 %s", b.State())
+	}
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	newBreaker := func() *Breaker {
+		return New("test",
+			WithMinRequests(2),
+			WithOpenTimeout(20*time.Millisecond),
+			WithHalfOpenRequests(2),
+		)
+	}
+
+	t.Run("探测成功后关闭", func(t *testing.T) {
+		b := newBreaker()
+		tripBreaker(t, b, 2)
+
+		time.Sleep(30 * time.Millisecond)
+		if b.State() != StateHalfOpen {
+			t.Fatalf("期望状态为 half_open，实际 %s", b.State())
+		}
+
+		done1, err := b.Allow()
+		if err != nil {
+			t.Fatalf("不期望错误: %v", err)
+		}
+		done2, err := b.Allow()
+		if err != nil {
+			t.Fatalf("不期望错误: %v", err)
+		}
+		if _, err := b.Allow(); !errors.Is(err, ErrTooManyRequests) {
+			t.Errorf("期望 ErrTooManyRequests，实际 %v", err)
+		}
+
+		done1(true)
+		if b.State() != StateHalfOpen {
+			t.Errorf("期望状态为 half_open，实际 %s", b.State())
+		}
+		done2(true)
+		if b.State() != StateClosed {
+			t.Errorf("期望状态为 closed，实际 %s", b.State())
+		}
+	})
+
+	t.Run("探测失败后重新打开", func(t *testing.T) {
+		b := newBreaker()
+		tripBreaker(t, b, 2)
+
+		time.Sleep(30 * time.Millisecond)
+		_ = b.Execute(func() error { return errDownstream })
+		if b.State() != StateOpen {
+			t.Errorf("期望状态为 open，实际 %s", b.State())
+		}
+	})
+
+	t.Run("忽略旧状态的请求结果", func(t *testing.T) {
+		b := newBreaker()
+		stale, err := b.Allow()
+		if err != nil {
+			t.Fatalf("不期望错误: %v", err)
+		}
+		tripBreaker(t, b, 2)
+
+		time.Sleep(30 * time.Millisecond)
+		stale(false)
+		if b.State() != StateHalfOpen {
+			t.Errorf("期望状态为 half_open，实际 %s", b.State())
+		}
+	})
+}
+
+func TestBreaker_WindowExpiry(t *testing.T) {
+	b := New("test",
+		WithMinRequests(2),
+		WithWindow(50*time.Millisecond, 5),
+	)
+
+	_ = b.Execute(func() error { return errDownstream })
+	time.Sleep(60 * time.Millisecond)
+	_ = b.Execute(func() error { return errDownstream })
+
+	if b.State() != StateClosed {
+		t.Errorf("过期的失败不应计入窗口，实际 %s", b.State())
+	}
+	if c := b.Counts(); c.Requests != 1 {
+		t.Errorf("期望窗口内 1 次请求，实际 %d", c.Requests)
+	}
+}
+
+func TestBreaker_OnStateChange(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		transitions []string
+	)
+	b := New("test",
+		WithMinRequests(1),
+		WithOpenTimeout(10*time.Millisecond),
+		WithOnStateChange(func(name string, from, to State) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+		}),
+	)
+
+	tripBreaker(t, b, 1)
+	time.Sleep(20 * time.Millisecond)
+	_ = b.Execute(func() error { return nil })
+
+	want := []string{"test:closed->open", "test:open->half_open", "test:half_open->closed"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(transitions) != len(want) {
+		t.Fatalf("期望 %v，实际 %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("期望 %v，实际 %v", want, transitions)
+			break
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(WithMinRequests(1))
+
+	if g.Get("a") != g.Get("a") {
+		t.Error("相同键应返回同一熔断器")
+	}
+
+	tripBreaker(t, g.Get("a"), 1)
+	if g.Get("b").State() != StateClosed {
+		t.Error("不同键的熔断器应互相隔离")
+	}
+
+	states := g.States()
+	if states["a"] != StateOpen || states["b"] != StateClosed {
+		t.Errorf("状态不符合预期: %v", states)
+	}
+}
+
+func TestState_String(t *testing.T) {
+	tests := map[State]string{
+		StateClosed:   "closed",
+		StateOpen:     "open",
+		StateHalfOpen: "half_open",
+		State(99):     "unknown",
+	}
+	for state, want := range tests {
+		if got := state.String(); got != want {
+			t.Errorf("期望 %s，实际 %s", want, got)
+		}
+	}
+}
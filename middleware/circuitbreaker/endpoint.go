@@ -0,0 +1,59 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"github.com/Tsukikage7/microservice-kit/endpoint"
+)
+
+// KeyFunc 从请求中提取熔断键.
+type KeyFunc func(ctx context.Context, request any) string
+
+// EndpointMiddleware 返回 Endpoint 熔断中间件.
+//
+// 熔断器打开时直接返回 ErrOpen 或 ErrTooManyRequests，不调用下游.
+//
+// 示例:
+//
+//	breaker := circuitbreaker.New("user-service")
+//	endpoint = circuitbreaker.EndpointMiddleware(breaker)(endpoint)
+func EndpointMiddleware(b *Breaker) endpoint.Middleware {
+	if b == nil {
+		panic("circuitbreaker: 熔断器不能为空")
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request any) (any, error) {
+			return call(b, ctx, request, next)
+		}
+	}
+}
+
+// KeyedEndpointMiddleware 返回按键隔离的 Endpoint 熔断中间件.
+//
+// 每个键使用独立的熔断器，例如按租户或下游实例隔离.
+func KeyedEndpointMiddleware(g *Group, keyFunc KeyFunc) endpoint.Middleware {
+	if g == nil {
+		panic("circuitbreaker: 熔断器组不能为空")
+	}
+	if keyFunc == nil {
+		panic("circuitbreaker: 键提取函数不能为空")
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request any) (any, error) {
+			return call(g.Get(keyFunc(ctx, request)), ctx, request, next)
+		}
+	}
+}
+
+func call(b *Breaker, ctx context.Context, request any, next endpoint.Endpoint) (any, error) {
+	done, err := b.Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := next(ctx, request)
+	done(!b.opts.isFailure(err))
+	return response, err
+}
@@ -0,0 +1,12 @@
+package circuitbreaker
+
+import "errors"
+
+// 预定义错误.
+var (
+	// ErrOpen 熔断器处于打开状态，请求被拒绝.
+	ErrOpen = errors.New("circuitbreaker: 熔断器已打开")
+
+	// ErrTooManyRequests 半开状态下探测请求数已达上限.
+	ErrTooManyRequests = errors.New("circuitbreaker: 半开状态请求过多")
+)
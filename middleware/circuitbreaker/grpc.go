@@ -0,0 +1,77 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCKeyFunc 从 gRPC 调用中提取熔断键.
+type GRPCKeyFunc func(method string, cc *grpc.ClientConn) string
+
+// MethodKey 按完整方法名隔离熔断器，默认策略.
+func MethodKey(method string, _ *grpc.ClientConn) string {
+	return method
+}
+
+// TargetKey 按连接目标隔离熔断器，同一下游服务的所有方法共用一个熔断器.
+func TargetKey(_ string, cc *grpc.ClientConn) string {
+	return cc.Target()
+}
+
+// UnaryClientInterceptor 返回 gRPC 一元客户端熔断拦截器.
+//
+// keyFunc 为 nil 时使用 MethodKey. 熔断拒绝时返回 codes.Unavailable.
+//
+// 使用示例:
+//
+//	group := circuitbreaker.NewGroup()
+//	conn, _ := grpc.NewClient(target,
+//	    grpc.WithUnaryInterceptor(circuitbreaker.UnaryClientInterceptor(group, nil)),
+//	)
+func UnaryClientInterceptor(g *Group, keyFunc GRPCKeyFunc) grpc.UnaryClientInterceptor {
+	if g == nil {
+		panic("circuitbreaker: 熔断器组不能为空")
+	}
+	if keyFunc == nil {
+		keyFunc = MethodKey
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b := g.Get(keyFunc(method, cc))
+		done, err := b.Allow()
+		if err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(!b.opts.isFailure(err))
+		return err
+	}
+}
+
+// StreamClientInterceptor 返回 gRPC 流式客户端熔断拦截器.
+//
+// 注意：只统计建立流的结果，流建立后的消息收发错误不计入熔断.
+func StreamClientInterceptor(g *Group, keyFunc GRPCKeyFunc) grpc.StreamClientInterceptor {
+	if g == nil {
+		panic("circuitbreaker: 熔断器组不能为空")
+	}
+	if keyFunc == nil {
+		keyFunc = MethodKey
+	}
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		b := g.Get(keyFunc(method, cc))
+		done, err := b.Allow()
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		done(!b.opts.isFailure(err))
+		return stream, err
+	}
+}
@@ -0,0 +1,60 @@
+package circuitbreaker
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	g := NewGroup(WithMinRequests(2))
+	interceptor := UnaryClientInterceptor(g, nil)
+
+	callCount := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		callCount++
+		if method == "/test/Bad" {
+			return status.Error(codes.Unavailable, "service unavailable")
+		}
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_ = interceptor(context.Background(), "/test/Bad", nil, nil, nil, invoker)
+	}
+
+	err := interceptor(context.Background(), "/test/Bad", nil, nil, nil, invoker)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("期望 Unavailable，实际 %v", err)
+	}
+	if callCount != 2 {
+		t.Errorf("期望调用 2 次，实际 %d 次", callCount)
+	}
+
+	if err := interceptor(context.Background(), "/test/Good", nil, nil, nil, invoker); err != nil {
+		t.Errorf("其他方法不应被熔断: %v", err)
+	}
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	g := NewGroup(WithMinRequests(1))
+	interceptor := StreamClientInterceptor(g, nil)
+
+	callCount := 0
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		callCount++
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	_, _ = interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/test/Stream", streamer)
+	_, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/test/Stream", streamer)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("期望 Unavailable，实际 %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("期望调用 1 次，实际 %d 次", callCount)
+	}
+}
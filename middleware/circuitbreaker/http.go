@@ -0,0 +1,64 @@
+package circuitbreaker
+
+import (
+	"net/http"
+)
+
+// HTTPKeyFunc 从 HTTP 请求中提取熔断键.
+type HTTPKeyFunc func(req *http.Request) string
+
+// HostKey 按下游主机隔离熔断器，默认策略.
+func HostKey(req *http.Request) string {
+	return req.URL.Host
+}
+
+// roundTripper 熔断 http.RoundTripper.
+type roundTripper struct {
+	group   *Group
+	next    http.RoundTripper
+	keyFunc HTTPKeyFunc
+}
+
+// RoundTripper 返回带熔断的 http.RoundTripper.
+//
+// 网络错误和 5xx 响应计为失败. next 为 nil 时使用 http.DefaultTransport，
+// keyFunc 为 nil 时使用 HostKey.
+//
+// 使用示例:
+//
+//	client := &http.Client{
+//	    Transport: circuitbreaker.RoundTripper(circuitbreaker.NewGroup(), nil, nil),
+//	}
+func RoundTripper(g *Group, next http.RoundTripper, keyFunc HTTPKeyFunc) http.RoundTripper {
+	if g == nil {
+		panic("circuitbreaker: 熔断器组不能为空")
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if keyFunc == nil {
+		keyFunc = HostKey
+	}
+	return &roundTripper{group: g, next: next, keyFunc: keyFunc}
+}
+
+// RoundTrip 实现 http.RoundTripper 接口.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	b := rt.group.Get(rt.keyFunc(req))
+	done, err := b.Allow()
+	if err != nil {
+		// RoundTripper 即使返回错误也必须关闭请求体
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		done(!b.opts.isFailure(err))
+		return nil, err
+	}
+	done(resp.StatusCode < http.StatusInternalServerError)
+	return resp, nil
+}
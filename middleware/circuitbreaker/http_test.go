@@ -0,0 +1,81 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRoundTripper(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: RoundTripper(NewGroup(WithMinRequests(2)), nil, nil)}
+
+	t.Run("4xx 不计为失败", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			resp, err := client.Get(server.URL + "/good")
+			if err != nil {
+				t.Fatalf("不期望错误: %v", err)
+			}
+			resp.Body.Close()
+		}
+	})
+
+	t.Run("5xx 触发熔断", func(t *testing.T) {
+		// 窗口内已有 3 次成功，再失败 3 次达到 50% 失败率
+		for i := 0; i < 3; i++ {
+			resp, err := client.Get(server.URL + "/bad")
+			if err != nil {
+				t.Fatalf("不期望错误: %v", err)
+			}
+			resp.Body.Close()
+		}
+
+		before := calls.Load()
+		_, err := client.Get(server.URL + "/bad")
+		if !errors.Is(err, ErrOpen) {
+			t.Errorf("期望 ErrOpen，实际 %v", err)
+		}
+		if calls.Load() != before {
+			t.Error("熔断时不应请求下游")
+		}
+	})
+
+	t.Run("熔断时关闭请求体", func(t *testing.T) {
+		body := &closeTracker{Reader: strings.NewReader("payload")}
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/bad", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Transport.RoundTrip(req); !errors.Is(err, ErrOpen) {
+			t.Fatalf("期望 ErrOpen，实际 %v", err)
+		}
+		if !body.closed {
+			t.Error("拒绝请求时应关闭请求体")
+		}
+	})
+}
+
+// closeTracker 记录是否被关闭的请求体.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,40 @@
+package circuitbreaker
+
+import (
+	"github.com/Tsukikage7/microservice-kit/observability/metrics"
+)
+
+// breakerMetrics 熔断器指标记录器.
+//
+// 封装 metrics.PrometheusCollector，未配置时所有方法均为空操作.
+type breakerMetrics struct {
+	collector *metrics.PrometheusCollector
+}
+
+// newBreakerMetrics 创建熔断器指标记录器.
+func newBreakerMetrics(collector *metrics.PrometheusCollector) *breakerMetrics {
+	return &breakerMetrics{collector: collector}
+}
+
+// recordStateChange 记录状态切换，并更新当前状态仪表盘.
+//
+// circuit_breaker_state 取值: 0=closed, 1=open, 2=half_open.
+func (m *breakerMetrics) recordStateChange(name string, from, to State) {
+	if m == nil {
+		return
+	}
+	m.collector.Counter("circuit_breaker_transitions_total", map[string]string{
+		"name": name,
+		"from": from.String(),
+		"to":   to.String(),
+	})
+	m.collector.Gauge("circuit_breaker_state", float64(to), map[string]string{"name": name})
+}
+
+// recordRejected 记录被熔断拒绝的请求.
+func (m *breakerMetrics) recordRejected(name string) {
+	if m == nil {
+		return
+	}
+	m.collector.Counter("circuit_breaker_rejected_total", map[string]string{"name": name})
+}
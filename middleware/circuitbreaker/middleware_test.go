@@ -0,0 +1,75 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEndpointMiddleware(t *testing.T) {
+	b := New("test", WithMinRequests(2))
+	callCount := 0
+	ep := EndpointMiddleware(b)(func(ctx context.Context, request any) (any, error) {
+		callCount++
+		return nil, errDownstream
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := ep(context.Background(), nil); !errors.Is(err, errDownstream) {
+			t.Errorf("期望下游错误，实际 %v", err)
+		}
+	}
+
+	if _, err := ep(context.Background(), nil); !errors.Is(err, ErrOpen) {
+		t.Errorf("期望 ErrOpen，实际 %v", err)
+	}
+	if callCount != 2 {
+		t.Errorf("期望调用 2 次，实际 %d 次", callCount)
+	}
+}
+
+func TestKeyedEndpointMiddleware(t *testing.T) {
+	g := NewGroup(WithMinRequests(1))
+	keyFunc := func(ctx context.Context, request any) string {
+		return request.(string)
+	}
+	ep := KeyedEndpointMiddleware(g, keyFunc)(func(ctx context.Context, request any) (any, error) {
+		if request == "bad" {
+			return nil, errDownstream
+		}
+		return "ok", nil
+	})
+
+	_, _ = ep(context.Background(), "bad")
+	if _, err := ep(context.Background(), "bad"); !errors.Is(err, ErrOpen) {
+		t.Errorf("期望 ErrOpen，实际 %v", err)
+	}
+
+	resp, err := ep(context.Background(), "good")
+	if err != nil {
+		t.Errorf("不同键不应被熔断: %v", err)
+	}
+	if resp != "ok" {
+		t.Errorf("期望 ok，实际 %v", resp)
+	}
+}
+
+func TestEndpointMiddleware_NilPanics(t *testing.T) {
+	t.Run("熔断器为空", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("期望 panic")
+			}
+		}()
+		EndpointMiddleware(nil)
+	})
+
+	t.Run("键提取函数为空", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("期望 panic")
+			}
+		}()
+		KeyedEndpointMiddleware(NewGroup(), nil)
+	})
+}
@@ -0,0 +1,189 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/observability/metrics"
+)
+
+// 默认配置.
+const (
+	DefaultWindow           = 10 * time.Second
+	DefaultBuckets          = 10
+	DefaultMinRequests      = 20
+	DefaultFailureRatio     = 0.5
+	DefaultOpenTimeout      = 30 * time.Second
+	DefaultHalfOpenRequests = 1
+)
+
+// Option 配置选项函数.
+type Option func(*options)
+
+// options 熔断器配置.
+type options struct {
+	window           time.Duration
+	buckets          int
+	minRequests      int64
+	failureRatio     float64
+	slowCallDuration time.Duration
+	slowCallRatio    float64
+	openTimeout      time.Duration
+	halfOpenRequests int
+	isFailure        func(err error) bool
+	onStateChange    func(name string, from, to State)
+	logger           logger.Logger
+	metrics          *breakerMetrics
+}
+
+// defaultOptions 返回默认配置.
+func defaultOptions() *options {
+	return &options{
+		window:           DefaultWindow,
+		buckets:          DefaultBuckets,
+		minRequests:      DefaultMinRequests,
+		failureRatio:     DefaultFailureRatio,
+		slowCallRatio:    1,
+		openTimeout:      DefaultOpenTimeout,
+		halfOpenRequests: DefaultHalfOpenRequests,
+		isFailure:        DefaultIsFailure,
+	}
+}
+
+// applyOptions 应用配置选项.
+func applyOptions(opts []Option) *options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithWindow 设置滚动窗口时长和分桶数.
+//
+// 窗口内的统计按桶滚动淘汰，分桶越多统计越平滑.
+func WithWindow(window time.Duration, buckets int) Option {
+	return func(o *options) {
+		if window > 0 && buckets > 0 && window >= time.Duration(buckets) {
+			o.window = window
+			o.buckets = buckets
+		}
+	}
+}
+
+// WithMinRequests 设置触发熔断判断所需的最小请求数.
+func WithMinRequests(n int64) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.minRequests = n
+		}
+	}
+}
+
+// WithFailureRatio 设置失败率阈值，取值 (0, 1].
+func WithFailureRatio(ratio float64) Option {
+	return func(o *options) {
+		if ratio > 0 && ratio <= 1 {
+			o.failureRatio = ratio
+		}
+	}
+}
+
+// WithSlowCall 设置慢调用阈值.
+//
+// 耗时不小于 duration 的调用计为慢调用，慢调用率达到 ratio 时打开熔断器.
+// duration 为 0 时关闭慢调用检测.
+func WithSlowCall(duration time.Duration, ratio float64) Option {
+	return func(o *options) {
+		if duration >= 0 && ratio > 0 && ratio <= 1 {
+			o.slowCallDuration = duration
+			o.slowCallRatio = ratio
+		}
+	}
+}
+
+// WithOpenTimeout 设置打开状态持续时间，超时后进入半开状态.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.openTimeout = d
+		}
+	}
+}
+
+// WithHalfOpenRequests 设置半开状态允许的探测请求数.
+//
+// 探测请求全部成功后关闭熔断器.
+func WithHalfOpenRequests(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.halfOpenRequests = n
+		}
+	}
+}
+
+// WithIsFailure 设置失败判断函数，用于 Execute 和 Endpoint、gRPC 中间件.
+func WithIsFailure(fn func(err error) bool) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.isFailure = fn
+		}
+	}
+}
+
+// WithOnStateChange 设置状态切换回调.
+//
+// 回调在持有熔断器锁时同步执行，不应阻塞.
+func WithOnStateChange(fn func(name string, from, to State)) Option {
+	return func(o *options) {
+		o.onStateChange = fn
+	}
+}
+
+// WithLogger 设置日志记录器.
+//
+// 设置后，状态切换会被记录到日志.
+func WithLogger(log logger.Logger) Option {
+	return func(o *options) {
+		o.logger = log
+	}
+}
+
+// WithMetrics 设置指标收集器，记录状态切换和拒绝次数.
+func WithMetrics(collector *metrics.PrometheusCollector) Option {
+	return func(o *options) {
+		if collector != nil {
+			o.metrics = newBreakerMetrics(collector)
+		}
+	}
+}
+
+// DefaultIsFailure 默认失败判断.
+//
+// 以下错误不计为失败，因为它们反映的是调用方问题而非下游故障:
+//   - context.Canceled
+//   - gRPC 客户端错误码: Canceled, InvalidArgument, NotFound, AlreadyExists,
+//     PermissionDenied, Unauthenticated, FailedPrecondition, OutOfRange
+func DefaultIsFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return true
+	}
+	switch st.Code() {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.PermissionDenied, codes.Unauthenticated,
+		codes.FailedPrecondition, codes.OutOfRange:
+		return false
+	default:
+		return true
+	}
+}
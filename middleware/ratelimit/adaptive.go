@@ -0,0 +1,272 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 自适应并发限流默认值.
+const (
+	DefaultAdaptiveInitialLimit = 20
+	DefaultAdaptiveMinLimit     = 1
+	DefaultAdaptiveMaxLimit     = 1000
+	DefaultAdaptiveSmoothing    = 0.2
+	DefaultAdaptiveTolerance    = 1.5
+	DefaultAdaptiveLongWindow   = 600
+	DefaultAdaptiveBackoffRatio = 0.9
+
+	// adaptiveWarmupSamples 预热阶段样本数，预热期间长期延迟取算术平均
+	adaptiveWarmupSamples = 10
+)
+
+// AdaptiveConfig 自适应并发限流配置.
+type AdaptiveConfig struct {
+	// InitialLimit 初始并发上限
+	InitialLimit int
+
+	// MinLimit 最小并发上限
+	MinLimit int
+
+	// MaxLimit 最大并发上限
+	MaxLimit int
+
+	// Smoothing 每次调整的平滑系数，取值 (0, 1]，越小调整越平缓
+	Smoothing float64
+
+	// Tolerance 可容忍的延迟增长倍数，当前延迟不超过长期延迟的该倍数时不缩减上限
+	Tolerance float64
+
+	// LongWindow 长期基准延迟的样本窗口大小
+	LongWindow int
+
+	// BackoffRatio 请求超时或过载时上限的缩减比例
+	BackoffRatio float64
+}
+
+// setDefaults 填充默认值.
+func (c *AdaptiveConfig) setDefaults() {
+	if c.MinLimit <= 0 {
+		c.MinLimit = DefaultAdaptiveMinLimit
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = max(DefaultAdaptiveMaxLimit, c.MinLimit)
+	}
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = min(max(DefaultAdaptiveInitialLimit, c.MinLimit), c.MaxLimit)
+	}
+	if c.Smoothing <= 0 {
+		c.Smoothing = DefaultAdaptiveSmoothing
+	}
+	if c.Tolerance <= 0 {
+		c.Tolerance = DefaultAdaptiveTolerance
+	}
+	if c.LongWindow <= 0 {
+		c.LongWindow = DefaultAdaptiveLongWindow
+	}
+	if c.BackoffRatio <= 0 {
+		c.BackoffRatio = DefaultAdaptiveBackoffRatio
+	}
+}
+
+// validate 验证配置.
+func (c *AdaptiveConfig) validate() error {
+	if c.MinLimit > c.MaxLimit {
+		return fmt.Errorf("%w: minLimit 不能大于 maxLimit", ErrInvalidConfig)
+	}
+	if c.InitialLimit < c.MinLimit || c.InitialLimit > c.MaxLimit {
+		return fmt.Errorf("%w: initialLimit 必须在 minLimit 和 maxLimit 之间", ErrInvalidConfig)
+	}
+	if c.Smoothing > 1 {
+		return fmt.Errorf("%w: smoothing 必须在 (0, 1] 之间", ErrInvalidConfig)
+	}
+	if c.Tolerance < 1 {
+		return fmt.Errorf("%w: tolerance 不能小于 1", ErrInvalidConfig)
+	}
+	if c.BackoffRatio >= 1 {
+		return fmt.Errorf("%w: backoffRatio 必须在 (0, 1) 之间", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// AdaptiveLimiter 自适应并发限流器.
+//
+// 基于延迟梯度动态调整并发上限：维护请求延迟的长期指数移动平均作为基准，
+// 每个请求结束时用 gradient = tolerance * 长期延迟 / 本次延迟 估计排队情况.
+// 延迟上升时 gradient < 1，上限随之收缩；延迟稳定时上限按 sqrt(limit) 逐步增长.
+// 请求超时或过载时上限按 BackoffRatio 缩减.
+//
+// 与其他限流器不同，许可需要在请求结束后通过 Release 归还，
+// 本包的中间件和拦截器会自动处理.
+type AdaptiveLimiter struct {
+	cfg AdaptiveConfig
+
+	mu       sync.Mutex
+	limit    float64       // 当前并发上限
+	inflight int           // 在途请求数
+	longRTT  float64       // 长期基准延迟（纳秒）
+	samples  int           // 已采集样本数
+	notify   chan struct{} // 许可归还时关闭，用于唤醒等待者
+}
+
+// NewAdaptiveLimiter 创建自适应并发限流器，cfg 为 nil 时使用默认配置.
+func NewAdaptiveLimiter(cfg *AdaptiveConfig) (*AdaptiveLimiter, error) {
+	var c AdaptiveConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	c.setDefaults()
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return &AdaptiveLimiter{
+		cfg:    c,
+		limit:  float64(c.InitialLimit),
+		notify: make(chan struct{}),
+	}, nil
+}
+
+// Allow 检查是否允许一个请求通过.
+func (al *AdaptiveLimiter) Allow(ctx context.Context) bool {
+	return al.AllowN(ctx, 1)
+}
+
+// AllowN 检查在途请求数加 n 是否超过当前上限，未超过时占用 n 个许可.
+func (al *AdaptiveLimiter) AllowN(_ context.Context, n int) bool {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	if al.inflight+n > int(al.limit) {
+		return false
+	}
+	al.inflight += n
+	return true
+}
+
+// Wait 阻塞等待直到允许请求通过.
+func (al *AdaptiveLimiter) Wait(ctx context.Context) error {
+	return al.WaitN(ctx, 1)
+}
+
+// WaitN 阻塞等待直到获得 n 个许可.
+func (al *AdaptiveLimiter) WaitN(ctx context.Context, n int) error {
+	for {
+		al.mu.Lock()
+		if al.inflight+n <= int(al.limit) {
+			al.inflight += n
+			al.mu.Unlock()
+			return nil
+		}
+		notify := al.notify
+		al.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+			// 有许可归还，重新检查
+		}
+	}
+}
+
+// Release 归还 n 个许可，并根据本次延迟调整并发上限.
+func (al *AdaptiveLimiter) Release(n int, latency time.Duration, dropped bool) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	inflight := al.inflight
+	al.inflight = max(al.inflight-n, 0)
+	al.update(latency, dropped, inflight)
+
+	close(al.notify)
+	al.notify = make(chan struct{})
+}
+
+// Limit 返回当前并发上限.
+func (al *AdaptiveLimiter) Limit() int {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return int(al.limit)
+}
+
+// Inflight 返回当前在途请求数.
+func (al *AdaptiveLimiter) Inflight() int {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	return al.inflight
+}
+
+// update 根据样本调整并发上限（需要持有锁）.
+func (al *AdaptiveLimiter) update(latency time.Duration, dropped bool, inflight int) {
+	if dropped {
+		al.setLimit(al.limit * al.cfg.BackoffRatio)
+		return
+	}
+
+	rtt := math.Max(float64(latency), 1)
+
+	// 更新长期基准延迟：预热阶段取算术平均，之后使用指数移动平均
+	al.samples++
+	if al.samples <= adaptiveWarmupSamples {
+		al.longRTT += (rtt - al.longRTT) / float64(al.samples)
+	} else {
+		al.longRTT += (rtt - al.longRTT) * 2 / float64(al.cfg.LongWindow+1)
+	}
+
+	// 延迟显著下降时加速基准回落，避免负载恢复后长期停留在低上限
+	if al.longRTT/rtt > 2 {
+		al.longRTT *= 0.95
+	}
+
+	// 应用负载不足以打满上限时，延迟样本不能说明上限是否合适
+	if float64(inflight) < al.limit/2 {
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1, al.cfg.Tolerance*al.longRTT/rtt))
+	newLimit := al.limit*gradient + math.Sqrt(al.limit)
+	al.setLimit(al.limit*(1-al.cfg.Smoothing) + newLimit*al.cfg.Smoothing)
+}
+
+// setLimit 设置并发上限，限制在 [MinLimit, MaxLimit] 范围内.
+func (al *AdaptiveLimiter) setLimit(limit float64) {
+	al.limit = math.Max(float64(al.cfg.MinLimit), math.Min(float64(al.cfg.MaxLimit), limit))
+}
+
+// releaser 返回请求结束时调用的回调.
+//
+// limiter 为 ReleasableLimiter 时在回调中归还许可，否则回调为空操作.
+func releaser(limiter Limiter) func(err error) {
+	rl, ok := limiter.(ReleasableLimiter)
+	if !ok {
+		return func(error) {}
+	}
+
+	start := time.Now()
+	return func(err error) {
+		rl.Release(1, time.Since(start), isDropped(err))
+	}
+}
+
+// isDropped 判断请求是否因超时或过载失败.
+func isDropped(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.ResourceExhausted, codes.Unavailable:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,208 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// saturate 占满当前并发上限后归还一个许可，模拟满负载下的一次请求.
+func saturate(al *AdaptiveLimiter, latency time.Duration, dropped bool) {
+	al.AllowN(context.Background(), al.Limit()-al.Inflight())
+	al.Release(1, latency, dropped)
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	t.Run("默认配置", func(t *testing.T) {
+		al, err := NewAdaptiveLimiter(nil)
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		if al.Limit() != DefaultAdaptiveInitialLimit {
+			t.Errorf("期望初始上限 %d，得到 %d", DefaultAdaptiveInitialLimit, al.Limit())
+		}
+	})
+
+	t.Run("无效配置", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			cfg  *AdaptiveConfig
+		}{
+			{"最小值大于最大值", &AdaptiveConfig{MinLimit: 10, MaxLimit: 5}},
+			{"初始值超出范围", &AdaptiveConfig{InitialLimit: 20, MaxLimit: 10}},
+			{"平滑系数过大", &AdaptiveConfig{Smoothing: 2}},
+			{"容忍倍数过小", &AdaptiveConfig{Tolerance: 0.5}},
+			{"缩减比例过大", &AdaptiveConfig{BackoffRatio: 1}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if _, err := NewAdaptiveLimiter(tc.cfg); !errors.Is(err, ErrInvalidConfig) {
+					t.Errorf("期望 ErrInvalidConfig，得到 %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("并发上限", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 2})
+		ctx := context.Background()
+
+		if !al.Allow(ctx) || !al.Allow(ctx) {
+			t.Fatal("前两个请求应该通过")
+		}
+		if al.Allow(ctx) {
+			t.Error("第三个请求应该被限流")
+		}
+
+		al.Release(1, time.Millisecond, false)
+		if !al.Allow(ctx) {
+			t.Error("归还许可后应该通过")
+		}
+	})
+
+	t.Run("延迟稳定时上限增长", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 10, MaxLimit: 100})
+		for i := 0; i < 50; i++ {
+			saturate(al, 10*time.Millisecond, false)
+		}
+		if al.Limit() <= 10 {
+			t.Errorf("期望上限增长，得到 %d", al.Limit())
+		}
+	})
+
+	t.Run("延迟上升时上限收缩", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 50})
+		for i := 0; i < 20; i++ {
+			saturate(al, 10*time.Millisecond, false)
+		}
+		before := al.Limit()
+
+		for i := 0; i < 20; i++ {
+			saturate(al, 100*time.Millisecond, false)
+		}
+		if al.Limit() >= before {
+			t.Errorf("期望上限从 %d 收缩，得到 %d", before, al.Limit())
+		}
+	})
+
+	t.Run("请求超时时上限收缩", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 100, BackoffRatio: 0.5})
+		saturate(al, time.Second, true)
+		if al.Limit() != 50 {
+			t.Errorf("期望上限 50，得到 %d", al.Limit())
+		}
+
+		for i := 0; i < 20; i++ {
+			saturate(al, time.Second, true)
+		}
+		if al.Limit() != DefaultAdaptiveMinLimit {
+			t.Errorf("期望上限不低于 %d，得到 %d", DefaultAdaptiveMinLimit, al.Limit())
+		}
+	})
+
+	t.Run("负载不足时不调整", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 100})
+		for i := 0; i < 20; i++ {
+			al.Allow(context.Background())
+			al.Release(1, 10*time.Millisecond, false)
+		}
+		if al.Limit() != 100 {
+			t.Errorf("期望上限保持 100，得到 %d", al.Limit())
+		}
+	})
+
+	t.Run("等待许可归还", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 1})
+		ctx := context.Background()
+		al.Allow(ctx)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			al.Release(1, time.Millisecond, false)
+		}()
+
+		if err := al.Wait(ctx); err != nil {
+			t.Errorf("不期望错误: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if err := al.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("期望 DeadlineExceeded，得到 %v", err)
+		}
+	})
+
+	t.Run("通过配置创建", func(t *testing.T) {
+		limiter, err := NewLimiter(&Config{Algorithm: AlgorithmAdaptive, Limit: 5, MaxLimit: 10})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		if _, ok := limiter.(ReleasableLimiter); !ok {
+			t.Error("期望实现 ReleasableLimiter")
+		}
+
+		_, err = NewLimiter(&Config{Algorithm: AlgorithmAdaptive, Limit: 20, MaxLimit: 10})
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("期望 ErrInvalidConfig，得到 %v", err)
+		}
+	})
+}
+
+func TestAdaptiveLimiter_Middleware(t *testing.T) {
+	t.Run("Endpoint 归还许可", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 1})
+		ep := EndpointMiddleware(al)(func(ctx context.Context, request any) (any, error) {
+			if al.Inflight() != 1 {
+				t.Errorf("期望在途请求 1，得到 %d", al.Inflight())
+			}
+			return nil, nil
+		})
+
+		for i := 0; i < 3; i++ {
+			if _, err := ep(context.Background(), nil); err != nil {
+				t.Fatalf("第 %d 个请求不期望错误: %v", i+1, err)
+			}
+		}
+		if al.Inflight() != 0 {
+			t.Errorf("期望在途请求 0，得到 %d", al.Inflight())
+		}
+	})
+
+	t.Run("gRPC 超时收缩上限", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 10, BackoffRatio: 0.5})
+		interceptor := UnaryServerInterceptor(al)
+		handler := func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.DeadlineExceeded, "timeout")
+		}
+
+		_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+		if al.Limit() != 5 {
+			t.Errorf("期望上限 5，得到 %d", al.Limit())
+		}
+		if al.Inflight() != 0 {
+			t.Errorf("期望在途请求 0，得到 %d", al.Inflight())
+		}
+	})
+
+	t.Run("handler panic 时归还许可", func(t *testing.T) {
+		al, _ := NewAdaptiveLimiter(&AdaptiveConfig{InitialLimit: 1})
+		handler := HTTPMiddleware(al)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		func() {
+			defer func() { _ = recover() }()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+		if al.Inflight() != 0 {
+			t.Errorf("期望在途请求 0，得到 %d", al.Inflight())
+		}
+	})
+}
@@ -3,6 +3,8 @@ package ratelimit
 import (
 	"fmt"
 	"time"
+
+	"github.com/go-redis/redis/v8"
 )
 
 // 限流算法类型.
@@ -11,6 +13,9 @@ const (
 	AlgorithmSlidingWindow = "sliding_window"
 	AlgorithmFixedWindow   = "fixed_window"
 	AlgorithmDistributed   = "distributed"
+	AlgorithmAdaptive      = "adaptive"
+	AlgorithmHierarchical  = "hierarchical"
+	AlgorithmGCRA          = "gcra"
 )
 
 // Config 限流配置.
@@ -24,17 +29,29 @@ type Config struct {
 	// Capacity 容量（令牌桶：桶容量）
 	Capacity float64 `mapstructure:"capacity" json:"capacity" yaml:"capacity"`
 
-	// Limit 限制（窗口算法：窗口内最大请求数）
+	// Limit 限制（窗口算法和 GCRA：窗口内最大请求数；自适应：初始并发上限）
 	Limit int `mapstructure:"limit" json:"limit" yaml:"limit"`
 
+	// Burst 突发容量（GCRA 用，默认等于 Limit）
+	Burst int `mapstructure:"burst" json:"burst" yaml:"burst"`
+
+	// MaxLimit 最大并发上限（自适应算法用）
+	MaxLimit int `mapstructure:"max_limit" json:"max_limit" yaml:"max_limit"`
+
 	// Window 窗口大小
 	Window time.Duration `mapstructure:"window" json:"window" yaml:"window"`
 
-	// Prefix 分布式限流键前缀
+	// Prefix 分布式限流和 GCRA 的键前缀
 	Prefix string `mapstructure:"prefix" json:"prefix" yaml:"prefix"`
 
+	// Levels 分层限流的各层级配置（分层限流用）
+	Levels []LevelConfig `mapstructure:"levels" json:"levels" yaml:"levels"`
+
 	// Counter 计数器实例（分布式限流用）
 	Counter RateCounter `mapstructure:"-" json:"-" yaml:"-"`
+
+	// Redis Redis 客户端（GCRA 用）
+	Redis redis.UniversalClient `mapstructure:"-" json:"-" yaml:"-"`
 }
 
 // Validate 验证配置.
@@ -64,6 +81,35 @@ func (c *Config) Validate() error {
 		if c.Window <= 0 {
 			return fmt.Errorf("%w: window 必须大于 0", ErrInvalidConfig)
 		}
+	case AlgorithmGCRA:
+		if c.Redis == nil {
+			return ErrNilRedis
+		}
+		if c.Limit <= 0 {
+			return fmt.Errorf("%w: limit 必须大于 0", ErrInvalidConfig)
+		}
+		if c.Window <= 0 {
+			return fmt.Errorf("%w: window 必须大于 0", ErrInvalidConfig)
+		}
+		if c.Burst < 0 {
+			return fmt.Errorf("%w: burst 不能为负数", ErrInvalidConfig)
+		}
+	case AlgorithmAdaptive:
+		if c.Limit < 0 || c.MaxLimit < 0 {
+			return fmt.Errorf("%w: limit 和 max_limit 不能为负数", ErrInvalidConfig)
+		}
+		if c.MaxLimit > 0 && c.Limit > c.MaxLimit {
+			return fmt.Errorf("%w: limit 不能大于 max_limit", ErrInvalidConfig)
+		}
+	case AlgorithmHierarchical:
+		if len(c.Levels) == 0 {
+			return fmt.Errorf("%w: levels 不能为空", ErrInvalidConfig)
+		}
+		for i := range c.Levels {
+			if err := c.Levels[i].validate(); err != nil {
+				return err
+			}
+		}
 	case "":
 		return fmt.Errorf("%w: algorithm 不能为空", ErrInvalidConfig)
 	default:
@@ -95,6 +141,21 @@ func NewLimiter(cfg *Config) (Limiter, error) {
 			Limit:   cfg.Limit,
 			Window:  cfg.Window,
 		})
+	case AlgorithmGCRA:
+		return NewGCRALimiter(&GCRAConfig{
+			Client: cfg.Redis,
+			Prefix: cfg.Prefix,
+			Limit:  cfg.Limit,
+			Window: cfg.Window,
+			Burst:  cfg.Burst,
+		})
+	case AlgorithmAdaptive:
+		return NewAdaptiveLimiter(&AdaptiveConfig{
+			InitialLimit: cfg.Limit,
+			MaxLimit:     cfg.MaxLimit,
+		})
+	case AlgorithmHierarchical:
+		return NewHierarchicalLimiter(cfg.Levels)
 	default:
 		return nil, fmt.Errorf("%w: 不支持的算法类型 %s", ErrInvalidConfig, cfg.Algorithm)
 	}
@@ -15,4 +15,7 @@ var (
 
 	// ErrNilCache 缓存为空.
 	ErrNilCache = errors.New("ratelimit: 分布式限流需要缓存")
+
+	// ErrNilRedis Redis 客户端为空.
+	ErrNilRedis = errors.New("ratelimit: GCRA 限流需要 Redis 客户端")
 )
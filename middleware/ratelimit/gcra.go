@@ -0,0 +1,239 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// gcraScript GCRA 限流 Lua 脚本.
+//
+// 每个键只保存理论到达时间（TAT，微秒），读取、判断和写回在一次脚本执行中完成，
+// 多个实例并发访问同一个键时结果仍然准确. 时间取 Redis 服务端时间，避免实例间时钟偏差.
+//
+// KEYS[1]: 限流键
+// ARGV[1]: 突发容量
+// ARGV[2]: 每个请求占用的时间（微秒）
+// ARGV[3]: 本次请求数
+//
+// 返回 {allowed, remaining, retry_after}，retry_after 单位为微秒.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = now
+local stored = redis.call("GET", key)
+if stored then
+	tat = math.max(tonumber(stored), now)
+end
+
+local new_tat = tat + n * interval
+local allow_at = new_tat - burst * interval
+if allow_at > now then
+	return {0, 0, allow_at - now}
+end
+
+local ttl = math.max(math.ceil((new_tat - now) / 1000), 1)
+redis.call("SET", key, string.format("%d", new_tat), "PX", ttl)
+return {1, math.floor((now - allow_at) / interval), 0}
+`)
+
+// GCRAConfig Redis GCRA 限流配置.
+type GCRAConfig struct {
+	// Client Redis 客户端（go-redis v8，与 storage/cache 相同），支持单机、集群和哨兵模式，
+	// 可以复用 Redis 缓存的连接池: c.Client().(redis.UniversalClient)
+	Client redis.UniversalClient
+
+	// Prefix 限流键前缀
+	Prefix string
+
+	// Limit 窗口内允许的最大请求数
+	Limit int
+
+	// Window 窗口大小
+	Window time.Duration
+
+	// Burst 突发容量，即空闲后可以连续通过的请求数，默认等于 Limit
+	Burst int
+}
+
+// GCRALimiter 基于 Redis 的 GCRA（通用信元速率算法）限流器.
+//
+// 与 DistributedLimiter 的固定窗口计数不同，GCRA 将配额均匀分布在窗口内，
+// 不会在窗口边界出现两倍突发，同时能精确给出剩余配额和重试等待时间.
+// 判断逻辑在单个 Lua 脚本中原子执行，Redis 不可用时默认放行.
+type GCRALimiter struct {
+	client   redis.UniversalClient
+	prefix   string
+	burst    int
+	interval time.Duration // 每个请求占用的时间
+}
+
+// NewGCRALimiter 创建 Redis GCRA 限流器.
+//
+// 使用示例:
+//
+//	limiter, err := ratelimit.NewGCRALimiter(&ratelimit.GCRAConfig{
+//	    Client: redisClient,
+//	    Limit:  100,
+//	    Window: time.Minute,
+//	})
+func NewGCRALimiter(cfg *GCRAConfig) (*GCRALimiter, error) {
+	if cfg == nil {
+		return nil, ErrInvalidConfig
+	}
+	if cfg.Client == nil {
+		return nil, ErrNilRedis
+	}
+	if cfg.Limit <= 0 {
+		return nil, fmt.Errorf("%w: limit 必须大于 0", ErrInvalidConfig)
+	}
+	if cfg.Window <= 0 {
+		return nil, fmt.Errorf("%w: window 必须大于 0", ErrInvalidConfig)
+	}
+	if cfg.Burst < 0 {
+		return nil, fmt.Errorf("%w: burst 不能为负数", ErrInvalidConfig)
+	}
+
+	interval := cfg.Window / time.Duration(cfg.Limit)
+	if interval < time.Microsecond {
+		return nil, fmt.Errorf("%w: window/limit 不能小于 1 微秒", ErrInvalidConfig)
+	}
+
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "ratelimit"
+	}
+	burst := cfg.Burst
+	if burst == 0 {
+		burst = cfg.Limit
+	}
+
+	return &GCRALimiter{
+		client:   cfg.Client,
+		prefix:   prefix,
+		burst:    burst,
+		interval: interval,
+	}, nil
+}
+
+// Take 检查指定键是否允许 n 个请求通过，允许时扣减配额.
+//
+// 返回的 Quota 中 Limit 为突发容量，Level 为限流键.
+func (gl *GCRALimiter) Take(ctx context.Context, key string, n int) (Quota, error) {
+	cacheKey := fmt.Sprintf("%s:%s", gl.prefix, key)
+	quota := Quota{Level: key, Limit: gl.burst}
+
+	res, err := gcraScript.Run(ctx, gl.client, []string{cacheKey},
+		gl.burst, gl.interval.Microseconds(), n).Int64Slice()
+	if err != nil {
+		return quota, err
+	}
+	if len(res) != 3 {
+		return quota, fmt.Errorf("ratelimit: GCRA 脚本返回值无效: %v", res)
+	}
+
+	quota.Allowed = res[0] == 1
+	quota.Remaining = max(int(res[1]), 0)
+	quota.RetryAfter = time.Duration(res[2]) * time.Microsecond
+	return quota, nil
+}
+
+// Allow 检查是否允许请求通过.
+func (gl *GCRALimiter) Allow(ctx context.Context) bool {
+	return gl.AllowWithKey(ctx, "default")
+}
+
+// AllowN 检查是否允许 n 个请求通过.
+func (gl *GCRALimiter) AllowN(ctx context.Context, n int) bool {
+	return gl.AllowNWithKey(ctx, "default", n)
+}
+
+// AllowWithKey 检查指定键是否允许请求通过.
+func (gl *GCRALimiter) AllowWithKey(ctx context.Context, key string) bool {
+	return gl.AllowNWithKey(ctx, key, 1)
+}
+
+// AllowNWithKey 检查指定键是否允许 n 个请求通过.
+func (gl *GCRALimiter) AllowNWithKey(ctx context.Context, key string, n int) bool {
+	quota, err := gl.Take(ctx, key, n)
+	if err != nil {
+		// 发生错误时默认放行，避免影响正常业务
+		return true
+	}
+	return quota.Allowed
+}
+
+// Wait 阻塞等待直到允许请求通过.
+func (gl *GCRALimiter) Wait(ctx context.Context) error {
+	return gl.WaitWithKey(ctx, "default")
+}
+
+// WaitN 阻塞等待直到允许 n 个请求通过.
+func (gl *GCRALimiter) WaitN(ctx context.Context, n int) error {
+	return gl.WaitNWithKey(ctx, "default", n)
+}
+
+// WaitWithKey 阻塞等待指定键直到允许请求通过.
+func (gl *GCRALimiter) WaitWithKey(ctx context.Context, key string) error {
+	return gl.WaitNWithKey(ctx, key, 1)
+}
+
+// WaitNWithKey 阻塞等待指定键直到允许 n 个请求通过.
+//
+// n 超过突发容量时永远无法通过，等待直到 ctx 结束.
+func (gl *GCRALimiter) WaitNWithKey(ctx context.Context, key string, n int) error {
+	for {
+		quota, err := gl.Take(ctx, key, n)
+		if err != nil || quota.Allowed {
+			return nil
+		}
+
+		// 最少等待 1ms
+		wait := max(quota.RetryAfter, time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+			// 继续尝试
+		}
+	}
+}
+
+// GetLimiter 获取指定键的限流器.
+//
+// 返回的限流器与 KeyedEndpointMiddleware 等配合使用，各键共享同一个 Redis 客户端.
+func (gl *GCRALimiter) GetLimiter(key string) Limiter {
+	return &gcraKeyLimiter{limiter: gl, key: key}
+}
+
+// gcraKeyLimiter 绑定固定键的 GCRA 限流器.
+type gcraKeyLimiter struct {
+	limiter *GCRALimiter
+	key     string
+}
+
+func (l *gcraKeyLimiter) Allow(ctx context.Context) bool {
+	return l.limiter.AllowNWithKey(ctx, l.key, 1)
+}
+
+func (l *gcraKeyLimiter) AllowN(ctx context.Context, n int) bool {
+	return l.limiter.AllowNWithKey(ctx, l.key, n)
+}
+
+func (l *gcraKeyLimiter) Wait(ctx context.Context) error {
+	return l.limiter.WaitNWithKey(ctx, l.key, 1)
+}
+
+func (l *gcraKeyLimiter) WaitN(ctx context.Context, n int) error {
+	return l.limiter.WaitNWithKey(ctx, l.key, n)
+}
@@ -0,0 +1,241 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestGCRA 创建基于 miniredis 的 GCRA 限流器，服务端时间固定为 now.
+func newTestGCRA(t *testing.T, limit int, window time.Duration, burst int) (*GCRALimiter, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Unix(1700000000, 0))
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	limiter, err := NewGCRALimiter(&GCRAConfig{
+		Client: client,
+		Prefix: "test",
+		Limit:  limit,
+		Window: window,
+		Burst:  burst,
+	})
+	if err != nil {
+		t.Fatalf("创建限流器失败: %v", err)
+	}
+	return limiter, mr
+}
+
+func TestGCRALimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("突发容量内放行", func(t *testing.T) {
+		limiter, _ := newTestGCRA(t, 10, time.Second, 0)
+
+		for i := 0; i < 10; i++ {
+			quota, err := limiter.Take(ctx, "user", 1)
+			if err != nil {
+				t.Fatalf("Take 失败: %v", err)
+			}
+			if !quota.Allowed {
+				t.Fatalf("第 %d 个请求应该通过", i+1)
+			}
+			if quota.Remaining != 9-i {
+				t.Errorf("第 %d 个请求剩余配额应该为 %d，实际 %d", i+1, 9-i, quota.Remaining)
+			}
+		}
+
+		quota, err := limiter.Take(ctx, "user", 1)
+		if err != nil {
+			t.Fatalf("Take 失败: %v", err)
+		}
+		if quota.Allowed {
+			t.Error("超过突发容量应该被拒绝")
+		}
+		if quota.RetryAfter != 100*time.Millisecond {
+			t.Errorf("RetryAfter 应该为 100ms，实际 %v", quota.RetryAfter)
+		}
+		if quota.Limit != 10 || quota.Level != "user" {
+			t.Errorf("Quota 信息不正确: %+v", quota)
+		}
+	})
+
+	t.Run("按速率恢复配额", func(t *testing.T) {
+		limiter, mr := newTestGCRA(t, 10, time.Second, 0)
+
+		if !limiter.AllowN(ctx, 10) {
+			t.Fatal("应该允许 10 个请求")
+		}
+		if limiter.Allow(ctx) {
+			t.Fatal("配额耗尽后应该被拒绝")
+		}
+
+		// 每 100ms 恢复一个请求
+		mr.SetTime(time.Unix(1700000000, 0).Add(250 * time.Millisecond))
+		if !limiter.AllowN(ctx, 2) {
+			t.Error("250ms 后应该允许 2 个请求")
+		}
+		if limiter.Allow(ctx) {
+			t.Error("恢复的配额用完后应该被拒绝")
+		}
+	})
+
+	t.Run("突发容量小于限额", func(t *testing.T) {
+		limiter, _ := newTestGCRA(t, 100, time.Second, 5)
+
+		if !limiter.AllowN(ctx, 5) {
+			t.Fatal("应该允许 5 个请求")
+		}
+		if limiter.Allow(ctx) {
+			t.Error("超过突发容量应该被拒绝")
+		}
+		if limiter.AllowN(ctx, 6) {
+			t.Error("超过突发容量的 n 永远不应通过")
+		}
+	})
+
+	t.Run("被拒绝的请求不消耗配额", func(t *testing.T) {
+		limiter, mr := newTestGCRA(t, 10, time.Second, 0)
+
+		limiter.AllowN(ctx, 10)
+		for i := 0; i < 5; i++ {
+			limiter.Allow(ctx)
+		}
+
+		mr.SetTime(time.Unix(1700000000, 0).Add(100 * time.Millisecond))
+		if !limiter.Allow(ctx) {
+			t.Error("拒绝的请求不应推迟配额恢复")
+		}
+	})
+
+	t.Run("不同键独立计数", func(t *testing.T) {
+		limiter, mr := newTestGCRA(t, 2, time.Second, 0)
+
+		if !limiter.AllowNWithKey(ctx, "a", 2) {
+			t.Fatal("键 a 应该允许 2 个请求")
+		}
+		if limiter.AllowWithKey(ctx, "a") {
+			t.Error("键 a 应该被拒绝")
+		}
+		if !limiter.GetLimiter("b").Allow(ctx) {
+			t.Error("键 b 应该通过")
+		}
+		if !mr.Exists("test:a") || !mr.Exists("test:b") {
+			t.Error("限流键应该带有前缀")
+		}
+	})
+
+	t.Run("键在配额恢复后过期", func(t *testing.T) {
+		limiter, mr := newTestGCRA(t, 10, time.Second, 0)
+
+		limiter.AllowN(ctx, 4)
+		ttl := mr.TTL("test:default")
+		if ttl <= 0 || ttl > 400*time.Millisecond {
+			t.Errorf("TTL 应该为 400ms 左右，实际 %v", ttl)
+		}
+
+		mr.FastForward(time.Second)
+		if mr.Exists("test:default") {
+			t.Error("键应该已过期")
+		}
+	})
+
+	t.Run("并发请求不超额", func(t *testing.T) {
+		limiter, _ := newTestGCRA(t, 50, time.Minute, 0)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if limiter.AllowWithKey(ctx, "shared") {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if allowed != 50 {
+			t.Errorf("应该恰好放行 50 个请求，实际 %d", allowed)
+		}
+	})
+
+	t.Run("Wait 超时", func(t *testing.T) {
+		limiter, _ := newTestGCRA(t, 1, time.Hour, 0)
+
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("第一次 Wait 应该成功: %v", err)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if err := limiter.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("应该返回 DeadlineExceeded，实际 %v", err)
+		}
+	})
+
+	t.Run("Redis 不可用时放行", func(t *testing.T) {
+		limiter, mr := newTestGCRA(t, 1, time.Hour, 0)
+		mr.Close()
+
+		if !limiter.AllowN(ctx, 5) {
+			t.Error("Redis 不可用时应该放行")
+		}
+		if _, err := limiter.Take(ctx, "default", 1); err == nil {
+			t.Error("Take 应该返回错误")
+		}
+	})
+}
+
+func TestNewGCRALimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	tests := []struct {
+		name string
+		cfg  *GCRAConfig
+		err  error
+	}{
+		{"nil 配置", nil, ErrInvalidConfig},
+		{"缺少客户端", &GCRAConfig{Limit: 1, Window: time.Second}, ErrNilRedis},
+		{"limit 无效", &GCRAConfig{Client: client, Window: time.Second}, ErrInvalidConfig},
+		{"window 无效", &GCRAConfig{Client: client, Limit: 1}, ErrInvalidConfig},
+		{"burst 无效", &GCRAConfig{Client: client, Limit: 1, Window: time.Second, Burst: -1}, ErrInvalidConfig},
+		{"间隔过小", &GCRAConfig{Client: client, Limit: 1000, Window: time.Millisecond / 2}, ErrInvalidConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewGCRALimiter(tt.cfg); !errors.Is(err, tt.err) {
+				t.Errorf("应该返回 %v，实际 %v", tt.err, err)
+			}
+		})
+	}
+
+	t.Run("通过 Config 创建", func(t *testing.T) {
+		cfg := &Config{Algorithm: AlgorithmGCRA, Limit: 1, Window: time.Second}
+		if err := cfg.Validate(); !errors.Is(err, ErrNilRedis) {
+			t.Errorf("缺少客户端应该返回 ErrNilRedis，实际 %v", err)
+		}
+
+		cfg.Redis = client
+		limiter, err := NewLimiter(cfg)
+		if err != nil {
+			t.Fatalf("创建限流器失败: %v", err)
+		}
+		if _, ok := limiter.(*GCRALimiter); !ok {
+			t.Errorf("应该创建 GCRALimiter，实际 %T", limiter)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"context"
+	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -19,10 +20,12 @@ func UnaryServerInterceptor(limiter Limiter) grpc.UnaryServerInterceptor {
 		req any,
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
-	) (any, error) {
+	) (resp any, err error) {
 		if !limiter.Allow(ctx) {
 			return nil, status.Error(codes.ResourceExhausted, "请求过于频繁，请稍后重试")
 		}
+		done := releaser(limiter)
+		defer func() { done(err) }()
 		return handler(ctx, req)
 	}
 }
@@ -34,10 +37,12 @@ func UnaryServerInterceptorWithWait(limiter Limiter) grpc.UnaryServerInterceptor
 		req any,
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
-	) (any, error) {
+	) (resp any, err error) {
 		if err := limiter.Wait(ctx); err != nil {
 			return nil, status.Error(codes.DeadlineExceeded, "请求超时")
 		}
+		done := releaser(limiter)
+		defer func() { done(err) }()
 		return handler(ctx, req)
 	}
 }
@@ -49,10 +54,12 @@ func StreamServerInterceptor(limiter Limiter) grpc.StreamServerInterceptor {
 		ss grpc.ServerStream,
 		info *grpc.StreamServerInfo,
 		handler grpc.StreamHandler,
-	) error {
+	) (err error) {
 		if !limiter.Allow(ss.Context()) {
 			return status.Error(codes.ResourceExhausted, "请求过于频繁，请稍后重试")
 		}
+		done := releaser(limiter)
+		defer func() { done(err) }()
 		return handler(srv, ss)
 	}
 }
@@ -67,7 +74,7 @@ func KeyedUnaryServerInterceptor(keyFunc GRPCKeyFunc, getLimiter KeyedLimiterFun
 		req any,
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
-	) (any, error) {
+	) (resp any, err error) {
 		key := keyFunc(ctx, info)
 		limiter := getLimiter(key)
 		if limiter == nil {
@@ -76,6 +83,8 @@ func KeyedUnaryServerInterceptor(keyFunc GRPCKeyFunc, getLimiter KeyedLimiterFun
 		if !limiter.Allow(ctx) {
 			return nil, status.Error(codes.ResourceExhausted, "请求过于频繁，请稍后重试")
 		}
+		done := releaser(limiter)
+		defer func() { done(err) }()
 		return handler(ctx, req)
 	}
 }
@@ -122,3 +131,52 @@ func CompositeGRPCKeyFunc(funcs ...GRPCKeyFunc) GRPCKeyFunc {
 		return key
 	}
 }
+
+// GRPCDescriptorFunc 用于从 gRPC 上下文中提取分层限流描述符.
+type GRPCDescriptorFunc func(ctx context.Context, fullMethod string) Descriptor
+
+// quotaTrailer 将配额转换为 trailer metadata.
+func quotaTrailer(quota Quota) metadata.MD {
+	md := metadata.MD{}
+	for _, kv := range quota.headers() {
+		md.Set(strings.ToLower(kv[0]), kv[1])
+	}
+	return md
+}
+
+// HierarchicalUnaryServerInterceptor 创建分层限流 gRPC 一元拦截器.
+//
+// 配额信息写入 trailer（ratelimit-limit、ratelimit-remaining，被限流时包含 retry-after），
+// 被限流时返回 ResourceExhausted 错误.
+func HierarchicalUnaryServerInterceptor(limiter *HierarchicalLimiter, descriptorFunc GRPCDescriptorFunc) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		quota := limiter.Check(ctx, descriptorFunc(ctx, info.FullMethod))
+		_ = grpc.SetTrailer(ctx, quotaTrailer(quota))
+		if !quota.Allowed {
+			return nil, status.Error(codes.ResourceExhausted, "请求过于频繁，请稍后重试")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// HierarchicalStreamServerInterceptor 创建分层限流 gRPC 流拦截器.
+func HierarchicalStreamServerInterceptor(limiter *HierarchicalLimiter, descriptorFunc GRPCDescriptorFunc) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		quota := limiter.Check(ss.Context(), descriptorFunc(ss.Context(), info.FullMethod))
+		ss.SetTrailer(quotaTrailer(quota))
+		if !quota.Allowed {
+			return status.Error(codes.ResourceExhausted, "请求过于频繁，请稍后重试")
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,301 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// hierarchicalSweepInterval 清理过期限流键的间隔.
+const hierarchicalSweepInterval = time.Minute
+
+// 配额响应头，gRPC trailer 使用对应的小写形式.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// LevelConfig 分层限流中单个层级的配置.
+type LevelConfig struct {
+	// Name 层级名称，如 user、tenant、global
+	Name string `mapstructure:"name" json:"name" yaml:"name"`
+
+	// Limit 窗口内最大请求数（默认限额）
+	Limit int `mapstructure:"limit" json:"limit" yaml:"limit"`
+
+	// Window 窗口大小
+	Window time.Duration `mapstructure:"window" json:"window" yaml:"window"`
+
+	// Tiers 按套餐等级覆盖 Limit，如 {"free": 10, "pro": 100}
+	Tiers map[string]int `mapstructure:"tiers" json:"tiers" yaml:"tiers"`
+}
+
+// limitFor 返回套餐等级对应的限额.
+func (c *LevelConfig) limitFor(tier string) int {
+	if limit, ok := c.Tiers[tier]; ok {
+		return limit
+	}
+	return c.Limit
+}
+
+// validate 验证层级配置.
+func (c *LevelConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: level name 不能为空", ErrInvalidConfig)
+	}
+	if c.Limit <= 0 {
+		return fmt.Errorf("%w: level %s 的 limit 必须大于 0", ErrInvalidConfig, c.Name)
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("%w: level %s 的 window 必须大于 0", ErrInvalidConfig, c.Name)
+	}
+	for tier, limit := range c.Tiers {
+		if limit <= 0 {
+			return fmt.Errorf("%w: level %s 套餐 %s 的 limit 必须大于 0", ErrInvalidConfig, c.Name, tier)
+		}
+	}
+	return nil
+}
+
+// Descriptor 描述一次请求在各层级的限流键.
+type Descriptor struct {
+	// Tier 套餐等级，用于选择 LevelConfig.Tiers 中的限额
+	Tier string
+
+	// Keys 层级名称到限流键的映射.
+	// 未提供的层级使用共享键，即该层级对所有请求统一计数，适合全局层级.
+	Keys map[string]string
+}
+
+// Quota 分层限流检查结果.
+type Quota struct {
+	// Allowed 是否允许通过
+	Allowed bool
+
+	// Level 决定结果的层级：拒绝时为触发限流的层级，通过时为剩余配额最少的层级
+	Level string
+
+	// Limit 该层级窗口内的限额
+	Limit int
+
+	// Remaining 该层级的剩余配额
+	Remaining int
+
+	// RetryAfter 被拒绝时建议的重试等待时间
+	RetryAfter time.Duration
+}
+
+// headers 返回配额对应的响应头键值，被拒绝时包含 Retry-After（向上取整的秒数）.
+func (q Quota) headers() [][2]string {
+	h := [][2]string{
+		{HeaderRateLimitLimit, strconv.Itoa(q.Limit)},
+		{HeaderRateLimitRemaining, strconv.Itoa(q.Remaining)},
+	}
+	if !q.Allowed {
+		seconds := max(int(math.Ceil(q.RetryAfter.Seconds())), 1)
+		h = append(h, [2]string{HeaderRetryAfter, strconv.Itoa(seconds)})
+	}
+	return h
+}
+
+type descriptorContextKey struct{}
+
+// ContextWithDescriptor 将限流描述符存入 context.
+//
+// HierarchicalLimiter 作为 Limiter 使用时从 context 读取描述符.
+func ContextWithDescriptor(ctx context.Context, d Descriptor) context.Context {
+	return context.WithValue(ctx, descriptorContextKey{}, d)
+}
+
+// DescriptorFromContext 从 context 读取限流描述符.
+func DescriptorFromContext(ctx context.Context) (Descriptor, bool) {
+	d, ok := ctx.Value(descriptorContextKey{}).(Descriptor)
+	return d, ok
+}
+
+// hierarchicalLevel 单个层级的运行时状态.
+type hierarchicalLevel struct {
+	cfg LevelConfig
+	tat map[string]time.Time // 限流键 -> 理论到达时间（GCRA）
+}
+
+// levelDecision 单个层级的检查结果.
+type levelDecision struct {
+	level  *hierarchicalLevel
+	key    string
+	newTAT time.Time
+	quota  Quota
+}
+
+// HierarchicalLimiter 分层限流器.
+//
+// 一次请求同时受多个层级的限额约束，例如每用户 10/s、每租户 1000/s、全局 10000/s，
+// 任一层级超限即拒绝. 只有所有层级都通过时才扣减配额，被拒绝的请求不消耗其他层级的配额.
+//
+// 每个层级使用 GCRA（通用信元速率算法）计数，每个键只保存一个时间戳，
+// 可以精确计算剩余配额和重试等待时间.
+type HierarchicalLimiter struct {
+	mu        sync.Mutex
+	levels    []*hierarchicalLevel
+	lastSweep time.Time
+}
+
+// NewHierarchicalLimiter 创建分层限流器.
+//
+// 使用示例:
+//
+//	limiter, err := ratelimit.NewHierarchicalLimiter([]ratelimit.LevelConfig{
+//	    {Name: "user", Limit: 10, Window: time.Second, Tiers: map[string]int{"pro": 100}},
+//	    {Name: "tenant", Limit: 1000, Window: time.Second},
+//	    {Name: "global", Limit: 10000, Window: time.Second},
+//	})
+func NewHierarchicalLimiter(levels []LevelConfig) (*HierarchicalLimiter, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("%w: levels 不能为空", ErrInvalidConfig)
+	}
+
+	names := make(map[string]struct{}, len(levels))
+	hl := &HierarchicalLimiter{lastSweep: time.Now()}
+	for _, cfg := range levels {
+		if err := cfg.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := names[cfg.Name]; ok {
+			return nil, fmt.Errorf("%w: level %s 重复", ErrInvalidConfig, cfg.Name)
+		}
+		names[cfg.Name] = struct{}{}
+		hl.levels = append(hl.levels, &hierarchicalLevel{
+			cfg: cfg,
+			tat: make(map[string]time.Time),
+		})
+	}
+	return hl, nil
+}
+
+// Check 按描述符检查一个请求.
+func (hl *HierarchicalLimiter) Check(ctx context.Context, d Descriptor) Quota {
+	return hl.CheckN(ctx, d, 1)
+}
+
+// CheckN 按描述符检查 n 个请求，全部层级通过时扣减配额.
+func (hl *HierarchicalLimiter) CheckN(_ context.Context, d Descriptor, n int) Quota {
+	now := time.Now()
+
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	hl.sweep(now)
+
+	decisions := make([]levelDecision, len(hl.levels))
+	var denied *levelDecision
+	for i, level := range hl.levels {
+		decisions[i] = level.check(now, d, n)
+		dec := &decisions[i]
+		if !dec.quota.Allowed && (denied == nil || dec.quota.RetryAfter > denied.quota.RetryAfter) {
+			denied = dec
+		}
+	}
+
+	if denied != nil {
+		return denied.quota
+	}
+
+	// 全部通过，提交配额并返回剩余配额最少的层级
+	result := decisions[0].quota
+	for _, dec := range decisions {
+		dec.level.tat[dec.key] = dec.newTAT
+		if dec.quota.Remaining < result.Remaining {
+			result = dec.quota
+		}
+	}
+	return result
+}
+
+// Allow 检查是否允许请求通过，描述符从 context 读取.
+func (hl *HierarchicalLimiter) Allow(ctx context.Context) bool {
+	return hl.AllowN(ctx, 1)
+}
+
+// AllowN 检查是否允许 n 个请求通过，描述符从 context 读取.
+func (hl *HierarchicalLimiter) AllowN(ctx context.Context, n int) bool {
+	d, _ := DescriptorFromContext(ctx)
+	return hl.CheckN(ctx, d, n).Allowed
+}
+
+// Wait 阻塞等待直到允许请求通过.
+func (hl *HierarchicalLimiter) Wait(ctx context.Context) error {
+	return hl.WaitN(ctx, 1)
+}
+
+// WaitN 阻塞等待直到允许 n 个请求通过.
+func (hl *HierarchicalLimiter) WaitN(ctx context.Context, n int) error {
+	d, _ := DescriptorFromContext(ctx)
+	for {
+		quota := hl.CheckN(ctx, d, n)
+		if quota.Allowed {
+			return nil
+		}
+
+		// 最少等待 1ms
+		wait := max(quota.RetryAfter, time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+			// 继续尝试
+		}
+	}
+}
+
+// sweep 定期清理已过期的键，避免键数量无限增长（需要持有锁）.
+func (hl *HierarchicalLimiter) sweep(now time.Time) {
+	if now.Sub(hl.lastSweep) < hierarchicalSweepInterval {
+		return
+	}
+	hl.lastSweep = now
+	for _, level := range hl.levels {
+		for key, tat := range level.tat {
+			if !tat.After(now) {
+				delete(level.tat, key)
+			}
+		}
+	}
+}
+
+// check 使用 GCRA 检查单个层级，不修改状态.
+func (l *hierarchicalLevel) check(now time.Time, d Descriptor, n int) levelDecision {
+	key := d.Keys[l.cfg.Name]
+	limit := l.cfg.limitFor(d.Tier)
+	window := l.cfg.Window
+	interval := window / time.Duration(limit) // 每个请求占用的时间
+
+	tat, ok := l.tat[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(time.Duration(n) * interval)
+	allowAt := newTAT.Add(-window)
+
+	dec := levelDecision{
+		level:  l,
+		key:    key,
+		newTAT: newTAT,
+		quota: Quota{
+			Level: l.cfg.Name,
+			Limit: limit,
+		},
+	}
+
+	if now.Before(allowAt) {
+		dec.quota.RetryAfter = allowAt.Sub(now)
+		return dec
+	}
+
+	dec.quota.Allowed = true
+	dec.quota.Remaining = int((window - newTAT.Sub(now)) / interval)
+	return dec
+}
@@ -0,0 +1,265 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestHierarchicalLimiter(t *testing.T) *HierarchicalLimiter {
+	t.Helper()
+	limiter, err := NewHierarchicalLimiter([]LevelConfig{
+		{Name: "user", Limit: 2, Window: time.Second, Tiers: map[string]int{"pro": 4}},
+		{Name: "tenant", Limit: 5, Window: time.Second},
+		{Name: "global", Limit: 100, Window: time.Second},
+	})
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	return limiter
+}
+
+func userDescriptor(user, tenant string) Descriptor {
+	return Descriptor{Keys: map[string]string{"user": user, "tenant": tenant}}
+}
+
+func TestHierarchicalLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("任一层级超限即拒绝", func(t *testing.T) {
+		limiter := newTestHierarchicalLimiter(t)
+
+		for i := 0; i < 2; i++ {
+			if !limiter.Check(ctx, userDescriptor("u1", "t1")).Allowed {
+				t.Fatalf("第 %d 个请求应该通过", i+1)
+			}
+		}
+		quota := limiter.Check(ctx, userDescriptor("u1", "t1"))
+		if quota.Allowed {
+			t.Fatal("用户层级超限应该被拒绝")
+		}
+		if quota.Level != "user" || quota.Limit != 2 || quota.Remaining != 0 {
+			t.Errorf("配额不符合预期: %+v", quota)
+		}
+		if quota.RetryAfter <= 0 || quota.RetryAfter > time.Second {
+			t.Errorf("RetryAfter 不符合预期: %v", quota.RetryAfter)
+		}
+
+		// 同租户的其他用户继续消耗租户配额
+		for _, user := range []string{"u2", "u2", "u3"} {
+			if !limiter.Check(ctx, userDescriptor(user, "t1")).Allowed {
+				t.Fatalf("用户 %s 的请求应该通过", user)
+			}
+		}
+		quota = limiter.Check(ctx, userDescriptor("u4", "t1"))
+		if quota.Allowed || quota.Level != "tenant" {
+			t.Errorf("期望租户层级拒绝，得到 %+v", quota)
+		}
+	})
+
+	t.Run("拒绝的请求不消耗配额", func(t *testing.T) {
+		limiter := newTestHierarchicalLimiter(t)
+
+		limiter.Check(ctx, userDescriptor("u1", "t1"))
+		limiter.Check(ctx, userDescriptor("u1", "t1"))
+		for i := 0; i < 10; i++ {
+			limiter.Check(ctx, userDescriptor("u1", "t1"))
+		}
+
+		// 租户层级只消耗了 2 个配额，剩余 3 个
+		for _, user := range []string{"u2", "u2", "u3"} {
+			if !limiter.Check(ctx, userDescriptor(user, "t1")).Allowed {
+				t.Fatalf("用户 %s 的请求应该通过", user)
+			}
+		}
+		if limiter.Check(ctx, userDescriptor("u4", "t1")).Allowed {
+			t.Error("租户配额耗尽后应该被拒绝")
+		}
+	})
+
+	t.Run("按套餐选择限额", func(t *testing.T) {
+		limiter := newTestHierarchicalLimiter(t)
+		d := Descriptor{Tier: "pro", Keys: map[string]string{"user": "u1", "tenant": "t1"}}
+
+		for i := 0; i < 4; i++ {
+			if !limiter.Check(ctx, d).Allowed {
+				t.Fatalf("第 %d 个请求应该通过", i+1)
+			}
+		}
+		quota := limiter.Check(ctx, d)
+		if quota.Allowed || quota.Limit != 4 {
+			t.Errorf("期望 pro 套餐限额 4，得到 %+v", quota)
+		}
+	})
+
+	t.Run("配额随时间恢复", func(t *testing.T) {
+		limiter, _ := NewHierarchicalLimiter([]LevelConfig{
+			{Name: "user", Limit: 2, Window: 100 * time.Millisecond},
+		})
+		limiter.Check(ctx, userDescriptor("u1", ""))
+		limiter.Check(ctx, userDescriptor("u1", ""))
+
+		quota := limiter.Check(ctx, userDescriptor("u1", ""))
+		if quota.Allowed {
+			t.Fatal("应该被拒绝")
+		}
+		time.Sleep(quota.RetryAfter)
+		if !limiter.Check(ctx, userDescriptor("u1", "")).Allowed {
+			t.Error("等待 RetryAfter 后应该通过")
+		}
+	})
+
+	t.Run("作为 Limiter 使用", func(t *testing.T) {
+		var limiter Limiter = newTestHierarchicalLimiter(t)
+		ctx := ContextWithDescriptor(ctx, userDescriptor("u1", "t1"))
+
+		limiter.Allow(ctx)
+		limiter.Allow(ctx)
+		if limiter.Allow(ctx) {
+			t.Error("第三个请求应该被限流")
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("期望 DeadlineExceeded，得到 %v", err)
+		}
+	})
+
+	t.Run("无效配置", func(t *testing.T) {
+		testCases := []struct {
+			name   string
+			levels []LevelConfig
+		}{
+			{"无层级", nil},
+			{"无名称", []LevelConfig{{Limit: 1, Window: time.Second}}},
+			{"无限额", []LevelConfig{{Name: "user", Window: time.Second}}},
+			{"无窗口", []LevelConfig{{Name: "user", Limit: 1}}},
+			{"套餐限额无效", []LevelConfig{{Name: "user", Limit: 1, Window: time.Second, Tiers: map[string]int{"free": 0}}}},
+			{"名称重复", []LevelConfig{
+				{Name: "user", Limit: 1, Window: time.Second},
+				{Name: "user", Limit: 2, Window: time.Second},
+			}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if _, err := NewHierarchicalLimiter(tc.levels); !errors.Is(err, ErrInvalidConfig) {
+					t.Errorf("期望 ErrInvalidConfig，得到 %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("通过配置创建", func(t *testing.T) {
+		limiter, err := NewLimiter(&Config{
+			Algorithm: AlgorithmHierarchical,
+			Levels:    []LevelConfig{{Name: "global", Limit: 1, Window: time.Second}},
+		})
+		if err != nil {
+			t.Fatalf("创建失败: %v", err)
+		}
+		if !limiter.Allow(ctx) || limiter.Allow(ctx) {
+			t.Error("全局层级应只允许 1 个请求")
+		}
+
+		_, err = NewLimiter(&Config{Algorithm: AlgorithmHierarchical})
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("期望 ErrInvalidConfig，得到 %v", err)
+		}
+	})
+}
+
+func TestHierarchicalHTTPMiddleware(t *testing.T) {
+	limiter := newTestHierarchicalLimiter(t)
+	handler := HierarchicalHTTPMiddleware(limiter, func(r *http.Request) Descriptor {
+		return userDescriptor(r.Header.Get("X-User"), "t1")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", "u1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("期望 200，得到 %d", rec.Code)
+	}
+	if rec.Header().Get(HeaderRateLimitLimit) != "2" || rec.Header().Get(HeaderRateLimitRemaining) != "1" {
+		t.Errorf("配额头不符合预期: %v", rec.Header())
+	}
+	if rec.Header().Get(HeaderRetryAfter) != "" {
+		t.Error("通过时不应包含 Retry-After")
+	}
+
+	send()
+	rec = send()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("期望 429，得到 %d", rec.Code)
+	}
+	if rec.Header().Get(HeaderRateLimitRemaining) != "0" || rec.Header().Get(HeaderRetryAfter) != "1" {
+		t.Errorf("配额头不符合预期: %v", rec.Header())
+	}
+}
+
+// trailerStream 记录 trailer 的 ServerTransportStream.
+type trailerStream struct {
+	trailer metadata.MD
+}
+
+func (s *trailerStream) Method() string                  { return "/test/Method" }
+func (s *trailerStream) SetHeader(md metadata.MD) error  { return nil }
+func (s *trailerStream) SendHeader(md metadata.MD) error { return nil }
+func (s *trailerStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+func TestHierarchicalUnaryServerInterceptor(t *testing.T) {
+	limiter := newTestHierarchicalLimiter(t)
+	interceptor := HierarchicalUnaryServerInterceptor(limiter, func(ctx context.Context, fullMethod string) Descriptor {
+		return userDescriptor("u1", "t1")
+	})
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "success", nil
+	}
+
+	call := func() (*trailerStream, error) {
+		stream := &trailerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Method"}, handler)
+		return stream, err
+	}
+
+	stream, err := call()
+	if err != nil {
+		t.Fatalf("不期望错误: %v", err)
+	}
+	if got := stream.trailer.Get("ratelimit-remaining"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("trailer 不符合预期: %v", stream.trailer)
+	}
+
+	call()
+	stream, err = call()
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("期望 ResourceExhausted，得到 %v", err)
+	}
+	if got := stream.trailer.Get("retry-after"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("trailer 不符合预期: %v", stream.trailer)
+	}
+	if got := stream.trailer.Get("ratelimit-limit"); len(got) != 1 || got[0] != "2" {
+		t.Errorf("trailer 不符合预期: %v", stream.trailer)
+	}
+}
@@ -14,7 +14,7 @@ func HTTPMiddleware(limiter Limiter) func(http.Handler) http.Handler {
 				http.Error(w, "请求过于频繁，请稍后重试", http.StatusTooManyRequests)
 				return
 			}
-			next.ServeHTTP(w, r)
+			serveAndRelease(limiter, next, w, r)
 		})
 	}
 }
@@ -29,7 +29,7 @@ func HTTPMiddlewareWithWait(limiter Limiter) func(http.Handler) http.Handler {
 				http.Error(w, "请求超时", http.StatusGatewayTimeout)
 				return
 			}
-			next.ServeHTTP(w, r)
+			serveAndRelease(limiter, next, w, r)
 		})
 	}
 }
@@ -53,7 +53,7 @@ func KeyedHTTPMiddleware(keyFunc HTTPKeyFunc, getLimiter KeyedLimiterFunc) func(
 				http.Error(w, "请求过于频繁，请稍后重试", http.StatusTooManyRequests)
 				return
 			}
-			next.ServeHTTP(w, r)
+			serveAndRelease(limiter, next, w, r)
 		})
 	}
 }
@@ -94,3 +94,35 @@ func CompositeKeyFunc(funcs ...HTTPKeyFunc) HTTPKeyFunc {
 		return key
 	}
 }
+
+// serveAndRelease 处理请求，结束后为 ReleasableLimiter 归还许可.
+//
+// 请求 context 超时视为过载，其余情况只报告耗时.
+func serveAndRelease(limiter Limiter, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	done := releaser(limiter)
+	defer func() { done(r.Context().Err()) }()
+	next.ServeHTTP(w, r)
+}
+
+// HTTPDescriptorFunc 用于从 HTTP 请求中提取分层限流描述符.
+type HTTPDescriptorFunc func(r *http.Request) Descriptor
+
+// HierarchicalHTTPMiddleware 创建分层限流 HTTP 中间件.
+//
+// 响应中写入 RateLimit-Limit、RateLimit-Remaining 头，
+// 被限流时返回 429 Too Many Requests 并写入 Retry-After 头.
+func HierarchicalHTTPMiddleware(limiter *HierarchicalLimiter, descriptorFunc HTTPDescriptorFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			quota := limiter.Check(r.Context(), descriptorFunc(r))
+			for _, kv := range quota.headers() {
+				w.Header().Set(kv[0], kv[1])
+			}
+			if !quota.Allowed {
+				http.Error(w, "请求过于频繁，请稍后重试", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
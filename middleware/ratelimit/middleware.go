@@ -11,10 +11,12 @@ import (
 // 当请求被限流时返回 ErrRateLimited 错误.
 func EndpointMiddleware(limiter Limiter) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
-		return func(ctx context.Context, request any) (any, error) {
+		return func(ctx context.Context, request any) (response any, err error) {
 			if !limiter.Allow(ctx) {
 				return nil, ErrRateLimited
 			}
+			done := releaser(limiter)
+			defer func() { done(err) }()
 			return next(ctx, request)
 		}
 	}
@@ -25,10 +27,12 @@ func EndpointMiddleware(limiter Limiter) endpoint.Middleware {
 // 当请求被限流时阻塞等待，直到可以通过或 context 超时.
 func EndpointMiddlewareWithWait(limiter Limiter) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
-		return func(ctx context.Context, request any) (any, error) {
+		return func(ctx context.Context, request any) (response any, err error) {
 			if err := limiter.Wait(ctx); err != nil {
 				return nil, err
 			}
+			done := releaser(limiter)
+			defer func() { done(err) }()
 			return next(ctx, request)
 		}
 	}
@@ -47,7 +51,7 @@ type KeyedLimiterFunc func(key string) Limiter
 // 可以为不同的用户/IP 使用不同的限流策略.
 func KeyedEndpointMiddleware(keyFunc KeyFunc, getLimiter KeyedLimiterFunc) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
-		return func(ctx context.Context, request any) (any, error) {
+		return func(ctx context.Context, request any) (response any, err error) {
 			key := keyFunc(ctx, request)
 			limiter := getLimiter(key)
 			if limiter == nil {
@@ -56,6 +60,25 @@ func KeyedEndpointMiddleware(keyFunc KeyFunc, getLimiter KeyedLimiterFunc) endpo
 			if !limiter.Allow(ctx) {
 				return nil, ErrRateLimited
 			}
+			done := releaser(limiter)
+			defer func() { done(err) }()
+			return next(ctx, request)
+		}
+	}
+}
+
+// DescriptorFunc 用于从请求中提取分层限流描述符.
+type DescriptorFunc func(ctx context.Context, request any) Descriptor
+
+// HierarchicalEndpointMiddleware 创建分层限流 Endpoint 中间件.
+//
+// 任一层级超限时返回 ErrRateLimited 错误.
+func HierarchicalEndpointMiddleware(limiter *HierarchicalLimiter, descriptorFunc DescriptorFunc) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request any) (any, error) {
+			if !limiter.Check(ctx, descriptorFunc(ctx, request)).Allowed {
+				return nil, ErrRateLimited
+			}
 			return next(ctx, request)
 		}
 	}
@@ -24,6 +24,18 @@ type Limiter interface {
 	WaitN(ctx context.Context, n int) error
 }
 
+// ReleasableLimiter 需要在请求结束后归还许可的限流器.
+//
+// 并发限流器通过 Allow/Wait 获取许可，中间件在请求结束后调用 Release 归还，
+// 同时报告请求耗时，供限流器调整并发上限.
+type ReleasableLimiter interface {
+	Limiter
+
+	// Release 归还 n 个许可.
+	// latency 为请求耗时，dropped 表示请求因超时或过载失败.
+	Release(n int, latency time.Duration, dropped bool)
+}
+
 // RateCounter 分布式限流器所需的计数器接口.
 //
 // 这是分布式限流的最小依赖接口.
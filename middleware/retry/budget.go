@@ -0,0 +1,95 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/middleware/timeout"
+)
+
+// 重试预算默认值.
+const (
+	DefaultBudgetRatio  = 0.1
+	DefaultBudgetTokens = 10
+)
+
+// Budget 重试预算.
+//
+// 基于令牌桶实现：每次成功调用存入 ratio 个令牌，每次重试或对冲请求消耗 1 个令牌，
+// 令牌不足时放弃重试. 稳定状态下重试数不超过成功调用数的 ratio 倍，
+// 避免故障期间重试成倍放大下游负载.
+//
+// 同一个 Budget 可在多个重试器之间共享.
+type Budget struct {
+	mu        sync.Mutex
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+// NewBudget 创建重试预算.
+//
+// ratio 为重试数与成功调用数之比，maxTokens 为令牌上限，同时也是初始令牌数，
+// 保证低流量时仍可少量重试. 参数不合法时使用默认值.
+//
+// 使用示例:
+//
+//	budget := retry.NewBudget(0.1, 10) // 重试数不超过成功数的 10%
+//	retrier := retry.NewGRPCRetrier(nil).WithBudget(budget)
+func NewBudget(ratio, maxTokens float64) *Budget {
+	if ratio <= 0 {
+		ratio = DefaultBudgetRatio
+	}
+	if maxTokens < 1 {
+		maxTokens = DefaultBudgetTokens
+	}
+	return &Budget{
+		ratio:     ratio,
+		maxTokens: maxTokens,
+		tokens:    maxTokens,
+	}
+}
+
+// Deposit 记录一次成功调用，存入令牌.
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.maxTokens)
+}
+
+// Withdraw 尝试消耗一个令牌，令牌不足时返回 false.
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Tokens 返回当前令牌数.
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// allowRetry 判断是否允许再发出一次请求.
+//
+// 剩余时间不超过 wait 时放弃，避免在 deadline 实际已过时仍发出请求；
+// 否则从预算中扣除一个令牌.
+func (cfg *Config) allowRetry(ctx context.Context, wait time.Duration) bool {
+	if remaining, ok := timeout.Remaining(ctx); ok && remaining <= wait {
+		return false
+	}
+	return cfg.Budget == nil || cfg.Budget.Withdraw()
+}
+
+// recordSuccess 记录成功调用.
+func (cfg *Config) recordSuccess() {
+	if cfg.Budget != nil {
+		cfg.Budget.Deposit()
+	}
+}
@@ -0,0 +1,161 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBudget(t *testing.T) {
+	t.Run("初始令牌数为上限", func(t *testing.T) {
+		b := NewBudget(0.5, 2)
+		if !b.Withdraw() || !b.Withdraw() {
+			t.Fatal("期望可以消耗 2 个令牌")
+		}
+		if b.Withdraw() {
+			t.Error("令牌耗尽后不应允许重试")
+		}
+	})
+
+	t.Run("成功调用存入令牌", func(t *testing.T) {
+		b := NewBudget(0.5, 2)
+		b.Withdraw()
+		b.Withdraw()
+
+		b.Deposit()
+		if b.Withdraw() {
+			t.Error("0.5 个令牌不足以重试")
+		}
+		b.Deposit()
+		if !b.Withdraw() {
+			t.Error("两次成功后应允许一次重试")
+		}
+	})
+
+	t.Run("令牌不超过上限", func(t *testing.T) {
+		b := NewBudget(1, 2)
+		for i := 0; i < 10; i++ {
+			b.Deposit()
+		}
+		if b.Tokens() != 2 {
+			t.Errorf("期望 2 个令牌，得到 %v", b.Tokens())
+		}
+	})
+
+	t.Run("非法参数使用默认值", func(t *testing.T) {
+		b := NewBudget(0, 0)
+		if b.Tokens() != DefaultBudgetTokens {
+			t.Errorf("期望 %d 个令牌，得到 %v", DefaultBudgetTokens, b.Tokens())
+		}
+	})
+}
+
+func TestEndpointMiddleware_Budget(t *testing.T) {
+	budget := NewBudget(0.1, 2)
+	cfg := &Config{
+		MaxAttempts: 5,
+		Delay:       time.Millisecond,
+		Backoff:     FixedBackoff,
+		Retryable:   AlwaysRetry,
+		Budget:      budget,
+	}
+
+	callCount := 0
+	ep := EndpointMiddleware(cfg)(func(ctx context.Context, request any) (any, error) {
+		callCount++
+		return nil, errors.New("error")
+	})
+
+	// 第一次调用消耗全部 2 个令牌
+	_, _ = ep(context.Background(), nil)
+	if callCount != 3 {
+		t.Errorf("期望调用 3 次，实际 %d 次", callCount)
+	}
+
+	// 预算耗尽后不再重试
+	callCount = 0
+	_, _ = ep(context.Background(), nil)
+	if callCount != 1 {
+		t.Errorf("期望调用 1 次，实际 %d 次", callCount)
+	}
+}
+
+func TestUnaryClientInterceptor_Budget(t *testing.T) {
+	retrier := NewGRPCRetrier(&Config{MaxAttempts: 3, Delay: time.Millisecond}).
+		WithBudget(NewBudget(0.1, 1))
+	interceptor := retrier.UnaryClientInterceptor()
+
+	callCount := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		callCount++
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	_ = interceptor(context.Background(), "/test/Method", nil, nil, nil, invoker)
+	_ = interceptor(context.Background(), "/test/Other", nil, nil, nil, invoker)
+	if callCount != 3 {
+		t.Errorf("两次调用共享 1 个令牌，期望调用 3 次，实际 %d 次", callCount)
+	}
+}
+
+func TestHTTPClient_Budget(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(nil, &Config{MaxAttempts: 3, Delay: time.Millisecond, Backoff: FixedBackoff}).
+		WithBudget(NewBudget(0.1, 1))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("不期望错误: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if calls.Load() != 3 {
+		t.Errorf("期望请求 3 次，实际 %d 次", calls.Load())
+	}
+}
+
+func TestRetry_HonorsDeadline(t *testing.T) {
+	cfg := &Config{
+		MaxAttempts: 5,
+		Delay:       100 * time.Millisecond,
+		Backoff:     FixedBackoff,
+		Retryable:   AlwaysRetry,
+	}
+
+	callCount := 0
+	ep := EndpointMiddleware(cfg)(func(ctx context.Context, request any) (any, error) {
+		callCount++
+		return nil, errors.New("error")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ep(ctx, nil)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望返回下游错误，实际 %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("剩余时间不足以等待退避，期望调用 1 次，实际 %d 次", callCount)
+	}
+	if time.Since(start) > 40*time.Millisecond {
+		t.Error("不应等待到 deadline")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // GRPCRetryableFunc 判断 gRPC 错误是否应该重试.
@@ -14,6 +15,8 @@ type GRPCRetryableFunc func(err error) bool
 
 // UnaryClientInterceptor 返回 gRPC 一元客户端重试拦截器.
 //
+// 配置 HedgeDelay 且响应类型为 proto.Message 时，以对冲方式发送请求.
+//
 // 使用示例:
 //
 //	cfg := retry.DefaultConfig()
@@ -34,6 +37,13 @@ func UnaryClientInterceptor(cfg *Config) grpc.UnaryClientInterceptor {
 	}
 
 	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// 对冲请求并发执行，每次请求需要独立的响应消息
+		if msg, ok := reply.(proto.Message); ok && cfg.HedgeDelay > 0 {
+			return hedgeUnary(ctx, cfg, retryable, msg, func(ctx context.Context, reply proto.Message) error {
+				return invoker(ctx, method, req, reply, cc, opts...)
+			})
+		}
+
 		var err error
 
 		for attempt := 0; attempt < cfg.MaxAttempts; attempt++ {
@@ -47,6 +57,7 @@ func UnaryClientInterceptor(cfg *Config) grpc.UnaryClientInterceptor {
 			// 执行 RPC 调用
 			err = invoker(ctx, method, req, reply, cc, opts...)
 			if err == nil {
+				cfg.recordSuccess()
 				return nil
 			}
 
@@ -58,6 +69,9 @@ func UnaryClientInterceptor(cfg *Config) grpc.UnaryClientInterceptor {
 			// 如果不是最后一次尝试，则等待
 			if attempt < cfg.MaxAttempts-1 {
 				wait := cfg.Backoff(attempt, cfg.Delay)
+				if !cfg.allowRetry(ctx, wait) {
+					return err
+				}
 				select {
 				case <-time.After(wait):
 					continue
@@ -74,7 +88,7 @@ func UnaryClientInterceptor(cfg *Config) grpc.UnaryClientInterceptor {
 // StreamClientInterceptor 返回 gRPC 流式客户端重试拦截器.
 //
 // 注意：流式 RPC 的重试只在建立连接阶段生效。
-// 一旦流开始传输，重试可能导致数据不一致。
+// 一旦流开始传输，重试可能导致数据不一致。流式 RPC 不支持对冲请求。
 //
 // 使用示例:
 //
@@ -110,6 +124,7 @@ func StreamClientInterceptor(cfg *Config) grpc.StreamClientInterceptor {
 			// 创建流
 			stream, err = streamer(ctx, desc, cc, method, opts...)
 			if err == nil {
+				cfg.recordSuccess()
 				return stream, nil
 			}
 
@@ -121,6 +136,9 @@ func StreamClientInterceptor(cfg *Config) grpc.StreamClientInterceptor {
 			// 如果不是最后一次尝试，则等待
 			if attempt < cfg.MaxAttempts-1 {
 				wait := cfg.Backoff(attempt, cfg.Delay)
+				if !cfg.allowRetry(ctx, wait) {
+					return nil, err
+				}
 				select {
 				case <-time.After(wait):
 					continue
@@ -134,6 +152,24 @@ func StreamClientInterceptor(cfg *Config) grpc.StreamClientInterceptor {
 	}
 }
 
+// hedgeUnary 以对冲方式执行一元调用，获胜请求的响应合并到 reply.
+func hedgeUnary(ctx context.Context, cfg *Config, retryable RetryableFunc, reply proto.Message, invoke func(context.Context, proto.Message) error) error {
+	resp, cancel, err := hedge(ctx, cfg,
+		func(_ any, err error) bool { return err != nil && retryable(err) },
+		func(ctx context.Context) (any, error) {
+			msg := reply.ProtoReflect().New().Interface()
+			return msg, invoke(ctx, msg)
+		},
+		nil,
+	)
+	defer cancel()
+
+	if msg, ok := resp.(proto.Message); ok && err == nil {
+		proto.Merge(reply, msg)
+	}
+	return err
+}
+
 // DefaultGRPCRetryable 默认的 gRPC 重试判断.
 // 重试临时错误和资源耗尽错误.
 func DefaultGRPCRetryable(err error) bool {
@@ -228,3 +264,17 @@ func (r *GRPCRetrier) WithRetryableCodes(codes ...codes.Code) *GRPCRetrier {
 	r.cfg.Retryable = RetryableCodesFunc(codes...)
 	return r
 }
+
+// WithBudget 设置重试预算，同一重试器的所有调用共享该预算.
+func (r *GRPCRetrier) WithBudget(b *Budget) *GRPCRetrier {
+	r.cfg.Budget = b
+	return r
+}
+
+// WithHedging 启用对冲请求，d 为发出下一次请求前的等待时间.
+//
+// 仅对响应类型为 proto.Message 的一元调用生效.
+func (r *GRPCRetrier) WithHedging(d time.Duration) *GRPCRetrier {
+	r.cfg.HedgeDelay = d
+	return r
+}
@@ -0,0 +1,117 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// attemptFunc 执行单次请求.
+type attemptFunc func(ctx context.Context) (any, error)
+
+// resultRetryableFunc 判断单次请求结果是否应该重试.
+type resultRetryableFunc func(resp any, err error) bool
+
+type hedgeResult struct {
+	idx  int
+	resp any
+	err  error
+}
+
+// hedge 执行对冲请求.
+//
+// 首次请求发出后，每经过 HedgeDelay 仍未返回就再发出一次，总数不超过 MaxAttempts；
+// 请求返回可重试错误时立即发出下一次. 返回第一个成功或不可重试的结果并取消其余请求，
+// 全部失败时返回最后一个结果.
+//
+// 返回的 cancel 释放获胜请求的 context，调用方使用完结果后必须调用.
+// discard 用于释放被丢弃结果持有的资源，可以为 nil.
+func hedge(ctx context.Context, cfg *Config, retryable resultRetryableFunc, attempt attemptFunc, discard func(any)) (resp any, cancel context.CancelFunc, err error) {
+	results := make(chan hedgeResult, cfg.MaxAttempts)
+	cancels := make([]context.CancelFunc, 0, cfg.MaxAttempts)
+	inflight := 0
+
+	launch := func() {
+		actx, cancel := context.WithCancel(ctx)
+		idx := len(cancels)
+		cancels = append(cancels, cancel)
+		inflight++
+		go func() {
+			resp, err := attempt(actx)
+			results <- hedgeResult{idx: idx, resp: resp, err: err}
+		}()
+	}
+
+	release := func(resp any) {
+		if discard != nil && resp != nil {
+			discard(resp)
+		}
+	}
+
+	// finish 取消除 winner 外的所有请求，并在后台释放仍在途请求的结果
+	finish := func(winner int) {
+		for i, c := range cancels {
+			if i != winner {
+				c()
+			}
+		}
+		go func(n int) {
+			for range n {
+				release((<-results).resp)
+			}
+		}(inflight)
+	}
+
+	canLaunch := func(wait time.Duration) bool {
+		return len(cancels) < cfg.MaxAttempts && cfg.allowRetry(ctx, wait)
+	}
+
+	launch()
+	timer := time.NewTimer(cfg.HedgeDelay)
+	defer timer.Stop()
+
+	var last *hedgeResult
+	for {
+		select {
+		case r := <-results:
+			inflight--
+			if !retryable(r.resp, r.err) {
+				if r.err == nil {
+					cfg.recordSuccess()
+				}
+				finish(r.idx)
+				if last != nil {
+					release(last.resp)
+				}
+				return r.resp, cancels[r.idx], r.err
+			}
+
+			if last != nil {
+				cancels[last.idx]()
+				release(last.resp)
+			}
+			last = &r
+
+			if canLaunch(0) {
+				launch()
+				timer.Reset(cfg.HedgeDelay)
+			} else if inflight == 0 {
+				finish(last.idx)
+				return last.resp, cancels[last.idx], last.err
+			}
+
+		case <-timer.C:
+			// 剩余时间不足一个对冲延迟时，新请求大概率无法及时返回
+			if canLaunch(cfg.HedgeDelay) {
+				launch()
+				timer.Reset(cfg.HedgeDelay)
+			}
+
+		case <-ctx.Done():
+			finish(-1)
+			if last != nil {
+				release(last.resp)
+			}
+			return nil, func() {}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestEndpointMiddleware_Hedging(t *testing.T) {
+	t.Run("慢请求触发对冲", func(t *testing.T) {
+		retrier := NewEndpointRetrier(&Config{MaxAttempts: 2, Retryable: AlwaysRetry}).
+			WithHedging(10 * time.Millisecond)
+
+		var calls atomic.Int32
+		ep := retrier.Middleware()(func(ctx context.Context, request any) (any, error) {
+			if calls.Add(1) == 1 {
+				// 首次请求很慢，应被取消
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return "hedged", nil
+		})
+
+		start := time.Now()
+		resp, err := ep(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("不期望错误: %v", err)
+		}
+		if resp != "hedged" {
+			t.Errorf("期望对冲请求的结果，得到 %v", resp)
+		}
+		if time.Since(start) > time.Second {
+			t.Error("对冲请求未生效")
+		}
+	})
+
+	t.Run("快速请求不触发对冲", func(t *testing.T) {
+		cfg := &Config{MaxAttempts: 3, Retryable: AlwaysRetry, HedgeDelay: 50 * time.Millisecond}
+
+		var calls atomic.Int32
+		ep := EndpointMiddleware(cfg)(func(ctx context.Context, request any) (any, error) {
+			calls.Add(1)
+			return "ok", nil
+		})
+
+		if _, err := ep(context.Background(), nil); err != nil {
+			t.Fatalf("不期望错误: %v", err)
+		}
+		time.Sleep(80 * time.Millisecond)
+		if calls.Load() != 1 {
+			t.Errorf("期望调用 1 次，实际 %d 次", calls.Load())
+		}
+	})
+
+	t.Run("全部失败返回最后的错误", func(t *testing.T) {
+		cfg := &Config{MaxAttempts: 3, Retryable: AlwaysRetry, HedgeDelay: time.Second}
+
+		var calls atomic.Int32
+		ep := EndpointMiddleware(cfg)(func(ctx context.Context, request any) (any, error) {
+			calls.Add(1)
+			return nil, errors.New("error")
+		})
+
+		if _, err := ep(context.Background(), nil); err == nil {
+			t.Error("期望错误")
+		}
+		if calls.Load() != 3 {
+			t.Errorf("期望调用 3 次，实际 %d 次", calls.Load())
+		}
+	})
+
+	t.Run("不可重试错误立即返回", func(t *testing.T) {
+		cfg := &Config{MaxAttempts: 3, Retryable: NeverRetry, HedgeDelay: time.Second}
+
+		var calls atomic.Int32
+		ep := EndpointMiddleware(cfg)(func(ctx context.Context, request any) (any, error) {
+			calls.Add(1)
+			return nil, errors.New("error")
+		})
+
+		_, _ = ep(context.Background(), nil)
+		if calls.Load() != 1 {
+			t.Errorf("期望调用 1 次，实际 %d 次", calls.Load())
+		}
+	})
+
+	t.Run("剩余时间不足时不对冲", func(t *testing.T) {
+		cfg := &Config{MaxAttempts: 2, Retryable: AlwaysRetry, HedgeDelay: 30 * time.Millisecond}
+
+		var calls atomic.Int32
+		ep := EndpointMiddleware(cfg)(func(ctx context.Context, request any) (any, error) {
+			calls.Add(1)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := ep(ctx, nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("期望 DeadlineExceeded，实际 %v", err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("期望调用 1 次，实际 %d 次", calls.Load())
+		}
+	})
+
+	t.Run("对冲请求消耗预算", func(t *testing.T) {
+		budget := NewBudget(0.1, 1)
+		budget.Withdraw()
+		cfg := &Config{MaxAttempts: 2, Retryable: AlwaysRetry, HedgeDelay: 10 * time.Millisecond, Budget: budget}
+
+		var calls atomic.Int32
+		ep := EndpointMiddleware(cfg)(func(ctx context.Context, request any) (any, error) {
+			calls.Add(1)
+			time.Sleep(30 * time.Millisecond)
+			return "ok", nil
+		})
+
+		if _, err := ep(context.Background(), nil); err != nil {
+			t.Fatalf("不期望错误: %v", err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("预算耗尽时不应对冲，实际调用 %d 次", calls.Load())
+		}
+	})
+}
+
+func TestUnaryClientInterceptor_Hedging(t *testing.T) {
+	retrier := NewGRPCRetrier(&Config{MaxAttempts: 2}).WithHedging(10 * time.Millisecond)
+	interceptor := retrier.UnaryClientInterceptor()
+
+	var calls atomic.Int32
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.(*wrapperspb.StringValue).Value = "hedged"
+		return nil
+	}
+
+	reply := &wrapperspb.StringValue{}
+	if err := interceptor(context.Background(), "/test/Method", nil, reply, nil, invoker); err != nil {
+		t.Fatalf("不期望错误: %v", err)
+	}
+	if reply.Value != "hedged" {
+		t.Errorf("期望响应合并到 reply，得到 %q", reply.Value)
+	}
+}
+
+func TestHTTPClient_Hedging(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(nil, &Config{MaxAttempts: 2}).WithHedging(20 * time.Millisecond)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("不期望错误: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("读取响应失败: %v", err)
+	}
+	if string(body) != "payload" {
+		t.Errorf("期望对冲请求携带原始 body，得到 %q", body)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("期望请求 2 次，实际 %d 次", calls.Load())
+	}
+}
@@ -44,6 +44,20 @@ func (c *HTTPClient) WithRetryable(fn HTTPRetryableFunc) *HTTPClient {
 	return c
 }
 
+// WithBudget 设置重试预算，同一客户端的所有请求共享该预算.
+func (c *HTTPClient) WithBudget(b *Budget) *HTTPClient {
+	c.cfg.Budget = b
+	return c
+}
+
+// WithHedging 启用对冲请求，d 为发出下一次请求前的等待时间.
+//
+// 对冲请求会重复发送请求，仅适用于幂等请求.
+func (c *HTTPClient) WithHedging(d time.Duration) *HTTPClient {
+	c.cfg.HedgeDelay = d
+	return c
+}
+
 // Do 执行 HTTP 请求，支持重试.
 func (c *HTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return c.DoWithContext(req.Context(), req)
@@ -64,6 +78,10 @@ func (c *HTTPClient) DoWithContext(ctx context.Context, req *http.Request) (*htt
 		req.Body.Close()
 	}
 
+	if c.cfg.HedgeDelay > 0 {
+		return c.doHedged(ctx, req, bodyBytes)
+	}
+
 	for attempt := 0; attempt < c.cfg.MaxAttempts; attempt++ {
 		// 检查上下文是否已取消
 		select {
@@ -82,6 +100,9 @@ func (c *HTTPClient) DoWithContext(ctx context.Context, req *http.Request) (*htt
 
 		// 判断是否应该重试
 		if !c.retryable(resp, err) {
+			if err == nil {
+				c.cfg.recordSuccess()
+			}
 			return resp, err
 		}
 
@@ -94,6 +115,9 @@ func (c *HTTPClient) DoWithContext(ctx context.Context, req *http.Request) (*htt
 		// 如果不是最后一次尝试，则等待
 		if attempt < c.cfg.MaxAttempts-1 {
 			wait := c.cfg.Backoff(attempt, c.cfg.Delay)
+			if !c.cfg.allowRetry(ctx, wait) {
+				return resp, err
+			}
 			select {
 			case <-time.After(wait):
 				continue
@@ -106,6 +130,54 @@ func (c *HTTPClient) DoWithContext(ctx context.Context, req *http.Request) (*htt
 	return resp, err
 }
 
+// doHedged 以对冲方式执行 HTTP 请求.
+func (c *HTTPClient) doHedged(ctx context.Context, req *http.Request, bodyBytes []byte) (*http.Response, error) {
+	result, cancel, err := hedge(ctx, c.cfg,
+		func(result any, err error) bool {
+			resp, _ := result.(*http.Response)
+			return c.retryable(resp, err)
+		},
+		func(ctx context.Context) (any, error) {
+			r := req.Clone(ctx)
+			if bodyBytes != nil {
+				r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+			}
+			resp, err := c.client.Do(r)
+			if resp == nil {
+				return nil, err
+			}
+			return resp, err
+		},
+		func(result any) {
+			resp := result.(*http.Response)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		},
+	)
+
+	resp, _ := result.(*http.Response)
+	if resp == nil {
+		cancel()
+		return nil, err
+	}
+	// 获胜请求的 context 在响应体关闭时释放
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, err
+}
+
+// cancelOnClose 关闭时取消请求 context 的响应体.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close 关闭响应体并取消 context.
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 // DefaultHTTPRetryable 默认的 HTTP 重试判断.
 // 重试网络错误和 5xx 响应.
 func DefaultHTTPRetryable(resp *http.Response, err error) bool {
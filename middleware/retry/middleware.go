@@ -13,6 +13,8 @@ type Config struct {
 	Delay       time.Duration // 重试间隔
 	Backoff     BackoffFunc   // 退避策略
 	Retryable   RetryableFunc // 判断是否应该重试
+	Budget      *Budget       // 重试预算，为 nil 时不限制
+	HedgeDelay  time.Duration // 对冲延迟，大于 0 时启用对冲请求，总请求数不超过 MaxAttempts
 }
 
 // BackoffFunc 计算第 n 次重试的等待时间.
@@ -75,6 +77,16 @@ func EndpointMiddleware(cfg *Config) endpoint.Middleware {
 
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request any) (response any, err error) {
+			if cfg.HedgeDelay > 0 {
+				response, cancel, err := hedge(ctx, cfg,
+					func(_ any, err error) bool { return err != nil && cfg.Retryable(err) },
+					func(ctx context.Context) (any, error) { return next(ctx, request) },
+					nil,
+				)
+				cancel()
+				return response, err
+			}
+
 			for attempt := 0; attempt < cfg.MaxAttempts; attempt++ {
 				// 检查上下文是否已取消
 				select {
@@ -86,6 +98,7 @@ func EndpointMiddleware(cfg *Config) endpoint.Middleware {
 				// 执行 endpoint
 				response, err = next(ctx, request)
 				if err == nil {
+					cfg.recordSuccess()
 					return response, nil
 				}
 
@@ -97,6 +110,9 @@ func EndpointMiddleware(cfg *Config) endpoint.Middleware {
 				// 如果不是最后一次尝试，则等待
 				if attempt < cfg.MaxAttempts-1 {
 					wait := cfg.Backoff(attempt, cfg.Delay)
+					if !cfg.allowRetry(ctx, wait) {
+						return response, err
+					}
 					select {
 					case <-time.After(wait):
 						continue
@@ -152,3 +168,15 @@ func (r *EndpointRetrier) WithRetryable(fn RetryableFunc) *EndpointRetrier {
 	r.cfg.Retryable = fn
 	return r
 }
+
+// WithBudget 设置重试预算.
+func (r *EndpointRetrier) WithBudget(b *Budget) *EndpointRetrier {
+	r.cfg.Budget = b
+	return r
+}
+
+// WithHedging 启用对冲请求，d 为发出下一次请求前的等待时间.
+func (r *EndpointRetrier) WithHedging(d time.Duration) *EndpointRetrier {
+	r.cfg.HedgeDelay = d
+	return r
+}
@@ -0,0 +1,12 @@
+package outbox
+
+import "errors"
+
+// 预定义错误.
+var (
+	// ErrNilTx 事务为空.
+	ErrNilTx = errors.New("outbox: 事务不能为空")
+
+	// ErrEmptyTopic 消息主题为空.
+	ErrEmptyTopic = errors.New("outbox: 消息主题为空")
+)
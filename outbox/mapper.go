@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/domain"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+)
+
+// 投递时附加的消息头.
+const (
+	// HeaderMessageID 消息唯一标识，消费方可据此去重
+	HeaderMessageID = "message-id"
+	// HeaderEventName 领域事件名称
+	HeaderEventName = "event-name"
+	// HeaderOccurredTime 领域事件发生时间（RFC3339Nano）
+	HeaderOccurredTime = "occurred-time"
+)
+
+// MessageMapper 将领域事件转换为待投递的消息.
+//
+// 只需要填充 Topic、Key、Value 和 Headers，其余字段由 Relay 在投递时设置.
+type MessageMapper func(event domain.DomainEvent) (*messaging.Message, error)
+
+// TopicFunc 根据领域事件返回消息主题.
+type TopicFunc func(event domain.DomainEvent) string
+
+// PartitionKeyer 可以提供消息键的领域事件.
+//
+// 相同键的消息路由到同一分区，通常返回聚合 ID 以保证同一聚合的事件有序.
+type PartitionKeyer interface {
+	PartitionKey() string
+}
+
+// JSONMapper 返回以 JSON 序列化事件的 MessageMapper.
+//
+// topic 为 nil 时使用事件名称作为主题. 事件实现 PartitionKeyer 时使用其返回值作为消息键.
+func JSONMapper(topic TopicFunc) MessageMapper {
+	if topic == nil {
+		topic = func(event domain.DomainEvent) string { return event.EventName() }
+	}
+
+	return func(event domain.DomainEvent) (*messaging.Message, error) {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+
+		msg := &messaging.Message{
+			Topic: topic(event),
+			Value: value,
+		}
+		if keyer, ok := event.(PartitionKeyer); ok {
+			msg.Key = []byte(keyer.PartitionKey())
+		}
+		return msg, nil
+	}
+}
+
+// toMessage 将发件箱记录转换为消息.
+func (r *Record) toMessage() *messaging.Message {
+	headers := make(map[string]string, len(r.Headers)+3)
+	for k, v := range r.Headers {
+		headers[k] = v
+	}
+	headers[HeaderMessageID] = r.MessageID
+	headers[HeaderEventName] = r.EventName
+	headers[HeaderOccurredTime] = r.OccurredTime.Format(time.RFC3339Nano)
+
+	return &messaging.Message{
+		Topic:     r.Topic,
+		Key:       r.Key,
+		Value:     r.Payload,
+		Headers:   headers,
+		Timestamp: r.OccurredTime,
+	}
+}
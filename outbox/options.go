@@ -0,0 +1,179 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// Option 发件箱配置选项.
+type Option func(*options)
+
+// options 发件箱配置.
+type options struct {
+	table  string
+	mapper MessageMapper
+}
+
+// applyOptions 应用配置选项.
+func applyOptions(opts []Option) *options {
+	o := &options{
+		table:  DefaultTable,
+		mapper: JSONMapper(nil),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTable 设置发件箱表名.
+//
+// 默认: outbox_messages.
+func WithTable(table string) Option {
+	return func(o *options) {
+		if table != "" {
+			o.table = table
+		}
+	}
+}
+
+// WithMapper 设置领域事件到消息的转换函数.
+//
+// 默认: JSONMapper(nil)，以事件名称作为主题.
+func WithMapper(mapper MessageMapper) Option {
+	return func(o *options) {
+		if mapper != nil {
+			o.mapper = mapper
+		}
+	}
+}
+
+// RelayOption Relay 配置选项.
+type RelayOption func(*relayOptions)
+
+// relayOptions Relay 配置.
+type relayOptions struct {
+	name            string
+	logger          logger.Logger
+	pollInterval    time.Duration
+	batchSize       int
+	lockTimeout     time.Duration
+	maxAttempts     int
+	maxBackoff      time.Duration
+	retention       time.Duration
+	cleanupInterval time.Duration
+}
+
+// applyRelayOptions 应用 Relay 配置选项.
+func applyRelayOptions(opts []RelayOption) *relayOptions {
+	o := &relayOptions{
+		name:            "outbox-relay",
+		pollInterval:    time.Second,
+		batchSize:       100,
+		lockTimeout:     30 * time.Second,
+		maxAttempts:     10,
+		maxBackoff:      5 * time.Minute,
+		retention:       24 * time.Hour,
+		cleanupInterval: time.Hour,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRelayName 设置 Relay 名称，用于日志和 app.Server.Name.
+//
+// 默认: outbox-relay.
+func WithRelayName(name string) RelayOption {
+	return func(o *relayOptions) {
+		o.name = name
+	}
+}
+
+// WithRelayLogger 设置日志记录器.
+func WithRelayLogger(log logger.Logger) RelayOption {
+	return func(o *relayOptions) {
+		o.logger = log
+	}
+}
+
+// WithPollInterval 设置轮询间隔.
+//
+// 一批消息满载时不等待，立即拉取下一批.
+// 默认: 1 秒.
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// WithBatchSize 设置每批拉取的消息数.
+//
+// 默认: 100.
+func WithBatchSize(n int) RelayOption {
+	return func(o *relayOptions) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithLockTimeout 设置投递租约时长.
+//
+// Relay 崩溃后，其持有的消息在租约到期后由其他 Relay 接管.
+// 应大于一批消息的投递耗时.
+// 默认: 30 秒.
+func WithLockTimeout(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		if d > 0 {
+			o.lockTimeout = d
+		}
+	}
+}
+
+// WithMaxAttempts 设置最大投递次数，超过后消息标记为 StatusFailed.
+//
+// n <= 0 表示无限重试.
+// 默认: 10.
+func WithMaxAttempts(n int) RelayOption {
+	return func(o *relayOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithMaxBackoff 设置投递失败后重试间隔的上限.
+//
+// 重试间隔从轮询间隔开始按 2 的幂增长.
+// 默认: 5 分钟.
+func WithMaxBackoff(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		if d > 0 {
+			o.maxBackoff = d
+		}
+	}
+}
+
+// WithRetention 设置已投递消息的保留时长，到期后由清理任务删除.
+//
+// d <= 0 表示投递成功后立即删除.
+// 默认: 24 小时.
+func WithRetention(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.retention = d
+	}
+}
+
+// WithCleanupInterval 设置清理任务的执行间隔.
+//
+// 默认: 1 小时.
+func WithCleanupInterval(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		if d > 0 {
+			o.cleanupInterval = d
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package outbox 提供事务性发件箱（Transactional Outbox）.
+//
+// 领域事件与业务数据在同一个数据库事务中写入发件箱表，
+// 再由后台 Relay 轮询发件箱并通过 messaging.Producer 投递，
+// 避免"提交成功但消息丢失"或"消息已发送但事务回滚"的问题.
+//
+// 投递语义为至少一次（at-least-once），消费方需要按 HeaderMessageID 去重.
+//
+// 基本用法:
+//
+//	ob := outbox.New(db)
+//	_ = ob.AutoMigrate(ctx)
+//
+//	// 业务事务内保存聚合和领域事件
+//	err := db.Transaction(func(tx *gorm.DB) error {
+//	    if err := tx.Save(order).Error; err != nil {
+//	        return err
+//	    }
+//	    return ob.SaveAggregate(ctx, tx, order)
+//	})
+//
+//	// 后台投递，Relay 实现 app.Server
+//	relay := outbox.NewRelay(ob, producer, outbox.WithRelayLogger(log))
+//	application.Use(relay)
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Tsukikage7/microservice-kit/domain"
+)
+
+// DefaultTable 默认发件箱表名.
+const DefaultTable = "outbox_messages"
+
+// Status 发件箱消息状态.
+type Status string
+
+// 发件箱消息状态常量.
+const (
+	// StatusPending 等待投递
+	StatusPending Status = "pending"
+	// StatusPublished 已投递
+	StatusPublished Status = "published"
+	// StatusFailed 超过最大重试次数，不再投递
+	StatusFailed Status = "failed"
+)
+
+// Record 发件箱表记录.
+type Record struct {
+	// ID 自增主键，决定投递顺序
+	ID uint64 `gorm:"primaryKey;autoIncrement"`
+
+	// MessageID 消息唯一标识，投递时写入 HeaderMessageID
+	MessageID string `gorm:"column:message_id;size:64;uniqueIndex"`
+
+	// EventName 领域事件名称
+	EventName string `gorm:"column:event_name;size:255"`
+
+	// Topic 消息主题
+	Topic string `gorm:"column:topic;size:255;not null"`
+
+	// Key 消息键
+	Key []byte `gorm:"column:msg_key"`
+
+	// Payload 消息内容
+	Payload []byte `gorm:"column:payload"`
+
+	// Headers 消息头
+	Headers map[string]string `gorm:"column:headers;serializer:json;type:text"`
+
+	// Status 投递状态
+	Status Status `gorm:"column:status;size:16;not null;index:idx_outbox_status_available,priority:1"`
+
+	// Attempts 已尝试投递次数
+	Attempts int `gorm:"column:attempts;not null;default:0"`
+
+	// LastError 最近一次投递失败的原因
+	LastError string `gorm:"column:last_error;type:text"`
+
+	// AvailableTime 最早可投递时间，失败重试时推后
+	AvailableTime time.Time `gorm:"column:available_time;index:idx_outbox_status_available,priority:2"`
+
+	// LockedBy 持有投递租约的 Relay 批次标识
+	LockedBy string `gorm:"column:locked_by;size:64;index"`
+
+	// LockedUntil 投递租约到期时间，到期后其他 Relay 可以接管
+	LockedUntil *time.Time `gorm:"column:locked_until"`
+
+	// OccurredTime 领域事件发生时间
+	OccurredTime time.Time `gorm:"column:occurred_time"`
+
+	// CreatedTime 写入时间
+	CreatedTime time.Time `gorm:"column:created_time;autoCreateTime"`
+
+	// PublishedTime 投递成功时间
+	PublishedTime *time.Time `gorm:"column:published_time;index"`
+}
+
+// Aggregate 可以提供待发布领域事件的聚合.
+//
+// domain.AggregateRoot 的指针满足该接口.
+type Aggregate interface {
+	DomainEvents() []domain.DomainEvent
+	ClearDomainEvents()
+}
+
+// Outbox 事务性发件箱.
+type Outbox struct {
+	db   *gorm.DB
+	opts *options
+}
+
+// New 创建发件箱，db 供 Relay 轮询和 AutoMigrate 使用.
+func New(db *gorm.DB, opts ...Option) *Outbox {
+	if db == nil {
+		panic("outbox: db 不能为空")
+	}
+	return &Outbox{db: db, opts: applyOptions(opts)}
+}
+
+// Table 返回发件箱表名.
+func (o *Outbox) Table() string {
+	return o.opts.table
+}
+
+// AutoMigrate 创建或更新发件箱表结构.
+func (o *Outbox) AutoMigrate(ctx context.Context) error {
+	return o.db.WithContext(ctx).Table(o.opts.table).AutoMigrate(&Record{})
+}
+
+// Save 在事务 tx 中保存领域事件.
+//
+// tx 必须是业务数据所在的事务，事务提交后事件才对 Relay 可见.
+func (o *Outbox) Save(ctx context.Context, tx *gorm.DB, events ...domain.DomainEvent) error {
+	if tx == nil {
+		return ErrNilTx
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	records := make([]*Record, 0, len(events))
+	for _, event := range events {
+		msg, err := o.opts.mapper(event)
+		if err != nil {
+			return err
+		}
+		if msg == nil || msg.Topic == "" {
+			return ErrEmptyTopic
+		}
+
+		records = append(records, &Record{
+			MessageID:     uuid.NewString(),
+			EventName:     event.EventName(),
+			Topic:         msg.Topic,
+			Key:           msg.Key,
+			Payload:       msg.Value,
+			Headers:       msg.Headers,
+			Status:        StatusPending,
+			AvailableTime: now,
+			OccurredTime:  event.OccurredTime(),
+		})
+	}
+
+	return tx.WithContext(ctx).Table(o.opts.table).Create(records).Error
+}
+
+// SaveAggregate 在事务 tx 中保存聚合的领域事件，成功后清除聚合上的事件.
+func (o *Outbox) SaveAggregate(ctx context.Context, tx *gorm.DB, agg Aggregate) error {
+	if err := o.Save(ctx, tx, agg.DomainEvents()...); err != nil {
+		return err
+	}
+	agg.ClearDomainEvents()
+	return nil
+}
+
+// table 返回绑定发件箱表的查询.
+func (o *Outbox) table(ctx context.Context) *gorm.DB {
+	return o.db.WithContext(ctx).Table(o.opts.table)
+}
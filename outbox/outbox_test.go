@@ -0,0 +1,204 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/Tsukikage7/microservice-kit/domain"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+)
+
+// orderCreated 测试用领域事件.
+type orderCreated struct {
+	domain.BaseEvent
+	OrderID string `json:"order_id"`
+}
+
+func (e orderCreated) PartitionKey() string { return e.OrderID }
+
+func newOrderCreated(id string) orderCreated {
+	return orderCreated{BaseEvent: domain.NewBaseEvent("OrderCreated"), OrderID: id}
+}
+
+// order 测试用聚合.
+type order struct {
+	domain.AggregateRoot[string]
+}
+
+// orderModel 测试用业务表.
+type orderModel struct {
+	ID string `gorm:"primaryKey"`
+}
+
+// mockProducer 记录发送的消息，可按需返回错误.
+type mockProducer struct {
+	mu   sync.Mutex
+	sent []*messaging.Message
+	err  error
+}
+
+func (p *mockProducer) SendMessage(_ context.Context, msg *messaging.Message) (*messaging.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.sent = append(p.sent, msg)
+	return msg, nil
+}
+
+func (p *mockProducer) SendBatch(ctx context.Context, msgs []*messaging.Message) ([]*messaging.Message, error) {
+	for _, msg := range msgs {
+		if _, err := p.SendMessage(ctx, msg); err != nil {
+			return nil, err
+		}
+	}
+	return msgs, nil
+}
+
+func (p *mockProducer) Close() error { return nil }
+
+func (p *mockProducer) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *mockProducer) messages() []*messaging.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*messaging.Message(nil), p.sent...)
+}
+
+// newTestDB 创建独立的内存 SQLite 数据库.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&orderModel{}))
+	return db
+}
+
+func newTestOutbox(t *testing.T, opts ...Option) (*Outbox, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	ob := New(db, opts...)
+	require.NoError(t, ob.AutoMigrate(context.Background()))
+	return ob, db
+}
+
+func countRecords(t *testing.T, ob *Outbox, status Status) int64 {
+	t.Helper()
+	var n int64
+	require.NoError(t, ob.table(context.Background()).Where("status = ?", status).Count(&n).Error)
+	return n
+}
+
+func TestOutbox_SaveAggregate(t *testing.T) {
+	ctx := context.Background()
+	ob, db := newTestOutbox(t)
+
+	agg := &order{AggregateRoot: domain.NewAggregateRoot("order-1")}
+	agg.RaiseEvent(newOrderCreated("order-1"))
+	agg.RaiseEvent(domain.NewBaseEvent("OrderPaid"))
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&orderModel{ID: agg.ID()}).Error; err != nil {
+			return err
+		}
+		return ob.SaveAggregate(ctx, tx, agg)
+	})
+	require.NoError(t, err)
+	assert.Empty(t, agg.DomainEvents())
+
+	var records []Record
+	require.NoError(t, ob.table(ctx).Order("id").Find(&records).Error)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "OrderCreated", records[0].Topic)
+	assert.Equal(t, "OrderCreated", records[0].EventName)
+	assert.Equal(t, []byte("order-1"), records[0].Key)
+	assert.JSONEq(t, `{"order_id":"order-1"}`, string(records[0].Payload))
+	assert.Equal(t, StatusPending, records[0].Status)
+	assert.NotEmpty(t, records[0].MessageID)
+	assert.Nil(t, records[1].Key)
+	assert.NotEqual(t, records[0].MessageID, records[1].MessageID)
+}
+
+func TestOutbox_RollbackDiscardsEvents(t *testing.T) {
+	ctx := context.Background()
+	ob, db := newTestOutbox(t)
+
+	errBusiness := errors.New("business failed")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := ob.Save(ctx, tx, newOrderCreated("order-1")); err != nil {
+			return err
+		}
+		return errBusiness
+	})
+	require.ErrorIs(t, err, errBusiness)
+	assert.Zero(t, countRecords(t, ob, StatusPending))
+}
+
+func TestOutbox_Save(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("自定义表名和 Mapper", func(t *testing.T) {
+		ob, db := newTestOutbox(t,
+			WithTable("order_outbox"),
+			WithMapper(func(event domain.DomainEvent) (*messaging.Message, error) {
+				return &messaging.Message{
+					Topic:   "orders",
+					Value:   []byte(event.EventName()),
+					Headers: map[string]string{"source": "test"},
+				}, nil
+			}),
+		)
+		assert.Equal(t, "order_outbox", ob.Table())
+
+		require.NoError(t, ob.Save(ctx, db, domain.NewBaseEvent("OrderCreated")))
+
+		var record Record
+		require.NoError(t, db.Table("order_outbox").First(&record).Error)
+		assert.Equal(t, "orders", record.Topic)
+		assert.Equal(t, map[string]string{"source": "test"}, record.Headers)
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		ob, db := newTestOutbox(t, WithMapper(func(domain.DomainEvent) (*messaging.Message, error) {
+			return &messaging.Message{}, nil
+		}))
+
+		assert.ErrorIs(t, ob.Save(ctx, nil, domain.NewBaseEvent("A")), ErrNilTx)
+		assert.ErrorIs(t, ob.Save(ctx, db, domain.NewBaseEvent("A")), ErrEmptyTopic)
+		assert.NoError(t, ob.Save(ctx, db))
+	})
+
+	t.Run("Mapper 错误", func(t *testing.T) {
+		errMap := errors.New("map failed")
+		ob, db := newTestOutbox(t, WithMapper(func(domain.DomainEvent) (*messaging.Message, error) {
+			return nil, errMap
+		}))
+
+		agg := &order{AggregateRoot: domain.NewAggregateRoot("order-1")}
+		agg.RaiseEvent(domain.NewBaseEvent("A"))
+		assert.ErrorIs(t, ob.SaveAggregate(ctx, db, agg), errMap)
+		assert.Len(t, agg.DomainEvents(), 1, "保存失败时不清除事件")
+	})
+}
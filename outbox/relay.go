@@ -0,0 +1,259 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+)
+
+// Relay 发件箱投递器，轮询发件箱表并通过 messaging.Producer 投递消息.
+//
+// Relay 实现 app.Server，可直接注册到 app.Application.
+// 多个实例可以同时运行：每批消息通过租约（locked_by/locked_until）认领，
+// 同一时刻只有一个 Relay 投递同一条消息，Relay 崩溃后租约到期由其他实例接管.
+//
+// 消息按写入顺序投递. 失败的消息按指数退避重试，重试期间后续消息照常投递，
+// 因此出现失败时不保证顺序；超过最大次数后标记为 StatusFailed，不再投递.
+type Relay struct {
+	outbox   *Outbox
+	producer messaging.Producer
+	opts     *relayOptions
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+// NewRelay 创建发件箱投递器.
+func NewRelay(ob *Outbox, producer messaging.Producer, opts ...RelayOption) *Relay {
+	if ob == nil {
+		panic("outbox: outbox 不能为空")
+	}
+	if producer == nil {
+		panic("outbox: producer 不能为空")
+	}
+	return &Relay{
+		outbox:   ob,
+		producer: producer,
+		opts:     applyRelayOptions(opts),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start 启动投递循环，阻塞直到 ctx 取消或调用 Stop.
+func (r *Relay) Start(ctx context.Context) error {
+	if !r.started.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer close(r.done)
+
+	r.logInfo("[Outbox] 投递器启动")
+
+	poll := time.NewTicker(r.opts.pollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.opts.cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.stopCh:
+			return nil
+		case <-poll.C:
+		case <-cleanup.C:
+			if _, err := r.Cleanup(ctx); err != nil {
+				r.logError("[Outbox] 清理已投递消息失败", err)
+			}
+		}
+	}
+}
+
+// Stop 停止投递循环，等待当前批次投递完成.
+func (r *Relay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+	if !r.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-r.done:
+		r.logInfo("[Outbox] 投递器停止")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name 返回投递器名称.
+func (r *Relay) Name() string { return r.opts.name }
+
+// Addr 返回发件箱表名.
+func (r *Relay) Addr() string { return r.outbox.Table() }
+
+// drain 连续处理满载的批次，直到发件箱暂时为空或出错.
+func (r *Relay) drain(ctx context.Context) {
+	for {
+		n, err := r.Process(ctx)
+		if err != nil {
+			r.logError("[Outbox] 投递失败", err)
+			return
+		}
+		if n < r.opts.batchSize {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stopCh:
+			return
+		default:
+		}
+	}
+}
+
+// Process 认领并投递一批消息，返回本批认领的消息数.
+//
+// 通常由 Start 循环调用，也可用于测试或手动触发.
+func (r *Relay) Process(ctx context.Context) (int, error) {
+	token, records, err := r.claim(ctx)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	for _, record := range records {
+		if _, sendErr := r.producer.SendMessage(ctx, record.toMessage()); sendErr != nil {
+			err = r.markFailed(ctx, token, record, sendErr)
+		} else {
+			err = r.markPublished(ctx, token, record)
+		}
+		if err != nil {
+			return len(records), err
+		}
+	}
+	return len(records), nil
+}
+
+// Cleanup 删除超过保留时长的已投递消息，返回删除的条数.
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-r.opts.retention)
+	res := r.outbox.table(ctx).
+		Where("status = ? AND published_time < ?", StatusPublished, before).
+		Delete(&Record{})
+	return res.RowsAffected, res.Error
+}
+
+// claim 认领一批可投递的消息.
+//
+// 先查询候选 ID，再通过条件更新写入租约，只有更新成功的消息才归本批次所有，
+// 不依赖 SELECT ... FOR UPDATE SKIP LOCKED，可以在 MySQL、PostgreSQL 和 SQLite 上使用.
+func (r *Relay) claim(ctx context.Context) (string, []*Record, error) {
+	now := time.Now()
+
+	var ids []uint64
+	err := r.outbox.table(ctx).
+		Where("status = ? AND available_time <= ?", StatusPending, now).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Order("id").
+		Limit(r.opts.batchSize).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return "", nil, err
+	}
+
+	token := uuid.NewString()
+	until := now.Add(r.opts.lockTimeout)
+	res := r.outbox.table(ctx).
+		Where("id IN ?", ids).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Updates(map[string]any{"locked_by": token, "locked_until": until})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return "", nil, res.Error
+	}
+
+	var records []*Record
+	err = r.outbox.table(ctx).
+		Where("locked_by = ? AND status = ?", token, StatusPending).
+		Order("id").
+		Find(&records).Error
+	return token, records, err
+}
+
+// markPublished 标记消息已投递，保留时长为 0 时直接删除.
+func (r *Relay) markPublished(ctx context.Context, token string, record *Record) error {
+	query := r.outbox.table(ctx).Where("id = ? AND locked_by = ?", record.ID, token)
+	if r.opts.retention <= 0 {
+		return query.Delete(&Record{}).Error
+	}
+
+	return query.Updates(map[string]any{
+		"status":         StatusPublished,
+		"attempts":       record.Attempts + 1,
+		"last_error":     "",
+		"published_time": time.Now(),
+		"locked_by":      "",
+		"locked_until":   nil,
+	}).Error
+}
+
+// markFailed 记录投递失败，按指数退避推迟下次投递.
+func (r *Relay) markFailed(ctx context.Context, token string, record *Record, sendErr error) error {
+	attempts := record.Attempts + 1
+	status := StatusPending
+	if r.opts.maxAttempts > 0 && attempts >= r.opts.maxAttempts {
+		status = StatusFailed
+	}
+
+	if r.opts.logger != nil {
+		r.opts.logger.With(
+			logger.String("relay", r.opts.name),
+			logger.String("message_id", record.MessageID),
+			logger.String("topic", record.Topic),
+			logger.Int("attempts", attempts),
+			logger.Err(sendErr),
+		).Warn("[Outbox] 消息投递失败")
+	}
+
+	return r.outbox.table(ctx).
+		Where("id = ? AND locked_by = ?", record.ID, token).
+		Updates(map[string]any{
+			"status":         status,
+			"attempts":       attempts,
+			"last_error":     sendErr.Error(),
+			"available_time": time.Now().Add(r.backoff(attempts)),
+			"locked_by":      "",
+			"locked_until":   nil,
+		}).Error
+}
+
+// backoff 返回第 attempts 次失败后的重试间隔.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.opts.pollInterval
+	for i := 1; i < attempts && d < r.opts.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.opts.maxBackoff)
+}
+
+func (r *Relay) logInfo(msg string) {
+	if r.opts.logger != nil {
+		r.opts.logger.With(logger.String("relay", r.opts.name)).Info(msg)
+	}
+}
+
+func (r *Relay) logError(msg string, err error) {
+	if r.opts.logger != nil {
+		r.opts.logger.With(logger.String("relay", r.opts.name), logger.Err(err)).Error(msg)
+	}
+}
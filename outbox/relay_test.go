@@ -0,0 +1,198 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveEvents(t *testing.T, ob *Outbox, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		require.NoError(t, ob.Save(context.Background(), ob.db, newOrderCreated(id)))
+	}
+}
+
+func TestRelay_Process(t *testing.T) {
+	ctx := context.Background()
+	ob, _ := newTestOutbox(t)
+	producer := &mockProducer{}
+	relay := NewRelay(ob, producer, WithBatchSize(2))
+
+	saveEvents(t, ob, "order-1", "order-2", "order-3")
+
+	n, err := relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	msgs := producer.messages()
+	require.Len(t, msgs, 3)
+	for i, msg := range msgs {
+		assert.Equal(t, "OrderCreated", msg.Topic)
+		assert.Equal(t, []byte(fmt.Sprintf("order-%d", i+1)), msg.Key, "按写入顺序投递")
+		assert.NotEmpty(t, msg.Headers[HeaderMessageID])
+		assert.Equal(t, "OrderCreated", msg.Headers[HeaderEventName])
+		assert.NotEmpty(t, msg.Headers[HeaderOccurredTime])
+	}
+	assert.Equal(t, int64(3), countRecords(t, ob, StatusPublished))
+}
+
+func TestRelay_Retry(t *testing.T) {
+	ctx := context.Background()
+	ob, _ := newTestOutbox(t)
+	producer := &mockProducer{err: errors.New("broker down")}
+	relay := NewRelay(ob, producer, WithMaxAttempts(3))
+
+	saveEvents(t, ob, "order-1")
+
+	n, err := relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var record Record
+	require.NoError(t, ob.table(ctx).First(&record).Error)
+	assert.Equal(t, StatusPending, record.Status)
+	assert.Equal(t, 1, record.Attempts)
+	assert.Equal(t, "broker down", record.LastError)
+	assert.Empty(t, record.LockedBy)
+
+	// 退避期间不会重复投递
+	n, err = relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// 退避结束后恢复投递
+	require.NoError(t, ob.table(ctx).Where("id = ?", record.ID).
+		Update("available_time", time.Now().Add(-time.Second)).Error)
+	producer.setErr(nil)
+	n, err = relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, producer.messages(), 1)
+
+	require.NoError(t, ob.table(ctx).First(&record).Error)
+	assert.Equal(t, StatusPublished, record.Status)
+	assert.Equal(t, 2, record.Attempts)
+	assert.Empty(t, record.LastError)
+}
+
+func TestRelay_MaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	ob, _ := newTestOutbox(t)
+	producer := &mockProducer{err: errors.New("broker down")}
+	relay := NewRelay(ob, producer, WithMaxAttempts(2), WithMaxBackoff(time.Nanosecond))
+
+	saveEvents(t, ob, "order-1")
+
+	for range 2 {
+		_, err := relay.Process(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), countRecords(t, ob, StatusFailed))
+
+	n, err := relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "失败的消息不再投递")
+}
+
+func TestRelay_Lease(t *testing.T) {
+	ctx := context.Background()
+	ob, _ := newTestOutbox(t)
+	producer := &mockProducer{}
+	relay := NewRelay(ob, producer)
+
+	saveEvents(t, ob, "order-1")
+
+	// 模拟其他 Relay 持有未过期的租约
+	require.NoError(t, ob.table(ctx).Where("1 = 1").Updates(map[string]any{
+		"locked_by":    "other",
+		"locked_until": time.Now().Add(time.Minute),
+	}).Error)
+	n, err := relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// 租约过期后接管
+	require.NoError(t, ob.table(ctx).Where("1 = 1").
+		Update("locked_until", time.Now().Add(-time.Second)).Error)
+	n, err = relay.Process(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, producer.messages(), 1)
+}
+
+func TestRelay_Cleanup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("按保留时长清理", func(t *testing.T) {
+		ob, _ := newTestOutbox(t)
+		relay := NewRelay(ob, &mockProducer{}, WithRetention(time.Hour))
+
+		saveEvents(t, ob, "order-1", "order-2")
+		_, err := relay.Process(ctx)
+		require.NoError(t, err)
+
+		deleted, err := relay.Cleanup(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+
+		require.NoError(t, ob.table(ctx).Where("1 = 1").
+			Update("published_time", time.Now().Add(-2*time.Hour)).Error)
+		deleted, err = relay.Cleanup(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+	})
+
+	t.Run("保留时长为 0 时投递后删除", func(t *testing.T) {
+		ob, _ := newTestOutbox(t)
+		relay := NewRelay(ob, &mockProducer{}, WithRetention(0))
+
+		saveEvents(t, ob, "order-1")
+		_, err := relay.Process(ctx)
+		require.NoError(t, err)
+
+		var n int64
+		require.NoError(t, ob.table(ctx).Count(&n).Error)
+		assert.Zero(t, n)
+	})
+}
+
+func TestRelay_StartStop(t *testing.T) {
+	ob, _ := newTestOutbox(t)
+	producer := &mockProducer{}
+	relay := NewRelay(ob, producer, WithPollInterval(10*time.Millisecond), WithRelayName("orders-relay"))
+	assert.Equal(t, "orders-relay", relay.Name())
+	assert.Equal(t, DefaultTable, relay.Addr())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- relay.Start(context.Background()) }()
+
+	saveEvents(t, ob, "order-1")
+	require.Eventually(t, func() bool {
+		return len(producer.messages()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, relay.Stop(stopCtx))
+	require.NoError(t, <-errCh)
+}
+
+func TestNewRelay_Panics(t *testing.T) {
+	ob, _ := newTestOutbox(t)
+	assert.Panics(t, func() { NewRelay(nil, &mockProducer{}) })
+	assert.Panics(t, func() { NewRelay(ob, nil) })
+	assert.Panics(t, func() { New(nil) })
+	assert.NotPanics(t, func() { NewRelay(ob, &mockProducer{}).Stop(context.Background()) })
+}
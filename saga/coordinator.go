@@ -0,0 +1,321 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/messaging"
+)
+
+// RecoveryPolicy 执行中断的 Saga 的恢复策略.
+type RecoveryPolicy int
+
+const (
+	// RecoverResume 从中断的步骤重新执行，步骤必须幂等（默认）.
+	RecoverResume RecoveryPolicy = iota
+
+	// RecoverCompensate 补偿已完成的步骤和中断的步骤.
+	RecoverCompensate
+)
+
+// Coordinator Saga 协调器，负责处理异步步骤的回复和恢复中断的 Saga.
+//
+// 注册的 Saga 必须与 Coordinator 使用同一个 Store，且 Store 需要支持 List 和版本检查
+// （MemoryStore、GORMStore、MongoStore）.
+// 恢复任务定期扫描执行中和补偿中的 Saga:
+//   - 异步步骤等待回复超过 WithReplyTimeout：补偿，包括等待中的步骤
+//   - 超过 WithStaleAfter 没有更新状态：按 RecoveryPolicy 重新执行或补偿；
+//     超过 Saga 的 WithTimeout 时总是补偿
+//   - 补偿中断：继续补偿
+//
+// Coordinator 实现 app.Server，可直接注册到 app.Application.
+// 恢复前先以读取到的版本保存状态认领 Saga，继续执行时每次保存都检查版本，
+// 因此多个实例的恢复任务和回复处理不会同时继续执行同一个 Saga.
+// 执行可能在任意步骤中断后重新执行，步骤和补偿操作仍需幂等.
+type Coordinator struct {
+	store Store
+	opts  *coordinatorOptions
+
+	mu    sync.RWMutex
+	sagas map[string]*Saga
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	done     chan struct{}
+}
+
+// NewCoordinator 创建 Saga 协调器.
+func NewCoordinator(store Store, opts ...CoordinatorOption) *Coordinator {
+	if store == nil {
+		panic("saga: store 不能为空")
+	}
+	return &Coordinator{
+		store:  store,
+		opts:   applyCoordinatorOptions(opts),
+		sagas:  make(map[string]*Saga),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Register 注册 Saga，相同名称重复注册时覆盖.
+func (c *Coordinator) Register(sagas ...*Saga) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range sagas {
+		if s == nil {
+			panic("saga: saga 不能为空")
+		}
+		c.sagas[s.name] = s
+	}
+}
+
+// saga 按名称查找已注册的 Saga.
+func (c *Coordinator) saga(name string) *Saga {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sagas[name]
+}
+
+// HandleReply 处理异步步骤的回复，匹配成功后继续执行 Saga.
+//
+// 重复、过期或无法匹配的回复被忽略并返回 nil；Saga 继续等待其他回复、已被其他执行者接管
+// 或执行失败并完成补偿时同样返回 nil. 读写状态失败、Saga 未注册等其他错误原样返回，
+// 消费者可据此重新投递.
+func (c *Coordinator) HandleReply(ctx context.Context, msg *messaging.Message) error {
+	id := msg.Headers[HeaderSagaID]
+	correlationID := msg.Headers[HeaderCorrelationID]
+	if id == "" || correlationID == "" {
+		c.logDebug("[Saga] 忽略缺少关联头的回复", id, msg.Headers[HeaderSagaStep])
+		return nil
+	}
+
+	state, err := c.store.Get(ctx, id)
+	if errors.Is(err, ErrSagaNotFound) {
+		c.logDebug("[Saga] 忽略未知 Saga 的回复", id, msg.Headers[HeaderSagaStep])
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	index := waitingStep(state, correlationID)
+	if state.Status != SagaStatusRunning || index < 0 {
+		c.logDebug("[Saga] 忽略重复或过期的回复", id, msg.Headers[HeaderSagaStep])
+		return nil
+	}
+
+	s := c.saga(state.Name)
+	if s == nil {
+		return fmt.Errorf("%w: %s", ErrSagaNotRegistered, state.Name)
+	}
+
+	err = s.applyReply(ctx, state, index, msg)
+	c.logOutcome(state, err)
+	if errors.Is(err, ErrSagaPending) || errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrSagaFailed) {
+		return nil
+	}
+	return err
+}
+
+// ReplyHandler 返回处理回复的消息处理函数，用于 messaging.Consumer.
+//
+// 示例:
+//
+//	consumer.Consume(ctx, []string{"order-saga-replies"}, coordinator.ReplyHandler(ctx))
+func (c *Coordinator) ReplyHandler(ctx context.Context) messaging.MessageHandler {
+	return func(msg *messaging.Message) error {
+		return c.HandleReply(ctx, msg)
+	}
+}
+
+// waitingStep 返回等待指定关联 ID 回复的步骤索引，不存在时返回 -1.
+func waitingStep(state *State, correlationID string) int {
+	for i, r := range state.StepResults {
+		if r.Status == StepStatusWaiting && r.CorrelationID == correlationID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Recover 扫描一次执行中和补偿中的 Saga，返回恢复的数量.
+//
+// 通常由 Start 启动的恢复任务定期调用，也可用于测试或手动触发.
+func (c *Coordinator) Recover(ctx context.Context) (int, error) {
+	recovered := 0
+	for _, status := range []SagaStatus{SagaStatusRunning, SagaStatusCompensating} {
+		states, err := c.store.List(ctx, status, c.opts.batchSize)
+		if err != nil {
+			return recovered, err
+		}
+		for _, state := range states {
+			if ctx.Err() != nil {
+				return recovered, ctx.Err()
+			}
+			if c.recoverState(ctx, state) {
+				recovered++
+			}
+		}
+	}
+	return recovered, nil
+}
+
+// recoverState 恢复单个 Saga，未达到恢复条件或被其他执行者抢先时返回 false.
+func (c *Coordinator) recoverState(ctx context.Context, state *State) bool {
+	s := c.saga(state.Name)
+	if s == nil {
+		c.logWarn("[Saga] 恢复跳过未注册的 Saga", state, ErrSagaNotRegistered)
+		return false
+	}
+	if len(state.StepResults) != len(s.steps) || state.CurrentStep >= len(s.steps) {
+		c.logWarn("[Saga] 恢复跳过步骤与定义不一致的 Saga", state, ErrInvalidStep)
+		return false
+	}
+
+	var recover func() error
+	data := dataFromState(state)
+	idle := time.Since(state.UpdatedAt)
+	current := state.StepResults[state.CurrentStep]
+
+	switch {
+	case state.Status == SagaStatusCompensating:
+		if idle < c.opts.staleAfter {
+			return false
+		}
+		recover = func() error {
+			return s.finishCompensation(ctx, state, data, errors.New(state.Error))
+		}
+	case current.Status == StepStatusWaiting:
+		if idle < s.opts.replyTimeout {
+			return false
+		}
+		recover = func() error {
+			return s.rollback(ctx, state, data, fmt.Errorf("%w: %s", ErrReplyTimeout, current.StepName))
+		}
+	case idle < c.opts.staleAfter:
+		return false
+	case c.opts.policy == RecoverCompensate || (s.opts.timeout > 0 && time.Since(state.StartedAt) > s.opts.timeout):
+		recover = func() error {
+			return s.rollback(ctx, state, data, ErrSagaInterrupted)
+		}
+	default:
+		recover = func() error {
+			return s.run(ctx, state, data)
+		}
+	}
+
+	// 先以当前版本保存一次认领 Saga，多个恢复任务同时扫描到时只有一个成功
+	state.UpdatedAt = time.Now()
+	if err := c.store.Save(ctx, state); err != nil {
+		if !errors.Is(err, ErrVersionConflict) {
+			c.logWarn("[Saga] 恢复认领失败", state, err)
+		}
+		return false
+	}
+
+	c.logOutcome(state, recover())
+	return true
+}
+
+// Start 启动恢复任务，阻塞直到 ctx 取消或调用 Stop.
+func (c *Coordinator) Start(ctx context.Context) error {
+	if !c.started.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer close(c.done)
+
+	if c.opts.logger != nil {
+		c.opts.logger.Info("[Saga] 协调器启动")
+	}
+
+	ticker := time.NewTicker(c.opts.recoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Recover(ctx); err != nil && ctx.Err() == nil && c.opts.logger != nil {
+			c.opts.logger.With(logger.Err(err)).Error("[Saga] 恢复任务执行失败")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.stopCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop 停止恢复任务，等待正在进行的恢复完成.
+func (c *Coordinator) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	if !c.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-c.done:
+		if c.opts.logger != nil {
+			c.opts.logger.Info("[Saga] 协调器停止")
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name 返回服务名称.
+func (c *Coordinator) Name() string { return "saga-coordinator" }
+
+// Addr 返回服务地址（无监听地址）.
+func (c *Coordinator) Addr() string { return "" }
+
+// logOutcome 记录继续执行后的结果.
+func (c *Coordinator) logOutcome(state *State, err error) {
+	if c.opts.logger == nil {
+		return
+	}
+	log := c.opts.logger.With(
+		logger.String("saga", state.Name),
+		logger.String("saga_id", state.ID),
+		logger.String("status", string(state.Status)),
+	)
+	switch {
+	case err == nil:
+		log.Info("[Saga] 继续执行完成")
+	case errors.Is(err, ErrSagaPending):
+		log.Debug("[Saga] 继续执行，等待异步步骤回复")
+	case errors.Is(err, ErrVersionConflict):
+		log.Debug("[Saga] 已被其他执行者接管")
+	default:
+		log.With(logger.Err(err)).Warn("[Saga] 继续执行失败")
+	}
+}
+
+// logWarn 记录警告日志.
+func (c *Coordinator) logWarn(msg string, state *State, err error) {
+	if c.opts.logger != nil {
+		c.opts.logger.With(
+			logger.String("saga", state.Name),
+			logger.String("saga_id", state.ID),
+			logger.Err(err),
+		).Warn(msg)
+	}
+}
+
+// logDebug 记录调试日志.
+func (c *Coordinator) logDebug(msg, id, step string) {
+	if c.opts.logger != nil {
+		c.opts.logger.With(
+			logger.String("saga_id", id),
+			logger.String("step", step),
+		).Debug(msg)
+	}
+}
@@ -0,0 +1,429 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/messaging"
+)
+
+// mockProducer 记录发送的消息.
+type mockProducer struct {
+	mu   sync.Mutex
+	sent []*messaging.Message
+}
+
+func (p *mockProducer) SendMessage(_ context.Context, msg *messaging.Message) (*messaging.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, msg)
+	return msg, nil
+}
+
+func (p *mockProducer) SendBatch(ctx context.Context, msgs []*messaging.Message) ([]*messaging.Message, error) {
+	for _, msg := range msgs {
+		if _, err := p.SendMessage(ctx, msg); err != nil {
+			return nil, err
+		}
+	}
+	return msgs, nil
+}
+
+func (p *mockProducer) Close() error { return nil }
+
+func (p *mockProducer) messages() []*messaging.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*messaging.Message(nil), p.sent...)
+}
+
+// recorder 记录步骤和补偿的执行顺序.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) step(name string) StepFunc {
+	return func(ctx context.Context, data *Data) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.calls = append(r.calls, name)
+		return nil
+	}
+}
+
+func (r *recorder) comp(name string) CompensateFunc {
+	return CompensateFunc(r.step(name))
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+// reserveCommand 构造预留库存命令.
+func reserveCommand(ctx context.Context, data *Data) (*messaging.Message, error) {
+	return &messaging.Message{
+		Topic: "inventory-commands",
+		Value: []byte(data.GetString("order_id")),
+	}, nil
+}
+
+// newOrderSaga 创建包含异步步骤的测试 Saga.
+func newOrderSaga(rec *recorder, store Store, producer messaging.Producer, opts ...Option) *Saga {
+	return New("create-order").
+		Step("create-order", rec.step("create"), rec.comp("cancel")).
+		AsyncStep("reserve-inventory", reserveCommand,
+			func(ctx context.Context, data *Data, reply *messaging.Message) error {
+				data.Set("reservation_id", string(reply.Value))
+				return nil
+			},
+			rec.comp("release"),
+		).
+		Step("confirm-order", func(ctx context.Context, data *Data) error {
+			if data.GetString("reservation_id") == "" || data.GetInt("quantity") != 3 {
+				return errors.New("missing data")
+			}
+			return rec.step("confirm")(ctx, data)
+		}, nil).
+		Options(append([]Option{WithStore(store), WithProducer(producer)}, opts...)...).
+		Build()
+}
+
+// newOrderData 创建测试数据.
+func newOrderData() *Data {
+	data := NewData()
+	data.Set("order_id", "ORD-1")
+	data.Set("quantity", 3)
+	return data
+}
+
+func TestAsyncStepReply(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	store := NewMemoryStore()
+	producer := &mockProducer{}
+	s := newOrderSaga(rec, store, producer)
+
+	coordinator := NewCoordinator(store, WithCoordinatorLogger(&testLogger{}))
+	coordinator.Register(s)
+
+	err := s.ExecuteWithID(ctx, "saga-1", newOrderData())
+	if !errors.Is(err, ErrSagaPending) {
+		t.Fatalf("expected ErrSagaPending, got %v", err)
+	}
+
+	sent := producer.messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(sent))
+	}
+	cmd := sent[0]
+	if cmd.Headers[HeaderSagaID] != "saga-1" || cmd.Headers[HeaderSagaStep] != "reserve-inventory" {
+		t.Errorf("unexpected command headers: %v", cmd.Headers)
+	}
+
+	state, err := store.Get(ctx, "saga-1")
+	if err != nil {
+		t.Fatalf("get state: %v", err)
+	}
+	if state.Status != SagaStatusRunning || state.StepResults[1].Status != StepStatusWaiting {
+		t.Fatalf("expected waiting step, got %s/%s", state.Status, state.StepResults[1].Status)
+	}
+
+	// 回复在状态恢复后处理，数据从存储中读取
+	if err := coordinator.HandleReply(ctx, NewReply(cmd, "order-replies", []byte("RES-1"))); err != nil {
+		t.Fatalf("handle reply: %v", err)
+	}
+
+	state, _ = store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusCompleted {
+		t.Errorf("expected completed, got %s (%s)", state.Status, state.Error)
+	}
+	if state.Data["reservation_id"] != "RES-1" {
+		t.Errorf("expected reservation_id saved, got %v", state.Data["reservation_id"])
+	}
+
+	// 重复回复被忽略
+	if err := coordinator.HandleReply(ctx, NewReply(cmd, "order-replies", []byte("RES-1"))); err != nil {
+		t.Fatalf("duplicate reply: %v", err)
+	}
+	if calls := rec.list(); len(calls) != 2 || calls[0] != "create" || calls[1] != "confirm" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
+
+func TestAsyncStepFailureReply(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	store := NewMemoryStore()
+	producer := &mockProducer{}
+	s := newOrderSaga(rec, store, producer)
+
+	coordinator := NewCoordinator(store)
+	coordinator.Register(s)
+
+	if err := s.ExecuteWithID(ctx, "saga-1", newOrderData()); !errors.Is(err, ErrSagaPending) {
+		t.Fatalf("expected ErrSagaPending, got %v", err)
+	}
+
+	reply := NewFailureReply(producer.messages()[0], "order-replies", errors.New("out of stock"))
+	if err := coordinator.HandleReply(ctx, reply); err != nil {
+		t.Fatalf("handle reply: %v", err)
+	}
+
+	state, _ := store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusCompensated {
+		t.Errorf("expected compensated, got %s", state.Status)
+	}
+	if state.StepResults[1].Status != StepStatusFailed {
+		t.Errorf("expected failed async step, got %s", state.StepResults[1].Status)
+	}
+
+	// 失败回复表示命令未生效，异步步骤本身不补偿
+	if calls := rec.list(); len(calls) != 2 || calls[1] != "cancel" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
+
+// flakyStore 可让 Save 临时失败的存储.
+type flakyStore struct {
+	Store
+	mu       sync.Mutex
+	failures int
+}
+
+func (s *flakyStore) failNext(n int) {
+	s.mu.Lock()
+	s.failures = n
+	s.mu.Unlock()
+}
+
+func (s *flakyStore) Save(ctx context.Context, state *State) error {
+	s.mu.Lock()
+	if s.failures > 0 {
+		s.failures--
+		s.mu.Unlock()
+		return errors.New("store unavailable")
+	}
+	s.mu.Unlock()
+	return s.Store.Save(ctx, state)
+}
+
+func TestHandleReplySaveFailure(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	store := &flakyStore{Store: NewMemoryStore()}
+	producer := &mockProducer{}
+	s := newOrderSaga(rec, store, producer)
+
+	coordinator := NewCoordinator(store)
+	coordinator.Register(s)
+
+	if err := s.ExecuteWithID(ctx, "saga-1", newOrderData()); !errors.Is(err, ErrSagaPending) {
+		t.Fatalf("expected ErrSagaPending, got %v", err)
+	}
+	reply := NewReply(producer.messages()[0], "order-replies", []byte("RES-1"))
+
+	// 保存失败时返回错误，消费者重新投递
+	store.failNext(1)
+	if err := coordinator.HandleReply(ctx, reply); err == nil {
+		t.Fatal("expected store error")
+	}
+	state, _ := store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusRunning || state.StepResults[1].Status != StepStatusWaiting {
+		t.Fatalf("expected waiting step, got %s/%s", state.Status, state.StepResults[1].Status)
+	}
+
+	if err := coordinator.HandleReply(ctx, reply); err != nil {
+		t.Fatalf("redelivered reply: %v", err)
+	}
+	state, _ = store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusCompleted {
+		t.Errorf("expected completed, got %s (%s)", state.Status, state.Error)
+	}
+}
+
+func TestHandleReplyIgnoresUnknown(t *testing.T) {
+	coordinator := NewCoordinator(NewMemoryStore())
+
+	msg := &messaging.Message{Headers: map[string]string{
+		HeaderSagaID:        "missing",
+		HeaderCorrelationID: "c-1",
+	}}
+	if err := coordinator.HandleReply(context.Background(), msg); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+	if err := coordinator.HandleReply(context.Background(), &messaging.Message{}); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestCoordinatorRecoverReplyTimeout(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	store := NewMemoryStore()
+	s := newOrderSaga(rec, store, &mockProducer{}, WithReplyTimeout(10*time.Millisecond))
+
+	coordinator := NewCoordinator(store)
+	coordinator.Register(s)
+
+	if err := s.ExecuteWithID(ctx, "saga-1", newOrderData()); !errors.Is(err, ErrSagaPending) {
+		t.Fatalf("expected ErrSagaPending, got %v", err)
+	}
+
+	// 未超时不处理
+	if n, err := coordinator.Recover(ctx); err != nil || n != 0 {
+		t.Fatalf("expected nothing recovered, got %d, %v", n, err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if n, err := coordinator.Recover(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 recovered, got %d, %v", n, err)
+	}
+
+	state, _ := store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusCompensated {
+		t.Errorf("expected compensated, got %s", state.Status)
+	}
+	// 等待中的步骤结果未知，同样补偿
+	if calls := rec.list(); len(calls) != 3 || calls[1] != "release" || calls[2] != "cancel" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
+
+// saveInterrupted 模拟进程在执行第二个步骤时崩溃留下的状态.
+func saveInterrupted(t *testing.T, store Store, s *Saga) {
+	t.Helper()
+	state := NewState("saga-1", s.Name(), s.Steps())
+	for i, step := range s.steps {
+		state.StepResults[i].StepName = step.Name
+	}
+	state.Status = SagaStatusRunning
+	state.Data = map[string]any{"order_id": "ORD-1", "quantity": 3, "reservation_id": "RES-1"}
+	state.StepResults[0].Status = StepStatusCompleted
+	state.StepResults[1].Status = StepStatusRunning
+	state.CurrentStep = 1
+	state.UpdatedAt = time.Now().Add(-time.Hour)
+	if err := store.Save(context.Background(), state); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+}
+
+func TestCoordinatorRecoverResume(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	store := NewMemoryStore()
+	s := New("create-order").
+		Step("create", rec.step("create"), rec.comp("cancel")).
+		Step("pay", rec.step("pay"), rec.comp("refund")).
+		Step("confirm", rec.step("confirm"), nil).
+		Options(WithStore(store)).
+		Build()
+	saveInterrupted(t, store, s)
+
+	coordinator := NewCoordinator(store, WithStaleAfter(time.Minute))
+	coordinator.Register(s)
+
+	if n, err := coordinator.Recover(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 recovered, got %d, %v", n, err)
+	}
+
+	state, _ := store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusCompleted {
+		t.Errorf("expected completed, got %s", state.Status)
+	}
+	// 已完成的步骤不重复执行
+	if calls := rec.list(); len(calls) != 2 || calls[0] != "pay" || calls[1] != "confirm" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	if n, _ := coordinator.Recover(ctx); n != 0 {
+		t.Errorf("expected nothing to recover, got %d", n)
+	}
+}
+
+func TestCoordinatorRecoverCompensate(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	store := NewMemoryStore()
+	s := New("create-order").
+		Step("create", rec.step("create"), rec.comp("cancel")).
+		Step("pay", rec.step("pay"), rec.comp("refund")).
+		Step("confirm", rec.step("confirm"), nil).
+		Options(WithStore(store)).
+		Build()
+	saveInterrupted(t, store, s)
+
+	coordinator := NewCoordinator(store, WithRecoveryPolicy(RecoverCompensate))
+	coordinator.Register(s)
+
+	if n, err := coordinator.Recover(ctx); err != nil || n != 1 {
+		t.Fatalf("expected 1 recovered, got %d, %v", n, err)
+	}
+
+	state, _ := store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusCompensated {
+		t.Errorf("expected compensated, got %s", state.Status)
+	}
+	if state.Error != ErrSagaInterrupted.Error() {
+		t.Errorf("unexpected error: %s", state.Error)
+	}
+	// 中断的步骤结果未知，同样补偿
+	if calls := rec.list(); len(calls) != 2 || calls[0] != "refund" || calls[1] != "cancel" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
+
+func TestCoordinatorStartStop(t *testing.T) {
+	coordinator := NewCoordinator(NewMemoryStore(), WithRecoveryInterval(10*time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() { done <- coordinator.Start(context.Background()) }()
+
+	time.Sleep(30 * time.Millisecond)
+	if err := coordinator.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("start: %v", err)
+	}
+}
+
+func TestMemoryStoreList(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	defer store.Close()
+
+	for i, status := range []SagaStatus{SagaStatusRunning, SagaStatusCompleted, SagaStatusRunning} {
+		state := NewState(string(rune('a'+i)), "test", 1)
+		state.Status = status
+		state.UpdatedAt = time.Now().Add(-time.Duration(i) * time.Minute)
+		if err := store.Save(ctx, state); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	running, err := store.List(ctx, SagaStatusRunning, 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(running) != 2 || running[0].ID != "c" {
+		t.Errorf("expected oldest first, got %d states", len(running))
+	}
+
+	limited, _ := store.List(ctx, SagaStatusRunning, 1)
+	if len(limited) != 1 {
+		t.Errorf("expected 1 state, got %d", len(limited))
+	}
+
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrSagaNotFound) {
+		t.Errorf("expected ErrSagaNotFound, got %v", err)
+	}
+}
@@ -13,6 +13,9 @@ var (
 	// ErrStepFailed 步骤执行失败.
 	ErrStepFailed = errors.New("saga: 步骤执行失败")
 
+	// ErrStepTimeout 步骤执行超时.
+	ErrStepTimeout = errors.New("saga: 步骤执行超时")
+
 	// ErrNoSteps 没有定义步骤.
 	ErrNoSteps = errors.New("saga: 没有定义步骤")
 
@@ -22,6 +25,21 @@ var (
 	// ErrSagaNotFound Saga 不存在.
 	ErrSagaNotFound = errors.New("saga: Saga 不存在")
 
+	// ErrSagaPending Saga 已发送异步步骤的命令，等待回复后继续执行.
+	ErrSagaPending = errors.New("saga: 等待异步步骤回复")
+
+	// ErrReplyTimeout 等待异步步骤回复超时.
+	ErrReplyTimeout = errors.New("saga: 等待异步步骤回复超时")
+
+	// ErrSagaInterrupted 执行中断后由恢复任务补偿.
+	ErrSagaInterrupted = errors.New("saga: 执行中断")
+
+	// ErrVersionConflict 状态已被其他执行者更新（乐观并发控制）.
+	ErrVersionConflict = errors.New("saga: 状态版本冲突")
+
+	// ErrSagaNotRegistered Saga 未注册到 Coordinator.
+	ErrSagaNotRegistered = errors.New("saga: Saga 未注册")
+
 	// ErrNilLogger 日志记录器为空.
 	ErrNilLogger = errors.New("saga: 日志记录器不能为空")
 )
@@ -0,0 +1,187 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultStoreTable 数据库存储默认的表名（MongoDB 为集合名）.
+const DefaultStoreTable = "sagas"
+
+// terminalStatuses Cleanup 删除的终态，补偿失败的 Saga 需要人工处理，不会被删除.
+var terminalStatuses = []SagaStatus{SagaStatusCompleted, SagaStatusFailed, SagaStatusCompensated}
+
+// Record Saga 状态表记录.
+type Record struct {
+	// ID Saga ID
+	ID string `gorm:"column:id;primaryKey;size:64"`
+
+	// Name Saga 名称
+	Name string `gorm:"column:name;size:128;not null"`
+
+	// Status Saga 状态
+	Status SagaStatus `gorm:"column:status;size:32;not null;index:idx_saga_status_updated,priority:1"`
+
+	// Version 状态版本，用于乐观并发控制
+	Version int64 `gorm:"column:version;not null"`
+
+	// State 序列化后的完整状态
+	State string `gorm:"column:state;type:text;not null"`
+
+	// StartedAt 开始时间
+	StartedAt time.Time `gorm:"column:started_at"`
+
+	// UpdatedAt 最后更新时间
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime:false;index:idx_saga_status_updated,priority:2"`
+
+	// CompletedAt 结束时间
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+}
+
+// GORMStoreOption GORM 存储配置选项.
+type GORMStoreOption func(*GORMStore)
+
+// WithStoreTable 设置表名，默认 DefaultStoreTable.
+func WithStoreTable(table string) GORMStoreOption {
+	return func(s *GORMStore) {
+		s.table = table
+	}
+}
+
+// GORMStore 基于 GORM 的 Saga 状态存储.
+//
+// 状态按 (status, updated_at) 建立索引，支持 Coordinator 的恢复扫描；
+// 更新时以版本号作为条件，实现乐观并发控制.
+type GORMStore struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewGORMStore 创建基于 GORM 的存储.
+func NewGORMStore(db *gorm.DB, opts ...GORMStoreOption) *GORMStore {
+	if db == nil {
+		panic("saga: db 不能为空")
+	}
+	s := &GORMStore{db: db, table: DefaultStoreTable}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// AutoMigrate 创建或更新状态表结构.
+func (s *GORMStore) AutoMigrate(ctx context.Context) error {
+	return s.db.WithContext(ctx).Table(s.table).AutoMigrate(&Record{})
+}
+
+// Save 保存 Saga 状态，版本不一致时返回 ErrVersionConflict.
+func (s *GORMStore) Save(ctx context.Context, state *State) error {
+	version := state.Version + 1
+	data, err := encodeState(state, version)
+	if err != nil {
+		return err
+	}
+
+	var res *gorm.DB
+	if state.Version == 0 {
+		res = s.session(ctx).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&Record{
+				ID:          state.ID,
+				Name:        state.Name,
+				Status:      state.Status,
+				Version:     version,
+				State:       string(data),
+				StartedAt:   state.StartedAt,
+				UpdatedAt:   state.UpdatedAt,
+				CompletedAt: state.CompletedAt,
+			})
+	} else {
+		res = s.session(ctx).
+			Where("id = ? AND version = ?", state.ID, state.Version).
+			Updates(map[string]any{
+				"status":       state.Status,
+				"version":      version,
+				"state":        string(data),
+				"updated_at":   state.UpdatedAt,
+				"completed_at": state.CompletedAt,
+			})
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	state.Version = version
+	return nil
+}
+
+// Get 获取 Saga 状态.
+func (s *GORMStore) Get(ctx context.Context, id string) (*State, error) {
+	var record Record
+	err := s.session(ctx).Where("id = ?", id).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSagaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record.decode()
+}
+
+// Delete 删除 Saga 状态.
+func (s *GORMStore) Delete(ctx context.Context, id string) error {
+	return s.session(ctx).Where("id = ?", id).Delete(&Record{}).Error
+}
+
+// List 按更新时间升序列出指定状态的 Saga，limit <= 0 时返回全部.
+func (s *GORMStore) List(ctx context.Context, status SagaStatus, limit int) ([]*State, error) {
+	query := s.session(ctx).Where("status = ?", status).Order("updated_at ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var records []*Record
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	states := make([]*State, 0, len(records))
+	for _, record := range records {
+		state, err := record.decode()
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Cleanup 删除 before 之前结束的已完成、失败和已补偿的 Saga，返回删除的条数.
+func (s *GORMStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	res := s.session(ctx).
+		Where("status IN ? AND updated_at < ?", terminalStatuses, before).
+		Delete(&Record{})
+	return res.RowsAffected, res.Error
+}
+
+// session 返回绑定状态表的会话.
+func (s *GORMStore) session(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Table(s.table)
+}
+
+// decode 反序列化记录中的状态，版本以列值为准.
+func (r *Record) decode() (*State, error) {
+	state, err := decodeState([]byte(r.State))
+	if err != nil {
+		return nil, err
+	}
+	state.Version = r.Version
+	return state, nil
+}
@@ -0,0 +1,161 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/storage/mongodb"
+)
+
+// mongoDocument Saga 状态文档.
+type mongoDocument struct {
+	ID          string     `bson:"_id"`
+	Name        string     `bson:"name"`
+	Status      SagaStatus `bson:"status"`
+	Version     int64      `bson:"version"`
+	State       string     `bson:"state"`
+	StartedAt   time.Time  `bson:"started_at"`
+	UpdatedAt   time.Time  `bson:"updated_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty"`
+}
+
+// MongoStore 基于 MongoDB 的 Saga 状态存储.
+//
+// 状态按 (status, updated_at) 建立索引，支持 Coordinator 的恢复扫描；
+// 更新时以版本号作为过滤条件，实现乐观并发控制.
+//
+// 示例:
+//
+//	store := saga.NewMongoStore(client.Collection(saga.DefaultStoreTable))
+//	if err := store.EnsureIndexes(ctx); err != nil {
+//	    return err
+//	}
+type MongoStore struct {
+	coll mongodb.Collection
+}
+
+// NewMongoStore 创建基于 MongoDB 的存储.
+func NewMongoStore(coll mongodb.Collection) *MongoStore {
+	if coll == nil {
+		panic("saga: collection 不能为空")
+	}
+	return &MongoStore{coll: coll}
+}
+
+// EnsureIndexes 创建恢复扫描使用的索引.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.coll.CreateIndex(ctx, mongodb.IndexModel{
+		Keys:    mongodb.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}},
+		Options: &mongodb.IndexOptions{Name: "idx_saga_status_updated"},
+	})
+	return err
+}
+
+// Save 保存 Saga 状态，版本不一致时返回 ErrVersionConflict.
+func (s *MongoStore) Save(ctx context.Context, state *State) error {
+	version := state.Version + 1
+	data, err := encodeState(state, version)
+	if err != nil {
+		return err
+	}
+
+	doc := &mongoDocument{
+		ID:          state.ID,
+		Name:        state.Name,
+		Status:      state.Status,
+		Version:     version,
+		State:       string(data),
+		StartedAt:   state.StartedAt,
+		UpdatedAt:   state.UpdatedAt,
+		CompletedAt: state.CompletedAt,
+	}
+
+	if state.Version == 0 {
+		if _, err := s.coll.InsertOne(ctx, doc); err != nil {
+			if mongodb.IsDuplicateKeyError(err) {
+				return ErrVersionConflict
+			}
+			return err
+		}
+	} else {
+		res, err := s.coll.ReplaceOne(ctx, mongodb.M{"_id": state.ID, "version": state.Version}, doc)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return ErrVersionConflict
+		}
+	}
+
+	state.Version = version
+	return nil
+}
+
+// Get 获取 Saga 状态.
+func (s *MongoStore) Get(ctx context.Context, id string) (*State, error) {
+	var doc mongoDocument
+	err := s.coll.FindOne(ctx, mongodb.M{"_id": id}).Decode(&doc)
+	if errors.Is(err, mongodb.ErrNoDocuments) {
+		return nil, ErrSagaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.decode()
+}
+
+// Delete 删除 Saga 状态.
+func (s *MongoStore) Delete(ctx context.Context, id string) error {
+	_, err := s.coll.DeleteOne(ctx, mongodb.M{"_id": id})
+	return err
+}
+
+// List 按更新时间升序列出指定状态的 Saga，limit <= 0 时返回全部.
+func (s *MongoStore) List(ctx context.Context, status SagaStatus, limit int) ([]*State, error) {
+	opts := []mongodb.FindOption{mongodb.WithFindSort(mongodb.D{{Key: "updated_at", Value: 1}})}
+	if limit > 0 {
+		opts = append(opts, mongodb.WithFindLimit(int64(limit)))
+	}
+
+	cursor, err := s.coll.Find(ctx, mongodb.M{"status": status}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var docs []mongoDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	states := make([]*State, 0, len(docs))
+	for i := range docs {
+		state, err := docs[i].decode()
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Cleanup 删除 before 之前结束的已完成、失败和已补偿的 Saga，返回删除的条数.
+func (s *MongoStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.coll.DeleteMany(ctx, mongodb.M{
+		"status":     mongodb.M{"$in": terminalStatuses},
+		"updated_at": mongodb.M{"$lt": before},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// decode 反序列化文档中的状态，版本以文档字段为准.
+func (d *mongoDocument) decode() (*State, error) {
+	state, err := decodeState([]byte(d.State))
+	if err != nil {
+		return nil, err
+	}
+	state.Version = d.Version
+	return state, nil
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/messaging"
 )
 
 // Option 配置选项函数.
@@ -18,6 +19,9 @@ type options struct {
 	retryDelay  time.Duration
 	onStepStart func(stepName string)
 	onStepEnd   func(stepName string, err error)
+
+	producer     messaging.Producer
+	replyTimeout time.Duration
 }
 
 // defaultOptions 返回默认配置.
@@ -27,6 +31,8 @@ func defaultOptions() *options {
 		timeout:    0, // 无超时
 		retryCount: 0, // 不重试
 		retryDelay: time.Second,
+
+		replyTimeout: 5 * time.Minute,
 	}
 }
 
@@ -83,3 +89,101 @@ func WithStepHooks(onStart func(stepName string), onEnd func(stepName string, er
 		o.onStepEnd = onEnd
 	}
 }
+
+// WithProducer 设置发送异步步骤命令的生产者.
+//
+// 包含异步步骤的 Saga 必须设置.
+func WithProducer(producer messaging.Producer) Option {
+	return func(o *options) {
+		o.producer = producer
+	}
+}
+
+// WithReplyTimeout 设置异步步骤等待回复的超时时间.
+//
+// 超时后由 Coordinator 的恢复任务补偿.
+// 默认: 5 分钟.
+func WithReplyTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.replyTimeout = timeout
+		}
+	}
+}
+
+// CoordinatorOption Coordinator 配置选项.
+type CoordinatorOption func(*coordinatorOptions)
+
+// coordinatorOptions Coordinator 配置.
+type coordinatorOptions struct {
+	logger           logger.Logger
+	recoveryInterval time.Duration
+	staleAfter       time.Duration
+	batchSize        int
+	policy           RecoveryPolicy
+}
+
+// applyCoordinatorOptions 应用 Coordinator 配置选项.
+func applyCoordinatorOptions(opts []CoordinatorOption) *coordinatorOptions {
+	o := &coordinatorOptions{
+		recoveryInterval: 30 * time.Second,
+		staleAfter:       5 * time.Minute,
+		batchSize:        100,
+		policy:           RecoverResume,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithCoordinatorLogger 设置 Coordinator 日志记录器.
+func WithCoordinatorLogger(log logger.Logger) CoordinatorOption {
+	return func(o *coordinatorOptions) {
+		o.logger = log
+	}
+}
+
+// WithRecoveryInterval 设置恢复任务的扫描间隔.
+//
+// 默认: 30 秒.
+func WithRecoveryInterval(d time.Duration) CoordinatorOption {
+	return func(o *coordinatorOptions) {
+		if d > 0 {
+			o.recoveryInterval = d
+		}
+	}
+}
+
+// WithStaleAfter 设置执行中断的判定时间.
+//
+// 执行中或补偿中的 Saga 超过该时间没有更新状态时视为执行进程已崩溃，由恢复任务接管.
+// 必须大于最慢步骤（含重试）的执行时间，否则正在执行的 Saga 会被重复执行.
+// 默认: 5 分钟.
+func WithStaleAfter(d time.Duration) CoordinatorOption {
+	return func(o *coordinatorOptions) {
+		if d > 0 {
+			o.staleAfter = d
+		}
+	}
+}
+
+// WithRecoveryBatchSize 设置每次扫描每种状态读取的 Saga 数量.
+//
+// 默认: 100.
+func WithRecoveryBatchSize(n int) CoordinatorOption {
+	return func(o *coordinatorOptions) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithRecoveryPolicy 设置执行中断的 Saga 的恢复策略.
+//
+// 默认: RecoverResume.
+func WithRecoveryPolicy(policy RecoveryPolicy) CoordinatorOption {
+	return func(o *coordinatorOptions) {
+		o.policy = policy
+	}
+}
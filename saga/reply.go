@@ -0,0 +1,64 @@
+package saga
+
+import "github.com/Tsukikage7/microservice-kit/messaging"
+
+// 异步步骤命令和回复使用的消息头.
+const (
+	// HeaderSagaID Saga ID.
+	HeaderSagaID = "saga-id"
+
+	// HeaderSagaName Saga 名称.
+	HeaderSagaName = "saga-name"
+
+	// HeaderSagaStep 步骤名称.
+	HeaderSagaStep = "saga-step"
+
+	// HeaderCorrelationID 命令的关联 ID，回复必须原样带回.
+	HeaderCorrelationID = "saga-correlation-id"
+
+	// HeaderReplyError 失败回复的错误信息，为空表示成功.
+	HeaderReplyError = "saga-reply-error"
+)
+
+// NewReply 根据收到的命令构造成功回复.
+//
+// 回复复制命令的 Saga ID、步骤和关联 ID，由执行命令的服务发送到 Saga 的回复主题.
+//
+// 示例:
+//
+//	consumer.Consume(ctx, []string{"inventory-commands"}, func(cmd *messaging.Message) error {
+//	    result, err := reserve(ctx, cmd.Value)
+//	    if err != nil {
+//	        _, err = producer.SendMessage(ctx, saga.NewFailureReply(cmd, "order-saga-replies", err))
+//	        return err
+//	    }
+//	    _, err = producer.SendMessage(ctx, saga.NewReply(cmd, "order-saga-replies", result))
+//	    return err
+//	})
+func NewReply(cmd *messaging.Message, topic string, value []byte) *messaging.Message {
+	headers := make(map[string]string, 4)
+	for _, key := range []string{HeaderSagaID, HeaderSagaName, HeaderSagaStep, HeaderCorrelationID} {
+		if v, ok := cmd.Headers[key]; ok {
+			headers[key] = v
+		}
+	}
+	return &messaging.Message{
+		Topic:   topic,
+		Key:     []byte(cmd.Headers[HeaderSagaID]),
+		Value:   value,
+		Headers: headers,
+	}
+}
+
+// NewFailureReply 根据收到的命令构造失败回复，Saga 收到后补偿已完成的步骤.
+//
+// 失败回复表示命令没有生效，当前步骤本身不会被补偿.
+func NewFailureReply(cmd *messaging.Message, topic string, err error) *messaging.Message {
+	reply := NewReply(cmd, topic, nil)
+	reason := "unknown error"
+	if err != nil {
+		reason = err.Error()
+	}
+	reply.Headers[HeaderReplyError] = reason
+	return reply
+}
@@ -30,31 +30,41 @@
 //	    // 使用上一步的数据
 //	    return nil
 //	}
+//
+// 同时执行的步骤使用 Parallel 分组，单个步骤可以通过 StepOption 设置超时、重试和执行条件.
+//
+// 跨服务的步骤使用 AsyncStep 通过 messaging 发送命令，由 Coordinator 处理回复并恢复中断的 Saga.
 package saga
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/messaging"
 )
 
 // Saga 表示一个 Saga 事务.
 type Saga struct {
-	name    string
-	steps   []Step
-	opts    *options
-	mu      sync.Mutex
-	idGen   func() string
+	name   string
+	steps  []Step
+	stages [][]int
+	opts   *options
+	mu     sync.Mutex
+	idGen  func() string
 }
 
 // Builder Saga 构建器.
 type Builder struct {
-	name  string
-	steps []Step
-	opts  []Option
+	name   string
+	steps  []Step
+	stages [][]int
+	opts   []Option
 }
 
 // New 创建 Saga 构建器.
@@ -70,13 +80,66 @@ func New(name string) *Builder {
 // name: 步骤名称
 // action: 正向操作
 // compensate: 补偿操作（可选，传 nil 表示不需要补偿）
-func (b *Builder) Step(name string, action StepFunc, compensate CompensateFunc) *Builder {
-	b.steps = append(b.steps, Step{
+// opts: 步骤配置（可选），如 WithStepTimeout、WithStepRetry、WithCondition
+func (b *Builder) Step(name string, action StepFunc, compensate CompensateFunc, opts ...StepOption) *Builder {
+	return b.add(NewStep(name, action, compensate, opts...))
+}
+
+// Parallel 添加并行执行的一组步骤.
+//
+// 组内的步骤同时开始，全部结束后才执行后续步骤. 任一步骤失败时不取消其他步骤，
+// 等待全部结束后补偿组内执行成功的步骤和之前已完成的步骤. 组内的步骤不能是异步步骤.
+//
+// 示例:
+//
+//	saga.New("create-order").
+//	    Step("create", createOrder, cancelOrder).
+//	    Parallel(
+//	        saga.NewStep("reserve-inventory", reserve, release),
+//	        saga.NewStep("authorize-payment", authorize, void, saga.WithStepTimeout(5*time.Second)),
+//	    ).
+//	    Step("confirm", confirmOrder, nil).
+//	    Build()
+func (b *Builder) Parallel(steps ...Step) *Builder {
+	stage := make([]int, len(steps))
+	for i, step := range steps {
+		stage[i] = len(b.steps)
+		b.steps = append(b.steps, step)
+	}
+	b.stages = append(b.stages, stage)
+	return b
+}
+
+// add 添加单独执行的步骤.
+func (b *Builder) add(step Step) *Builder {
+	b.stages = append(b.stages, []int{len(b.steps)})
+	b.steps = append(b.steps, step)
+	return b
+}
+
+// AsyncStep 添加异步步骤.
+//
+// 异步步骤通过 Producer 向其他服务发送 command 构造的命令，并等待携带相同关联 ID 的回复：
+// 回复由 Coordinator.HandleReply 处理，onReply 成功后继续执行后续步骤.
+// 回复通过 NewReply/NewFailureReply 构造，失败回复或 onReply 返回错误时触发补偿.
+// 等待超过 WithReplyTimeout 时由 Coordinator 的恢复任务补偿，包括当前步骤.
+//
+// name: 步骤名称
+// command: 构造命令消息
+// onReply: 处理回复（可选）
+// compensate: 补偿操作（可选）
+// opts: 步骤配置（可选），超时和重试作用于命令的构造和发送
+func (b *Builder) AsyncStep(name string, command CommandFunc, onReply ReplyFunc, compensate CompensateFunc, opts ...StepOption) *Builder {
+	step := Step{
 		Name:       name,
-		Action:     action,
+		Command:    command,
+		OnReply:    onReply,
 		Compensate: compensate,
-	})
-	return b
+	}
+	for _, opt := range opts {
+		opt(&step)
+	}
+	return b.add(step)
 }
 
 // Options 设置配置选项.
@@ -91,11 +154,29 @@ func (b *Builder) Build() *Saga {
 		panic("saga: 没有定义步骤")
 	}
 
+	opts := applyOptions(b.opts)
+	for _, step := range b.steps {
+		if step.IsAsync() && opts.producer == nil {
+			panic("saga: 异步步骤需要通过 WithProducer 设置生产者")
+		}
+	}
+	for _, stage := range b.stages {
+		if len(stage) == 0 {
+			panic("saga: 并行组没有定义步骤")
+		}
+		for _, i := range stage {
+			if len(stage) > 1 && b.steps[i].IsAsync() {
+				panic("saga: 并行组不支持异步步骤")
+			}
+		}
+	}
+
 	return &Saga{
-		name:  b.name,
-		steps: b.steps,
-		opts:  applyOptions(b.opts),
-		idGen: defaultIDGenerator,
+		name:   b.name,
+		steps:  b.steps,
+		stages: b.stages,
+		opts:   opts,
+		idGen:  defaultIDGenerator,
 	}
 }
 
@@ -112,19 +193,35 @@ func (s *Saga) Execute(ctx context.Context) error {
 }
 
 // ExecuteWithData 使用指定的共享数据执行 Saga.
+//
+// 执行到异步步骤时发送命令后返回 ErrSagaPending，收到回复后由 Coordinator 继续执行.
 func (s *Saga) ExecuteWithData(ctx context.Context, data *Data) error {
+	return s.ExecuteWithID(ctx, s.idGen(), data)
+}
+
+// ExecuteWithID 使用指定的 ID 和共享数据执行 Saga.
+//
+// ID 用于在 Store 中查询状态，通常使用业务 ID（如订单号）.
+func (s *Saga) ExecuteWithID(ctx context.Context, id string, data *Data) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// 创建状态
-	state := NewState(s.idGen(), s.name, len(s.steps))
-	for i, step := range s.steps {
-		state.StepResults[i].StepName = step.Name
+	state := NewState(id, s.name, len(s.steps))
+	for stage, indexes := range s.stages {
+		for _, i := range indexes {
+			state.StepResults[i].StepName = s.steps[i].Name
+			state.StepResults[i].Stage = stage
+		}
 	}
+	state.Data = data.values
 
-	// 保存初始状态
+	// 保存初始状态，版本冲突说明相同 ID 的 Saga 已存在
 	state.Status = SagaStatusRunning
 	if err := s.opts.store.Save(ctx, state); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return err
+		}
 		if s.opts.logger != nil {
 			s.opts.logger.WithContext(ctx).Warn(
 				"[Saga] 保存初始状态失败",
@@ -134,74 +231,95 @@ func (s *Saga) ExecuteWithData(ctx context.Context, data *Data) error {
 		}
 	}
 
+	return s.run(ctx, state, data)
+}
+
+// run 从当前阶段继续执行未完成的步骤，失败时执行补偿.
+//
+// 超时从 Saga 开始时间计算，恢复执行时同样生效.
+func (s *Saga) run(ctx context.Context, state *State, data *Data) error {
 	// 设置超时
 	if s.opts.timeout > 0 {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, s.opts.timeout)
+		ctx, cancel = context.WithDeadline(ctx, state.StartedAt.Add(s.opts.timeout))
 		defer cancel()
 	}
 
-	// 执行步骤
+	// 按阶段执行步骤
 	var lastErr error
-	completedSteps := 0
 
-	for i, step := range s.steps {
+	for _, indexes := range s.stages {
+		// 恢复执行时跳过已完成和已跳过的步骤
+		pending := make([]int, 0, len(indexes))
+		for _, i := range indexes {
+			if status := state.StepResults[i].Status; status != StepStatusCompleted && status != StepStatusSkipped {
+				pending = append(pending, i)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
 		// 检查 context
 		if ctx.Err() != nil {
 			lastErr = ctx.Err()
 			break
 		}
 
-		// 更新状态
-		state.CurrentStep = i
-		state.StepResults[i].Status = StepStatusRunning
-
-		// 执行钩子
-		if s.opts.onStepStart != nil {
-			s.opts.onStepStart(step.Name)
+		// 判断执行条件并更新状态
+		state.CurrentStep = indexes[0]
+		running := make([]int, 0, len(pending))
+		for _, i := range pending {
+			if cond := s.steps[i].Condition; cond != nil && !cond(data) {
+				state.StepResults[i].Status = StepStatusSkipped
+				if s.opts.logger != nil {
+					s.opts.logger.WithContext(ctx).Debug(
+						"[Saga] 步骤条件不满足，已跳过",
+						logger.String("saga", s.name),
+						logger.String("step", s.steps[i].Name),
+					)
+				}
+				continue
+			}
+			state.StepResults[i].Status = StepStatusRunning
+			running = append(running, i)
 		}
-
-		// 记录开始时间
-		startTime := time.Now()
-
-		// 执行步骤（带重试）
-		err := s.executeStepWithRetry(ctx, step, data)
-
-		// 记录执行时间
-		state.StepResults[i].Duration = time.Since(startTime).Milliseconds()
-
-		// 执行钩子
-		if s.opts.onStepEnd != nil {
-			s.opts.onStepEnd(step.Name, err)
+		if err := s.saveState(ctx, state); err != nil {
+			return err
+		}
+		if len(running) == 0 {
+			continue
 		}
 
+		var err error
+		if len(running) == 1 {
+			err = s.executeStep(ctx, state, running[0], data)
+		} else {
+			err = s.executeParallel(ctx, state, running, data)
+		}
+		if errors.Is(err, ErrVersionConflict) {
+			return err
+		}
 		if err != nil {
-			state.StepResults[i].Status = StepStatusFailed
-			state.StepResults[i].Error = err
 			lastErr = err
+			break
+		}
 
+		// 异步步骤已发送命令，收到回复后继续执行
+		if index := running[0]; state.StepResults[index].Status == StepStatusWaiting {
 			if s.opts.logger != nil {
-				s.opts.logger.WithContext(ctx).Error(
-					"[Saga] 步骤执行失败",
+				s.opts.logger.WithContext(ctx).Debug(
+					"[Saga] 异步步骤已发送命令",
 					logger.String("saga", s.name),
-					logger.String("step", step.Name),
-					logger.Err(err),
+					logger.String("saga_id", state.ID),
+					logger.String("step", s.steps[index].Name),
 				)
 			}
-
-			break
+			return ErrSagaPending
 		}
 
-		state.StepResults[i].Status = StepStatusCompleted
-		completedSteps = i + 1
-
-		if s.opts.logger != nil {
-			s.opts.logger.WithContext(ctx).Debug(
-				"[Saga] 步骤执行完成",
-				logger.String("saga", s.name),
-				logger.String("step", step.Name),
-				logger.Int64("duration_ms", state.StepResults[i].Duration),
-			)
+		if err := s.saveState(ctx, state); err != nil {
+			return err
 		}
 	}
 
@@ -210,7 +328,9 @@ func (s *Saga) ExecuteWithData(ctx context.Context, data *Data) error {
 		state.Status = SagaStatusCompleted
 		now := time.Now()
 		state.CompletedAt = &now
-		s.saveState(ctx, state)
+		if err := s.saveState(ctx, state); err != nil {
+			return err
+		}
 
 		if s.opts.logger != nil {
 			s.opts.logger.WithContext(ctx).Info(
@@ -222,46 +342,239 @@ func (s *Saga) ExecuteWithData(ctx context.Context, data *Data) error {
 		return nil
 	}
 
-	// 执行补偿
+	return s.rollback(ctx, state, data, lastErr)
+}
+
+// executeParallel 并行执行同一阶段的步骤，全部结束后返回第一个失败步骤的错误.
+//
+// 每个步骤只写入自己的结果，失败不取消其他步骤，执行成功的步骤由补偿处理.
+func (s *Saga) executeParallel(ctx context.Context, state *State, indexes []int, data *Data) error {
+	errs := make([]error, len(indexes))
+	var wg sync.WaitGroup
+	for n, i := range indexes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[n] = s.executeStep(ctx, state, i, data)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeStep 执行单个步骤并记录结果，异步步骤只发送命令.
+func (s *Saga) executeStep(ctx context.Context, state *State, index int, data *Data) error {
+	step := s.steps[index]
+	result := &state.StepResults[index]
+
+	// 执行钩子
+	if s.opts.onStepStart != nil {
+		s.opts.onStepStart(step.Name)
+	}
+
+	// 记录开始时间
+	startTime := time.Now()
+
+	// 执行步骤（带重试）
+	action := step.Action
+	if step.IsAsync() {
+		action = func(ctx context.Context, data *Data) error {
+			return s.sendCommand(ctx, state, index, data)
+		}
+	}
+	err := s.executeStepWithRetry(ctx, step, data, action, result)
+	if errors.Is(err, ErrVersionConflict) {
+		return err
+	}
+
+	// 记录执行时间
+	result.Duration = time.Since(startTime).Milliseconds()
+
+	if err == nil && step.IsAsync() {
+		return nil
+	}
+
+	// 执行钩子
+	if s.opts.onStepEnd != nil {
+		s.opts.onStepEnd(step.Name, err)
+	}
+
+	if err != nil {
+		result.Status = StepStatusFailed
+		result.Error = err
+
+		if s.opts.logger != nil {
+			s.opts.logger.WithContext(ctx).Error(
+				"[Saga] 步骤执行失败",
+				logger.String("saga", s.name),
+				logger.String("step", step.Name),
+				logger.Err(err),
+			)
+		}
+
+		return err
+	}
+
+	result.Status = StepStatusCompleted
+
+	if s.opts.logger != nil {
+		s.opts.logger.WithContext(ctx).Debug(
+			"[Saga] 步骤执行完成",
+			logger.String("saga", s.name),
+			logger.String("step", step.Name),
+			logger.Int64("duration_ms", result.Duration),
+		)
+	}
+
+	return nil
+}
+
+// rollback 进入补偿状态并逆序补偿已完成的步骤.
+func (s *Saga) rollback(ctx context.Context, state *State, data *Data, cause error) error {
 	state.Status = SagaStatusCompensating
-	state.Error = lastErr.Error()
-	s.saveState(ctx, state)
+	state.Error = cause.Error()
+	if err := s.saveState(ctx, state); err != nil {
+		return err
+	}
 
 	if s.opts.logger != nil {
 		s.opts.logger.WithContext(ctx).Info(
 			"[Saga] 开始执行补偿",
 			logger.String("saga", s.name),
-			logger.Int("completed_steps", completedSteps),
+			logger.String("saga_id", state.ID),
 		)
 	}
 
-	compensateErr := s.compensate(ctx, data, state, completedSteps)
+	return s.finishCompensation(ctx, state, data, cause)
+}
+
+// finishCompensation 执行补偿并保存最终状态.
+func (s *Saga) finishCompensation(ctx context.Context, state *State, data *Data, cause error) error {
+	compensateErr := s.compensate(ctx, data, state)
+	if errors.Is(compensateErr, ErrVersionConflict) {
+		return compensateErr
+	}
 
 	now := time.Now()
 	state.CompletedAt = &now
 
 	if compensateErr != nil {
 		state.Status = SagaStatusCompensateFailed
-		s.saveState(ctx, state)
-		return fmt.Errorf("%w: %v (compensation failed: %v)", ErrSagaFailed, lastErr, compensateErr)
+		if err := s.saveState(ctx, state); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w (compensation failed: %v)", ErrSagaFailed, cause, compensateErr)
 	}
 
 	state.Status = SagaStatusCompensated
-	s.saveState(ctx, state)
-	return fmt.Errorf("%w: %v", ErrSagaFailed, lastErr)
+	if err := s.saveState(ctx, state); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrSagaFailed, cause)
+}
+
+// sendCommand 发送异步步骤的命令.
+//
+// 等待状态在发送前保存，保证回复到达时能匹配到步骤.
+func (s *Saga) sendCommand(ctx context.Context, state *State, index int, data *Data) error {
+	step := s.steps[index]
+	msg, err := step.Command(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	correlationID := uuid.NewString()
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers[HeaderSagaID] = state.ID
+	msg.Headers[HeaderSagaName] = state.Name
+	msg.Headers[HeaderSagaStep] = step.Name
+	msg.Headers[HeaderCorrelationID] = correlationID
+
+	state.StepResults[index].Status = StepStatusWaiting
+	state.StepResults[index].CorrelationID = correlationID
+	state.UpdatedAt = time.Now()
+	if err := s.opts.store.Save(ctx, state); err != nil {
+		return err
+	}
+
+	_, err = s.opts.producer.SendMessage(ctx, msg)
+	return err
+}
+
+// applyReply 处理异步步骤的回复并继续执行.
+func (s *Saga) applyReply(ctx context.Context, state *State, index int, reply *messaging.Message) error {
+	step := s.steps[index]
+	data := dataFromState(state)
+
+	var err error
+	if reason := reply.Headers[HeaderReplyError]; reason != "" {
+		err = fmt.Errorf("%w: %s", ErrStepFailed, reason)
+	} else if step.OnReply != nil {
+		err = step.OnReply(ctx, data, reply)
+	}
+
+	result := &state.StepResults[index]
+	result.Duration = time.Since(state.UpdatedAt).Milliseconds()
+
+	if s.opts.onStepEnd != nil {
+		s.opts.onStepEnd(step.Name, err)
+	}
+
+	if err != nil {
+		result.Status = StepStatusFailed
+		result.Error = err
+
+		if s.opts.logger != nil {
+			s.opts.logger.WithContext(ctx).Error(
+				"[Saga] 异步步骤执行失败",
+				logger.String("saga", s.name),
+				logger.String("saga_id", state.ID),
+				logger.String("step", step.Name),
+				logger.Err(err),
+			)
+		}
+	} else {
+		result.Status = StepStatusCompleted
+	}
+
+	// 回复结果保存失败时返回错误，重新投递的回复仍能匹配到等待中的步骤
+	state.UpdatedAt = time.Now()
+	if saveErr := s.opts.store.Save(ctx, state); saveErr != nil {
+		return saveErr
+	}
+
+	if err != nil {
+		return s.rollback(ctx, state, data, err)
+	}
+	return s.run(ctx, state, data)
 }
 
 // executeStepWithRetry 带重试执行步骤.
-func (s *Saga) executeStepWithRetry(ctx context.Context, step Step, data *Data) error {
+//
+// 步骤设置了 Retry 时覆盖 Saga 的重试配置，执行次数和是否超时记录到 result.
+func (s *Saga) executeStepWithRetry(ctx context.Context, step Step, data *Data, action StepFunc, result *StepResult) error {
+	retryCount, retryDelay := s.opts.retryCount, s.opts.retryDelay
+	if step.Retry != nil {
+		retryCount, retryDelay = step.Retry.Count, step.Retry.Delay
+	}
+
 	var lastErr error
 
-	for attempt := 0; attempt <= s.opts.retryCount; attempt++ {
+	for attempt := 0; attempt <= retryCount; attempt++ {
 		if attempt > 0 {
 			// 等待重试
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(s.opts.retryDelay):
+			case <-time.After(retryDelay):
 			}
 
 			if s.opts.logger != nil {
@@ -273,9 +586,11 @@ func (s *Saga) executeStepWithRetry(ctx context.Context, step Step, data *Data)
 			}
 		}
 
-		err := step.Action(ctx, data)
-		if err == nil {
-			return nil
+		result.Attempts++
+		timedOut, err := executeWithTimeout(ctx, step, data, action)
+		result.TimedOut = timedOut
+		if err == nil || errors.Is(err, ErrVersionConflict) {
+			return err
 		}
 
 		lastErr = err
@@ -284,13 +599,45 @@ func (s *Saga) executeStepWithRetry(ctx context.Context, step Step, data *Data)
 	return lastErr
 }
 
+// executeWithTimeout 执行一次步骤，超过步骤的超时时间时返回 ErrStepTimeout.
+func executeWithTimeout(ctx context.Context, step Step, data *Data, action StepFunc) (bool, error) {
+	if step.Timeout <= 0 {
+		return false, action(ctx, data)
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, step.Timeout)
+	defer cancel()
+
+	err := action(stepCtx, data)
+	// 只有步骤自身超时才算超时，Saga 整体超时或取消返回原错误
+	if err != nil && ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return true, fmt.Errorf("%w: %s: %w", ErrStepTimeout, step.Name, err)
+	}
+	return false, err
+}
+
+// needsCompensation 步骤是否需要补偿.
+//
+// 已完成的步骤、补偿中断的步骤，以及结果未知的步骤（执行中断、已发送命令未收到回复）需要补偿.
+func needsCompensation(status StepStatus) bool {
+	switch status {
+	case StepStatusCompleted, StepStatusCompensating, StepStatusRunning, StepStatusWaiting:
+		return true
+	default:
+		return false
+	}
+}
+
 // compensate 执行补偿操作.
-func (s *Saga) compensate(ctx context.Context, data *Data, state *State, completedSteps int) error {
+func (s *Saga) compensate(ctx context.Context, data *Data, state *State) error {
 	var lastErr error
 
 	// 逆序执行补偿
-	for i := completedSteps - 1; i >= 0; i-- {
+	for i := len(s.steps) - 1; i >= 0; i-- {
 		step := s.steps[i]
+		if !needsCompensation(state.StepResults[i].Status) {
+			continue
+		}
 
 		// 没有补偿函数，跳过
 		if step.Compensate == nil {
@@ -320,6 +667,9 @@ func (s *Saga) compensate(ctx context.Context, data *Data, state *State, complet
 		}
 
 		state.StepResults[i].Status = StepStatusCompensated
+		if err := s.saveState(ctx, state); err != nil {
+			return err
+		}
 
 		if s.opts.logger != nil {
 			s.opts.logger.WithContext(ctx).Debug(
@@ -334,8 +684,15 @@ func (s *Saga) compensate(ctx context.Context, data *Data, state *State, complet
 }
 
 // saveState 保存状态.
-func (s *Saga) saveState(ctx context.Context, state *State) {
+//
+// 只返回版本冲突：状态已被其他执行者（恢复任务或其他实例）更新，当前执行必须停止.
+// 其他保存错误只记录日志，不影响执行.
+func (s *Saga) saveState(ctx context.Context, state *State) error {
+	state.UpdatedAt = time.Now()
 	if err := s.opts.store.Save(ctx, state); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return err
+		}
 		if s.opts.logger != nil {
 			s.opts.logger.WithContext(ctx).Warn(
 				"[Saga] 保存状态失败",
@@ -345,6 +702,7 @@ func (s *Saga) saveState(ctx context.Context, state *State) {
 			)
 		}
 	}
+	return nil
 }
 
 // Name 返回 Saga 名称.
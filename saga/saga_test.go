@@ -3,6 +3,7 @@ package saga
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -389,3 +390,177 @@ func TestBuilderPanicOnNoSteps(t *testing.T) {
 
 	New("empty-saga").Build()
 }
+
+func TestSagaParallel(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	// 两个步骤互相等待，只有并行执行才能都完成
+	reserved, authorized := make(chan struct{}), make(chan struct{})
+	branch := func(key string, self, other chan struct{}) StepFunc {
+		return func(ctx context.Context, data *Data) error {
+			close(self)
+			select {
+			case <-other:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			data.Set(key, true)
+			return nil
+		}
+	}
+
+	rec := &recorder{}
+	s := New("create-order").
+		Step("create", rec.step("create"), nil).
+		Parallel(
+			NewStep("reserve", branch("reserved", reserved, authorized), nil),
+			NewStep("authorize", branch("authorized", authorized, reserved), nil),
+		).
+		Step("confirm", rec.step("confirm"), nil).
+		Options(WithStore(store), WithTimeout(5*time.Second)).
+		Build()
+
+	if err := s.ExecuteWithID(ctx, "order-1", NewData()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state, _ := store.Get(ctx, "order-1")
+	if state.Status != SagaStatusCompleted {
+		t.Errorf("expected completed, got %s", state.Status)
+	}
+	for i, stage := range []int{0, 1, 1, 2} {
+		if r := state.StepResults[i]; r.Stage != stage || r.Status != StepStatusCompleted || r.Attempts != 1 {
+			t.Errorf("unexpected result of %s: %+v", r.StepName, r)
+		}
+	}
+	if state.Data["reserved"] != true || state.Data["authorized"] != true {
+		t.Errorf("unexpected data: %v", state.Data)
+	}
+}
+
+func TestSagaParallelFailure(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	rec := &recorder{}
+
+	var mu sync.Mutex
+	var after []string
+	s := New("create-order").
+		Step("create", rec.step("create"), rec.comp("cancel")).
+		Parallel(
+			NewStep("reserve", rec.step("reserve"), rec.comp("release")),
+			NewStep("authorize", func(context.Context, *Data) error {
+				return errors.New("card declined")
+			}, rec.comp("void")),
+		).
+		Step("confirm", func(context.Context, *Data) error {
+			mu.Lock()
+			after = append(after, "confirm")
+			mu.Unlock()
+			return nil
+		}, nil).
+		Options(WithStore(store)).
+		Build()
+
+	err := s.ExecuteWithID(ctx, "order-1", NewData())
+	if !errors.Is(err, ErrSagaFailed) {
+		t.Fatalf("expected ErrSagaFailed, got %v", err)
+	}
+	if len(after) != 0 {
+		t.Errorf("steps after failed group should not run: %v", after)
+	}
+
+	// 并行组中执行成功的步骤和之前的步骤都被补偿，失败的步骤不补偿
+	calls := rec.list()
+	if len(calls) != 4 || calls[2] != "release" || calls[3] != "cancel" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	state, _ := store.Get(ctx, "order-1")
+	if state.Status != SagaStatusCompensated {
+		t.Errorf("expected compensated, got %s", state.Status)
+	}
+	if state.StepResults[1].Status != StepStatusCompensated || state.StepResults[2].Status != StepStatusFailed {
+		t.Errorf("unexpected results: %+v", state.StepResults)
+	}
+}
+
+func TestBuilderPanicOnAsyncInParallel(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+	}()
+
+	New("test").
+		Parallel(
+			NewStep("a", func(context.Context, *Data) error { return nil }, nil),
+			Step{Name: "b", Command: reserveCommand},
+		).
+		Options(WithProducer(&mockProducer{})).
+		Build()
+}
+
+func TestStepTimeoutAndRetry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	var attempts int
+	slow := func(ctx context.Context, data *Data) error {
+		attempts++
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	// 步骤的重试配置覆盖 Saga 的 WithRetry
+	s := New("timeout-saga").
+		Step("slow", slow, nil, WithStepTimeout(20*time.Millisecond), WithStepRetry(1, 0)).
+		Options(WithStore(store), WithRetry(5, time.Second)).
+		Build()
+
+	err := s.ExecuteWithID(ctx, "saga-1", NewData())
+	if !errors.Is(err, ErrSagaFailed) || !errors.Is(err, ErrStepTimeout) {
+		t.Fatalf("expected step timeout, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+
+	state, _ := store.Get(ctx, "saga-1")
+	if r := state.StepResults[0]; r.Attempts != 2 || !r.TimedOut || r.Error == nil {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
+
+func TestStepCondition(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	rec := &recorder{}
+
+	needsShipping := func(data *Data) bool { return data.GetBool("physical") }
+	s := New("create-order").
+		Step("create", rec.step("create"), rec.comp("cancel")).
+		Step("ship", rec.step("ship"), rec.comp("cancel-shipment"), WithCondition(needsShipping)).
+		Step("charge", func(context.Context, *Data) error {
+			return errors.New("insufficient funds")
+		}, nil).
+		Options(WithStore(store)).
+		Build()
+
+	data := NewData()
+	data.Set("physical", false)
+	if err := s.ExecuteWithID(ctx, "order-1", data); !errors.Is(err, ErrSagaFailed) {
+		t.Fatalf("expected ErrSagaFailed, got %v", err)
+	}
+
+	// 跳过的步骤不执行也不补偿
+	if calls := rec.list(); len(calls) != 2 || calls[0] != "create" || calls[1] != "cancel" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	state, _ := store.Get(ctx, "order-1")
+	if r := state.StepResults[1]; r.Status != StepStatusSkipped || r.Attempts != 0 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
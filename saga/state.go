@@ -27,6 +27,12 @@ type State struct {
 	// StartedAt 开始时间
 	StartedAt time.Time `json:"started_at"`
 
+	// Version 状态版本，支持乐观并发控制的 Store 每次保存后递增，新建的状态为 0
+	Version int64 `json:"version"`
+
+	// UpdatedAt 最近一次保存时间，恢复时用于判断执行是否中断
+	UpdatedAt time.Time `json:"updated_at"`
+
 	// CompletedAt 完成时间
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 
@@ -79,13 +85,15 @@ func NewState(id, name string, stepCount int) *State {
 		}
 	}
 
+	now := time.Now()
 	return &State{
 		ID:          id,
 		Name:        name,
 		Status:      SagaStatusPending,
 		CurrentStep: 0,
 		StepResults: results,
-		StartedAt:   time.Now(),
+		StartedAt:   now,
+		UpdatedAt:   now,
 		Data:        make(map[string]any),
 	}
 }
@@ -2,6 +2,10 @@ package saga
 
 import (
 	"context"
+	"sync"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/messaging"
 )
 
 // StepFunc 步骤执行函数类型.
@@ -18,6 +22,28 @@ type StepFunc func(ctx context.Context, data *Data) error
 // 补偿函数应该是幂等的，因为可能被多次调用.
 type CompensateFunc func(ctx context.Context, data *Data) error
 
+// CommandFunc 异步步骤的命令构造函数.
+//
+// 返回的消息由 Saga 添加关联头后通过 Producer 发送.
+type CommandFunc func(ctx context.Context, data *Data) (*messaging.Message, error)
+
+// ReplyFunc 异步步骤的回复处理函数.
+//
+// 可以从回复中读取数据写入 data，返回错误表示步骤失败，将触发补偿.
+type ReplyFunc func(ctx context.Context, data *Data, reply *messaging.Message) error
+
+// ConditionFunc 步骤执行条件，返回 false 时跳过步骤.
+type ConditionFunc func(data *Data) bool
+
+// RetryPolicy 步骤重试配置.
+type RetryPolicy struct {
+	// Count 重试次数
+	Count int
+
+	// Delay 重试间隔
+	Delay time.Duration
+}
+
 // Step 表示 Saga 中的一个步骤.
 type Step struct {
 	// Name 步骤名称
@@ -29,6 +55,69 @@ type Step struct {
 	// Compensate 补偿操作（可选）
 	// 如果为 nil，表示该步骤不需要补偿
 	Compensate CompensateFunc
+
+	// Command 异步步骤的命令构造函数，设置后 Action 不生效
+	Command CommandFunc
+
+	// OnReply 异步步骤的回复处理函数（可选）
+	OnReply ReplyFunc
+
+	// Timeout 每次执行的超时时间（可选），0 表示只受 Saga 整体超时限制
+	Timeout time.Duration
+
+	// Retry 重试配置（可选），nil 时使用 Saga 的 WithRetry 配置
+	Retry *RetryPolicy
+
+	// Condition 执行条件（可选），返回 false 时跳过该步骤，跳过的步骤不补偿
+	Condition ConditionFunc
+}
+
+// StepOption 步骤配置选项.
+type StepOption func(*Step)
+
+// WithStepTimeout 设置步骤每次执行的超时时间.
+//
+// 超时后步骤失败并返回 ErrStepTimeout，按重试配置重试.
+func WithStepTimeout(timeout time.Duration) StepOption {
+	return func(s *Step) {
+		s.Timeout = timeout
+	}
+}
+
+// WithStepRetry 设置步骤的重试配置，覆盖 Saga 的 WithRetry.
+//
+// count 为 0 时该步骤不重试.
+func WithStepRetry(count int, delay time.Duration) StepOption {
+	return func(s *Step) {
+		s.Retry = &RetryPolicy{Count: count, Delay: delay}
+	}
+}
+
+// WithCondition 设置步骤的执行条件.
+//
+// 条件在步骤开始前根据共享数据判断，返回 false 时步骤标记为 StepStatusSkipped.
+func WithCondition(cond ConditionFunc) StepOption {
+	return func(s *Step) {
+		s.Condition = cond
+	}
+}
+
+// NewStep 创建步骤，用于 Builder.Parallel.
+func NewStep(name string, action StepFunc, compensate CompensateFunc, opts ...StepOption) Step {
+	step := Step{
+		Name:       name,
+		Action:     action,
+		Compensate: compensate,
+	}
+	for _, opt := range opts {
+		opt(&step)
+	}
+	return step
+}
+
+// IsAsync 是否为异步步骤.
+func (s Step) IsAsync() bool {
+	return s.Command != nil
 }
 
 // StepResult 步骤执行结果.
@@ -44,6 +133,18 @@ type StepResult struct {
 
 	// Duration 执行耗时
 	Duration int64 // 毫秒
+
+	// CorrelationID 异步步骤命令的关联 ID，回复通过它匹配步骤
+	CorrelationID string
+
+	// Stage 步骤所在的阶段，同一阶段的步骤并行执行
+	Stage int
+
+	// Attempts 执行次数，包括重试
+	Attempts int
+
+	// TimedOut 最后一次执行是否超时
+	TimedOut bool
 }
 
 // StepStatus 步骤状态.
@@ -59,6 +160,12 @@ const (
 	// StepStatusCompleted 执行完成.
 	StepStatusCompleted StepStatus = "completed"
 
+	// StepStatusWaiting 异步步骤已发送命令，等待回复.
+	StepStatusWaiting StepStatus = "waiting"
+
+	// StepStatusSkipped 执行条件不满足，已跳过.
+	StepStatusSkipped StepStatus = "skipped"
+
 	// StepStatusFailed 执行失败.
 	StepStatusFailed StepStatus = "failed"
 
@@ -74,11 +181,22 @@ const (
 
 // Data Saga 共享数据.
 //
-// 用于在步骤之间传递数据.
+// 用于在步骤之间传递数据，并发安全，并行执行的步骤可以同时读写.
 type Data struct {
+	mu     sync.RWMutex
 	values map[string]any
 }
 
+// dataFromState 从保存的状态恢复共享数据.
+//
+// 恢复后的数据与状态共享同一个 map，步骤写入的数据随状态一起保存.
+func dataFromState(state *State) *Data {
+	if state.Data == nil {
+		state.Data = make(map[string]any)
+	}
+	return &Data{values: state.Data}
+}
+
 // NewData 创建新的共享数据.
 func NewData() *Data {
 	return &Data{
@@ -88,18 +206,22 @@ func NewData() *Data {
 
 // Set 设置数据.
 func (d *Data) Set(key string, value any) {
+	d.mu.Lock()
 	d.values[key] = value
+	d.mu.Unlock()
 }
 
 // Get 获取数据.
 func (d *Data) Get(key string) (any, bool) {
+	d.mu.RLock()
 	v, ok := d.values[key]
+	d.mu.RUnlock()
 	return v, ok
 }
 
 // GetString 获取字符串数据.
 func (d *Data) GetString(key string) string {
-	v, ok := d.values[key]
+	v, ok := d.Get(key)
 	if !ok {
 		return ""
 	}
@@ -111,7 +233,7 @@ func (d *Data) GetString(key string) string {
 
 // GetInt 获取整数数据.
 func (d *Data) GetInt(key string) int {
-	v, ok := d.values[key]
+	v, ok := d.Get(key)
 	if !ok {
 		return 0
 	}
@@ -122,6 +244,9 @@ func (d *Data) GetInt(key string) int {
 		return int(n)
 	case int32:
 		return int(n)
+	case float64:
+		// 从状态存储恢复的数字为 float64
+		return int(n)
 	default:
 		return 0
 	}
@@ -129,7 +254,7 @@ func (d *Data) GetInt(key string) int {
 
 // GetInt64 获取 int64 数据.
 func (d *Data) GetInt64(key string) int64 {
-	v, ok := d.values[key]
+	v, ok := d.Get(key)
 	if !ok {
 		return 0
 	}
@@ -140,6 +265,8 @@ func (d *Data) GetInt64(key string) int64 {
 		return int64(n)
 	case int32:
 		return int64(n)
+	case float64:
+		return int64(n)
 	default:
 		return 0
 	}
@@ -147,7 +274,7 @@ func (d *Data) GetInt64(key string) int64 {
 
 // GetBool 获取布尔数据.
 func (d *Data) GetBool(key string) bool {
-	v, ok := d.values[key]
+	v, ok := d.Get(key)
 	if !ok {
 		return false
 	}
@@ -159,11 +286,15 @@ func (d *Data) GetBool(key string) bool {
 
 // Delete 删除数据.
 func (d *Data) Delete(key string) {
+	d.mu.Lock()
 	delete(d.values, key)
+	d.mu.Unlock()
 }
 
 // Keys 返回所有键.
 func (d *Data) Keys() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	keys := make([]string, 0, len(d.values))
 	for k := range d.values {
 		keys = append(keys, k)
@@ -3,12 +3,20 @@ package saga
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/Tsukikage7/microservice-kit/storage/cache"
 )
 
 // Store Saga 状态存储接口.
+//
+// 支持乐观并发控制的实现（MemoryStore、GORMStore、MongoStore）在 Save 时比较 state.Version：
+// 版本为 0 时新建，ID 已存在返回 ErrVersionConflict；否则只有存储中的版本与 state.Version 一致时才更新，
+// 不一致返回 ErrVersionConflict. 保存成功后 state.Version 递增.
+// 版本冲突保证同一个 Saga 不会被多个恢复任务或回复处理同时继续执行.
 type Store interface {
 	// Save 保存 Saga 状态.
 	Save(ctx context.Context, state *State) error
@@ -47,16 +55,22 @@ type stateDTO struct {
 	StepResults []stepResultDTO `json:"step_results"`
 	Error       string          `json:"error,omitempty"`
 	StartedAt   time.Time       `json:"started_at"`
+	Version     int64           `json:"version"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 	CompletedAt *time.Time      `json:"completed_at,omitempty"`
 	Data        map[string]any  `json:"data,omitempty"`
 }
 
 // stepResultDTO 用于序列化的步骤结果结构.
 type stepResultDTO struct {
-	StepName string     `json:"step_name"`
-	Status   StepStatus `json:"status"`
-	Error    string     `json:"error,omitempty"`
-	Duration int64      `json:"duration"`
+	StepName      string     `json:"step_name"`
+	Status        StepStatus `json:"status"`
+	Error         string     `json:"error,omitempty"`
+	Duration      int64      `json:"duration"`
+	CorrelationID string     `json:"correlation_id,omitempty"`
+	Stage         int        `json:"stage"`
+	Attempts      int        `json:"attempts,omitempty"`
+	TimedOut      bool       `json:"timed_out,omitempty"`
 }
 
 // toDTO 将 State 转换为 DTO.
@@ -69,15 +83,21 @@ func toDTO(state *State) *stateDTO {
 		StepResults: make([]stepResultDTO, len(state.StepResults)),
 		Error:       state.Error,
 		StartedAt:   state.StartedAt,
+		Version:     state.Version,
+		UpdatedAt:   state.UpdatedAt,
 		CompletedAt: state.CompletedAt,
 		Data:        state.Data,
 	}
 
 	for i, r := range state.StepResults {
 		dto.StepResults[i] = stepResultDTO{
-			StepName: r.StepName,
-			Status:   r.Status,
-			Duration: r.Duration,
+			StepName:      r.StepName,
+			Status:        r.Status,
+			Duration:      r.Duration,
+			CorrelationID: r.CorrelationID,
+			Stage:         r.Stage,
+			Attempts:      r.Attempts,
+			TimedOut:      r.TimedOut,
 		}
 		if r.Error != nil {
 			dto.StepResults[i].Error = r.Error.Error()
@@ -97,17 +117,26 @@ func fromDTO(dto *stateDTO) *State {
 		StepResults: make([]StepResult, len(dto.StepResults)),
 		Error:       dto.Error,
 		StartedAt:   dto.StartedAt,
+		Version:     dto.Version,
+		UpdatedAt:   dto.UpdatedAt,
 		CompletedAt: dto.CompletedAt,
 		Data:        dto.Data,
 	}
 
 	for i, r := range dto.StepResults {
 		state.StepResults[i] = StepResult{
-			StepName: r.StepName,
-			Status:   r.Status,
-			Duration: r.Duration,
+			StepName:      r.StepName,
+			Status:        r.Status,
+			Duration:      r.Duration,
+			CorrelationID: r.CorrelationID,
+			Stage:         r.Stage,
+			Attempts:      r.Attempts,
+			TimedOut:      r.TimedOut,
 		}
 		// 注意：序列化后 error 信息会丢失类型，只保留消息
+		if r.Error != "" {
+			state.StepResults[i].Error = errors.New(r.Error)
+		}
 	}
 
 	return state
@@ -115,7 +144,8 @@ func fromDTO(dto *stateDTO) *State {
 
 // KVStore 基于 KV 接口的 Saga 状态存储.
 //
-// 适用于分布式部署场景.
+// 适用于分布式部署场景. KV 接口不支持比较写入，KVStore 不做版本检查，
+// 需要恢复和并发控制时使用 GORMStore 或 MongoStore.
 type KVStore struct {
 	kv         KV
 	keyPrefix  string
@@ -177,12 +207,7 @@ func (s *KVStore) Get(ctx context.Context, id string) (*State, error) {
 		return nil, ErrSagaNotFound
 	}
 
-	var dto stateDTO
-	if err := json.Unmarshal([]byte(data), &dto); err != nil {
-		return nil, err
-	}
-
-	return fromDTO(&dto), nil
+	return decodeState([]byte(data))
 }
 
 // Delete 删除 Saga 状态.
@@ -193,7 +218,7 @@ func (s *KVStore) Delete(ctx context.Context, id string) error {
 
 // List 列出指定状态的 Saga.
 //
-// 注意: KV 实现不支持高效的条件查询，返回空列表.
+// 注意: KV 实现不支持高效的条件查询，返回空列表，因此不能用于 Coordinator 的恢复任务.
 // 建议在生产环境使用专门的索引或数据库来支持列表查询.
 func (s *KVStore) List(ctx context.Context, status SagaStatus, limit int) ([]*State, error) {
 	// KV 不支持高效的条件查询，返回空列表
@@ -229,6 +254,111 @@ func (c *cacheKV) Del(ctx context.Context, keys ...string) error {
 	return c.cache.Del(ctx, keys...)
 }
 
+// MemoryStore 内存 Saga 状态存储.
+//
+// 支持按状态列表查询和版本检查，适用于测试和单实例部署；进程重启后状态丢失.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	states   map[string][]byte
+	versions map[string]int64
+}
+
+// NewMemoryStore 创建内存存储.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		states:   make(map[string][]byte),
+		versions: make(map[string]int64),
+	}
+}
+
+// Save 保存 Saga 状态.
+//
+// 状态序列化后保存，与持久化存储的行为一致.
+func (s *MemoryStore) Save(ctx context.Context, state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.versions[state.ID]
+	if exists != (state.Version != 0) || current != state.Version {
+		return ErrVersionConflict
+	}
+
+	data, err := encodeState(state, state.Version+1)
+	if err != nil {
+		return err
+	}
+	s.states[state.ID] = data
+	s.versions[state.ID] = state.Version + 1
+	state.Version++
+	return nil
+}
+
+// Get 获取 Saga 状态.
+func (s *MemoryStore) Get(ctx context.Context, id string) (*State, error) {
+	s.mu.RLock()
+	data, ok := s.states[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrSagaNotFound
+	}
+	return decodeState(data)
+}
+
+// Delete 删除 Saga 状态.
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.states, id)
+	delete(s.versions, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// List 按更新时间升序列出指定状态的 Saga，limit <= 0 时返回全部.
+func (s *MemoryStore) List(ctx context.Context, status SagaStatus, limit int) ([]*State, error) {
+	s.mu.RLock()
+	var states []*State
+	for _, data := range s.states {
+		state, err := decodeState(data)
+		if err != nil {
+			s.mu.RUnlock()
+			return nil, err
+		}
+		if state.Status == status {
+			states = append(states, state)
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(states, func(a, b *State) int {
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	})
+	if limit > 0 && len(states) > limit {
+		states = states[:limit]
+	}
+	return states, nil
+}
+
+// Close 关闭存储（空实现）.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// encodeState 以指定版本序列化状态.
+func encodeState(state *State, version int64) ([]byte, error) {
+	dto := toDTO(state)
+	dto.Version = version
+	return json.Marshal(dto)
+}
+
+// decodeState 反序列化状态.
+func decodeState(data []byte) (*State, error) {
+	var dto stateDTO
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return fromDTO(&dto), nil
+}
+
 // nopStore 空存储，不保存任何状态.
 //
 // 适用于不需要持久化状态的场景.
@@ -0,0 +1,238 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newTestGORMStore(t *testing.T) *GORMStore {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store := NewGORMStore(db)
+	if err := store.AutoMigrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return store
+}
+
+// versionedStores 返回支持版本检查的存储实现.
+func versionedStores(t *testing.T) map[string]Store {
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"gorm":   newTestGORMStore(t),
+	}
+}
+
+func TestStoreVersioning(t *testing.T) {
+	for name, store := range versionedStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			state := NewState("saga-1", "order", 2)
+			state.Status = SagaStatusRunning
+			state.Data = map[string]any{"order_id": "ORD-1"}
+			if err := store.Save(ctx, state); err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+			if state.Version != 1 {
+				t.Errorf("expected version 1, got %d", state.Version)
+			}
+
+			// 相同 ID 再次新建视为冲突
+			if err := store.Save(ctx, NewState("saga-1", "order", 2)); !errors.Is(err, ErrVersionConflict) {
+				t.Errorf("expected ErrVersionConflict on duplicate insert, got %v", err)
+			}
+
+			first, err := store.Get(ctx, "saga-1")
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			second, _ := store.Get(ctx, "saga-1")
+			if first.Version != 1 || first.Data["order_id"] != "ORD-1" {
+				t.Errorf("unexpected state: %+v", first)
+			}
+
+			first.CurrentStep = 1
+			if err := store.Save(ctx, first); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if first.Version != 2 {
+				t.Errorf("expected version 2, got %d", first.Version)
+			}
+
+			// 基于旧版本的更新被拒绝，且不修改存储中的状态
+			second.Status = SagaStatusCompensating
+			if err := store.Save(ctx, second); !errors.Is(err, ErrVersionConflict) {
+				t.Errorf("expected ErrVersionConflict on stale update, got %v", err)
+			}
+			if second.Version != 1 {
+				t.Errorf("version should not change on conflict, got %d", second.Version)
+			}
+			got, _ := store.Get(ctx, "saga-1")
+			if got.Version != 2 || got.Status != SagaStatusRunning || got.CurrentStep != 1 {
+				t.Errorf("unexpected state after conflict: %+v", got)
+			}
+
+			if err := store.Delete(ctx, "saga-1"); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if _, err := store.Get(ctx, "saga-1"); !errors.Is(err, ErrSagaNotFound) {
+				t.Errorf("expected ErrSagaNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	for name, store := range versionedStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			for i, status := range []SagaStatus{SagaStatusRunning, SagaStatusCompleted, SagaStatusRunning, SagaStatusRunning} {
+				state := NewState(fmt.Sprintf("saga-%d", i), "order", 1)
+				state.Status = status
+				state.UpdatedAt = now.Add(-time.Duration(i) * time.Minute)
+				if err := store.Save(ctx, state); err != nil {
+					t.Fatalf("save: %v", err)
+				}
+			}
+
+			states, err := store.List(ctx, SagaStatusRunning, 2)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			// 最久未更新的排在前面
+			if len(states) != 2 || states[0].ID != "saga-3" || states[1].ID != "saga-2" {
+				t.Fatalf("unexpected list: %v", states)
+			}
+			if states[0].Version != 1 {
+				t.Errorf("expected version 1, got %d", states[0].Version)
+			}
+
+			all, _ := store.List(ctx, SagaStatusRunning, 0)
+			if len(all) != 3 {
+				t.Errorf("expected 3 running, got %d", len(all))
+			}
+		})
+	}
+}
+
+func TestGORMStoreCleanup(t *testing.T) {
+	ctx := context.Background()
+	store := newTestGORMStore(t)
+	old := time.Now().Add(-48 * time.Hour)
+	for id, status := range map[string]SagaStatus{
+		"completed":         SagaStatusCompleted,
+		"compensated":       SagaStatusCompensated,
+		"compensate-failed": SagaStatusCompensateFailed,
+		"running":           SagaStatusRunning,
+	} {
+		state := NewState(id, "order", 1)
+		state.Status = status
+		state.UpdatedAt = old
+		if err := store.Save(ctx, state); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	recent := NewState("recent", "order", 1)
+	recent.Status = SagaStatusCompleted
+	if err := store.Save(ctx, recent); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	n, err := store.Cleanup(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 deleted, got %d, %v", n, err)
+	}
+	for _, id := range []string{"compensate-failed", "running", "recent"} {
+		if _, err := store.Get(ctx, id); err != nil {
+			t.Errorf("%s should be kept: %v", id, err)
+		}
+	}
+}
+
+func TestExecuteWithIDDuplicate(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	s := New("order").
+		Step("create", func(context.Context, *Data) error { return nil }, nil).
+		Options(WithStore(store)).
+		Build()
+
+	if err := s.ExecuteWithID(ctx, "order-1", NewData()); err != nil {
+		t.Fatalf("first execute: %v", err)
+	}
+	if err := s.ExecuteWithID(ctx, "order-1", NewData()); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict, got %v", err)
+	}
+}
+
+func TestCoordinatorRecoverConcurrent(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	store := newTestGORMStore(t)
+	newSaga := func() *Saga {
+		return New("create-order").
+			Step("create", rec.step("create"), rec.comp("cancel")).
+			Step("pay", rec.step("pay"), rec.comp("refund")).
+			Step("confirm", rec.step("confirm"), nil).
+			Options(WithStore(store)).
+			Build()
+	}
+	saveInterrupted(t, store, newSaga())
+
+	// 模拟两个实例的恢复任务同时扫描到同一个中断的 Saga
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		recovered int
+	)
+	start := make(chan struct{})
+	for range 2 {
+		coordinator := NewCoordinator(store, WithStaleAfter(time.Minute))
+		coordinator.Register(newSaga())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			n, err := coordinator.Recover(ctx)
+			if err != nil {
+				t.Errorf("recover: %v", err)
+			}
+			mu.Lock()
+			recovered += n
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if recovered != 1 {
+		t.Errorf("expected 1 recovered, got %d", recovered)
+	}
+	if calls := rec.list(); len(calls) != 2 || calls[0] != "pay" || calls[1] != "confirm" {
+		t.Errorf("steps should run once: %v", calls)
+	}
+	state, _ := store.Get(ctx, "saga-1")
+	if state.Status != SagaStatusCompleted {
+		t.Errorf("expected completed, got %s", state.Status)
+	}
+}
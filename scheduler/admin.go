@@ -0,0 +1,268 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAdminPrefix 管理接口默认路径前缀.
+const DefaultAdminPrefix = "/scheduler"
+
+// maxAdminBodySize 管理接口请求体的最大字节数.
+const maxAdminBodySize = 1 << 20
+
+// JobInfo 管理接口返回的任务信息.
+type JobInfo struct {
+	Name        string    `json:"name"`
+	Schedule    string    `json:"schedule,omitempty"`
+	RunAt       time.Time `json:"run_at,omitzero"`
+	Jitter      string    `json:"jitter,omitempty"`
+	CatchUp     string    `json:"catch_up"`
+	State       string    `json:"state"`
+	Paused      bool      `json:"paused"`
+	Singleton   bool      `json:"singleton"`
+	Distributed bool      `json:"distributed"`
+	Timeout     string    `json:"timeout"`
+	RetryCount  int       `json:"retry_count"`
+	Stats       StatsInfo `json:"stats"`
+}
+
+// StatsInfo 管理接口返回的本实例执行统计.
+type StatsInfo struct {
+	RunCount      int64         `json:"run_count"`
+	SuccessCount  int64         `json:"success_count"`
+	FailCount     int64         `json:"fail_count"`
+	SkipCount     int64         `json:"skip_count"`
+	LastRunAt     time.Time     `json:"last_run_at,omitzero"`
+	LastSuccessAt time.Time     `json:"last_success_at,omitzero"`
+	LastFailAt    time.Time     `json:"last_fail_at,omitzero"`
+	LastError     string        `json:"last_error,omitempty"`
+	LastDuration  time.Duration `json:"last_duration"`
+	TotalDuration time.Duration `json:"total_duration"`
+}
+
+// NewJobInfo 根据任务生成管理接口的任务信息.
+func NewJobInfo(job *Job) JobInfo {
+	stats := job.Stats()
+	info := JobInfo{
+		Name:        job.Name,
+		Schedule:    job.Schedule,
+		RunAt:       job.RunAt,
+		CatchUp:     job.CatchUp.String(),
+		State:       job.State().String(),
+		Paused:      job.IsPaused(),
+		Singleton:   job.Singleton,
+		Distributed: job.Distributed,
+		Timeout:     job.Timeout.String(),
+		RetryCount:  job.RetryCount,
+		Stats: StatsInfo{
+			RunCount:      stats.RunCount,
+			SuccessCount:  stats.SuccessCount,
+			FailCount:     stats.FailCount,
+			SkipCount:     stats.SkipCount,
+			LastRunAt:     stats.LastRunAt,
+			LastSuccessAt: stats.LastSuccessAt,
+			LastFailAt:    stats.LastFailAt,
+			LastDuration:  stats.LastDuration,
+			TotalDuration: stats.TotalDuration,
+		},
+	}
+	if job.Jitter > 0 {
+		info.Jitter = job.Jitter.String()
+	}
+	if stats.LastError != nil {
+		info.Stats.LastError = stats.LastError.Error()
+	}
+	return info
+}
+
+// AdminOption 管理接口配置选项.
+type AdminOption func(*AdminHandler)
+
+// WithAdminPrefix 设置管理接口路径前缀.
+//
+// 默认: /scheduler.
+func WithAdminPrefix(prefix string) AdminOption {
+	return func(h *AdminHandler) {
+		h.prefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// AdminHandler 调度器 HTTP 管理接口.
+//
+// 路由（以默认前缀为例）:
+//
+//	GET  /scheduler/jobs                 列出任务
+//	GET  /scheduler/jobs/{name}          查看任务
+//	GET  /scheduler/jobs/{name}/runs     查看执行记录，?limit=50
+//	POST /scheduler/jobs/{name}/pause    暂停任务
+//	POST /scheduler/jobs/{name}/resume   恢复任务
+//	POST /scheduler/jobs/{name}/trigger  立即执行一次
+//	PUT  /scheduler/jobs/{name}/schedule 修改调度表达式，请求体 {"schedule": "@every 5m"}
+//
+// 成功时返回任务信息或执行记录的 JSON，失败时返回 {"error": "..."} 和对应的状态码.
+// 管理接口本身不做鉴权，应挂载在内部端口或配合 auth 中间件使用.
+// 统计信息只反映当前实例，跨实例的执行情况通过执行记录查看.
+//
+// 多实例部署时调度器需要配置 WithPauseStore，pause 和 resume 才会对所有实例生效；
+// 否则只修改处理该请求的实例，经负载均衡访问时无法保证暂停了所有实例.
+type AdminHandler struct {
+	scheduler Scheduler
+	prefix    string
+	mux       *http.ServeMux
+}
+
+// NewAdminHandler 创建调度器 HTTP 管理接口.
+func NewAdminHandler(s Scheduler, opts ...AdminOption) *AdminHandler {
+	if s == nil {
+		panic("scheduler: 调度器不能为空")
+	}
+	h := &AdminHandler{scheduler: s, prefix: DefaultAdminPrefix}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("GET "+h.prefix+"/jobs", h.listJobs)
+	h.mux.HandleFunc("GET "+h.prefix+"/jobs/{name}", h.getJob)
+	h.mux.HandleFunc("GET "+h.prefix+"/jobs/{name}/runs", h.listRuns)
+	h.mux.HandleFunc("POST "+h.prefix+"/jobs/{name}/pause", h.action(s.Pause))
+	h.mux.HandleFunc("POST "+h.prefix+"/jobs/{name}/resume", h.action(s.Resume))
+	h.mux.HandleFunc("POST "+h.prefix+"/jobs/{name}/trigger", h.action(s.Trigger))
+	h.mux.HandleFunc("PUT "+h.prefix+"/jobs/{name}/schedule", h.reschedule)
+	return h
+}
+
+// ServeHTTP 实现 http.Handler.
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// RegisterRoutes 注册管理接口路由到 http.ServeMux.
+func (h *AdminHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle(h.prefix+"/", h)
+}
+
+// listJobs 按名称排序列出任务.
+func (h *AdminHandler) listJobs(w http.ResponseWriter, _ *http.Request) {
+	jobs := h.scheduler.List()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	infos := make([]JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		infos = append(infos, NewJobInfo(job))
+	}
+	writeAdminJSON(w, http.StatusOK, infos)
+}
+
+// getJob 查看单个任务.
+func (h *AdminHandler) getJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.scheduler.Get(r.PathValue("name"))
+	if !ok {
+		writeAdminError(w, ErrJobNotFound)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, NewJobInfo(job))
+}
+
+// listRuns 查看任务的执行记录.
+func (h *AdminHandler) listRuns(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeAdminJSON(w, http.StatusBadRequest, adminError{Error: "limit 必须是正整数"})
+			return
+		}
+		limit = n
+	}
+
+	name := r.PathValue("name")
+	if _, ok := h.scheduler.Get(name); !ok {
+		writeAdminError(w, ErrJobNotFound)
+		return
+	}
+
+	runs, err := h.scheduler.History(r.Context(), name, limit)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	if runs == nil {
+		runs = []*Run{}
+	}
+	writeAdminJSON(w, http.StatusOK, runs)
+}
+
+// action 返回执行任务操作的处理器，成功时返回最新的任务信息.
+func (h *AdminHandler) action(fn func(name string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := fn(name); err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		job, ok := h.scheduler.Get(name)
+		if !ok {
+			writeAdminError(w, ErrJobNotFound)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, NewJobInfo(job))
+	}
+}
+
+// rescheduleRequest 修改调度表达式的请求体.
+type rescheduleRequest struct {
+	Schedule string `json:"schedule"`
+}
+
+// reschedule 修改任务的调度表达式.
+func (h *AdminHandler) reschedule(w http.ResponseWriter, r *http.Request) {
+	var req rescheduleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodySize)).Decode(&req); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeAdminJSON(w, http.StatusRequestEntityTooLarge, adminError{Error: "请求体过大"})
+			return
+		}
+		writeAdminJSON(w, http.StatusBadRequest, adminError{Error: "请求体格式错误"})
+		return
+	}
+	h.action(func(name string) error {
+		return h.scheduler.Reschedule(name, req.Schedule)
+	})(w, r)
+}
+
+// adminError 管理接口错误响应.
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// writeAdminError 将调度器错误转换为 HTTP 状态码.
+func writeAdminError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoRunStore):
+		status = http.StatusNotImplemented
+	case errors.Is(err, ErrScheduleEmpty), errors.Is(err, ErrScheduleInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrSchedulerClosed):
+		status = http.StatusServiceUnavailable
+	}
+	writeAdminJSON(w, status, adminError{Error: err.Error()})
+}
+
+// writeAdminJSON 写入 JSON 响应.
+func writeAdminJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
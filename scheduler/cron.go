@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
 
 	"github.com/Tsukikage7/microservice-kit/logger"
@@ -13,6 +14,7 @@ import (
 // cronScheduler 基于 Cron 的调度器实现.
 type cronScheduler struct {
 	cron    *cron.Cron
+	parser  cron.Parser
 	jobs    map[string]*Job
 	opts    *options
 	mu      sync.RWMutex
@@ -28,26 +30,53 @@ func newCronScheduler(opts ...Option) (*cronScheduler, error) {
 		opt(o)
 	}
 
-	var cronOpts []cron.Option
-	if o.withSeconds {
-		cronOpts = append(cronOpts, cron.WithSeconds())
-	}
+	parser := newParser(o.withSeconds)
+	cronOpts := []cron.Option{cron.WithParser(parser)}
 	if o.location != nil {
 		cronOpts = append(cronOpts, cron.WithLocation(o.location))
 	}
 
 	return &cronScheduler{
-		cron: cron.New(cronOpts...),
-		jobs: make(map[string]*Job),
-		opts: o,
+		cron:   cron.New(cronOpts...),
+		parser: parser,
+		jobs:   make(map[string]*Job),
+		opts:   o,
 	}, nil
 }
 
 // Add 添加任务.
 func (s *cronScheduler) Add(job *Job) error {
+	return s.add(job, nil)
+}
+
+// AddWorkflow 添加工作流.
+//
+// 步骤未设置超时时使用调度器的默认超时. 工作流未设置超时时，
+// 默认为最长依赖链上各步骤的超时（包括重试和重试间隔）之和.
+func (s *cronScheduler) AddWorkflow(wf *Workflow) error {
+	return s.add(wf.job, func() {
+		for _, step := range wf.steps {
+			if step.job.Timeout == 0 {
+				step.job.Timeout = s.opts.defaultTimeout
+			}
+		}
+		if wf.job.Timeout == 0 {
+			wf.job.Timeout = wf.criticalPath()
+		}
+		wf.exec = s
+	})
+}
+
+// add 校验并添加任务，prepare 在校验通过后、注册之前调用，调用时持有锁.
+func (s *cronScheduler) add(job *Job, prepare func()) error {
 	if err := job.Validate(); err != nil {
 		return err
 	}
+	if !job.IsOnce() {
+		if _, err := s.buildSchedule(job.Schedule, job.Jitter); err != nil {
+			return err
+		}
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -60,6 +89,10 @@ func (s *cronScheduler) Add(job *Job) error {
 		return ErrJobExists
 	}
 
+	if prepare != nil {
+		prepare()
+	}
+
 	// 设置默认超时
 	if job.Timeout == 0 {
 		job.Timeout = s.opts.defaultTimeout
@@ -77,7 +110,7 @@ func (s *cronScheduler) Add(job *Job) error {
 
 	s.jobs[job.Name] = job
 	s.logDebugf("任务已添加: %s [schedule:%s, singleton:%v, distributed:%v]",
-		job.Name, job.Schedule, job.Singleton, job.Distributed)
+		job.Name, job.describeSchedule(), job.Singleton, job.Distributed)
 
 	return nil
 }
@@ -92,10 +125,7 @@ func (s *cronScheduler) Remove(name string) error {
 		return ErrJobNotFound
 	}
 
-	if s.running && job.entryID > 0 {
-		s.cron.Remove(cron.EntryID(job.entryID))
-	}
-
+	s.unregisterJob(job)
 	delete(s.jobs, name)
 	s.logDebugf("任务已移除: %s", name)
 
@@ -147,7 +177,15 @@ func (s *cronScheduler) Start() error {
 
 	s.logDebug("调度器已启动")
 	for _, job := range s.jobs {
-		s.logDebugf("已注册: %s [schedule:%s]", job.Name, job.Schedule)
+		s.logDebugf("已注册: %s [schedule:%s]", job.Name, job.describeSchedule())
+		if job.CatchUp != CatchUpNone && !job.IsOnce() {
+			// 持有锁时计数，Shutdown 设置 closed 后不会再增加
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.catchUp(job)
+			}()
+		}
 	}
 
 	return nil
@@ -167,6 +205,9 @@ func (s *cronScheduler) Stop() {
 
 	s.mu.Lock()
 	s.running = false
+	for _, job := range s.jobs {
+		s.unregisterJob(job)
+	}
 	s.mu.Unlock()
 
 	s.logDebug("调度器已停止")
@@ -180,6 +221,12 @@ func (s *cronScheduler) Shutdown(ctx context.Context) error {
 		return nil
 	}
 	s.closed = true
+	for _, job := range s.jobs {
+		if job.timer != nil {
+			job.timer.Stop()
+			job.timer = nil
+		}
+	}
 	s.mu.Unlock()
 
 	// 停止接受新任务
@@ -236,35 +283,295 @@ func (s *cronScheduler) Trigger(name string) error {
 		return ErrJobNotFound
 	}
 
-	go s.executeJob(job)
+	go s.executeJob(job, TriggerManual)
 	return nil
 }
 
-// registerJob 注册任务到 cron.
+// Pause 暂停任务.
+//
+// 配置 PauseStore 时暂停状态写入共享存储，对所有实例生效.
+func (s *cronScheduler) Pause(name string) error {
+	job, exists := s.Get(name)
+	if !exists {
+		return ErrJobNotFound
+	}
+	if err := s.storePaused(name, true); err != nil {
+		return err
+	}
+	if job.paused.CompareAndSwap(false, true) {
+		s.logDebugf("任务已暂停: %s", name)
+	}
+	return nil
+}
+
+// Resume 恢复任务.
+//
+// 暂停期间到期的一次性任务在恢复后立即执行.
+func (s *cronScheduler) Resume(name string) error {
+	job, exists := s.Get(name)
+	if !exists {
+		return ErrJobNotFound
+	}
+	if err := s.storePaused(name, false); err != nil {
+		return err
+	}
+	resumed := job.paused.CompareAndSwap(true, false)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running && s.jobs[name] == job && job.IsOnce() {
+		switch {
+		case job.timer == nil && resumed:
+			if err := s.registerJob(job); err != nil {
+				return err
+			}
+		case job.timer != nil && !time.Now().Before(job.RunAt):
+			// 到期后等待重新检查暂停状态的一次性任务立即执行
+			job.timer.Reset(0)
+		}
+	}
+	if resumed {
+		s.logDebugf("任务已恢复: %s", name)
+	}
+	return nil
+}
+
+// storePaused 将暂停状态写入 PauseStore，未配置时直接返回.
+func (s *cronScheduler) storePaused(name string, paused bool) error {
+	if s.opts.pauseStore == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pauseCheckTimeout)
+	defer cancel()
+	return s.opts.pauseStore.SetPaused(ctx, name, paused)
+}
+
+// isPaused 返回任务是否暂停.
+//
+// 配置 PauseStore 时以共享状态为准并同步到本地，读取失败时使用本地状态.
+func (s *cronScheduler) isPaused(ctx context.Context, job *Job) bool {
+	if s.opts.pauseStore == nil {
+		return job.IsPaused()
+	}
+	ctx, cancel := context.WithTimeout(ctx, pauseCheckTimeout)
+	defer cancel()
+	paused, err := s.opts.pauseStore.IsPaused(ctx, job.Name)
+	if err != nil {
+		s.logErrorf("读取暂停状态失败，使用本地状态 [job:%s] [error:%v]", job.Name, err)
+		return job.IsPaused()
+	}
+	job.paused.Store(paused)
+	return paused
+}
+
+// Reschedule 修改任务的调度表达式.
+func (s *cronScheduler) Reschedule(name, schedule string) error {
+	if schedule == "" {
+		return ErrScheduleEmpty
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[name]
+	if !exists {
+		return ErrJobNotFound
+	}
+	if _, err := s.buildSchedule(schedule, job.Jitter); err != nil {
+		return err
+	}
+
+	s.unregisterJob(job)
+	job.Schedule = schedule
+	job.RunAt = time.Time{}
+	if s.running {
+		if err := s.registerJob(job); err != nil {
+			return err
+		}
+	}
+
+	s.logDebugf("任务调度已修改: %s [schedule:%s]", name, schedule)
+	return nil
+}
+
+// History 返回任务的执行记录.
+func (s *cronScheduler) History(ctx context.Context, name string, limit int) ([]*Run, error) {
+	if s.opts.runStore == nil {
+		return nil, ErrNoRunStore
+	}
+	return s.opts.runStore.List(ctx, name, limit)
+}
+
+// registerJob 注册任务到 cron，一次性任务使用定时器，调用方需持有锁.
 func (s *cronScheduler) registerJob(job *Job) error {
 	j := job // 避免闭包问题
-	entryID, err := s.cron.AddFunc(j.Schedule, func() {
-		s.executeJob(j)
-	})
+	if j.IsOnce() {
+		j.timer = time.AfterFunc(time.Until(j.RunAt), func() {
+			s.runOnce(j)
+		})
+		return nil
+	}
+
+	schedule, err := s.buildSchedule(j.Schedule, j.Jitter)
 	if err != nil {
-		return ErrScheduleInvalid
+		return err
 	}
+	entryID := s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.executeJob(j, TriggerSchedule)
+	}))
 	j.entryID = int(entryID)
 	return nil
 }
 
+// unregisterJob 取消任务的调度，调用方需持有锁.
+func (s *cronScheduler) unregisterJob(job *Job) {
+	if job.entryID > 0 {
+		s.cron.Remove(cron.EntryID(job.entryID))
+		job.entryID = 0
+	}
+	if job.timer != nil {
+		job.timer.Stop()
+		job.timer = nil
+	}
+}
+
+// buildSchedule 解析调度表达式，jitter > 0 时增加随机延迟.
+func (s *cronScheduler) buildSchedule(expr string, jitter time.Duration) (cron.Schedule, error) {
+	schedule, err := s.parser.Parse(expr)
+	if err != nil {
+		return nil, ErrScheduleInvalid
+	}
+	if jitter > 0 {
+		return &jitterSchedule{base: schedule, jitter: jitter}, nil
+	}
+	return schedule, nil
+}
+
+// runOnce 执行一次性任务，完成后从调度器移除.
+//
+// 任务已暂停时保留在调度器中，恢复后立即执行. 配置 PauseStore 时任务可能在其他实例上恢复，
+// 因此每隔 pausedOnceRecheck 重新检查一次暂停状态.
+func (s *cronScheduler) runOnce(job *Job) {
+	s.mu.RLock()
+	timer := job.timer
+	scheduled := s.jobs[job.Name] == job && timer != nil
+	s.mu.RUnlock()
+	if !scheduled {
+		// 已被移除或重新调度
+		return
+	}
+
+	// 读取共享暂停状态时不持有锁，避免存储延迟阻塞调度器的其他操作
+	paused := s.isPaused(context.Background(), job)
+
+	s.mu.Lock()
+	if s.jobs[job.Name] != job || job.timer != timer {
+		// 检查期间被移除、重新调度或由 Resume 重新触发
+		s.mu.Unlock()
+		return
+	}
+	job.timer = nil
+	if paused {
+		if s.opts.pauseStore != nil && s.running {
+			job.timer = time.AfterFunc(pausedOnceRecheck, func() {
+				s.runOnce(job)
+			})
+		}
+		s.mu.Unlock()
+		job.stats.recordSkip()
+		s.logDebugf("一次性任务等待恢复: %s", job.Name)
+		return
+	}
+	s.mu.Unlock()
+
+	s.executeJob(job, TriggerSchedule)
+
+	s.mu.Lock()
+	if s.jobs[job.Name] == job && job.IsOnce() {
+		delete(s.jobs, job.Name)
+		s.logDebugf("一次性任务已完成并移除: %s", job.Name)
+	}
+	s.mu.Unlock()
+}
+
+// catchUp 根据最近一次调度执行的时间补偿停机期间错过的执行.
+//
+// 只统计按调度表达式触发和补偿触发的执行，停机期间的手动触发不影响补偿.
+// 最近 DefaultHistoryLimit 条记录都是手动触发时不补偿.
+func (s *cronScheduler) catchUp(job *Job) {
+	if s.opts.runStore == nil {
+		s.logWarn("未配置执行记录存储，跳过错过执行的补偿: " + job.Name)
+		return
+	}
+
+	ctx := context.Background()
+	runs, err := s.opts.runStore.List(ctx, job.Name, DefaultHistoryLimit)
+	if err != nil {
+		s.logErrorf("读取执行记录失败 [job:%s] [error:%v]", job.Name, err)
+		return
+	}
+	last, ok := lastScheduledStart(runs)
+	if !ok {
+		return
+	}
+
+	schedule, err := s.parser.Parse(job.Schedule)
+	if err != nil {
+		return
+	}
+	limit := 1
+	if job.CatchUp == CatchUpAll {
+		limit = MaxCatchUpRuns
+	}
+	missed := missedRuns(schedule, last, time.Now(), limit)
+	if missed == 0 {
+		return
+	}
+
+	s.logDebugf("补偿错过的执行: %s [missed:%d]", job.Name, missed)
+	for range missed {
+		if !s.active() {
+			return
+		}
+		s.executeJob(job, TriggerCatchUp)
+	}
+}
+
+// active 检查调度器是否运行中且未关闭.
+func (s *cronScheduler) active() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.running && !s.closed
+}
+
 // executeJob 执行任务.
-func (s *cronScheduler) executeJob(job *Job) {
+func (s *cronScheduler) executeJob(job *Job, trigger RunTrigger) {
 	s.wg.Add(1)
 	defer s.wg.Done()
 
-	ctx := context.Background()
+	s.execute(context.Background(), job, trigger)
+}
+
+// execute 按暂停、单例、分布式锁、前置钩子、重试的顺序执行任务.
+//
+// 返回的 JobContext 中 Skipped 表示任务未执行，Error 为最后一次尝试的错误.
+func (s *cronScheduler) execute(ctx context.Context, job *Job, trigger RunTrigger) *JobContext {
 	jc := &JobContext{
 		Job:       job,
 		StartTime: time.Now(),
 		Attempt:   1,
 	}
 
+	// 0. 暂停检查（手动触发不受影响），在获取分布式锁之前读取共享的暂停状态
+	if trigger != TriggerManual && s.isPaused(ctx, job) {
+		jc.Skipped = true
+		jc.SkipReason = "job paused"
+		job.stats.recordSkip()
+		s.opts.hooks.runSkipHooks(ctx, jc)
+		s.logDebugf("任务跳过（已暂停）: %s", job.Name)
+		return jc
+	}
+
 	// 1. 单例检查（本地）
 	if job.Singleton {
 		if !job.tryStart() {
@@ -273,7 +580,7 @@ func (s *cronScheduler) executeJob(job *Job) {
 			job.stats.recordSkip()
 			s.opts.hooks.runSkipHooks(ctx, jc)
 			s.logDebugf("任务跳过（单例模式）: %s", job.Name)
-			return
+			return jc
 		}
 		defer job.finish()
 	}
@@ -294,7 +601,7 @@ func (s *cronScheduler) executeJob(job *Job) {
 			jc.Error = err
 			job.stats.recordSkip()
 			s.opts.hooks.runSkipHooks(ctx, jc)
-			return
+			return jc
 		}
 		if !acquired {
 			jc.Skipped = true
@@ -302,7 +609,7 @@ func (s *cronScheduler) executeJob(job *Job) {
 			job.stats.recordSkip()
 			s.opts.hooks.runSkipHooks(ctx, jc)
 			s.logDebugf("任务跳过（分布式锁）: %s", job.Name)
-			return
+			return jc
 		}
 		defer func() {
 			if err := s.opts.locker.Unlock(ctx, lockKey); err != nil {
@@ -314,15 +621,19 @@ func (s *cronScheduler) executeJob(job *Job) {
 	// 3. 执行前置钩子
 	if err := s.opts.hooks.runBeforeHooks(ctx, jc); err != nil {
 		s.logDebugf("前置钩子阻止任务执行 [job:%s] [error:%v]", job.Name, err)
-		return
+		jc.Skipped = true
+		jc.SkipReason = "blocked by before hook"
+		jc.Error = err
+		return jc
 	}
 
 	// 4. 执行任务（带重试）
-	s.runWithRetry(ctx, job, jc)
+	s.runWithRetry(ctx, job, jc, trigger)
+	return jc
 }
 
 // runWithRetry 执行任务（带重试）.
-func (s *cronScheduler) runWithRetry(ctx context.Context, job *Job, jc *JobContext) {
+func (s *cronScheduler) runWithRetry(ctx context.Context, job *Job, jc *JobContext, trigger RunTrigger) {
 	maxAttempts := job.RetryCount + 1
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
@@ -333,12 +644,15 @@ func (s *cronScheduler) runWithRetry(ctx context.Context, job *Job, jc *JobConte
 
 		start := time.Now()
 		job.stats.recordStart()
+		run, state := s.startRun(ctx, job, trigger, attempt, start)
+		execCtx = context.WithValue(execCtx, runKey{}, state)
 
 		s.logDebugf("开始执行任务: %s [attempt:%d/%d]", job.Name, attempt, maxAttempts)
 
 		err := job.Handler(execCtx)
 		duration := time.Since(start)
 		cancel()
+		s.finishRun(ctx, run, state, err, duration)
 
 		jc.Duration = duration
 		jc.Error = err
@@ -367,6 +681,52 @@ func (s *cronScheduler) runWithRetry(ctx context.Context, job *Job, jc *JobConte
 	}
 }
 
+// startRun 写入执行中的记录，未配置 RunStore 时只返回用于收集输出大小的状态.
+func (s *cronScheduler) startRun(ctx context.Context, job *Job, trigger RunTrigger, attempt int, start time.Time) (*Run, *runState) {
+	state := &runState{trigger: trigger}
+	if s.opts.runStore == nil {
+		return nil, state
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		s.logErrorf("生成执行记录 ID 失败 [job:%s] [error:%v]", job.Name, err)
+		return nil, state
+	}
+	run := &Run{
+		ID:        id.String(),
+		JobName:   job.Name,
+		Instance:  s.opts.instance,
+		Trigger:   trigger,
+		Attempt:   attempt,
+		Status:    RunStatusRunning,
+		StartTime: start,
+	}
+	if err := s.opts.runStore.Save(ctx, run); err != nil {
+		s.logErrorf("保存执行记录失败 [job:%s] [error:%v]", job.Name, err)
+	}
+	return run, state
+}
+
+// finishRun 更新执行记录的结果.
+func (s *cronScheduler) finishRun(ctx context.Context, run *Run, state *runState, err error, duration time.Duration) {
+	if run == nil {
+		return
+	}
+
+	run.EndTime = run.StartTime.Add(duration)
+	run.Duration = duration
+	run.OutputSize = state.outputSize.Load()
+	run.Status = RunStatusSuccess
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	}
+	if err := s.opts.runStore.Save(ctx, run); err != nil {
+		s.logErrorf("保存执行记录失败 [job:%s] [error:%v]", run.JobName, err)
+	}
+}
+
 // 日志辅助方法.
 
 func (s *cronScheduler) logger() logger.Logger {
@@ -33,4 +33,22 @@ var (
 
 	// ErrJobSkipped 任务被跳过（上一次执行未完成）.
 	ErrJobSkipped = errors.New("scheduler: 任务被跳过，上一次执行未完成")
+
+	// ErrNoRunStore 未配置执行记录存储.
+	ErrNoRunStore = errors.New("scheduler: 未配置执行记录存储")
+
+	// ErrWorkflowEmpty 工作流没有步骤.
+	ErrWorkflowEmpty = errors.New("scheduler: 工作流至少需要一个步骤")
+
+	// ErrStepExists 工作流步骤已存在.
+	ErrStepExists = errors.New("scheduler: 工作流步骤已存在")
+
+	// ErrStepNotFound 工作流步骤依赖的步骤不存在.
+	ErrStepNotFound = errors.New("scheduler: 依赖的步骤不存在")
+
+	// ErrWorkflowNotAdded 工作流未添加到调度器.
+	ErrWorkflowNotAdded = errors.New("scheduler: 工作流未添加到调度器")
+
+	// ErrWorkflowFailed 工作流有步骤执行失败.
+	ErrWorkflowFailed = errors.New("scheduler: 工作流执行失败")
 )
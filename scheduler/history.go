@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// RunStatus 执行记录状态.
+type RunStatus string
+
+const (
+	// RunStatusRunning 执行中.
+	RunStatusRunning RunStatus = "running"
+	// RunStatusSuccess 执行成功.
+	RunStatusSuccess RunStatus = "success"
+	// RunStatusFailed 执行失败.
+	RunStatusFailed RunStatus = "failed"
+)
+
+// RunTrigger 执行触发方式.
+type RunTrigger string
+
+const (
+	// TriggerSchedule 按调度表达式触发.
+	TriggerSchedule RunTrigger = "schedule"
+	// TriggerManual 通过 Trigger 手动触发.
+	TriggerManual RunTrigger = "manual"
+	// TriggerCatchUp 启动时补偿停机期间错过的执行.
+	TriggerCatchUp RunTrigger = "catchup"
+)
+
+// Run 任务的一次执行记录.
+//
+// 每次尝试（包括重试）生成一条记录.
+type Run struct {
+	// ID 记录唯一标识.
+	ID string `json:"id" gorm:"primaryKey;size:36"`
+
+	// JobName 任务名称.
+	JobName string `json:"job_name" gorm:"size:255;index:idx_scheduler_run_job,priority:1"`
+
+	// Instance 执行实例标识.
+	Instance string `json:"instance" gorm:"size:255"`
+
+	// Trigger 触发方式.
+	Trigger RunTrigger `json:"trigger" gorm:"size:32"`
+
+	// Attempt 第几次尝试（从 1 开始）.
+	Attempt int `json:"attempt"`
+
+	// Status 执行状态.
+	Status RunStatus `json:"status" gorm:"size:32"`
+
+	// Error 失败时的错误信息.
+	Error string `json:"error,omitempty" gorm:"type:text"`
+
+	// OutputSize 任务通过 RecordOutput 上报的输出大小（字节）.
+	OutputSize int64 `json:"output_size"`
+
+	// StartTime 开始时间.
+	StartTime time.Time `json:"start_time" gorm:"index:idx_scheduler_run_job,priority:2"`
+
+	// EndTime 结束时间，执行中为零值.
+	EndTime time.Time `json:"end_time,omitzero"`
+
+	// Duration 执行耗时.
+	Duration time.Duration `json:"duration"`
+}
+
+// RunStore 执行记录存储.
+//
+// 调度器在每次尝试开始和结束时各调用一次 Save，实现需要按 ID 覆盖写入.
+type RunStore interface {
+	// Save 保存执行记录.
+	Save(ctx context.Context, run *Run) error
+
+	// List 按开始时间倒序列出任务的执行记录，limit <= 0 时使用实现的默认值.
+	List(ctx context.Context, jobName string, limit int) ([]*Run, error)
+}
+
+// DefaultHistoryLimit List 默认返回的记录数.
+const DefaultHistoryLimit = 50
+
+// runKey 执行记录在 context 中的键.
+type runKey struct{}
+
+// runState 执行中记录的可变状态.
+type runState struct {
+	trigger    RunTrigger
+	outputSize atomic.Int64
+}
+
+// RecordOutput 上报任务输出大小（字节），可多次调用累加.
+//
+// 只在调度器执行的 JobFunc 中生效，结果写入执行记录的 OutputSize.
+//
+// 示例:
+//
+//	func export(ctx context.Context) error {
+//	    n, err := writeReport(w)
+//	    scheduler.RecordOutput(ctx, n)
+//	    return err
+//	}
+func RecordOutput(ctx context.Context, size int64) {
+	if state, ok := ctx.Value(runKey{}).(*runState); ok {
+		state.outputSize.Add(size)
+	}
+}
+
+// sortRuns 按开始时间倒序排列执行记录.
+func sortRuns(runs []*Run) {
+	sort.SliceStable(runs, func(i, j int) bool {
+		if runs[i].StartTime.Equal(runs[j].StartTime) {
+			return runs[i].ID > runs[j].ID
+		}
+		return runs[i].StartTime.After(runs[j].StartTime)
+	})
+}
+
+// lastScheduledStart 返回按开始时间倒序排列的记录中，最近一次调度或补偿触发的开始时间.
+func lastScheduledStart(runs []*Run) (time.Time, bool) {
+	for _, run := range runs {
+		if run.Trigger != TriggerManual {
+			return run.StartTime, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// defaultInstance 返回默认实例标识：主机名-进程号.
+func defaultInstance() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// RunStoreOption 执行记录存储配置选项.
+type RunStoreOption func(*runStoreOptions)
+
+// runStoreOptions 执行记录存储配置.
+type runStoreOptions struct {
+	table     string
+	keyPrefix string
+	maxRuns   int
+	ttl       time.Duration
+}
+
+// applyRunStoreOptions 应用执行记录存储配置选项.
+func applyRunStoreOptions(opts []RunStoreOption) *runStoreOptions {
+	o := &runStoreOptions{
+		table:     "scheduler_runs",
+		keyPrefix: "scheduler:runs:",
+		maxRuns:   100,
+		ttl:       7 * 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRunTable 设置执行记录表名（GORM）.
+//
+// 默认: scheduler_runs.
+func WithRunTable(table string) RunStoreOption {
+	return func(o *runStoreOptions) {
+		if table != "" {
+			o.table = table
+		}
+	}
+}
+
+// WithRunKeyPrefix 设置执行记录缓存键前缀（缓存）.
+//
+// 默认: scheduler:runs:.
+func WithRunKeyPrefix(prefix string) RunStoreOption {
+	return func(o *runStoreOptions) {
+		if prefix != "" {
+			o.keyPrefix = prefix
+		}
+	}
+}
+
+// WithMaxRuns 设置每个任务保留的执行记录数（缓存）.
+//
+// 默认: 100.
+func WithMaxRuns(n int) RunStoreOption {
+	return func(o *runStoreOptions) {
+		if n > 0 {
+			o.maxRuns = n
+		}
+	}
+}
+
+// WithRunTTL 设置执行记录的过期时间（缓存），每次写入后刷新.
+//
+// 默认: 7 天.
+func WithRunTTL(d time.Duration) RunStoreOption {
+	return func(o *runStoreOptions) {
+		if d > 0 {
+			o.ttl = d
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+// CacheRunStore 基于 cache.Cache 的执行记录存储.
+//
+// 每个任务的记录以 JSON 数组保存在一个键中，只保留最近 WithMaxRuns 条，
+// 写入时使用 cache.TryLock 串行化多实例的读改写.
+type CacheRunStore struct {
+	cache cache.Cache
+	opts  *runStoreOptions
+}
+
+// NewCacheRunStore 创建基于缓存的执行记录存储.
+//
+// 示例:
+//
+//	redisCache, _ := cache.NewCache(cache.NewRedisConfig("localhost:6379"), log)
+//	store := scheduler.NewCacheRunStore(redisCache, scheduler.WithMaxRuns(200))
+//	s := scheduler.MustNewScheduler(scheduler.WithRunStore(store))
+func NewCacheRunStore(c cache.Cache, opts ...RunStoreOption) *CacheRunStore {
+	if c == nil {
+		panic("scheduler: 缓存不能为空")
+	}
+	return &CacheRunStore{cache: c, opts: applyRunStoreOptions(opts)}
+}
+
+// 写锁参数.
+const (
+	cacheRunLockTTL   = 5 * time.Second
+	cacheRunLockRetry = 20 * time.Millisecond
+)
+
+// Save 保存执行记录，相同 ID 覆盖写入.
+func (s *CacheRunStore) Save(ctx context.Context, run *Run) error {
+	key := s.opts.keyPrefix + run.JobName
+	unlock, err := s.lock(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs, err := s.load(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	updated := make([]*Run, 0, len(runs)+1)
+	updated = append(updated, run)
+	for _, r := range runs {
+		if r.ID != run.ID {
+			updated = append(updated, r)
+		}
+	}
+	sortRuns(updated)
+	if len(updated) > s.opts.maxRuns {
+		updated = updated[:s.opts.maxRuns]
+	}
+
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, key, string(data), s.opts.ttl)
+}
+
+// List 按开始时间倒序列出任务的执行记录.
+func (s *CacheRunStore) List(ctx context.Context, jobName string, limit int) ([]*Run, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	runs, err := s.load(ctx, s.opts.keyPrefix+jobName)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+// load 读取任务的全部执行记录.
+func (s *CacheRunStore) load(ctx context.Context, key string) ([]*Run, error) {
+	data, err := s.cache.Get(ctx, key)
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []*Run
+	if err := json.Unmarshal([]byte(data), &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// lock 获取任务记录的写锁，返回释放函数.
+func (s *CacheRunStore) lock(ctx context.Context, key string) (func(), error) {
+	lockKey := key + ":lock"
+	token := uuid.NewString()
+	for {
+		ok, err := s.cache.TryLock(ctx, lockKey, token, cacheRunLockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() { _ = s.cache.Unlock(context.WithoutCancel(ctx), lockKey, token) }, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(cacheRunLockRetry):
+		}
+	}
+}
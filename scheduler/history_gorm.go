@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GORMRunStore 基于 GORM 的执行记录存储.
+//
+// 记录永久保存，可定期调用 Cleanup 删除旧记录.
+type GORMRunStore struct {
+	db   *gorm.DB
+	opts *runStoreOptions
+}
+
+// NewGORMRunStore 创建基于 GORM 的执行记录存储.
+func NewGORMRunStore(db *gorm.DB, opts ...RunStoreOption) *GORMRunStore {
+	if db == nil {
+		panic("scheduler: 数据库连接不能为空")
+	}
+	return &GORMRunStore{db: db, opts: applyRunStoreOptions(opts)}
+}
+
+// AutoMigrate 创建或更新执行记录表.
+func (s *GORMRunStore) AutoMigrate(ctx context.Context) error {
+	return s.db.WithContext(ctx).Table(s.opts.table).AutoMigrate(&Run{})
+}
+
+// Save 保存执行记录，相同 ID 覆盖写入.
+func (s *GORMRunStore) Save(ctx context.Context, run *Run) error {
+	return s.table(ctx).Save(run).Error
+}
+
+// List 按开始时间倒序列出任务的执行记录.
+func (s *GORMRunStore) List(ctx context.Context, jobName string, limit int) ([]*Run, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	var runs []*Run
+	err := s.table(ctx).
+		Where("job_name = ?", jobName).
+		Order("start_time DESC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
+
+// Cleanup 删除 before 之前开始的执行记录，返回删除的条数.
+func (s *GORMRunStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	res := s.table(ctx).Where("start_time < ?", before).Delete(&Run{})
+	return res.RowsAffected, res.Error
+}
+
+// table 返回绑定执行记录表的会话.
+func (s *GORMRunStore) table(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Table(s.opts.table)
+}
@@ -0,0 +1,448 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+// testLogger 用于测试的模拟日志器.
+type testLogger struct{}
+
+func (m *testLogger) Debug(args ...any)                             {}
+func (m *testLogger) Debugf(format string, args ...any)             {}
+func (m *testLogger) Info(args ...any)                              {}
+func (m *testLogger) Infof(format string, args ...any)              {}
+func (m *testLogger) Warn(args ...any)                              {}
+func (m *testLogger) Warnf(format string, args ...any)              {}
+func (m *testLogger) Error(args ...any)                             {}
+func (m *testLogger) Errorf(format string, args ...any)             {}
+func (m *testLogger) Fatal(args ...any)                             {}
+func (m *testLogger) Fatalf(format string, args ...any)             {}
+func (m *testLogger) Panic(args ...any)                             {}
+func (m *testLogger) Panicf(format string, args ...any)             {}
+func (m *testLogger) With(fields ...logger.Field) logger.Logger     { return m }
+func (m *testLogger) WithContext(ctx context.Context) logger.Logger { return m }
+func (m *testLogger) Sync() error                                   { return nil }
+func (m *testLogger) Close() error                                  { return nil }
+
+// testRunStores 返回需要验证的执行记录存储.
+func testRunStores(t *testing.T) map[string]RunStore {
+	t.Helper()
+
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	t.Cleanup(func() { memCache.Close() })
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	gormStore := NewGORMRunStore(db)
+	require.NoError(t, gormStore.AutoMigrate(context.Background()))
+
+	return map[string]RunStore{
+		"Cache": NewCacheRunStore(memCache, WithMaxRuns(3)),
+		"GORM":  gormStore,
+	}
+}
+
+func TestRunStore_SaveAndList(t *testing.T) {
+	for name, store := range testRunStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			base := time.Now().Truncate(time.Millisecond)
+
+			for i := range 3 {
+				run := &Run{
+					ID:        fmt.Sprintf("run-%d", i),
+					JobName:   "sync",
+					Instance:  "node-1",
+					Attempt:   1,
+					Status:    RunStatusRunning,
+					StartTime: base.Add(time.Duration(i) * time.Second),
+				}
+				require.NoError(t, store.Save(ctx, run))
+			}
+
+			// 结束时按 ID 覆盖
+			require.NoError(t, store.Save(ctx, &Run{
+				ID:         "run-2",
+				JobName:    "sync",
+				Instance:   "node-1",
+				Attempt:    1,
+				Status:     RunStatusFailed,
+				Error:      "boom",
+				OutputSize: 42,
+				StartTime:  base.Add(2 * time.Second),
+				EndTime:    base.Add(3 * time.Second),
+				Duration:   time.Second,
+			}))
+			require.NoError(t, store.Save(ctx, &Run{ID: "other", JobName: "other", StartTime: base}))
+
+			runs, err := store.List(ctx, "sync", 0)
+			require.NoError(t, err)
+			require.Len(t, runs, 3)
+			assert.Equal(t, []string{"run-2", "run-1", "run-0"}, []string{runs[0].ID, runs[1].ID, runs[2].ID})
+			assert.Equal(t, RunStatusFailed, runs[0].Status)
+			assert.Equal(t, "boom", runs[0].Error)
+			assert.Equal(t, int64(42), runs[0].OutputSize)
+			assert.Equal(t, time.Second, runs[0].Duration)
+
+			runs, err = store.List(ctx, "sync", 1)
+			require.NoError(t, err)
+			assert.Len(t, runs, 1)
+		})
+	}
+}
+
+func TestCacheRunStore_MaxRuns(t *testing.T) {
+	ctx := context.Background()
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+
+	store := NewCacheRunStore(memCache, WithMaxRuns(2))
+	base := time.Now()
+	for i := range 4 {
+		require.NoError(t, store.Save(ctx, &Run{
+			ID:        fmt.Sprintf("run-%d", i),
+			JobName:   "sync",
+			StartTime: base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	runs, err := store.List(ctx, "sync", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2, "只保留最近的记录")
+	assert.Equal(t, "run-3", runs[0].ID)
+}
+
+func TestScheduler_RecordsRuns(t *testing.T) {
+	ctx := context.Background()
+	stores := testRunStores(t)
+	s := MustNewScheduler(WithRunStore(stores["Cache"]), WithInstanceID("node-1"))
+
+	var calls atomic.Int32
+	done := make(chan struct{})
+	require.NoError(t, s.Add(NewJob("export").
+		Schedule("0 0 0 1 1 *").
+		Handler(func(ctx context.Context) error {
+			RecordOutput(ctx, 100)
+			RecordOutput(ctx, 28)
+			if calls.Add(1) == 1 {
+				return errors.New("first attempt failed")
+			}
+			close(done)
+			return nil
+		}).
+		Retry(1, 0).
+		MustBuild()))
+
+	require.NoError(t, s.Trigger("export"))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("任务未执行")
+	}
+	require.NoError(t, s.Shutdown(ctx))
+
+	runs, err := s.History(ctx, "export", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2, "每次尝试一条记录")
+
+	assert.Equal(t, RunStatusSuccess, runs[0].Status)
+	assert.Equal(t, 2, runs[0].Attempt)
+	assert.Equal(t, RunStatusFailed, runs[1].Status)
+	assert.Equal(t, "first attempt failed", runs[1].Error)
+	for _, run := range runs {
+		assert.Equal(t, "node-1", run.Instance)
+		assert.Equal(t, TriggerManual, run.Trigger)
+		assert.Equal(t, int64(128), run.OutputSize)
+		assert.False(t, run.EndTime.IsZero())
+	}
+
+	_, err = MustNewScheduler().History(ctx, "export", 10)
+	assert.ErrorIs(t, err, ErrNoRunStore)
+}
+
+func TestScheduler_PauseResume(t *testing.T) {
+	s := MustNewScheduler()
+	cs := s.(*cronScheduler)
+
+	var calls atomic.Int32
+	job := NewJob("sync").
+		Schedule("0 0 0 1 1 *").
+		Handler(func(context.Context) error { calls.Add(1); return nil }).
+		MustBuild()
+	require.NoError(t, s.Add(job))
+
+	require.NoError(t, s.Pause("sync"))
+	assert.Equal(t, JobStatePaused, job.State())
+
+	cs.executeJob(job, TriggerSchedule)
+	assert.Zero(t, calls.Load(), "暂停期间跳过调度触发")
+	assert.Equal(t, int64(1), job.Stats().SkipCount)
+
+	cs.executeJob(job, TriggerManual)
+	assert.Equal(t, int32(1), calls.Load(), "手动触发不受暂停影响")
+
+	require.NoError(t, s.Resume("sync"))
+	assert.Equal(t, JobStateIdle, job.State())
+	cs.executeJob(job, TriggerSchedule)
+	assert.Equal(t, int32(2), calls.Load())
+
+	assert.ErrorIs(t, s.Pause("missing"), ErrJobNotFound)
+	assert.ErrorIs(t, s.Resume("missing"), ErrJobNotFound)
+}
+
+func TestScheduler_SharedPause(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+	store := NewCachePauseStore(memCache, "")
+
+	// 两个实例共享暂停状态
+	var calls atomic.Int32
+	jobs := make([]*Job, 2)
+	schedulers := make([]*cronScheduler, 2)
+	for i := range schedulers {
+		s := MustNewScheduler(WithPauseStore(store), WithInstanceID(fmt.Sprintf("node-%d", i)))
+		jobs[i] = NewJob("sync").
+			Schedule("0 0 0 1 1 *").
+			Handler(func(context.Context) error { calls.Add(1); return nil }).
+			Distributed().
+			MustBuild()
+		require.NoError(t, s.Add(jobs[i]))
+		schedulers[i] = s.(*cronScheduler)
+	}
+
+	require.NoError(t, schedulers[0].Pause("sync"))
+	jc := schedulers[1].execute(context.Background(), jobs[1], TriggerSchedule)
+	assert.True(t, jc.Skipped, "其他实例上暂停的任务被跳过")
+	assert.Zero(t, calls.Load())
+	assert.Equal(t, JobStatePaused, jobs[1].State(), "共享状态同步到本地")
+
+	paused, err := store.IsPaused(context.Background(), "sync")
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	require.NoError(t, schedulers[1].Resume("sync"))
+	jc = schedulers[0].execute(context.Background(), jobs[0], TriggerSchedule)
+	assert.False(t, jc.Skipped, "其他实例上恢复的任务继续执行")
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, JobStateIdle, jobs[0].State())
+}
+
+// blockingPauseStore 读写时阻塞直到 release 关闭的暂停状态存储.
+type blockingPauseStore struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingPauseStore) wait() {
+	s.entered <- struct{}{}
+	<-s.release
+}
+
+func (s *blockingPauseStore) waitEntered(t *testing.T) {
+	t.Helper()
+	select {
+	case <-s.entered:
+	case <-time.After(time.Second):
+		close(s.release)
+		t.Fatal("没有访问暂停状态存储")
+	}
+}
+
+func (s *blockingPauseStore) SetPaused(context.Context, string, bool) error {
+	s.wait()
+	return nil
+}
+
+func (s *blockingPauseStore) IsPaused(context.Context, string) (bool, error) {
+	s.wait()
+	return false, nil
+}
+
+func TestScheduler_PauseStoreOutsideLock(t *testing.T) {
+	store := &blockingPauseStore{entered: make(chan struct{}, 2), release: make(chan struct{})}
+	s := MustNewScheduler(WithPauseStore(store))
+	defer s.Shutdown(context.Background())
+
+	done := make(chan struct{})
+	require.NoError(t, s.Add(NewJob("once").
+		At(time.Now()).
+		Handler(func(context.Context) error { close(done); return nil }).
+		MustBuild()))
+
+	// 一次性任务到期后读取暂停状态，Resume 写入暂停状态，两者都阻塞在存储上
+	require.NoError(t, s.Start())
+	store.waitEntered(t)
+	resumed := make(chan error, 1)
+	go func() { resumed <- s.Resume("once") }()
+	store.waitEntered(t)
+
+	listed := make(chan struct{})
+	go func() {
+		s.List()
+		_, _ = s.Get("once")
+		close(listed)
+	}()
+	select {
+	case <-listed:
+	case <-time.After(time.Second):
+		t.Fatal("访问暂停状态存储时持有调度器的锁")
+	}
+
+	close(store.release)
+	require.NoError(t, <-resumed)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("一次性任务没有执行")
+	}
+}
+
+func doAdmin(t *testing.T, h http.Handler, method, path string) (int, []byte) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec.Code, rec.Body.Bytes()
+}
+
+func TestAdminHandler_SharedPause(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+	store := NewCachePauseStore(memCache, "")
+
+	// 负载均衡后的两个实例
+	var calls atomic.Int32
+	handlers := make([]http.Handler, 2)
+	schedulers := make([]*cronScheduler, 2)
+	for i := range schedulers {
+		s := MustNewScheduler(WithPauseStore(store))
+		require.NoError(t, s.Add(NewJob("report").
+			Schedule("0 0 0 1 1 *").
+			Handler(func(context.Context) error { calls.Add(1); return nil }).
+			Distributed().
+			MustBuild()))
+		schedulers[i] = s.(*cronScheduler)
+		handlers[i] = NewAdminHandler(s)
+	}
+
+	status, _ := doAdmin(t, handlers[0], http.MethodPost, "/scheduler/jobs/report/pause")
+	require.Equal(t, http.StatusOK, status)
+	job, _ := schedulers[1].Get("report")
+	assert.True(t, schedulers[1].execute(context.Background(), job, TriggerSchedule).Skipped)
+
+	status, resp := doAdmin(t, handlers[1], http.MethodGet, "/scheduler/jobs/report")
+	require.Equal(t, http.StatusOK, status)
+	var info JobInfo
+	require.NoError(t, json.Unmarshal(resp, &info))
+	assert.True(t, info.Paused)
+
+	status, _ = doAdmin(t, handlers[1], http.MethodPost, "/scheduler/jobs/report/resume")
+	require.Equal(t, http.StatusOK, status)
+	job, _ = schedulers[0].Get("report")
+	assert.False(t, schedulers[0].execute(context.Background(), job, TriggerSchedule).Skipped)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestAdminHandler(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+
+	s := MustNewScheduler(WithRunStore(NewCacheRunStore(memCache)))
+	defer s.Shutdown(context.Background())
+
+	done := make(chan struct{}, 1)
+	for _, name := range []string{"b-job", "a-job"} {
+		require.NoError(t, s.Add(NewJob(name).
+			Schedule("0 0 0 1 1 *").
+			Handler(func(context.Context) error { done <- struct{}{}; return nil }).
+			MustBuild()))
+	}
+
+	mux := http.NewServeMux()
+	NewAdminHandler(s, WithAdminPrefix("/admin/scheduler/")).RegisterRoutes(mux)
+
+	t.Run("列出任务", func(t *testing.T) {
+		status, resp := doAdmin(t, mux, http.MethodGet, "/admin/scheduler/jobs")
+		require.Equal(t, http.StatusOK, status)
+
+		var jobs []JobInfo
+		require.NoError(t, json.Unmarshal(resp, &jobs))
+		require.Len(t, jobs, 2)
+		assert.Equal(t, "a-job", jobs[0].Name)
+		assert.Equal(t, "idle", jobs[0].State)
+	})
+
+	t.Run("暂停和恢复", func(t *testing.T) {
+		status, resp := doAdmin(t, mux, http.MethodPost, "/admin/scheduler/jobs/a-job/pause")
+		require.Equal(t, http.StatusOK, status)
+		var info JobInfo
+		require.NoError(t, json.Unmarshal(resp, &info))
+		assert.True(t, info.Paused)
+		assert.Equal(t, "paused", info.State)
+
+		status, resp = doAdmin(t, mux, http.MethodPost, "/admin/scheduler/jobs/a-job/resume")
+		require.Equal(t, http.StatusOK, status)
+		require.NoError(t, json.Unmarshal(resp, &info))
+		assert.False(t, info.Paused)
+	})
+
+	t.Run("触发并查看执行记录", func(t *testing.T) {
+		status, _ := doAdmin(t, mux, http.MethodPost, "/admin/scheduler/jobs/a-job/trigger")
+		require.Equal(t, http.StatusOK, status)
+		<-done
+
+		var runs []*Run
+		require.Eventually(t, func() bool {
+			_, resp := doAdmin(t, mux, http.MethodGet, "/admin/scheduler/jobs/a-job/runs?limit=5")
+			require.NoError(t, json.Unmarshal(resp, &runs))
+			return len(runs) == 1 && runs[0].Status == RunStatusSuccess
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, TriggerManual, runs[0].Trigger)
+	})
+
+	t.Run("错误处理", func(t *testing.T) {
+		status, resp := doAdmin(t, mux, http.MethodGet, "/admin/scheduler/jobs/missing")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.True(t, strings.Contains(string(resp), "任务未找到"))
+
+		status, _ = doAdmin(t, mux, http.MethodPost, "/admin/scheduler/jobs/missing/trigger")
+		assert.Equal(t, http.StatusNotFound, status)
+
+		status, _ = doAdmin(t, mux, http.MethodGet, "/admin/scheduler/jobs/a-job/runs?limit=abc")
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		noStore := MustNewScheduler()
+		require.NoError(t, noStore.Add(NewJob("x").Schedule("0 0 0 1 1 *").
+			Handler(func(context.Context) error { return nil }).MustBuild()))
+		status, _ = doAdmin(t, NewAdminHandler(noStore), http.MethodGet, "/scheduler/jobs/x/runs")
+		assert.Equal(t, http.StatusNotImplemented, status)
+	})
+}
@@ -41,9 +41,20 @@ type Job struct {
 	// Name 任务名称（唯一标识）.
 	Name string
 
-	// Schedule Cron 表达式.
+	// Schedule Cron 表达式，支持 @every 1m30s 等描述符.
 	Schedule string
 
+	// RunAt 一次性任务的执行时间，设置后忽略 Schedule.
+	// 执行后任务自动从调度器移除；已过期的时间会在注册后立即执行.
+	RunAt time.Time
+
+	// Jitter 每次调度触发时增加 [0, Jitter) 的随机延迟，避免多个任务同时触发.
+	// 应小于调度间隔.
+	Jitter time.Duration
+
+	// CatchUp 错过执行的补偿策略，需要配置 RunStore.
+	CatchUp CatchUpPolicy
+
 	// Handler 任务处理函数.
 	Handler JobFunc
 
@@ -66,7 +77,9 @@ type Job struct {
 
 	// internal fields
 	entryID   int
+	timer     *time.Timer
 	state     atomic.Int32
+	paused    atomic.Bool
 	stats     *JobStats
 	statsOnce sync.Once
 }
@@ -146,7 +159,7 @@ func (j *Job) Validate() error {
 	if j.Name == "" {
 		return ErrJobNameEmpty
 	}
-	if j.Schedule == "" {
+	if j.Schedule == "" && j.RunAt.IsZero() {
 		return ErrScheduleEmpty
 	}
 	if j.Handler == nil {
@@ -156,8 +169,32 @@ func (j *Job) Validate() error {
 }
 
 // State 获取任务状态.
+//
+// 已暂停且未在执行的任务返回 JobStatePaused.
 func (j *Job) State() JobState {
-	return JobState(j.state.Load())
+	state := JobState(j.state.Load())
+	if state == JobStateIdle && j.paused.Load() {
+		return JobStatePaused
+	}
+	return state
+}
+
+// IsOnce 检查是否为一次性任务.
+func (j *Job) IsOnce() bool {
+	return !j.RunAt.IsZero()
+}
+
+// describeSchedule 返回用于日志的调度描述.
+func (j *Job) describeSchedule() string {
+	if j.IsOnce() {
+		return "at " + j.RunAt.Format(time.RFC3339)
+	}
+	return j.Schedule
+}
+
+// IsPaused 检查任务是否已暂停.
+func (j *Job) IsPaused() bool {
+	return j.paused.Load()
 }
 
 // IsRunning 检查任务是否正在执行.
@@ -205,6 +242,31 @@ func NewJob(name string) *JobBuilder {
 // Schedule 设置调度表达式.
 func (b *JobBuilder) Schedule(expr string) *JobBuilder {
 	b.job.Schedule = expr
+	b.job.RunAt = time.Time{}
+	return b
+}
+
+// Every 设置固定间隔调度，等价于 Schedule("@every " + d.String()).
+func (b *JobBuilder) Every(d time.Duration) *JobBuilder {
+	return b.Schedule("@every " + d.String())
+}
+
+// At 设置为一次性任务，在 t 时执行.
+func (b *JobBuilder) At(t time.Time) *JobBuilder {
+	b.job.RunAt = t
+	b.job.Schedule = ""
+	return b
+}
+
+// Jitter 设置随机延迟上限.
+func (b *JobBuilder) Jitter(d time.Duration) *JobBuilder {
+	b.job.Jitter = d
+	return b
+}
+
+// CatchUp 设置错过执行的补偿策略.
+func (b *JobBuilder) CatchUp(policy CatchUpPolicy) *JobBuilder {
+	b.job.CatchUp = policy
 	return b
 }
 
@@ -19,6 +19,9 @@ type options struct {
 	lockTTL        time.Duration
 	withSeconds    bool
 	location       *time.Location
+	runStore       RunStore
+	pauseStore     PauseStore
+	instance       string
 }
 
 // defaultOptions 返回默认配置.
@@ -28,6 +31,7 @@ func defaultOptions() *options {
 		lockTTL:        10 * time.Minute,
 		withSeconds:    true,
 		location:       time.Local,
+		instance:       defaultInstance(),
 	}
 }
 
@@ -103,3 +107,46 @@ func WithLocation(loc *time.Location) Option {
 	}
 }
 
+
+// WithRunStore 设置执行记录存储.
+//
+// 设置后每次执行（包括重试）都会写入一条记录，可通过 History 或管理接口查看.
+//
+// 示例:
+//
+//	store := scheduler.NewGORMRunStore(db)
+//	store.AutoMigrate(ctx)
+//	s := scheduler.MustNewScheduler(scheduler.WithRunStore(store))
+func WithRunStore(store RunStore) Option {
+	return func(o *options) {
+		o.runStore = store
+	}
+}
+
+// WithPauseStore 设置暂停状态存储.
+//
+// 未设置时暂停状态只保存在当前实例的内存中，多实例部署时需要设置，
+// 否则在一个实例上暂停的分布式任务仍会由其他实例执行.
+//
+// 示例:
+//
+//	s := scheduler.MustNewScheduler(
+//	    scheduler.WithLocker(locker),
+//	    scheduler.WithPauseStore(scheduler.NewCachePauseStore(redisCache, "")),
+//	)
+func WithPauseStore(store PauseStore) Option {
+	return func(o *options) {
+		o.pauseStore = store
+	}
+}
+
+// WithInstanceID 设置实例标识，写入执行记录的 Instance 字段.
+//
+// 默认: 主机名-进程号.
+func WithInstanceID(instance string) Option {
+	return func(o *options) {
+		if instance != "" {
+			o.instance = instance
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+// PauseStore 任务暂停状态存储.
+//
+// 配置后 Pause 和 Resume 写入共享存储，每次调度触发时在获取分布式锁之前读取，
+// 在任一实例（或通过负载均衡的管理接口）暂停任务即对所有实例生效.
+type PauseStore interface {
+	// SetPaused 设置任务是否暂停.
+	SetPaused(ctx context.Context, jobName string, paused bool) error
+
+	// IsPaused 返回任务是否暂停，未设置过时返回 false.
+	IsPaused(ctx context.Context, jobName string) (bool, error)
+}
+
+// DefaultPauseKeyPrefix CachePauseStore 默认的缓存键前缀.
+const DefaultPauseKeyPrefix = "scheduler:paused:"
+
+// 暂停状态检查参数.
+const (
+	// pauseCheckTimeout 读写 PauseStore 的超时时间
+	pauseCheckTimeout = 3 * time.Second
+
+	// pausedOnceRecheck 已暂停的一次性任务重新检查共享暂停状态的间隔
+	pausedOnceRecheck = 10 * time.Second
+)
+
+// CachePauseStore 基于 cache.Cache 的暂停状态存储.
+//
+// 暂停的任务以一个不过期的键表示，恢复时删除该键.
+type CachePauseStore struct {
+	cache     cache.Cache
+	keyPrefix string
+}
+
+// NewCachePauseStore 创建基于缓存的暂停状态存储，keyPrefix 为空时使用 DefaultPauseKeyPrefix.
+//
+// 示例:
+//
+//	redisCache, _ := cache.NewCache(cache.NewRedisConfig("localhost:6379"), log)
+//	s := scheduler.MustNewScheduler(
+//	    scheduler.WithLocker(lock.NewRedis(redisCache)),
+//	    scheduler.WithPauseStore(scheduler.NewCachePauseStore(redisCache, "")),
+//	)
+func NewCachePauseStore(c cache.Cache, keyPrefix string) *CachePauseStore {
+	if c == nil {
+		panic("scheduler: 缓存不能为空")
+	}
+	if keyPrefix == "" {
+		keyPrefix = DefaultPauseKeyPrefix
+	}
+	return &CachePauseStore{cache: c, keyPrefix: keyPrefix}
+}
+
+// SetPaused 设置任务是否暂停.
+func (s *CachePauseStore) SetPaused(ctx context.Context, jobName string, paused bool) error {
+	if paused {
+		return s.cache.Set(ctx, s.keyPrefix+jobName, "1", 0)
+	}
+	return s.cache.Del(ctx, s.keyPrefix+jobName)
+}
+
+// IsPaused 返回任务是否暂停.
+func (s *CachePauseStore) IsPaused(ctx context.Context, jobName string) (bool, error) {
+	return s.cache.Exists(ctx, s.keyPrefix+jobName)
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// CatchUpPolicy 错过执行的补偿策略.
+//
+// 进程停机期间到期的执行称为错过的执行，调度器启动时根据 RunStore 中最近一次执行的开始时间计算.
+type CatchUpPolicy int
+
+const (
+	// CatchUpNone 不补偿，等待下一次调度（默认）.
+	CatchUpNone CatchUpPolicy = iota
+	// CatchUpOnce 错过一次或多次时，启动后立即补偿执行一次.
+	CatchUpOnce
+	// CatchUpAll 启动后按错过的次数依次补偿执行，最多 MaxCatchUpRuns 次.
+	CatchUpAll
+)
+
+// String 返回策略字符串.
+func (p CatchUpPolicy) String() string {
+	switch p {
+	case CatchUpNone:
+		return "none"
+	case CatchUpOnce:
+		return "once"
+	case CatchUpAll:
+		return "all"
+	default:
+		return "unknown"
+	}
+}
+
+// MaxCatchUpRuns CatchUpAll 策略最多补偿的执行次数.
+const MaxCatchUpRuns = 100
+
+// newParser 创建与调度器配置一致的表达式解析器.
+func newParser(withSeconds bool) cron.Parser {
+	if withSeconds {
+		return cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	}
+	return cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+}
+
+// jitterSchedule 在基础调度的每次触发时间上增加随机延迟.
+type jitterSchedule struct {
+	base   cron.Schedule
+	jitter time.Duration
+}
+
+// Next 返回下一次触发时间.
+func (s *jitterSchedule) Next(t time.Time) time.Time {
+	next := s.base.Next(t)
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(rand.N(s.jitter))
+}
+
+// missedRuns 计算 last 之后、now 之前错过的执行次数，最多返回 limit.
+func missedRuns(schedule cron.Schedule, last, now time.Time, limit int) int {
+	missed := 0
+	for next := schedule.Next(last); !next.IsZero() && !next.After(now) && missed < limit; next = schedule.Next(next) {
+		missed++
+	}
+	return missed
+}
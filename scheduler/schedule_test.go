@@ -0,0 +1,232 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+)
+
+func TestScheduler_OnceJob(t *testing.T) {
+	s := MustNewScheduler()
+	require.NoError(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	done := make(chan struct{})
+	job := NewJob("welcome").
+		At(time.Now().Add(50 * time.Millisecond)).
+		Handler(func(context.Context) error { close(done); return nil }).
+		MustBuild()
+	assert.True(t, job.IsOnce())
+	require.NoError(t, s.Add(job))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("一次性任务未执行")
+	}
+	require.Eventually(t, func() bool {
+		_, ok := s.Get("welcome")
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond, "执行后自动移除")
+}
+
+func TestScheduler_OncePausedUntilResume(t *testing.T) {
+	s := MustNewScheduler()
+	require.NoError(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	var calls atomic.Int32
+	require.NoError(t, s.Add(NewJob("report").
+		At(time.Now().Add(-time.Minute)).
+		Handler(func(context.Context) error { calls.Add(1); return nil }).
+		MustBuild()))
+	require.NoError(t, s.Pause("report"))
+
+	// 已过期的一次性任务注册后立即触发，暂停时保留
+	job, ok := s.Get("report")
+	require.True(t, ok)
+	require.Eventually(t, func() bool { return job.Stats().SkipCount == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Zero(t, calls.Load())
+
+	require.NoError(t, s.Resume("report"))
+	require.Eventually(t, func() bool {
+		_, ok := s.Get("report")
+		return !ok && calls.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_Reschedule(t *testing.T) {
+	s := MustNewScheduler()
+	require.NoError(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	var calls atomic.Int32
+	require.NoError(t, s.Add(NewJob("sync").
+		Schedule("0 0 0 1 1 *").
+		Handler(func(context.Context) error { calls.Add(1); return nil }).
+		MustBuild()))
+
+	assert.ErrorIs(t, s.Reschedule("sync", "invalid"), ErrScheduleInvalid)
+	assert.ErrorIs(t, s.Reschedule("sync", ""), ErrScheduleEmpty)
+	assert.ErrorIs(t, s.Reschedule("missing", "@every 1s"), ErrJobNotFound)
+
+	require.NoError(t, s.Reschedule("sync", "* * * * * *"))
+	job, _ := s.Get("sync")
+	assert.Equal(t, "* * * * * *", job.Schedule)
+	require.Eventually(t, func() bool { return calls.Load() > 0 }, 5*time.Second, 50*time.Millisecond)
+
+	assert.Len(t, s.(*cronScheduler).cron.Entries(), 1, "旧的调度已移除")
+}
+
+func TestScheduler_AddValidatesSchedule(t *testing.T) {
+	s := MustNewScheduler()
+	err := s.Add(NewJob("bad").Schedule("not a cron").
+		Handler(func(context.Context) error { return nil }).MustBuild())
+	assert.ErrorIs(t, err, ErrScheduleInvalid)
+
+	job := NewJob("interval").Every(90 * time.Second).
+		Handler(func(context.Context) error { return nil }).MustBuild()
+	assert.Equal(t, "@every 1m30s", job.Schedule)
+	require.NoError(t, s.Add(job))
+
+	_, err = NewJob("empty").Handler(func(context.Context) error { return nil }).Build()
+	assert.ErrorIs(t, err, ErrScheduleEmpty)
+}
+
+func TestJitterSchedule(t *testing.T) {
+	base, err := newParser(true).Parse("@every 1m")
+	require.NoError(t, err)
+	schedule := &jitterSchedule{base: base, jitter: 10 * time.Second}
+
+	now := time.Now().Truncate(time.Second)
+	for range 100 {
+		next := schedule.Next(now)
+		assert.False(t, next.Before(now.Add(time.Minute)))
+		assert.True(t, next.Before(now.Add(time.Minute+10*time.Second)))
+	}
+}
+
+func TestMissedRuns(t *testing.T) {
+	schedule, err := newParser(true).Parse("0 0 * * * *")
+	require.NoError(t, err)
+
+	last := time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local)
+	now := time.Date(2024, 1, 1, 11, 30, 0, 0, time.Local)
+	assert.Equal(t, 3, missedRuns(schedule, last, now, 100))
+	assert.Equal(t, 1, missedRuns(schedule, last, now, 1))
+	assert.Zero(t, missedRuns(schedule, last, last.Add(30*time.Minute), 100))
+}
+
+func TestScheduler_CatchUp(t *testing.T) {
+	ctx := context.Background()
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+
+	store := NewCacheRunStore(memCache)
+	// 上次执行在 3 小时前，停机期间错过了 3 次整点调度
+	last := time.Now().Truncate(time.Hour).Add(-3 * time.Hour)
+	for _, name := range []string{"hourly-once", "hourly-all", "hourly-none", "hourly-manual"} {
+		require.NoError(t, store.Save(ctx, &Run{
+			ID: name, JobName: name, Trigger: TriggerSchedule, Status: RunStatusSuccess, StartTime: last,
+		}))
+	}
+	// 停机期间的手动触发不影响补偿
+	require.NoError(t, store.Save(ctx, &Run{
+		ID: "manual", JobName: "hourly-manual", Trigger: TriggerManual, Status: RunStatusSuccess,
+		StartTime: time.Now().Add(-time.Minute),
+	}))
+
+	s := MustNewScheduler(WithRunStore(store))
+	counters := map[string]*atomic.Int32{}
+	for name, policy := range map[string]CatchUpPolicy{
+		"hourly-once":   CatchUpOnce,
+		"hourly-all":    CatchUpAll,
+		"hourly-none":   CatchUpNone,
+		"hourly-manual": CatchUpOnce,
+	} {
+		counter := &atomic.Int32{}
+		counters[name] = counter
+		require.NoError(t, s.Add(NewJob(name).
+			Schedule("0 0 * * * *").
+			Handler(func(context.Context) error { counter.Add(1); return nil }).
+			CatchUp(policy).
+			MustBuild()))
+	}
+
+	require.NoError(t, s.Start())
+	defer s.Shutdown(ctx)
+
+	require.Eventually(t, func() bool {
+		return counters["hourly-once"].Load() == 1 && counters["hourly-all"].Load() == 3 &&
+			counters["hourly-manual"].Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Zero(t, counters["hourly-none"].Load())
+
+	runs, err := s.History(ctx, "hourly-once", 1)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerCatchUp, runs[0].Trigger)
+}
+
+// slowRunStore List 延迟返回的执行记录存储.
+type slowRunStore struct {
+	RunStore
+	delay  time.Duration
+	listed atomic.Bool
+}
+
+func (s *slowRunStore) List(ctx context.Context, jobName string, limit int) ([]*Run, error) {
+	time.Sleep(s.delay)
+	defer s.listed.Store(true)
+	return s.RunStore.List(ctx, jobName, limit)
+}
+
+func TestScheduler_ShutdownWaitsForCatchUp(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+
+	store := &slowRunStore{RunStore: NewCacheRunStore(memCache), delay: 100 * time.Millisecond}
+	s := MustNewScheduler(WithRunStore(store))
+	require.NoError(t, s.Add(NewJob("hourly").
+		Schedule("0 0 * * * *").
+		Handler(func(context.Context) error { return nil }).
+		CatchUp(CatchUpOnce).
+		MustBuild()))
+
+	require.NoError(t, s.Start())
+	require.NoError(t, s.Shutdown(context.Background()))
+	assert.True(t, store.listed.Load(), "Shutdown 返回前补偿已结束")
+}
+
+func TestAdminHandler_Reschedule(t *testing.T) {
+	s := MustNewScheduler()
+	require.NoError(t, s.Add(NewJob("sync").Schedule("0 0 0 1 1 *").
+		Handler(func(context.Context) error { return nil }).MustBuild()))
+	h := NewAdminHandler(s)
+
+	put := func(body string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/scheduler/jobs/sync/schedule", bytes.NewBufferString(body)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, put(`{"schedule": "@every 5m"}`))
+	job, _ := s.Get("sync")
+	assert.Equal(t, "@every 5m", job.Schedule)
+
+	assert.Equal(t, http.StatusBadRequest, put(`{"schedule": "bad"}`))
+	assert.Equal(t, http.StatusBadRequest, put(`not json`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge,
+		put(`{"schedule": "`+strings.Repeat("*", maxAdminBodySize)+`"}`))
+}
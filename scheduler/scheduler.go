@@ -1,12 +1,16 @@
 // Package scheduler 提供任务调度功能.
 //
 // 特性：
-//   - 支持秒级 Cron 表达式
+//   - 支持秒级 Cron 表达式、@every 固定间隔和一次性任务
+//   - 随机延迟（Jitter）和错过执行补偿（CatchUp）
+//   - 运行时暂停、恢复和修改调度
 //   - 单例模式：防止同一任务重叠执行
 //   - 分布式锁：多实例部署时保证只有一个实例执行（复用 cache 包）
 //   - 任务状态跟踪和统计
 //   - Hook 机制：BeforeJob/AfterJob/OnError/OnSkip
 //   - 失败重试
+//   - 工作流：按依赖关系编排多个步骤，无依赖的分支并发执行
+//   - 执行记录持久化（GORM、cache）和 HTTP 管理接口
 //   - 优雅关闭
 //
 // 示例：
@@ -38,6 +42,9 @@ type Scheduler interface {
 	// Add 添加任务.
 	Add(job *Job) error
 
+	// AddWorkflow 添加工作流，工作流以其名称作为任务调度.
+	AddWorkflow(wf *Workflow) error
+
 	// Remove 移除任务.
 	Remove(name string) error
 
@@ -61,6 +68,21 @@ type Scheduler interface {
 
 	// Trigger 立即触发任务执行（不影响正常调度）.
 	Trigger(name string) error
+
+	// Pause 暂停任务，暂停期间跳过调度触发，仍可通过 Trigger 手动执行.
+	// 配置 WithPauseStore 时对所有实例生效.
+	Pause(name string) error
+
+	// Resume 恢复已暂停的任务，配置 WithPauseStore 时对所有实例生效.
+	Resume(name string) error
+
+	// Reschedule 修改任务的调度表达式，立即生效.
+	// 一次性任务修改后变为周期任务.
+	Reschedule(name, schedule string) error
+
+	// History 按开始时间倒序返回任务的执行记录.
+	// 未配置 RunStore 时返回 ErrNoRunStore.
+	History(ctx context.Context, name string, limit int) ([]*Run, error)
 }
 
 // NewScheduler 创建调度器.
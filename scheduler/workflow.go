@@ -0,0 +1,398 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StepState 工作流步骤状态.
+type StepState string
+
+const (
+	// StepPending 等待上游步骤完成.
+	StepPending StepState = "pending"
+	// StepRunning 执行中.
+	StepRunning StepState = "running"
+	// StepSuccess 执行成功.
+	StepSuccess StepState = "success"
+	// StepFailed 重试耗尽后仍失败.
+	StepFailed StepState = "failed"
+	// StepSkipped 未执行：上游失败或跳过、分布式锁被占用、前置钩子阻止或工作流被取消.
+	StepSkipped StepState = "skipped"
+)
+
+// StepRun 步骤的一次执行状态.
+type StepRun struct {
+	Name       string    `json:"name"`
+	State      StepState `json:"state"`
+	Attempts   int       `json:"attempts"`
+	Error      string    `json:"error,omitempty"`
+	SkipReason string    `json:"skip_reason,omitempty"`
+	StartTime  time.Time `json:"start_time,omitzero"`
+	EndTime    time.Time `json:"end_time,omitzero"`
+}
+
+// WorkflowRun 工作流的一次执行状态.
+type WorkflowRun struct {
+	Workflow  string              `json:"workflow"`
+	Trigger   RunTrigger          `json:"trigger"`
+	State     StepState           `json:"state"`
+	StartTime time.Time           `json:"start_time"`
+	EndTime   time.Time           `json:"end_time,omitzero"`
+	Steps     map[string]*StepRun `json:"steps"`
+}
+
+// clone 返回深拷贝.
+func (r *WorkflowRun) clone() *WorkflowRun {
+	c := *r
+	c.Steps = make(map[string]*StepRun, len(r.Steps))
+	for name, step := range r.Steps {
+		s := *step
+		c.Steps[name] = &s
+	}
+	return &c
+}
+
+// stepExecutor 执行单个步骤，由调度器实现以复用分布式锁、重试、钩子和执行记录.
+type stepExecutor interface {
+	execute(ctx context.Context, job *Job, trigger RunTrigger) *JobContext
+}
+
+// workflowStep 工作流中的步骤.
+type workflowStep struct {
+	name string
+	job  *Job
+	deps []string
+}
+
+// Workflow 由多个有依赖关系的步骤组成的有向无环图.
+//
+// 工作流整体作为一个任务调度，没有依赖关系的步骤并发执行.
+// 步骤失败（重试耗尽）或跳过时，所有下游步骤被跳过；其他分支不受影响，照常执行完成.
+// 任一步骤失败时工作流返回 ErrWorkflowFailed.
+//
+// 每个步骤是一个 Job：复用其 Timeout、RetryCount/RetryInterval 和 Distributed 配置，
+// 执行时触发调度器的全局 Hooks，配置 RunStore 时以 "工作流名/步骤名" 记录执行历史.
+type Workflow struct {
+	job   *Job
+	steps []*workflowStep
+	exec  stepExecutor
+
+	mu   sync.RWMutex
+	last *WorkflowRun
+}
+
+// Name 返回工作流名称.
+func (w *Workflow) Name() string { return w.job.Name }
+
+// Job 返回调度工作流的任务，可用于查看状态和统计.
+func (w *Workflow) Job() *Job { return w.job }
+
+// Steps 按拓扑顺序返回步骤名称.
+func (w *Workflow) Steps() []string {
+	names := make([]string, len(w.steps))
+	for i, step := range w.steps {
+		names[i] = step.name
+	}
+	return names
+}
+
+// LastRun 返回最近一次（或正在进行的）执行状态，从未执行时返回 nil.
+func (w *Workflow) LastRun() *WorkflowRun {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.last == nil {
+		return nil
+	}
+	return w.last.clone()
+}
+
+// run 执行整个工作流.
+func (w *Workflow) run(ctx context.Context) error {
+	if w.exec == nil {
+		return ErrWorkflowNotAdded
+	}
+
+	trigger := TriggerSchedule
+	if state, ok := ctx.Value(runKey{}).(*runState); ok && state.trigger != "" {
+		trigger = state.trigger
+	}
+
+	run := &WorkflowRun{
+		Workflow:  w.job.Name,
+		Trigger:   trigger,
+		State:     StepRunning,
+		StartTime: time.Now(),
+		Steps:     make(map[string]*StepRun, len(w.steps)),
+	}
+	for _, step := range w.steps {
+		run.Steps[step.name] = &StepRun{Name: step.name, State: StepPending}
+	}
+	w.mu.Lock()
+	w.last = run
+	w.mu.Unlock()
+
+	done := make(map[string]chan struct{}, len(w.steps))
+	for _, step := range w.steps {
+		done[step.name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for _, step := range w.steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[step.name])
+			w.runStep(ctx, run, step, done)
+		}()
+	}
+	wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	run.EndTime = time.Now()
+	run.State = StepSuccess
+	var failed *StepRun
+	for _, step := range w.steps {
+		sr := run.Steps[step.name]
+		switch sr.State {
+		case StepFailed:
+			run.State = StepFailed
+			if failed == nil {
+				failed = sr
+			}
+		case StepSkipped:
+			if run.State == StepSuccess {
+				run.State = StepSkipped
+			}
+		}
+	}
+	if failed != nil {
+		return fmt.Errorf("%w: 步骤 %s: %s", ErrWorkflowFailed, failed.Name, failed.Error)
+	}
+	return nil
+}
+
+// criticalPath 返回最长依赖链上各步骤最长执行时间之和.
+//
+// 步骤的最长执行时间为每次尝试的超时加上重试间隔，步骤按拓扑顺序保存，依赖总在前面.
+func (w *Workflow) criticalPath() time.Duration {
+	finish := make(map[string]time.Duration, len(w.steps))
+	var longest time.Duration
+	for _, step := range w.steps {
+		var start time.Duration
+		for _, dep := range step.deps {
+			start = max(start, finish[dep])
+		}
+		job := step.job
+		cost := job.Timeout*time.Duration(job.RetryCount+1) + job.RetryInterval*time.Duration(job.RetryCount)
+		finish[step.name] = start + cost
+		longest = max(longest, finish[step.name])
+	}
+	return longest
+}
+
+// runStep 等待上游步骤完成后执行步骤.
+func (w *Workflow) runStep(ctx context.Context, run *WorkflowRun, step *workflowStep, done map[string]chan struct{}) {
+	for _, dep := range step.deps {
+		select {
+		case <-done[dep]:
+		case <-ctx.Done():
+			w.updateStep(run, step.name, func(sr *StepRun) {
+				sr.State = StepSkipped
+				sr.SkipReason = "workflow canceled"
+			})
+			return
+		}
+	}
+
+	// 上游未成功时跳过
+	w.mu.RLock()
+	var reason string
+	for _, dep := range step.deps {
+		if state := run.Steps[dep].State; state != StepSuccess {
+			reason = fmt.Sprintf("upstream %s %s", dep, state)
+			break
+		}
+	}
+	w.mu.RUnlock()
+	if reason != "" {
+		w.updateStep(run, step.name, func(sr *StepRun) {
+			sr.State = StepSkipped
+			sr.SkipReason = reason
+		})
+		return
+	}
+
+	w.updateStep(run, step.name, func(sr *StepRun) {
+		sr.State = StepRunning
+		sr.StartTime = time.Now()
+	})
+
+	jc := w.exec.execute(ctx, step.job, run.Trigger)
+
+	w.updateStep(run, step.name, func(sr *StepRun) {
+		sr.EndTime = time.Now()
+		switch {
+		case jc.Skipped:
+			sr.State = StepSkipped
+			sr.SkipReason = jc.SkipReason
+		case jc.Error != nil:
+			sr.State = StepFailed
+			sr.Attempts = jc.Attempt
+			sr.Error = jc.Error.Error()
+		default:
+			sr.State = StepSuccess
+			sr.Attempts = jc.Attempt
+		}
+	})
+}
+
+// updateStep 在锁内修改步骤状态.
+func (w *Workflow) updateStep(run *WorkflowRun, name string, fn func(sr *StepRun)) {
+	w.mu.Lock()
+	fn(run.Steps[name])
+	w.mu.Unlock()
+}
+
+// WorkflowBuilder 工作流构建器.
+type WorkflowBuilder struct {
+	job   *JobBuilder
+	steps []*workflowStep
+	names map[string]bool
+	err   error
+}
+
+// NewWorkflow 创建工作流构建器.
+//
+// 工作流默认启用单例模式，上一次执行未完成时跳过本次调度.
+func NewWorkflow(name string) *WorkflowBuilder {
+	return &WorkflowBuilder{
+		job:   NewJob(name).Singleton(),
+		names: make(map[string]bool),
+	}
+}
+
+// Schedule 设置调度表达式.
+func (b *WorkflowBuilder) Schedule(expr string) *WorkflowBuilder {
+	b.job.Schedule(expr)
+	return b
+}
+
+// Every 设置固定间隔调度.
+func (b *WorkflowBuilder) Every(d time.Duration) *WorkflowBuilder {
+	b.job.Every(d)
+	return b
+}
+
+// At 设置为一次性工作流.
+func (b *WorkflowBuilder) At(t time.Time) *WorkflowBuilder {
+	b.job.At(t)
+	return b
+}
+
+// Timeout 设置整个工作流的超时时间，应覆盖所有步骤的执行和重试.
+//
+// 默认为最长依赖链上各步骤的超时（包括重试和重试间隔）之和.
+func (b *WorkflowBuilder) Timeout(d time.Duration) *WorkflowBuilder {
+	b.job.Timeout(d)
+	return b
+}
+
+// Distributed 启用分布式模式，多实例部署时整个工作流只在一个实例上执行.
+func (b *WorkflowBuilder) Distributed() *WorkflowBuilder {
+	b.job.Distributed()
+	return b
+}
+
+// Jitter 设置随机延迟上限.
+func (b *WorkflowBuilder) Jitter(d time.Duration) *WorkflowBuilder {
+	b.job.Jitter(d)
+	return b
+}
+
+// CatchUp 设置错过执行的补偿策略.
+func (b *WorkflowBuilder) CatchUp(policy CatchUpPolicy) *WorkflowBuilder {
+	b.job.CatchUp(policy)
+	return b
+}
+
+// Step 添加步骤，deps 为依赖的步骤名称，必须先于当前步骤添加.
+//
+// 步骤由 JobBuilder 定义，只使用 Name、Handler、Timeout、Retry 和 Distributed，忽略调度相关的配置.
+//
+// 示例:
+//
+//	scheduler.NewWorkflow("nightly-etl").
+//	    Schedule("0 0 2 * * *").
+//	    Step(scheduler.NewJob("extract").Handler(extract).Retry(3, time.Minute)).
+//	    Step(scheduler.NewJob("transform-users").Handler(transformUsers), "extract").
+//	    Step(scheduler.NewJob("transform-orders").Handler(transformOrders), "extract").
+//	    Step(scheduler.NewJob("load").Handler(load), "transform-users", "transform-orders").
+//	    MustBuild()
+func (b *WorkflowBuilder) Step(step *JobBuilder, deps ...string) *WorkflowBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	job := step.job
+	switch {
+	case job.Name == "":
+		b.err = ErrJobNameEmpty
+		return b
+	case job.Handler == nil:
+		b.err = ErrHandlerNil
+		return b
+	case b.names[job.Name]:
+		b.err = fmt.Errorf("%w: %s", ErrStepExists, job.Name)
+		return b
+	}
+	for _, dep := range deps {
+		if !b.names[dep] {
+			b.err = fmt.Errorf("%w: %s 依赖 %s", ErrStepNotFound, job.Name, dep)
+			return b
+		}
+	}
+
+	b.names[job.Name] = true
+	b.steps = append(b.steps, &workflowStep{name: job.Name, job: job, deps: deps})
+	return b
+}
+
+// Build 构建工作流.
+func (b *WorkflowBuilder) Build() (*Workflow, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.steps) == 0 {
+		return nil, ErrWorkflowEmpty
+	}
+
+	w := &Workflow{steps: b.steps}
+	b.job.Handler(w.run)
+	job, err := b.job.Build()
+	if err != nil {
+		return nil, err
+	}
+	w.job = job
+
+	// 步骤以 "工作流名/步骤名" 作为任务名，分布式锁和执行记录互不冲突
+	for _, step := range w.steps {
+		step.job.Name = job.Name + "/" + step.name
+		step.job.Schedule = ""
+		step.job.RunAt = time.Time{}
+		step.job.initStats()
+	}
+	return w, nil
+}
+
+// MustBuild 构建工作流，失败时 panic.
+func (b *WorkflowBuilder) MustBuild() *Workflow {
+	w, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tsukikage7/microservice-kit/storage/cache"
+	"github.com/Tsukikage7/microservice-kit/storage/lock"
+)
+
+// noopStep 返回什么都不做的步骤处理函数.
+func noopStep(context.Context) error { return nil }
+
+func TestWorkflowBuilder_Validate(t *testing.T) {
+	_, err := NewWorkflow("wf").Every(time.Hour).Build()
+	assert.ErrorIs(t, err, ErrWorkflowEmpty)
+
+	_, err = NewWorkflow("wf").Every(time.Hour).
+		Step(NewJob("a").Handler(noopStep)).
+		Step(NewJob("a").Handler(noopStep)).
+		Build()
+	assert.ErrorIs(t, err, ErrStepExists)
+
+	_, err = NewWorkflow("wf").Every(time.Hour).
+		Step(NewJob("a").Handler(noopStep), "b").
+		Step(NewJob("b").Handler(noopStep)).
+		Build()
+	assert.ErrorIs(t, err, ErrStepNotFound, "依赖必须先声明，保证无环")
+
+	_, err = NewWorkflow("wf").Every(time.Hour).Step(NewJob("a")).Build()
+	assert.ErrorIs(t, err, ErrHandlerNil)
+
+	_, err = NewWorkflow("wf").Step(NewJob("a").Handler(noopStep)).Build()
+	assert.ErrorIs(t, err, ErrScheduleEmpty)
+
+	wf := NewWorkflow("wf").Every(time.Hour).
+		Step(NewJob("a").Handler(noopStep)).
+		Step(NewJob("b").Handler(noopStep), "a").
+		MustBuild()
+	assert.Equal(t, "wf", wf.Name())
+	assert.Equal(t, []string{"a", "b"}, wf.Steps())
+	assert.True(t, wf.Job().Singleton)
+	assert.Nil(t, wf.LastRun())
+	assert.ErrorIs(t, wf.Job().Handler(context.Background()), ErrWorkflowNotAdded)
+}
+
+func TestWorkflow_Diamond(t *testing.T) {
+	s := MustNewScheduler()
+	require.NoError(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) JobFunc {
+		return func(context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	// b 和 c 互相等待，只有并发执行才能都完成
+	bStarted, cStarted := make(chan struct{}), make(chan struct{})
+	branch := func(name string, self, other chan struct{}) JobFunc {
+		return func(ctx context.Context) error {
+			close(self)
+			select {
+			case <-other:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return record(name)(ctx)
+		}
+	}
+
+	wf := NewWorkflow("etl").Every(time.Hour).
+		Step(NewJob("extract").Handler(record("extract"))).
+		Step(NewJob("users").Handler(branch("users", bStarted, cStarted)).Timeout(5*time.Second), "extract").
+		Step(NewJob("orders").Handler(branch("orders", cStarted, bStarted)).Timeout(5*time.Second), "extract").
+		Step(NewJob("load").Handler(record("load")), "users", "orders").
+		MustBuild()
+	require.NoError(t, s.AddWorkflow(wf))
+	require.ErrorIs(t, s.AddWorkflow(wf), ErrJobExists)
+	require.NoError(t, s.Trigger("etl"))
+
+	require.Eventually(t, func() bool {
+		run := wf.LastRun()
+		return run != nil && run.State == StepSuccess
+	}, 5*time.Second, 10*time.Millisecond)
+
+	run := wf.LastRun()
+	assert.Equal(t, TriggerManual, run.Trigger)
+	for _, name := range []string{"extract", "users", "orders", "load"} {
+		assert.Equal(t, StepSuccess, run.Steps[name].State, name)
+		assert.Equal(t, 1, run.Steps[name].Attempts, name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, order, 4)
+	assert.Equal(t, "extract", order[0])
+	assert.Equal(t, "load", order[3])
+}
+
+func TestWorkflow_FailurePropagation(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+
+	store := NewCacheRunStore(memCache)
+	var errorHooks atomic.Int32
+	s := MustNewScheduler(
+		WithRunStore(store),
+		WithHooks(NewHooks().OnError(func(context.Context, *JobContext) { errorHooks.Add(1) }).Build()),
+	)
+	require.NoError(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	var loadCalls, auditCalls atomic.Int32
+	wf := NewWorkflow("etl").Every(time.Hour).
+		Step(NewJob("extract").Handler(func(context.Context) error {
+			return errors.New("source unavailable")
+		}).Retry(1, 0)).
+		Step(NewJob("transform").Handler(noopStep), "extract").
+		Step(NewJob("load").Handler(func(context.Context) error { loadCalls.Add(1); return nil }), "transform").
+		Step(NewJob("audit").Handler(func(context.Context) error { auditCalls.Add(1); return nil })).
+		MustBuild()
+	require.NoError(t, s.AddWorkflow(wf))
+	require.NoError(t, s.Trigger("etl"))
+
+	// 步骤和工作流都触发错误钩子
+	require.Eventually(t, func() bool {
+		return errorHooks.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	run := wf.LastRun()
+	require.NotNil(t, run)
+	assert.Equal(t, StepFailed, run.State)
+
+	assert.Equal(t, StepFailed, run.Steps["extract"].State)
+	assert.Equal(t, 2, run.Steps["extract"].Attempts)
+	assert.Equal(t, "source unavailable", run.Steps["extract"].Error)
+
+	assert.Equal(t, StepSkipped, run.Steps["transform"].State)
+	assert.Equal(t, "upstream extract failed", run.Steps["transform"].SkipReason)
+	assert.Equal(t, StepSkipped, run.Steps["load"].State)
+	assert.Equal(t, "upstream transform skipped", run.Steps["load"].SkipReason)
+	assert.Zero(t, loadCalls.Load())
+
+	// 独立分支不受影响
+	assert.Equal(t, StepSuccess, run.Steps["audit"].State)
+	assert.Equal(t, int32(1), auditCalls.Load())
+
+	assert.ErrorIs(t, wf.Job().Stats().LastError, ErrWorkflowFailed)
+
+	// 步骤以 "工作流名/步骤名" 记录执行历史
+	runs, err := store.List(context.Background(), "etl/extract", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, RunStatusFailed, runs[0].Status)
+	assert.Equal(t, TriggerManual, runs[0].Trigger)
+}
+
+func TestWorkflow_DistributedStepSkipped(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(nil, &testLogger{})
+	require.NoError(t, err)
+	defer memCache.Close()
+
+	s := MustNewScheduler(WithLocker(lock.NewRedis(memCache, lock.WithOwnerID("self"))))
+	require.NoError(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	var calls atomic.Int32
+	wf := NewWorkflow("report").Every(time.Hour).
+		Step(NewJob("collect").Handler(func(context.Context) error { calls.Add(1); return nil }).Distributed()).
+		Step(NewJob("send").Handler(func(context.Context) error { calls.Add(1); return nil }), "collect").
+		MustBuild()
+	require.NoError(t, s.AddWorkflow(wf))
+
+	// 模拟其他实例持有步骤的分布式锁
+	other := lock.NewRedis(memCache, lock.WithOwnerID("other"))
+	ok, err := other.TryLock(context.Background(), "report/collect", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, s.Trigger("report"))
+	require.Eventually(t, func() bool {
+		run := wf.LastRun()
+		return run != nil && run.State == StepSkipped
+	}, 5*time.Second, 10*time.Millisecond)
+
+	run := wf.LastRun()
+	assert.Equal(t, StepSkipped, run.Steps["collect"].State)
+	assert.Equal(t, StepSkipped, run.Steps["send"].State)
+	assert.Zero(t, calls.Load())
+}
+
+func TestWorkflow_DefaultTimeout(t *testing.T) {
+	s := MustNewScheduler(WithDefaultTimeout(5 * time.Minute))
+
+	wf := NewWorkflow("etl").Every(time.Hour).
+		Step(NewJob("extract").Handler(noopStep).Timeout(time.Minute).Retry(2, 10*time.Second)).
+		Step(NewJob("transform-users").Handler(noopStep), "extract").
+		Step(NewJob("transform-orders").Handler(noopStep).Timeout(10*time.Minute), "extract").
+		Step(NewJob("load").Handler(noopStep).Timeout(time.Minute), "transform-users", "transform-orders").
+		MustBuild()
+
+	// 名称冲突时不修改工作流
+	require.NoError(t, s.Add(NewJob("etl").Every(time.Hour).Handler(noopStep).MustBuild()))
+	assert.ErrorIs(t, s.AddWorkflow(wf), ErrJobExists)
+	assert.Zero(t, wf.Job().Timeout)
+	assert.Zero(t, wf.steps[1].job.Timeout)
+	assert.ErrorIs(t, wf.Job().Handler(context.Background()), ErrWorkflowNotAdded)
+
+	require.NoError(t, s.Remove("etl"))
+	require.NoError(t, s.AddWorkflow(wf))
+	assert.Equal(t, 5*time.Minute, wf.steps[1].job.Timeout)
+	// extract 3 次尝试和 2 次间隔 + transform-orders + load
+	assert.Equal(t, 3*time.Minute+20*time.Second+10*time.Minute+time.Minute, wf.Job().Timeout)
+}
+
+func TestWorkflow_SequentialStepsWithinDefaultTimeout(t *testing.T) {
+	s := MustNewScheduler(WithDefaultTimeout(100 * time.Millisecond))
+	cs := s.(*cronScheduler)
+
+	// 每个步骤都在默认超时内完成，但总耗时超过默认超时
+	slow := func(ctx context.Context) error {
+		select {
+		case <-time.After(60 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	wf := NewWorkflow("pipeline").Every(time.Hour).
+		Step(NewJob("a").Handler(slow)).
+		Step(NewJob("b").Handler(slow), "a").
+		Step(NewJob("c").Handler(slow), "b").
+		MustBuild()
+	require.NoError(t, s.AddWorkflow(wf))
+
+	jc := cs.execute(context.Background(), wf.Job(), TriggerManual)
+	require.NoError(t, jc.Error)
+	assert.Equal(t, StepSuccess, wf.LastRun().State)
+}
@@ -10,6 +10,14 @@ import (
 const (
 	TypeRedis  = "redis"
 	TypeMemory = "memory"
+	TypeTiered = "tiered"
+)
+
+// Redis 部署模式常量.
+const (
+	ModeStandalone = "standalone"
+	ModeCluster    = "cluster"
+	ModeSentinel   = "sentinel"
 )
 
 // 默认配置值.
@@ -19,6 +27,9 @@ const (
 	DefaultReadTimeout  = 3 * time.Second
 	DefaultWriteTimeout = 3 * time.Second
 	DefaultMaxRetries   = 3
+
+	DefaultLocalTTL            = 10 * time.Second
+	DefaultInvalidationChannel = "cache:invalidation"
 )
 
 // Cache 缓存接口.
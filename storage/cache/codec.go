@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Tsukikage7/microservice-kit/util/pbjson"
+)
+
+// Codec 缓存值编解码器.
+type Codec interface {
+	// Marshal 编码值.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal 解码到 v 指向的值.
+	Unmarshal(data []byte, v any) error
+}
+
+// 内置编解码器.
+var (
+	// JSONCodec JSON 编解码器（默认）.
+	JSONCodec Codec = jsonCodec{}
+
+	// MsgpackCodec MessagePack 编解码器，比 JSON 更紧凑.
+	MsgpackCodec Codec = msgpackCodec{}
+
+	// ProtoCodec protobuf 编解码器，使用 pbjson 编码为 JSON，值必须是 proto.Message.
+	ProtoCodec Codec = protoCodec{}
+)
+
+// jsonCodec JSON 编解码器.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec MessagePack 编解码器.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) { return msgpack.Marshal(v) }
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+// protoCodec protobuf 编解码器.
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, pbjson.ErrNotProtoMessage
+	}
+	return pbjson.Marshal(msg)
+}
+
+// Unmarshal 解码 proto 消息.
+//
+// v 可以是 proto.Message，也可以是指向 proto.Message 指针的指针（如 Loader 中的 **pb.User），
+// 后者为 nil 时创建新消息.
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	if msg, ok := v.(proto.Message); ok {
+		return pbjson.Unmarshal(data, msg)
+	}
+
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Pointer {
+		return pbjson.ErrNotProtoMessage
+	}
+	elem := ptr.Elem()
+	if elem.IsNil() {
+		elem.Set(reflect.New(elem.Type().Elem()))
+	}
+	msg, ok := elem.Interface().(proto.Message)
+	if !ok {
+		return pbjson.ErrNotProtoMessage
+	}
+	return pbjson.Unmarshal(data, msg)
+}
@@ -14,9 +14,20 @@ type Config struct {
 	WriteTimeout time.Duration `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout" mapstructure:"write_timeout"`
 	MaxRetries   int           `json:"max_retries" yaml:"max_retries" toml:"max_retries" mapstructure:"max_retries"`
 
-	// 内存缓存专用
+	// Redis 部署模式，Cluster 和 Sentinel 使用 Addrs
+	Mode             string   `json:"mode" yaml:"mode" toml:"mode" mapstructure:"mode"`
+	Addrs            []string `json:"addrs" yaml:"addrs" toml:"addrs" mapstructure:"addrs"`
+	MasterName       string   `json:"master_name" yaml:"master_name" toml:"master_name" mapstructure:"master_name"`
+	SentinelPassword string   `json:"sentinel_password" yaml:"sentinel_password" toml:"sentinel_password" mapstructure:"sentinel_password"`
+	ReadFromReplica  bool     `json:"read_from_replica" yaml:"read_from_replica" toml:"read_from_replica" mapstructure:"read_from_replica"`
+
+	// 内存缓存专用，多级缓存中作为本地 LRU 容量
 	MaxSize        int           `json:"max_size" yaml:"max_size" toml:"max_size" mapstructure:"max_size"`
 	CleanupInterval time.Duration `json:"cleanup_interval" yaml:"cleanup_interval" toml:"cleanup_interval" mapstructure:"cleanup_interval"`
+
+	// 多级缓存专用
+	LocalTTL            time.Duration `json:"local_ttl" yaml:"local_ttl" toml:"local_ttl" mapstructure:"local_ttl"`
+	InvalidationChannel string        `json:"invalidation_channel" yaml:"invalidation_channel" toml:"invalidation_channel" mapstructure:"invalidation_channel"`
 }
 
 // ConfigError 配置错误.
@@ -35,23 +46,78 @@ func (c *Config) Validate() error {
 		return ErrNilConfig
 	}
 
-	if c.Type != "" && c.Type != TypeRedis && c.Type != TypeMemory {
-		return &ConfigError{Field: "type", Message: "必须是 redis 或 memory"}
+	if c.Type != "" && c.Type != TypeRedis && c.Type != TypeMemory && c.Type != TypeTiered {
+		return &ConfigError{Field: "type", Message: "必须是 redis、memory 或 tiered"}
 	}
 
-	if c.Type == TypeRedis && c.Addr == "" {
-		return &ConfigError{Field: "addr", Message: "Redis 地址不能为空"}
+	if c.Type == TypeRedis || c.Type == TypeTiered {
+		return c.validateRedis()
 	}
 
 	return nil
 }
 
+// validateRedis 按部署模式验证 Redis 地址和数据库编号.
+func (c *Config) validateRedis() error {
+	switch c.Mode {
+	case "", ModeStandalone:
+		if c.Addr == "" {
+			return &ConfigError{Field: "addr", Message: "Redis 地址不能为空"}
+		}
+	case ModeCluster:
+		if len(c.RedisAddrs()) == 0 {
+			return &ConfigError{Field: "addrs", Message: "Redis Cluster 节点地址不能为空"}
+		}
+		if c.DB != 0 {
+			return &ConfigError{Field: "db", Message: "Redis Cluster 只支持 0 号数据库"}
+		}
+	case ModeSentinel:
+		if len(c.Addrs) == 0 {
+			return &ConfigError{Field: "addrs", Message: "Sentinel 地址不能为空"}
+		}
+		if c.MasterName == "" {
+			return &ConfigError{Field: "master_name", Message: "Sentinel 主节点名称不能为空"}
+		}
+		// 从副本读取时使用 go-redis 的 FailoverClusterClient，它不支持选择数据库，会静默使用 0 号数据库
+		if c.ReadFromReplica && c.DB != 0 {
+			return &ConfigError{Field: "db", Message: "Sentinel 从副本读取时只支持 0 号数据库"}
+		}
+	default:
+		return &ConfigError{Field: "mode", Message: "必须是 standalone、cluster 或 sentinel"}
+	}
+	return nil
+}
+
+// RedisAddrs 返回部署模式对应的 Redis 地址.
+//
+// 单机模式为 Addr；Cluster 模式为 Addrs，未设置时使用 Addr；Sentinel 模式为 Sentinel 地址.
+func (c *Config) RedisAddrs() []string {
+	switch c.Mode {
+	case ModeCluster:
+		if len(c.Addrs) == 0 && c.Addr != "" {
+			return []string{c.Addr}
+		}
+		return c.Addrs
+	case ModeSentinel:
+		return c.Addrs
+	default:
+		if c.Addr == "" {
+			return nil
+		}
+		return []string{c.Addr}
+	}
+}
+
 // ApplyDefaults 应用默认值.
 func (c *Config) ApplyDefaults() {
 	if c.Type == "" {
 		c.Type = TypeRedis
 	}
 
+	if c.Mode == "" {
+		c.Mode = ModeStandalone
+	}
+
 	if c.PoolSize <= 0 {
 		c.PoolSize = DefaultPoolSize
 	}
@@ -80,6 +146,15 @@ func (c *Config) ApplyDefaults() {
 	if c.CleanupInterval <= 0 {
 		c.CleanupInterval = time.Minute
 	}
+
+	// 多级缓存默认值
+	if c.LocalTTL <= 0 {
+		c.LocalTTL = DefaultLocalTTL
+	}
+
+	if c.InvalidationChannel == "" {
+		c.InvalidationChannel = DefaultInvalidationChannel
+	}
 }
 
 // DefaultConfig 返回默认配置.
@@ -99,6 +174,39 @@ func NewRedisConfig(addr string) *Config {
 	return config
 }
 
+// NewClusterConfig 创建 Redis Cluster 配置.
+func NewClusterConfig(addrs ...string) *Config {
+	config := &Config{
+		Type:  TypeRedis,
+		Mode:  ModeCluster,
+		Addrs: addrs,
+	}
+	config.ApplyDefaults()
+	return config
+}
+
+// NewSentinelConfig 创建 Redis Sentinel 配置.
+func NewSentinelConfig(masterName string, sentinelAddrs ...string) *Config {
+	config := &Config{
+		Type:       TypeRedis,
+		Mode:       ModeSentinel,
+		MasterName: masterName,
+		Addrs:      sentinelAddrs,
+	}
+	config.ApplyDefaults()
+	return config
+}
+
+// NewTieredConfig 创建多级缓存配置.
+func NewTieredConfig(addr string) *Config {
+	config := &Config{
+		Type: TypeTiered,
+		Addr: addr,
+	}
+	config.ApplyDefaults()
+	return config
+}
+
 // NewMemoryConfig 创建内存缓存配置.
 func NewMemoryConfig() *Config {
 	config := &Config{
@@ -54,6 +54,48 @@ func (s *ConfigTestSuite) TestValidate_RedisWithAddr() {
 	s.NoError(err)
 }
 
+func (s *ConfigTestSuite) TestValidate_ClusterMode() {
+	s.NoError(NewClusterConfig("node1:6379", "node2:6379").Validate())
+	s.NoError((&Config{Type: TypeRedis, Mode: ModeCluster, Addr: "node1:6379"}).Validate())
+
+	err := (&Config{Type: TypeRedis, Mode: ModeCluster}).Validate()
+	s.IsType(&ConfigError{}, err)
+}
+
+func (s *ConfigTestSuite) TestValidate_SentinelMode() {
+	s.NoError(NewSentinelConfig("mymaster", "sentinel1:26379").Validate())
+
+	err := (&Config{Type: TypeRedis, Mode: ModeSentinel, MasterName: "mymaster"}).Validate()
+	s.IsType(&ConfigError{}, err)
+	err = (&Config{Type: TypeRedis, Mode: ModeSentinel, Addrs: []string{"sentinel1:26379"}}).Validate()
+	s.IsType(&ConfigError{}, err)
+	err = (&Config{Type: TypeRedis, Mode: "invalid", Addr: "localhost:6379"}).Validate()
+	s.IsType(&ConfigError{}, err)
+}
+
+func (s *ConfigTestSuite) TestValidate_DBRequiresSingleMaster() {
+	config := NewSentinelConfig("mymaster", "sentinel1:26379")
+	config.DB = 3
+	s.NoError(config.Validate())
+
+	// 从副本读取使用 FailoverClusterClient，无法选择数据库
+	config.ReadFromReplica = true
+	err := config.Validate()
+	s.Require().IsType(&ConfigError{}, err)
+	s.Equal("db", err.(*ConfigError).Field)
+
+	config = NewClusterConfig("n1:6379")
+	config.DB = 1
+	s.IsType(&ConfigError{}, config.Validate())
+}
+
+func (s *ConfigTestSuite) TestRedisAddrs() {
+	s.Equal([]string{"localhost:6379"}, NewRedisConfig("localhost:6379").RedisAddrs())
+	s.Equal([]string{"n1", "n2"}, NewClusterConfig("n1", "n2").RedisAddrs())
+	s.Equal([]string{"s1"}, NewSentinelConfig("mymaster", "s1").RedisAddrs())
+	s.Empty(NewMemoryConfig().RedisAddrs())
+}
+
 func (s *ConfigTestSuite) TestValidate_MemoryType() {
 	config := &Config{Type: TypeMemory}
 	err := config.Validate()
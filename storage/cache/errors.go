@@ -30,4 +30,13 @@ var (
 
 	// ErrConnect 连接失败.
 	ErrConnect = errors.New("连接失败")
+
+	// ErrEmptyPattern 匹配模式为空.
+	ErrEmptyPattern = errors.New("匹配模式为空")
+
+	// ErrWrongType 键的类型与操作不匹配.
+	ErrWrongType = errors.New("键的类型与操作不匹配")
+
+	// ErrGroupNotFound 消费者组不存在.
+	ErrGroupNotFound = errors.New("消费者组不存在")
 )
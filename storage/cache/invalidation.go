@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// 批量失效配置.
+const (
+	// tagKeyPrefix Redis 中标签集合的键前缀，集合成员为关联了该标签的缓存键，保留给内部使用
+	tagKeyPrefix = "cache:tag:"
+
+	// invalidateBatchSize 每批 SCAN、SPOP 和 DEL 处理的键数量
+	invalidateBatchSize = 500
+)
+
+// Invalidator 批量失效接口，内存缓存、Redis 缓存和多级缓存均实现.
+//
+// 通过类型断言获取:
+//
+//	inv, ok := c.(cache.Invalidator)
+//	inv.SetWithTags(ctx, "page:product:42", html, time.Hour, "product:42")
+//	inv.InvalidateTags(ctx, "product:42")
+type Invalidator interface {
+	// SetWithTags 设置键值对并关联标签.
+	SetWithTags(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error
+
+	// InvalidateTags 删除关联了任一标签的键.
+	InvalidateTags(ctx context.Context, tags ...string) error
+
+	// DeleteByPattern 删除匹配 glob 模式的键，返回删除的数量.
+	//
+	// 模式语法与 Redis SCAN MATCH 相同，支持 *、?、[abc]、[^a-z] 和 \ 转义.
+	// Redis 实现通过 SCAN 分批遍历和删除，不会像 KEYS 一样阻塞服务端.
+	// 键前缀 cache:tag: 保留给标签集合，匹配的标签集合会被跳过.
+	DeleteByPattern(ctx context.Context, pattern string) (int64, error)
+}
+
+// matchPattern 判断 key 是否匹配 Redis glob 模式.
+func matchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			matched, rest := matchClass(pattern[1:], key[0])
+			if !matched {
+				return false
+			}
+			key = key[1:]
+			pattern = rest
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(key) == 0
+}
+
+// matchClass 匹配字符类 [...]，pattern 从 [ 之后开始，返回是否匹配以及 ] 之后的模式.
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			if pattern[1] == c {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		// 跳过 ]
+		pattern = pattern[1:]
+	}
+	return matched != negate, pattern
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// InvalidatorTestSuite 批量失效测试套件，对每种缓存实现运行相同的用例.
+type InvalidatorTestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	ctx    context.Context
+	logger logger.Logger
+}
+
+func TestInvalidatorSuite(t *testing.T) {
+	suite.Run(t, new(InvalidatorTestSuite))
+}
+
+func (s *InvalidatorTestSuite) SetupSuite() {
+	log, err := logger.NewLogger(logger.DefaultConfig())
+	s.Require().NoError(err)
+	s.logger = log
+}
+
+func (s *InvalidatorTestSuite) TearDownSuite() {
+	if s.logger != nil {
+		s.logger.Close()
+	}
+}
+
+func (s *InvalidatorTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.ctx = context.Background()
+}
+
+// each 对每种缓存实现运行 fn.
+func (s *InvalidatorTestSuite) each(fn func(c Cache, inv Invalidator)) {
+	configs := map[string]*Config{
+		"memory":  NewMemoryConfig(),
+		"redis":   NewRedisConfig(s.mr.Addr()),
+		"cluster": NewClusterConfig(s.mr.Addr()),
+		"tiered":  NewTieredConfig(s.mr.Addr()),
+	}
+	for name, config := range configs {
+		s.Run(name, func() {
+			s.mr.FlushAll()
+			c, err := NewCache(config, s.logger)
+			s.Require().NoError(err)
+			defer c.Close()
+
+			inv, ok := c.(Invalidator)
+			s.Require().True(ok)
+			fn(c, inv)
+		})
+	}
+}
+
+func (s *InvalidatorTestSuite) TestInvalidateTags() {
+	s.each(func(c Cache, inv Invalidator) {
+		s.Require().NoError(inv.SetWithTags(s.ctx, "page:product:1", "<html>", time.Minute, "product:1"))
+		s.Require().NoError(inv.SetWithTags(s.ctx, "list:category:9", "[1,2]", time.Minute, "product:1", "product:2"))
+		s.Require().NoError(inv.SetWithTags(s.ctx, "page:product:2", "<html>", 0, "product:2"))
+		s.Require().NoError(c.Set(s.ctx, "untagged", "v", time.Minute))
+
+		s.NoError(inv.InvalidateTags(s.ctx, "product:1"))
+		values, err := c.MGet(s.ctx, "page:product:1", "list:category:9", "page:product:2", "untagged")
+		s.NoError(err)
+		s.Equal([]string{"", "", "<html>", "v"}, values)
+
+		// 未知标签和重复失效不报错
+		s.NoError(inv.InvalidateTags(s.ctx, "product:1", "unknown"))
+		s.NoError(inv.InvalidateTags(s.ctx, "product:2"))
+		_, err = c.Get(s.ctx, "page:product:2")
+		s.ErrorIs(err, ErrNotFound)
+	})
+}
+
+func (s *InvalidatorTestSuite) TestInvalidateTags_ManyKeys() {
+	s.each(func(c Cache, inv Invalidator) {
+		total := invalidateBatchSize*2 + 7
+		for i := range total {
+			s.Require().NoError(inv.SetWithTags(s.ctx, fmt.Sprintf("{item}:%d", i), i, time.Minute, "bulk"))
+		}
+		s.NoError(inv.InvalidateTags(s.ctx, "bulk"))
+
+		deleted, err := inv.DeleteByPattern(s.ctx, "{item}:*")
+		s.NoError(err)
+		s.Zero(deleted)
+	})
+}
+
+func (s *InvalidatorTestSuite) TestDeleteByPattern() {
+	s.each(func(c Cache, inv Invalidator) {
+		s.Require().NoError(c.MSet(s.ctx, map[string]any{
+			"user:1:profile": "a",
+			"user:2:profile": "b",
+			"user:10:orders": "c",
+			"session:1":      "d",
+		}, time.Minute))
+
+		deleted, err := inv.DeleteByPattern(s.ctx, "user:?:*")
+		s.NoError(err)
+		s.Equal(int64(2), deleted)
+
+		values, err := c.MGet(s.ctx, "user:1:profile", "user:2:profile", "user:10:orders", "session:1")
+		s.NoError(err)
+		s.Equal([]string{"", "", "c", "d"}, values)
+
+		_, err = inv.DeleteByPattern(s.ctx, "")
+		s.ErrorIs(err, ErrEmptyPattern)
+	})
+}
+
+func (s *InvalidatorTestSuite) TestDeleteByPattern_KeepsTagSets() {
+	s.each(func(c Cache, inv Invalidator) {
+		s.Require().NoError(inv.SetWithTags(s.ctx, "cache:page:1", "v", time.Minute, "product:1"))
+
+		deleted, err := inv.DeleteByPattern(s.ctx, "cache:*")
+		s.NoError(err)
+		s.Equal(int64(1), deleted)
+
+		// 标签集合被保留，重新写入后仍能按标签失效
+		s.Require().NoError(c.Set(s.ctx, "cache:page:1", "v2", time.Minute))
+		s.NoError(inv.InvalidateTags(s.ctx, "product:1"))
+		_, err = c.Get(s.ctx, "cache:page:1")
+		s.ErrorIs(err, ErrNotFound)
+	})
+}
+
+func (s *InvalidatorTestSuite) TestRedis_TagSetExpiry() {
+	c, err := NewCache(NewRedisConfig(s.mr.Addr()), s.logger)
+	s.Require().NoError(err)
+	defer c.Close()
+	inv := c.(Invalidator)
+
+	s.Require().NoError(inv.SetWithTags(s.ctx, "a", "1", time.Minute, "t"))
+	s.Require().NoError(inv.SetWithTags(s.ctx, "b", "2", time.Hour, "t"))
+	s.Require().NoError(inv.SetWithTags(s.ctx, "c", "3", time.Second, "t"))
+	s.Equal(time.Hour, s.mr.TTL(tagKeyPrefix+"t"), "集合的过期时间不早于最晚过期的键")
+
+	s.Require().NoError(inv.SetWithTags(s.ctx, "d", "4", 0, "t"))
+	s.Zero(s.mr.TTL(tagKeyPrefix + "t"))
+}
+
+func (s *InvalidatorTestSuite) TestTiered_BroadcastsInvalidation() {
+	a, err := NewCache(NewTieredConfig(s.mr.Addr()), s.logger)
+	s.Require().NoError(err)
+	defer a.Close()
+	b, err := NewCache(NewTieredConfig(s.mr.Addr()), s.logger)
+	s.Require().NoError(err)
+	defer b.Close()
+
+	s.Require().NoError(a.(Invalidator).SetWithTags(s.ctx, "page:1", "v1", time.Minute, "product:1"))
+	s.Require().NoError(a.Set(s.ctx, "report:1", "r1", time.Minute))
+	_, err = b.MGet(s.ctx, "page:1", "report:1")
+	s.Require().NoError(err)
+
+	s.NoError(a.(Invalidator).InvalidateTags(s.ctx, "product:1"))
+	deleted, err := a.(Invalidator).DeleteByPattern(s.ctx, "report:*")
+	s.NoError(err)
+	s.Equal(int64(1), deleted)
+
+	s.Eventually(func() bool {
+		values, err := b.MGet(s.ctx, "page:1", "report:1")
+		return err == nil && values[0] == "" && values[1] == ""
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "anything/with/slashes", true},
+		{"user:*", "user:1:profile", true},
+		{"user:*", "session:1", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:10", false},
+		{"*:profile", "user:1:profile", true},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{"h[ae]llo", "hello", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"{item}:*", "{item}:1", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchPattern(tt.pattern, tt.key), "%s ~ %s", tt.pattern, tt.key)
+	}
+}
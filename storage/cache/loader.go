@@ -0,0 +1,324 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// 默认加载器配置.
+const (
+	DefaultLoaderTTL = 10 * time.Minute
+)
+
+// 缓存条目标记.
+const (
+	entryValue    = 'v'
+	entryNotFound = 'n'
+)
+
+// errInvalidEntry 缓存条目格式错误，通常是同一个键被其他方式写入.
+var errInvalidEntry = errors.New("缓存条目格式错误")
+
+// LoadFunc 从数据源加载值，数据不存在时返回 ErrNotFound.
+type LoadFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// LoaderOption 加载器配置选项.
+type LoaderOption func(*loaderOptions)
+
+// loaderOptions 加载器配置.
+type loaderOptions struct {
+	codec       Codec
+	prefix      string
+	ttl         time.Duration
+	jitter      float64
+	softTTL     time.Duration
+	notFoundTTL time.Duration
+	logger      logger.Logger
+}
+
+// WithCodec 设置值编解码器.
+//
+// 默认: JSONCodec.
+func WithCodec(codec Codec) LoaderOption {
+	return func(o *loaderOptions) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
+
+// WithKeyPrefix 设置缓存键前缀，缓存键为 prefix + fmt.Sprint(key).
+func WithKeyPrefix(prefix string) LoaderOption {
+	return func(o *loaderOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithTTL 设置缓存过期时间.
+//
+// 默认: 10 分钟.
+func WithTTL(ttl time.Duration) LoaderOption {
+	return func(o *loaderOptions) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithJitter 设置过期时间的随机抖动比例，过期时间在 [ttl*(1-jitter), ttl] 之间随机，
+// 避免同一批写入的键同时过期.
+//
+// jitter 取值 (0, 1)，默认不抖动.
+func WithJitter(jitter float64) LoaderOption {
+	return func(o *loaderOptions) {
+		if jitter > 0 && jitter < 1 {
+			o.jitter = jitter
+		}
+	}
+}
+
+// WithSoftTTL 设置软过期时间.
+//
+// 缓存写入超过软过期时间后仍返回旧值，同时在后台重新加载；超过 TTL 后才同步加载.
+// 必须小于 TTL，默认不启用.
+func WithSoftTTL(ttl time.Duration) LoaderOption {
+	return func(o *loaderOptions) {
+		if ttl > 0 {
+			o.softTTL = ttl
+		}
+	}
+}
+
+// WithNotFoundTTL 设置不存在结果的缓存时间，防止不存在的键穿透到数据源.
+//
+// 默认不缓存.
+func WithNotFoundTTL(ttl time.Duration) LoaderOption {
+	return func(o *loaderOptions) {
+		if ttl > 0 {
+			o.notFoundTTL = ttl
+		}
+	}
+}
+
+// WithLoaderLogger 设置日志记录器，记录后台刷新和写入缓存失败.
+func WithLoaderLogger(log logger.Logger) LoaderOption {
+	return func(o *loaderOptions) {
+		o.logger = log
+	}
+}
+
+// Loader 类型化的旁路缓存加载器.
+//
+// Get 先读取缓存，未命中时调用 LoadFunc 加载并写入缓存:
+//   - 同一个键的并发加载合并为一次（singleflight）
+//   - 过期时间可随机抖动，避免集中过期
+//   - 超过软过期时间返回旧值并在后台刷新
+//   - 不存在的结果可缓存一段时间
+//
+// 缓存不可用时直接从数据源加载.
+//
+// 示例:
+//
+//	users := cache.NewLoader(c, func(ctx context.Context, id int64) (*User, error) {
+//	    return repo.FindUser(ctx, id)
+//	}, cache.WithKeyPrefix("user:"), cache.WithTTL(time.Hour), cache.WithSoftTTL(50*time.Minute))
+//
+//	user, err := users.Get(ctx, 42)
+type Loader[K comparable, V any] struct {
+	cache Cache
+	load  LoadFunc[K, V]
+	opts  *loaderOptions
+	group singleflight.Group
+}
+
+// NewLoader 创建加载器.
+func NewLoader[K comparable, V any](c Cache, load LoadFunc[K, V], opts ...LoaderOption) *Loader[K, V] {
+	if c == nil {
+		panic("cache: cache 不能为空")
+	}
+	if load == nil {
+		panic("cache: load 不能为空")
+	}
+
+	o := &loaderOptions{
+		codec: JSONCodec,
+		ttl:   DefaultLoaderTTL,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.softTTL >= o.ttl {
+		o.softTTL = 0
+	}
+	return &Loader[K, V]{cache: c, load: load, opts: o}
+}
+
+// Get 获取值，数据不存在时返回 ErrNotFound.
+func (l *Loader[K, V]) Get(ctx context.Context, key K) (V, error) {
+	var zero V
+	cacheKey := l.Key(key)
+
+	raw, err := l.cache.Get(ctx, cacheKey)
+	switch {
+	case err == nil:
+		value, found, stale, derr := l.decode(raw)
+		if derr != nil {
+			l.logWarn("[cache] 缓存值解码失败，重新加载", cacheKey, derr)
+			break
+		}
+		if stale {
+			l.refresh(ctx, key, cacheKey)
+		}
+		if !found {
+			return zero, ErrNotFound
+		}
+		return value, nil
+	case errors.Is(err, ErrNotFound):
+	default:
+		l.logWarn("[cache] 读取缓存失败，从数据源加载", cacheKey, err)
+	}
+
+	ch := l.group.DoChan(cacheKey, func() (any, error) {
+		// 合并的加载不受单个调用方取消的影响
+		return l.fetch(context.WithoutCancel(ctx), key, cacheKey)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		value, _ := res.Val.(V)
+		return value, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// Set 写入值，用于数据更新后主动刷新缓存.
+func (l *Loader[K, V]) Set(ctx context.Context, key K, value V) error {
+	raw, err := l.encode(value)
+	if err != nil {
+		return err
+	}
+	return l.cache.Set(ctx, l.Key(key), raw, l.jittered(l.opts.ttl))
+}
+
+// Delete 删除缓存，下次 Get 时重新加载.
+func (l *Loader[K, V]) Delete(ctx context.Context, keys ...K) error {
+	cacheKeys := make([]string, len(keys))
+	for i, key := range keys {
+		cacheKeys[i] = l.Key(key)
+	}
+	return l.cache.Del(ctx, cacheKeys...)
+}
+
+// Key 返回键对应的缓存键.
+func (l *Loader[K, V]) Key(key K) string {
+	return l.opts.prefix + fmt.Sprint(key)
+}
+
+// refresh 在后台重新加载，同一个键同时只有一个刷新.
+func (l *Loader[K, V]) refresh(ctx context.Context, key K, cacheKey string) {
+	l.group.DoChan(cacheKey, func() (any, error) {
+		value, err := l.fetch(context.WithoutCancel(ctx), key, cacheKey)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			l.logWarn("[cache] 后台刷新失败", cacheKey, err)
+		}
+		return value, err
+	})
+}
+
+// fetch 从数据源加载并写入缓存，写入失败不影响返回值.
+func (l *Loader[K, V]) fetch(ctx context.Context, key K, cacheKey string) (V, error) {
+	value, err := l.load(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		if l.opts.notFoundTTL > 0 {
+			raw := string(entryNotFound) + "|"
+			if serr := l.cache.Set(ctx, cacheKey, raw, l.opts.notFoundTTL); serr != nil {
+				l.logWarn("[cache] 写入缓存失败", cacheKey, serr)
+			}
+		}
+		return value, ErrNotFound
+	}
+	if err != nil {
+		return value, err
+	}
+
+	raw, err := l.encode(value)
+	if err != nil {
+		return value, err
+	}
+	if serr := l.cache.Set(ctx, cacheKey, raw, l.jittered(l.opts.ttl)); serr != nil {
+		l.logWarn("[cache] 写入缓存失败", cacheKey, serr)
+	}
+	return value, nil
+}
+
+// encode 编码缓存条目.
+//
+// 格式: <标记><软过期时间戳（毫秒），未启用时为空>|<编码后的值>.
+func (l *Loader[K, V]) encode(value V) (string, error) {
+	data, err := l.opts.codec.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSerialize, err)
+	}
+	return string(entryValue) + l.softExpireAt() + "|" + string(data), nil
+}
+
+// decode 解码缓存条目，返回值、是否存在以及是否超过软过期时间.
+func (l *Loader[K, V]) decode(raw string) (value V, found bool, stale bool, err error) {
+	header, data, ok := strings.Cut(raw, "|")
+	if !ok || header == "" || (header[0] != entryValue && header[0] != entryNotFound) {
+		return value, false, false, errInvalidEntry
+	}
+	if expireAt := header[1:]; expireAt != "" {
+		ms, err := strconv.ParseInt(expireAt, 10, 64)
+		if err != nil {
+			return value, false, false, err
+		}
+		stale = time.Now().UnixMilli() >= ms
+	}
+	if header[0] == entryNotFound {
+		return value, false, false, nil
+	}
+	if err := l.opts.codec.Unmarshal([]byte(data), &value); err != nil {
+		return value, false, false, err
+	}
+	return value, true, stale, nil
+}
+
+// softExpireAt 返回软过期时间戳，未启用软过期时为空.
+func (l *Loader[K, V]) softExpireAt() string {
+	if l.opts.softTTL <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(time.Now().Add(l.opts.softTTL).UnixMilli(), 10)
+}
+
+// jittered 返回抖动后的过期时间.
+func (l *Loader[K, V]) jittered(ttl time.Duration) time.Duration {
+	if l.opts.jitter <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(rand.Float64()*l.opts.jitter*float64(ttl))
+}
+
+// logWarn 记录警告日志.
+func (l *Loader[K, V]) logWarn(msg, key string, err error) {
+	if l.opts.logger == nil {
+		return
+	}
+	l.opts.logger.With(
+		logger.String("key", key),
+		logger.Err(err),
+	).Warn(msg)
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// testUser 测试用缓存值.
+type testUser struct {
+	ID   int64  `json:"id" msgpack:"id"`
+	Name string `json:"name" msgpack:"name"`
+}
+
+// LoaderTestSuite 加载器测试套件.
+type LoaderTestSuite struct {
+	suite.Suite
+	cache  Cache
+	ctx    context.Context
+	logger logger.Logger
+	loads  atomic.Int32
+}
+
+func TestLoaderSuite(t *testing.T) {
+	suite.Run(t, new(LoaderTestSuite))
+}
+
+func (s *LoaderTestSuite) SetupSuite() {
+	log, err := logger.NewLogger(logger.DefaultConfig())
+	s.Require().NoError(err)
+	s.logger = log
+}
+
+func (s *LoaderTestSuite) TearDownSuite() {
+	if s.logger != nil {
+		s.logger.Close()
+	}
+}
+
+func (s *LoaderTestSuite) SetupTest() {
+	c, err := NewMemoryCache(NewMemoryConfig(), s.logger)
+	s.Require().NoError(err)
+	s.cache = c
+	s.ctx = context.Background()
+	s.loads.Store(0)
+}
+
+func (s *LoaderTestSuite) TearDownTest() {
+	s.cache.Close()
+}
+
+// loadUser 测试数据源，ID 为 0 时不存在.
+func (s *LoaderTestSuite) loadUser(_ context.Context, id int64) (testUser, error) {
+	s.loads.Add(1)
+	if id == 0 {
+		return testUser{}, ErrNotFound
+	}
+	return testUser{ID: id, Name: "user"}, nil
+}
+
+func (s *LoaderTestSuite) TestGet_CacheAside() {
+	for name, codec := range map[string]Codec{"json": JSONCodec, "msgpack": MsgpackCodec} {
+		s.Run(name, func() {
+			s.loads.Store(0)
+			loader := NewLoader(s.cache, s.loadUser, WithCodec(codec), WithKeyPrefix(name+":user:"))
+
+			user, err := loader.Get(s.ctx, 1)
+			s.NoError(err)
+			s.Equal(testUser{ID: 1, Name: "user"}, user)
+
+			user, err = loader.Get(s.ctx, 1)
+			s.NoError(err)
+			s.Equal(int64(1), user.ID)
+			s.Equal(int32(1), s.loads.Load())
+
+			exists, err := s.cache.Exists(s.ctx, name+":user:1")
+			s.NoError(err)
+			s.True(exists)
+
+			s.NoError(loader.Delete(s.ctx, 1))
+			_, err = loader.Get(s.ctx, 1)
+			s.NoError(err)
+			s.Equal(int32(2), s.loads.Load())
+		})
+	}
+}
+
+func (s *LoaderTestSuite) TestGet_ProtoCodec() {
+	loader := NewLoader(s.cache, func(_ context.Context, key string) (*wrapperspb.StringValue, error) {
+		s.loads.Add(1)
+		return wrapperspb.String("value-" + key), nil
+	}, WithCodec(ProtoCodec))
+
+	for range 2 {
+		msg, err := loader.Get(s.ctx, "a")
+		s.NoError(err)
+		s.Equal("value-a", msg.GetValue())
+	}
+	s.Equal(int32(1), s.loads.Load())
+
+	_, err := ProtoCodec.Marshal(testUser{})
+	s.Error(err)
+}
+
+func (s *LoaderTestSuite) TestGet_Singleflight() {
+	release := make(chan struct{})
+	loader := NewLoader(s.cache, func(_ context.Context, id int64) (testUser, error) {
+		s.loads.Add(1)
+		<-release
+		return testUser{ID: id}, nil
+	})
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := loader.Get(s.ctx, 7)
+			s.NoError(err)
+			s.Equal(int64(7), user.ID)
+		}()
+	}
+	s.Eventually(func() bool { return s.loads.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	s.Equal(int32(1), s.loads.Load())
+}
+
+func (s *LoaderTestSuite) TestGet_NotFound() {
+	loader := NewLoader(s.cache, s.loadUser, WithNotFoundTTL(time.Minute))
+
+	for range 3 {
+		_, err := loader.Get(s.ctx, 0)
+		s.ErrorIs(err, ErrNotFound)
+	}
+	s.Equal(int32(1), s.loads.Load(), "不存在的结果被缓存")
+
+	// 未启用时每次都穿透到数据源
+	s.loads.Store(0)
+	uncached := NewLoader(s.cache, s.loadUser, WithKeyPrefix("uncached:"))
+	for range 3 {
+		_, err := uncached.Get(s.ctx, 0)
+		s.ErrorIs(err, ErrNotFound)
+	}
+	s.Equal(int32(3), s.loads.Load())
+}
+
+func (s *LoaderTestSuite) TestGet_LoadError() {
+	loadErr := errors.New("db unavailable")
+	loader := NewLoader(s.cache, func(context.Context, int64) (testUser, error) {
+		return testUser{}, loadErr
+	})
+	_, err := loader.Get(s.ctx, 1)
+	s.ErrorIs(err, loadErr)
+
+	exists, err := s.cache.Exists(s.ctx, "1")
+	s.NoError(err)
+	s.False(exists, "加载失败不写入缓存")
+}
+
+func (s *LoaderTestSuite) TestGet_StaleWhileRevalidate() {
+	var version atomic.Int64
+	loader := NewLoader(s.cache, func(_ context.Context, id int64) (testUser, error) {
+		s.loads.Add(1)
+		return testUser{ID: id, Name: fmt.Sprintf("v%d", version.Load())}, nil
+	}, WithTTL(time.Minute), WithSoftTTL(30*time.Millisecond))
+
+	user, err := loader.Get(s.ctx, 1)
+	s.NoError(err)
+	s.Equal("v0", user.Name)
+
+	version.Store(1)
+	time.Sleep(40 * time.Millisecond)
+
+	// 超过软过期时间返回旧值，并在后台刷新
+	user, err = loader.Get(s.ctx, 1)
+	s.NoError(err)
+	s.Equal("v0", user.Name)
+	s.Eventually(func() bool {
+		user, err := loader.Get(s.ctx, 1)
+		return err == nil && user.Name == "v1"
+	}, time.Second, 5*time.Millisecond)
+	s.Equal(int32(2), s.loads.Load())
+}
+
+func (s *LoaderTestSuite) TestSetAndJitter() {
+	loader := NewLoader(s.cache, s.loadUser, WithTTL(time.Minute), WithJitter(0.5))
+	s.NoError(loader.Set(s.ctx, 9, testUser{ID: 9, Name: "preset"}))
+
+	user, err := loader.Get(s.ctx, 9)
+	s.NoError(err)
+	s.Equal("preset", user.Name)
+	s.Zero(s.loads.Load())
+
+	ttl, err := s.cache.TTL(s.ctx, loader.Key(9))
+	s.NoError(err)
+	s.LessOrEqual(ttl, time.Minute)
+	s.GreaterOrEqual(ttl, 29*time.Second)
+}
+
+func (s *LoaderTestSuite) TestGet_InvalidEntry() {
+	s.NoError(s.cache.Set(s.ctx, "5", "raw value", time.Minute))
+	loader := NewLoader(s.cache, s.loadUser)
+
+	user, err := loader.Get(s.ctx, 5)
+	s.NoError(err)
+	s.Equal(int64(5), user.ID)
+	s.Equal(int32(1), s.loads.Load())
+}
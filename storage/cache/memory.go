@@ -13,17 +13,29 @@ import (
 // memoryCache 内存缓存实现.
 type memoryCache struct {
 	data    map[string]*cacheItem
+	tags    map[string]map[string]struct{}
 	mu      sync.RWMutex
+
+	// notify 追加 Stream 消息时关闭并替换，唤醒阻塞的 XRead 和 XReadGroup
+	notify chan struct{}
+
 	config  *Config
 	logger  logger.Logger
 	closeCh chan struct{}
 }
 
-// cacheItem 缓存项.
+// cacheItem 缓存项，kind 决定使用哪个字段保存值.
 type cacheItem struct {
-	value      string
-	expireAt   time.Time
-	noExpire   bool
+	value    string
+	expireAt time.Time
+	noExpire bool
+
+	kind   itemKind
+	hash   map[string]string
+	list   []string
+	set    map[string]struct{}
+	zset   map[string]float64
+	stream *memoryStream
 }
 
 // isExpired 检查是否过期.
@@ -43,6 +55,8 @@ func NewMemoryCache(config *Config, log logger.Logger) (Cache, error) {
 
 	c := &memoryCache{
 		data:    make(map[string]*cacheItem),
+		tags:    make(map[string]map[string]struct{}),
+		notify:  make(chan struct{}),
 		config:  config,
 		logger:  log,
 		closeCh: make(chan struct{}),
@@ -71,7 +85,7 @@ func (m *memoryCache) cleanupLoop() {
 	}
 }
 
-// cleanup 清理过期项.
+// cleanup 清理过期项，并从标签中移除已不存在的键.
 func (m *memoryCache) cleanup() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -81,6 +95,17 @@ func (m *memoryCache) cleanup() {
 			delete(m.data, key)
 		}
 	}
+
+	for tag, keys := range m.tags {
+		for key := range keys {
+			if _, ok := m.data[key]; !ok {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(m.tags, tag)
+		}
+	}
 }
 
 // Set 设置键值对.
@@ -93,6 +118,12 @@ func (m *memoryCache) Set(ctx context.Context, key string, value any, ttl time.D
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.setLocked(key, data, ttl)
+	return nil
+}
+
+// setLocked 写入字符串值，调用方必须持有写锁.
+func (m *memoryCache) setLocked(key, data string, ttl time.Duration) {
 	// 检查容量
 	if len(m.data) >= m.config.MaxSize {
 		// 简单策略：删除一个过期的或第一个
@@ -107,7 +138,6 @@ func (m *memoryCache) Set(ctx context.Context, key string, value any, ttl time.D
 	}
 
 	m.data[key] = item
-	return nil
 }
 
 // evictOne 淘汰一个缓存项.
@@ -144,6 +174,9 @@ func (m *memoryCache) Get(ctx context.Context, key string) (string, error) {
 		return "", ErrNotFound
 	}
 
+	if item.kind != kindString {
+		return "", ErrWrongType
+	}
 	return item.value, nil
 }
 
@@ -216,6 +249,9 @@ func (m *memoryCache) IncrementBy(ctx context.Context, key string, value int64)
 	var current int64
 
 	if item, ok := m.data[key]; ok && !item.isExpired() {
+		if item.kind != kindString {
+			return 0, ErrWrongType
+		}
 		if _, err := fmt.Sscanf(item.value, "%d", &current); err != nil {
 			return 0, ErrNotInteger
 		}
@@ -307,7 +343,7 @@ func (m *memoryCache) MGet(ctx context.Context, keys ...string) ([]string, error
 
 	values := make([]string, len(keys))
 	for i, key := range keys {
-		if item, ok := m.data[key]; ok && !item.isExpired() {
+		if item, ok := m.data[key]; ok && !item.isExpired() && item.kind == kindString {
 			values[i] = item.value
 		}
 	}
@@ -336,6 +372,66 @@ func (m *memoryCache) MSet(ctx context.Context, pairs map[string]any, ttl time.D
 	return nil
 }
 
+// SetWithTags 设置键值对并关联标签.
+//
+// 值和标签在同一个临界区内写入，并发的 InvalidateTags 不会留下未关联标签的新值.
+func (m *memoryCache) SetWithTags(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	data, err := m.serialize(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setLocked(key, data, ttl)
+	for _, tag := range tags {
+		keys, ok := m.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			m.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	return nil
+}
+
+// InvalidateTags 删除关联了任一标签的键.
+func (m *memoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			delete(m.data, key)
+		}
+		delete(m.tags, tag)
+	}
+	return nil
+}
+
+// DeleteByPattern 删除匹配 glob 模式的键.
+func (m *memoryCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	if pattern == "" {
+		return 0, ErrEmptyPattern
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for key, item := range m.data {
+		if !matchPattern(pattern, key) {
+			continue
+		}
+		if !item.isExpired() {
+			deleted++
+		}
+		delete(m.data, key)
+	}
+	return deleted, nil
+}
+
 // Ping 测试连接（内存缓存始终可用）.
 func (m *memoryCache) Ping(ctx context.Context) error {
 	return nil
@@ -0,0 +1,958 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// itemKind 缓存项类型.
+type itemKind uint8
+
+// 缓存项类型常量.
+const (
+	kindString itemKind = iota
+	kindHash
+	kindList
+	kindSet
+	kindZSet
+	kindStream
+)
+
+// errInvalidStreamID Stream 消息 ID 格式错误.
+var errInvalidStreamID = errors.New("无效的 Stream 消息 ID")
+
+// memoryStream 内存 Stream.
+type memoryStream struct {
+	entries []XMessage
+	ids     []streamID
+	lastID  streamID
+	groups  map[string]*streamGroup
+}
+
+// streamGroup 消费者组.
+type streamGroup struct {
+	// lastID 最后投递的消息 ID
+	lastID streamID
+	// pending 已投递未确认的消息 ID 到消费者的映射
+	pending map[string]string
+}
+
+// streamID Stream 消息 ID，格式为 <毫秒时间戳>-<序号>.
+type streamID struct {
+	ms, seq uint64
+}
+
+func (id streamID) String() string {
+	return strconv.FormatUint(id.ms, 10) + "-" + strconv.FormatUint(id.seq, 10)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+// parseStreamID 解析消息 ID，"-" 和 "+" 表示最小和最大 ID，省略序号时使用 seq.
+func parseStreamID(s string, seq uint64) (streamID, error) {
+	switch s {
+	case "-":
+		return streamID{}, nil
+	case "+":
+		return streamID{ms: math.MaxUint64, seq: math.MaxUint64}, nil
+	}
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return streamID{}, errInvalidStreamID
+	}
+	if hasSeq {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return streamID{}, errInvalidStreamID
+		}
+	}
+	return streamID{ms: ms, seq: seq}, nil
+}
+
+// len 返回数据结构的元素数量.
+func (i *cacheItem) len() int {
+	switch i.kind {
+	case kindHash:
+		return len(i.hash)
+	case kindList:
+		return len(i.list)
+	case kindSet:
+		return len(i.set)
+	case kindZSet:
+		return len(i.zset)
+	case kindStream:
+		return len(i.stream.entries)
+	default:
+		return 1
+	}
+}
+
+// lookup 返回未过期的 kind 类型的项，键不存在时返回 nil，调用方必须持有写锁.
+func (m *memoryCache) lookup(key string, kind itemKind) (*cacheItem, error) {
+	item, ok := m.data[key]
+	if !ok {
+		return nil, nil
+	}
+	if item.isExpired() {
+		delete(m.data, key)
+		return nil, nil
+	}
+	if item.kind != kind {
+		return nil, ErrWrongType
+	}
+	return item, nil
+}
+
+// lookupOrCreate 返回 kind 类型的项，键不存在时创建，调用方必须持有写锁.
+func (m *memoryCache) lookupOrCreate(key string, kind itemKind) (*cacheItem, error) {
+	item, err := m.lookup(key, kind)
+	if err != nil || item != nil {
+		return item, err
+	}
+
+	if len(m.data) >= m.config.MaxSize {
+		m.evictOne()
+	}
+	item = &cacheItem{kind: kind, noExpire: true}
+	switch kind {
+	case kindHash:
+		item.hash = make(map[string]string)
+	case kindSet:
+		item.set = make(map[string]struct{})
+	case kindZSet:
+		item.zset = make(map[string]float64)
+	case kindStream:
+		item.stream = &memoryStream{groups: make(map[string]*streamGroup)}
+	}
+	m.data[key] = item
+	return item, nil
+}
+
+// removeIfEmpty 删除没有元素的数据结构，与 Redis 一致.
+func (m *memoryCache) removeIfEmpty(key string, item *cacheItem) {
+	if item.len() == 0 {
+		delete(m.data, key)
+	}
+}
+
+// HSet 设置哈希字段.
+func (m *memoryCache) HSet(ctx context.Context, key string, values map[string]any) error {
+	if len(values) == 0 {
+		return nil
+	}
+	data, err := m.serializeMap(values)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookupOrCreate(key, kindHash)
+	if err != nil {
+		return err
+	}
+	maps.Copy(item.hash, data)
+	return nil
+}
+
+// HGet 获取哈希字段.
+func (m *memoryCache) HGet(ctx context.Context, key, field string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindHash)
+	if err != nil {
+		return "", err
+	}
+	if item == nil {
+		return "", ErrNotFound
+	}
+	value, ok := item.hash[field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// HGetAll 获取所有哈希字段.
+func (m *memoryCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindHash)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return map[string]string{}, nil
+	}
+	return maps.Clone(item.hash), nil
+}
+
+// HDel 删除哈希字段.
+func (m *memoryCache) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindHash)
+	if err != nil || item == nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, field := range fields {
+		if _, ok := item.hash[field]; ok {
+			delete(item.hash, field)
+			deleted++
+		}
+	}
+	m.removeIfEmpty(key, item)
+	return deleted, nil
+}
+
+// HIncrBy 增加哈希字段的值.
+func (m *memoryCache) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookupOrCreate(key, kindHash)
+	if err != nil {
+		return 0, err
+	}
+	var current int64
+	if value, ok := item.hash[field]; ok {
+		if current, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+	current += incr
+	item.hash[field] = strconv.FormatInt(current, 10)
+	return current, nil
+}
+
+// HLen 返回哈希字段数量.
+func (m *memoryCache) HLen(ctx context.Context, key string) (int64, error) {
+	return m.count(key, kindHash)
+}
+
+// LPush 将值插入列表头部.
+func (m *memoryCache) LPush(ctx context.Context, key string, values ...any) (int64, error) {
+	return m.push(key, values, true)
+}
+
+// RPush 将值追加到列表尾部.
+func (m *memoryCache) RPush(ctx context.Context, key string, values ...any) (int64, error) {
+	return m.push(key, values, false)
+}
+
+// push 插入列表，head 为 true 时逐个插入头部.
+func (m *memoryCache) push(key string, values []any, head bool) (int64, error) {
+	data, err := m.serializeAll(values)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(data) == 0 {
+		item, err := m.lookup(key, kindList)
+		if err != nil || item == nil {
+			return 0, err
+		}
+		return int64(len(item.list)), nil
+	}
+
+	item, err := m.lookupOrCreate(key, kindList)
+	if err != nil {
+		return 0, err
+	}
+	if head {
+		slices.Reverse(data)
+		item.list = append(data, item.list...)
+	} else {
+		item.list = append(item.list, data...)
+	}
+	return int64(len(item.list)), nil
+}
+
+// LPop 弹出列表头部的值.
+func (m *memoryCache) LPop(ctx context.Context, key string) (string, error) {
+	return m.pop(key, true)
+}
+
+// RPop 弹出列表尾部的值.
+func (m *memoryCache) RPop(ctx context.Context, key string) (string, error) {
+	return m.pop(key, false)
+}
+
+// pop 弹出列表头部或尾部的值.
+func (m *memoryCache) pop(key string, head bool) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindList)
+	if err != nil {
+		return "", err
+	}
+	if item == nil {
+		return "", ErrNotFound
+	}
+
+	var value string
+	if head {
+		value, item.list = item.list[0], item.list[1:]
+	} else {
+		last := len(item.list) - 1
+		value, item.list = item.list[last], item.list[:last]
+	}
+	m.removeIfEmpty(key, item)
+	return value, nil
+}
+
+// LRange 返回列表指定范围的值.
+func (m *memoryCache) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindList)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return []string{}, nil
+	}
+	lo, hi := rangeIndex(start, stop, len(item.list))
+	return slices.Clone(item.list[lo:hi]), nil
+}
+
+// LTrim 裁剪列表.
+func (m *memoryCache) LTrim(ctx context.Context, key string, start, stop int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindList)
+	if err != nil || item == nil {
+		return err
+	}
+	lo, hi := rangeIndex(start, stop, len(item.list))
+	item.list = slices.Clone(item.list[lo:hi])
+	m.removeIfEmpty(key, item)
+	return nil
+}
+
+// LLen 返回列表长度.
+func (m *memoryCache) LLen(ctx context.Context, key string) (int64, error) {
+	return m.count(key, kindList)
+}
+
+// SAdd 添加集合成员.
+func (m *memoryCache) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookupOrCreate(key, kindSet)
+	if err != nil {
+		return 0, err
+	}
+	var added int64
+	for _, member := range members {
+		if _, ok := item.set[member]; !ok {
+			item.set[member] = struct{}{}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// SRem 删除集合成员.
+func (m *memoryCache) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindSet)
+	if err != nil || item == nil {
+		return 0, err
+	}
+	var removed int64
+	for _, member := range members {
+		if _, ok := item.set[member]; ok {
+			delete(item.set, member)
+			removed++
+		}
+	}
+	m.removeIfEmpty(key, item)
+	return removed, nil
+}
+
+// SMembers 返回所有集合成员.
+func (m *memoryCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindSet)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return []string{}, nil
+	}
+	return slices.Collect(maps.Keys(item.set)), nil
+}
+
+// SIsMember 检查集合成员是否存在.
+func (m *memoryCache) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindSet)
+	if err != nil || item == nil {
+		return false, err
+	}
+	_, ok := item.set[member]
+	return ok, nil
+}
+
+// SCard 返回集合成员数量.
+func (m *memoryCache) SCard(ctx context.Context, key string) (int64, error) {
+	return m.count(key, kindSet)
+}
+
+// SScan 增量遍历集合成员.
+//
+// 游标为按成员排序后的下标，每次检查 count 个成员（默认 10），返回其中匹配的成员.
+func (m *memoryCache) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if count <= 0 {
+		count = 10
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindSet)
+	if err != nil {
+		return nil, 0, err
+	}
+	if item == nil {
+		return []string{}, 0, nil
+	}
+
+	members := slices.Sorted(maps.Keys(item.set))
+	end := min(cursor+uint64(count), uint64(len(members)))
+	result := []string{}
+	for i := cursor; i < end; i++ {
+		if match == "" || matchPattern(match, members[i]) {
+			result = append(result, members[i])
+		}
+	}
+	if end >= uint64(len(members)) {
+		end = 0
+	}
+	return result, end, nil
+}
+
+// ZAdd 添加有序集合成员.
+func (m *memoryCache) ZAdd(ctx context.Context, key string, members ...Z) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookupOrCreate(key, kindZSet)
+	if err != nil {
+		return 0, err
+	}
+	var added int64
+	for _, z := range members {
+		if _, ok := item.zset[z.Member]; !ok {
+			added++
+		}
+		item.zset[z.Member] = z.Score
+	}
+	return added, nil
+}
+
+// ZIncrBy 增加有序集合成员的分数.
+func (m *memoryCache) ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookupOrCreate(key, kindZSet)
+	if err != nil {
+		return 0, err
+	}
+	item.zset[member] += incr
+	return item.zset[member], nil
+}
+
+// ZScore 返回有序集合成员的分数.
+func (m *memoryCache) ZScore(ctx context.Context, key, member string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindZSet)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		return 0, ErrNotFound
+	}
+	score, ok := item.zset[member]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return score, nil
+}
+
+// ZRevRank 返回有序集合成员从高到低的排名.
+func (m *memoryCache) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindZSet)
+	if err != nil {
+		return 0, err
+	}
+	if item == nil {
+		return 0, ErrNotFound
+	}
+	if _, ok := item.zset[member]; !ok {
+		return 0, ErrNotFound
+	}
+	sorted := sortedZ(item.zset)
+	for i, z := range sorted {
+		if z.Member == member {
+			return int64(len(sorted) - 1 - i), nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+// ZRange 返回有序集合从低到高指定范围的成员.
+func (m *memoryCache) ZRange(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	return m.zrange(key, start, stop, false)
+}
+
+// ZRevRange 返回有序集合从高到低指定范围的成员.
+func (m *memoryCache) ZRevRange(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	return m.zrange(key, start, stop, true)
+}
+
+// zrange 按下标返回有序集合的成员，reverse 为 true 时从高到低.
+func (m *memoryCache) zrange(key string, start, stop int64, reverse bool) ([]Z, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindZSet)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return []Z{}, nil
+	}
+	sorted := sortedZ(item.zset)
+	if reverse {
+		slices.Reverse(sorted)
+	}
+	lo, hi := rangeIndex(start, stop, len(sorted))
+	return sorted[lo:hi], nil
+}
+
+// ZRangeByScore 返回分数在范围内的成员.
+func (m *memoryCache) ZRangeByScore(ctx context.Context, key string, by ZRangeBy) ([]Z, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindZSet)
+	if err != nil {
+		return nil, err
+	}
+	result := []Z{}
+	if item == nil {
+		return result, nil
+	}
+
+	skipped := int64(0)
+	for _, z := range sortedZ(item.zset) {
+		if z.Score < by.Min || z.Score > by.Max {
+			continue
+		}
+		if skipped < by.Offset {
+			skipped++
+			continue
+		}
+		if by.Count > 0 && int64(len(result)) >= by.Count {
+			break
+		}
+		result = append(result, z)
+	}
+	return result, nil
+}
+
+// ZRem 删除有序集合成员.
+func (m *memoryCache) ZRem(ctx context.Context, key string, members ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kindZSet)
+	if err != nil || item == nil {
+		return 0, err
+	}
+	var removed int64
+	for _, member := range members {
+		if _, ok := item.zset[member]; ok {
+			delete(item.zset, member)
+			removed++
+		}
+	}
+	m.removeIfEmpty(key, item)
+	return removed, nil
+}
+
+// ZCard 返回有序集合成员数量.
+func (m *memoryCache) ZCard(ctx context.Context, key string) (int64, error) {
+	return m.count(key, kindZSet)
+}
+
+// XAdd 追加 Stream 消息，maxLen > 0 时精确裁剪.
+func (m *memoryCache) XAdd(ctx context.Context, stream string, values map[string]any, maxLen int64) (string, error) {
+	data, err := m.serializeMap(values)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookupOrCreate(stream, kindStream)
+	if err != nil {
+		return "", err
+	}
+	s := item.stream
+
+	id := streamID{ms: uint64(time.Now().UnixMilli())}
+	if !s.lastID.less(id) {
+		id = streamID{ms: s.lastID.ms, seq: s.lastID.seq + 1}
+	}
+	s.lastID = id
+	s.ids = append(s.ids, id)
+	s.entries = append(s.entries, XMessage{ID: id.String(), Values: data})
+	if maxLen > 0 && int64(len(s.entries)) > maxLen {
+		drop := len(s.entries) - int(maxLen)
+		s.ids = slices.Clone(s.ids[drop:])
+		s.entries = slices.Clone(s.entries[drop:])
+	}
+
+	close(m.notify)
+	m.notify = make(chan struct{})
+	return id.String(), nil
+}
+
+// XRange 返回 ID 范围内的 Stream 消息.
+func (m *memoryCache) XRange(ctx context.Context, stream, start, stop string, count int64) ([]XMessage, error) {
+	from, err := parseStreamID(start, 0)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseStreamID(stop, math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(stream, kindStream)
+	if err != nil {
+		return nil, err
+	}
+	result := []XMessage{}
+	if item == nil {
+		return result, nil
+	}
+	for i, id := range item.stream.ids {
+		if id.less(from) || to.less(id) {
+			continue
+		}
+		if count > 0 && int64(len(result)) >= count {
+			break
+		}
+		result = append(result, copyMessage(item.stream.entries[i]))
+	}
+	return result, nil
+}
+
+// XRead 读取 Stream 消息.
+func (m *memoryCache) XRead(ctx context.Context, stream, id string, count int64, block time.Duration) ([]XMessage, error) {
+	var after *streamID
+	if id != "$" {
+		parsed, err := parseStreamID(id, 0)
+		if err != nil {
+			return nil, err
+		}
+		after = &parsed
+	}
+
+	return m.waitStream(ctx, block, func() ([]XMessage, error) {
+		item, err := m.lookup(stream, kindStream)
+		if err != nil {
+			return nil, err
+		}
+		if after == nil {
+			// "$" 在第一次读取时解析为当前最后的 ID
+			after = &streamID{}
+			if item != nil {
+				*after = item.stream.lastID
+			}
+		}
+		if item == nil {
+			return nil, nil
+		}
+		messages, _ := item.stream.after(*after, count)
+		return messages, nil
+	})
+}
+
+// XLen 返回 Stream 消息数量.
+func (m *memoryCache) XLen(ctx context.Context, stream string) (int64, error) {
+	return m.count(stream, kindStream)
+}
+
+// XDel 删除 Stream 消息，已删除的消息仍保留在消费者组的待确认列表中.
+func (m *memoryCache) XDel(ctx context.Context, stream string, ids ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(stream, kindStream)
+	if err != nil || item == nil {
+		return 0, err
+	}
+	s := item.stream
+	var deleted int64
+	for _, id := range ids {
+		if i := slices.IndexFunc(s.entries, func(msg XMessage) bool { return msg.ID == id }); i >= 0 {
+			s.ids = slices.Delete(s.ids, i, i+1)
+			s.entries = slices.Delete(s.entries, i, i+1)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// XGroupCreate 创建消费者组.
+func (m *memoryCache) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookupOrCreate(stream, kindStream)
+	if err != nil {
+		return err
+	}
+	s := item.stream
+	if _, ok := s.groups[group]; ok {
+		return nil
+	}
+
+	lastID := s.lastID
+	if start != "$" {
+		if lastID, err = parseStreamID(start, 0); err != nil {
+			return err
+		}
+	}
+	s.groups[group] = &streamGroup{lastID: lastID, pending: make(map[string]string)}
+	return nil
+}
+
+// XReadGroup 以消费者组读取 Stream 消息.
+func (m *memoryCache) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]XMessage, error) {
+	return m.waitStream(ctx, block, func() ([]XMessage, error) {
+		item, err := m.lookup(stream, kindStream)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			return nil, ErrGroupNotFound
+		}
+		g, ok := item.stream.groups[group]
+		if !ok {
+			return nil, ErrGroupNotFound
+		}
+
+		messages, lastID := item.stream.after(g.lastID, count)
+		if len(messages) > 0 {
+			g.lastID = lastID
+			for _, msg := range messages {
+				g.pending[msg.ID] = consumer
+			}
+		}
+		return messages, nil
+	})
+}
+
+// XAck 确认 Stream 消息.
+func (m *memoryCache) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(stream, kindStream)
+	if err != nil || item == nil {
+		return 0, err
+	}
+	g, ok := item.stream.groups[group]
+	if !ok {
+		return 0, nil
+	}
+	var acked int64
+	for _, id := range ids {
+		if _, ok := g.pending[id]; ok {
+			delete(g.pending, id)
+			acked++
+		}
+	}
+	return acked, nil
+}
+
+// waitStream 在持有写锁时调用 read，没有消息且 block > 0 时等待新消息或超时.
+func (m *memoryCache) waitStream(ctx context.Context, block time.Duration, read func() ([]XMessage, error)) ([]XMessage, error) {
+	var timeout <-chan time.Time
+	if block > 0 {
+		timer := time.NewTimer(block)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		m.mu.Lock()
+		messages, err := read()
+		notify := m.notify
+		m.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) > 0 || block <= 0 {
+			if messages == nil {
+				messages = []XMessage{}
+			}
+			return messages, nil
+		}
+
+		select {
+		case <-notify:
+		case <-timeout:
+			return []XMessage{}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// after 返回 ID 大于 id 的消息（最多 count 条，count <= 0 表示不限）以及最后一条的 ID.
+func (s *memoryStream) after(id streamID, count int64) ([]XMessage, streamID) {
+	i := sort.Search(len(s.ids), func(i int) bool { return id.less(s.ids[i]) })
+	end := len(s.ids)
+	if count > 0 && int64(end-i) > count {
+		end = i + int(count)
+	}
+	if i >= end {
+		return nil, id
+	}
+	messages := make([]XMessage, 0, end-i)
+	for _, msg := range s.entries[i:end] {
+		messages = append(messages, copyMessage(msg))
+	}
+	return messages, s.ids[end-1]
+}
+
+// count 返回数据结构的元素数量，键不存在时为 0.
+func (m *memoryCache) count(key string, kind itemKind) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, err := m.lookup(key, kind)
+	if err != nil || item == nil {
+		return 0, err
+	}
+	return int64(item.len()), nil
+}
+
+// serializeAll 序列化多个值.
+func (m *memoryCache) serializeAll(values []any) ([]string, error) {
+	data := make([]string, len(values))
+	for i, value := range values {
+		s, err := m.serialize(value)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = s
+	}
+	return data, nil
+}
+
+// serializeMap 序列化 map 中的值.
+func (m *memoryCache) serializeMap(values map[string]any) (map[string]string, error) {
+	data := make(map[string]string, len(values))
+	for field, value := range values {
+		s, err := m.serialize(value)
+		if err != nil {
+			return nil, err
+		}
+		data[field] = s
+	}
+	return data, nil
+}
+
+// sortedZ 返回按分数从低到高排序的成员，分数相同时按成员字典序，与 Redis 一致.
+func sortedZ(zset map[string]float64) []Z {
+	sorted := make([]Z, 0, len(zset))
+	for member, score := range zset {
+		sorted = append(sorted, Z{Member: member, Score: score})
+	}
+	slices.SortFunc(sorted, func(a, b Z) int {
+		switch {
+		case a.Score < b.Score:
+			return -1
+		case a.Score > b.Score:
+			return 1
+		default:
+			return strings.Compare(a.Member, b.Member)
+		}
+	})
+	return sorted
+}
+
+// rangeIndex 将 Redis 风格的闭区间下标 [start, stop] 转换为切片范围 [lo, hi).
+func rangeIndex(start, stop int64, n int) (int, int) {
+	size := int64(n)
+	if start < 0 {
+		start = max(start+size, 0)
+	}
+	if stop < 0 {
+		stop += size
+	}
+	stop = min(stop, size-1)
+	if start > stop {
+		return 0, 0
+	}
+	return int(start), int(stop) + 1
+}
+
+// copyMessage 复制消息，避免调用方修改内部数据.
+func copyMessage(msg XMessage) XMessage {
+	return XMessage{ID: msg.ID, Values: maps.Clone(msg.Values)}
+}
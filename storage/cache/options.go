@@ -20,6 +20,8 @@ func NewCache(config *Config, log logger.Logger) (Cache, error) {
 		return NewRedisCache(config, log)
 	case TypeMemory:
 		return NewMemoryCache(config, log)
+	case TypeTiered:
+		return NewTieredCache(config, log)
 	default:
 		return nil, ErrUnsupported
 	}
@@ -3,6 +3,9 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -10,35 +13,67 @@ import (
 	"github.com/Tsukikage7/microservice-kit/logger"
 )
 
+// unlockScript 只有当锁的值匹配时才删除.
+//
+// 脚本只访问 KEYS[1]，单个键总是位于同一个哈希槽，在 Cluster 模式下同样可用.
+var unlockScript = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("del", KEYS[1])
+	else
+		return 0
+	end
+`)
+
+// tagScript 将键加入标签集合，并把集合的过期时间延长到不早于键的过期时间.
+//
+// 键永不过期时集合也永不过期，集合随最后一个键过期而删除，不会无限增长.
+// 脚本只访问 KEYS[1]，在 Cluster 模式下同样可用.
+var tagScript = redis.NewScript(`
+	local exists = redis.call("exists", KEYS[1])
+	redis.call("sadd", KEYS[1], ARGV[1])
+	local ttl = tonumber(ARGV[2])
+	if ttl <= 0 then
+		redis.call("persist", KEYS[1])
+	elseif exists == 0 then
+		redis.call("pexpire", KEYS[1], ttl)
+	else
+		local current = redis.call("pttl", KEYS[1])
+		if current >= 0 and current < ttl then
+			redis.call("pexpire", KEYS[1], ttl)
+		end
+	end
+	return 1
+`)
+
 // redisCache Redis 缓存实现.
 type redisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 	config *Config
 	logger logger.Logger
+
+	// cluster 为 true 时多键命令按哈希槽拆分
+	cluster bool
 }
 
 // NewRedisCache 创建 Redis 缓存.
+//
+// 按 Config.Mode 连接单机、Cluster 或 Sentinel，Client 返回 redis.UniversalClient.
 func NewRedisCache(config *Config, log logger.Logger) (Cache, error) {
 	if config == nil {
 		return nil, ErrNilConfig
 	}
 
-	if config.Addr == "" {
+	config.ApplyDefaults()
+
+	if len(config.RedisAddrs()) == 0 {
 		return nil, ErrEmptyAddr
 	}
+	if err := config.validateRedis(); err != nil {
+		return nil, err
+	}
 
-	config.ApplyDefaults()
-
-	client := redis.NewClient(&redis.Options{
-		Addr:         config.Addr,
-		Password:     config.Password,
-		DB:           config.DB,
-		PoolSize:     config.PoolSize,
-		DialTimeout:  config.Timeout,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
-		MaxRetries:   config.MaxRetries,
-	})
+	client := newRedisClient(config)
+	addr := strings.Join(config.RedisAddrs(), ",")
 
 	// 测试连接
 	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
@@ -46,24 +81,75 @@ func NewRedisCache(config *Config, log logger.Logger) (Cache, error) {
 
 	if err := client.Ping(ctx).Err(); err != nil {
 		log.With(
-			logger.String("addr", config.Addr),
+			logger.String("mode", config.Mode),
+			logger.String("addr", addr),
 			logger.Err(err),
 		).Error("[cache] Redis 连接失败")
+		client.Close()
 		return nil, ErrConnect
 	}
 
 	log.With(
-		logger.String("addr", config.Addr),
+		logger.String("mode", config.Mode),
+		logger.String("addr", addr),
 		logger.Int("db", config.DB),
 	).Debug("[cache] Redis 连接成功")
 
 	return &redisCache{
-		client: client,
-		config: config,
-		logger: log,
+		client:  client,
+		config:  config,
+		logger:  log,
+		cluster: config.Mode == ModeCluster,
 	}, nil
 }
 
+// newRedisClient 按部署模式创建 Redis 客户端.
+func newRedisClient(config *Config) redis.UniversalClient {
+	switch config.Mode {
+	case ModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:         config.RedisAddrs(),
+			Password:      config.Password,
+			PoolSize:      config.PoolSize,
+			DialTimeout:   config.Timeout,
+			ReadTimeout:   config.ReadTimeout,
+			WriteTimeout:  config.WriteTimeout,
+			MaxRetries:    config.MaxRetries,
+			RouteRandomly: config.ReadFromReplica,
+		})
+	case ModeSentinel:
+		opts := &redis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    config.Addrs,
+			SentinelPassword: config.SentinelPassword,
+			Password:         config.Password,
+			DB:               config.DB,
+			PoolSize:         config.PoolSize,
+			DialTimeout:      config.Timeout,
+			ReadTimeout:      config.ReadTimeout,
+			WriteTimeout:     config.WriteTimeout,
+			MaxRetries:       config.MaxRetries,
+		}
+		if config.ReadFromReplica {
+			// 读命令随机路由到主节点和从节点，写命令路由到主节点
+			opts.RouteRandomly = true
+			return redis.NewFailoverClusterClient(opts)
+		}
+		return redis.NewFailoverClient(opts)
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         config.Addr,
+			Password:     config.Password,
+			DB:           config.DB,
+			PoolSize:     config.PoolSize,
+			DialTimeout:  config.Timeout,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+			MaxRetries:   config.MaxRetries,
+		})
+	}
+}
+
 // Set 设置键值对.
 func (r *redisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	data, err := r.serialize(value)
@@ -88,6 +174,7 @@ func (r *redisCache) Get(ctx context.Context, key string) (string, error) {
 		if err == redis.Nil {
 			return "", ErrNotFound
 		}
+		err = redisError(err)
 		r.logger.With(
 			logger.String("key", key),
 			logger.Err(err),
@@ -97,13 +184,13 @@ func (r *redisCache) Get(ctx context.Context, key string) (string, error) {
 	return result, nil
 }
 
-// Del 删除键.
+// Del 删除键，Cluster 模式下跨槽的键按槽拆分.
 func (r *redisCache) Del(ctx context.Context, keys ...string) error {
 	if len(keys) == 0 {
 		return nil
 	}
 
-	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+	if _, err := r.del(ctx, keys); err != nil {
 		r.logger.With(
 			logger.Any("keys", keys),
 			logger.Err(err),
@@ -223,16 +310,7 @@ func (r *redisCache) TryLock(ctx context.Context, key string, value string, ttl
 
 // Unlock 释放分布式锁.
 func (r *redisCache) Unlock(ctx context.Context, key string, value string) error {
-	// Lua 脚本：只有当锁的值匹配时才删除
-	script := redis.NewScript(`
-		if redis.call("get", KEYS[1]) == ARGV[1] then
-			return redis.call("del", KEYS[1])
-		else
-			return 0
-		end
-	`)
-
-	result, err := script.Run(ctx, r.client, []string{key}, value).Result()
+	result, err := unlockScript.Run(ctx, r.client, []string{key}, value).Result()
 	if err != nil {
 		r.logger.With(
 			logger.String("key", key),
@@ -252,13 +330,13 @@ func (r *redisCache) Unlock(ctx context.Context, key string, value string) error
 	return nil
 }
 
-// MGet 批量获取.
+// MGet 批量获取，Cluster 模式下跨槽的键按槽拆分.
 func (r *redisCache) MGet(ctx context.Context, keys ...string) ([]string, error) {
 	if len(keys) == 0 {
 		return []string{}, nil
 	}
 
-	results, err := r.client.MGet(ctx, keys...).Result()
+	results, err := r.mget(ctx, keys)
 	if err != nil {
 		r.logger.With(
 			logger.Any("keys", keys),
@@ -277,6 +355,8 @@ func (r *redisCache) MGet(ctx context.Context, keys ...string) ([]string, error)
 }
 
 // MSet 批量设置.
+//
+// 通过 pipeline 逐个执行 SET，Cluster 模式下每个命令路由到键所在的节点.
 func (r *redisCache) MSet(ctx context.Context, pairs map[string]any, ttl time.Duration) error {
 	if len(pairs) == 0 {
 		return nil
@@ -300,6 +380,64 @@ func (r *redisCache) MSet(ctx context.Context, pairs map[string]any, ttl time.Du
 	return nil
 }
 
+// SetWithTags 设置键值对并关联标签.
+//
+// 每个标签对应一个集合 cache:tag:<tag>，先关联标签再写入值，
+// 写入失败时集合中多出的键在失效时按不存在处理，不影响正确性.
+func (r *redisCache) SetWithTags(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	data, err := r.serialize(value)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) > 0 {
+		// 脚本通过 pipeline 执行时无法回退 NOSCRIPT，因此使用 EVAL
+		pipe := r.client.Pipeline()
+		for _, tag := range tags {
+			tagScript.Eval(ctx, pipe, []string{tagKeyPrefix + tag}, key, ttl.Milliseconds())
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			r.logger.With(
+				logger.String("key", key),
+				logger.Any("tags", tags),
+				logger.Err(err),
+			).Error("[cache] 关联标签失败")
+			return err
+		}
+	}
+
+	return r.Set(ctx, key, data, ttl)
+}
+
+// InvalidateTags 删除关联了任一标签的键.
+func (r *redisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	if err := r.invalidateTags(ctx, tags, nil); err != nil {
+		r.logger.With(
+			logger.Any("tags", tags),
+			logger.Err(err),
+		).Error("[cache] 标签失效失败")
+		return err
+	}
+	return nil
+}
+
+// DeleteByPattern 删除匹配 glob 模式的键.
+//
+// 通过 SCAN 分批遍历，每批删除后继续，Cluster 模式下遍历所有主节点.
+// 遍历期间新写入的键可能不会被删除；标签集合（cache:tag: 前缀）不会被删除.
+func (r *redisCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	deleted, err := r.deleteByPattern(ctx, pattern, nil)
+	if err != nil {
+		r.logger.With(
+			logger.String("pattern", pattern),
+			logger.Int64("deleted", deleted),
+			logger.Err(err),
+		).Error("[cache] 按模式删除失败")
+		return deleted, err
+	}
+	return deleted, nil
+}
+
 // Ping 测试连接.
 func (r *redisCache) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
@@ -320,6 +458,155 @@ func (r *redisCache) Client() any {
 	return r.client
 }
 
+// del 删除键并返回删除的数量，Cluster 模式下跨槽的键按槽拆分后通过 pipeline 执行.
+func (r *redisCache) del(ctx context.Context, keys []string) (int64, error) {
+	groups := r.slotGroups(keys)
+	if groups == nil {
+		return r.client.Del(ctx, keys...).Result()
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(groups))
+	for i, group := range groups {
+		cmds[i] = pipe.Del(ctx, pickKeys(keys, group)...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.Val()
+	}
+	return deleted, nil
+}
+
+// invalidateTags 分批弹出标签集合中的键并删除，每批删除后调用 onDelete.
+//
+// SPOP 是原子的，失效期间并发关联的键会在后续批次中弹出，不会遗漏.
+func (r *redisCache) invalidateTags(ctx context.Context, tags []string, onDelete func(keys []string)) error {
+	for _, tag := range tags {
+		tagKey := tagKeyPrefix + tag
+		for {
+			keys, err := r.client.SPopN(ctx, tagKey, invalidateBatchSize).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				break
+			}
+			if _, err := r.del(ctx, keys); err != nil {
+				// 放回集合，重试时仍能删除
+				r.client.SAdd(ctx, tagKey, keys)
+				return err
+			}
+			if onDelete != nil {
+				onDelete(keys)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteByPattern 删除匹配模式的键，每批删除后调用 onDelete.
+func (r *redisCache) deleteByPattern(ctx context.Context, pattern string, onDelete func(keys []string)) (int64, error) {
+	if pattern == "" {
+		return 0, ErrEmptyPattern
+	}
+
+	cluster, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return r.scanDelete(ctx, r.client, pattern, onDelete)
+	}
+
+	// SCAN 只遍历单个节点，Cluster 模式下并发遍历每个主节点
+	var deleted atomic.Int64
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		n, err := r.scanDelete(ctx, node, pattern, onDelete)
+		deleted.Add(n)
+		return err
+	})
+	return deleted.Load(), err
+}
+
+// scanDelete 通过 SCAN 遍历 node 中匹配模式的键并分批删除.
+func (r *redisCache) scanDelete(ctx context.Context, node redis.Cmdable, pattern string, onDelete func(keys []string)) (int64, error) {
+	var (
+		cursor  uint64
+		deleted int64
+	)
+	for {
+		keys, next, err := node.Scan(ctx, cursor, pattern, invalidateBatchSize).Result()
+		if err != nil {
+			return deleted, err
+		}
+		// 跳过内部的标签集合，否则之后的 InvalidateTags 找不到关联的键
+		keys = slices.DeleteFunc(keys, func(key string) bool {
+			return strings.HasPrefix(key, tagKeyPrefix)
+		})
+		if len(keys) > 0 {
+			n, err := r.del(ctx, keys)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			if onDelete != nil {
+				onDelete(keys)
+			}
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
+
+// mget 批量获取，Cluster 模式下跨槽的键按槽拆分后通过 pipeline 执行，结果按 keys 的顺序返回.
+func (r *redisCache) mget(ctx context.Context, keys []string) ([]any, error) {
+	groups := r.slotGroups(keys)
+	if groups == nil {
+		return r.client.MGet(ctx, keys...).Result()
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(groups))
+	for i, group := range groups {
+		cmds[i] = pipe.MGet(ctx, pickKeys(keys, group)...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	results := make([]any, len(keys))
+	for i, group := range groups {
+		for j, v := range cmds[i].Val() {
+			results[group[j]] = v
+		}
+	}
+	return results, nil
+}
+
+// slotGroups 返回按哈希槽拆分的键下标分组，非 Cluster 模式或所有键位于同一个槽时返回 nil.
+func (r *redisCache) slotGroups(keys []string) [][]int {
+	if !r.cluster {
+		return nil
+	}
+	groups := groupBySlot(keys)
+	if len(groups) <= 1 {
+		return nil
+	}
+	return groups
+}
+
+// pickKeys 返回下标对应的键.
+func pickKeys(keys []string, indexes []int) []string {
+	picked := make([]string, len(indexes))
+	for i, idx := range indexes {
+		picked[i] = keys[idx]
+	}
+	return picked
+}
+
 // serialize 序列化值.
 func (r *redisCache) serialize(value any) (string, error) {
 	switch v := value.(type) {
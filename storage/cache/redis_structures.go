@@ -0,0 +1,546 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// HSet 设置哈希字段.
+func (r *redisCache) HSet(ctx context.Context, key string, values map[string]any) error {
+	if len(values) == 0 {
+		return nil
+	}
+	data, err := r.serializeMap(values)
+	if err != nil {
+		return err
+	}
+	if err := r.client.HSet(ctx, key, data).Err(); err != nil {
+		return r.fail("HSET", key, err)
+	}
+	return nil
+}
+
+// HGet 获取哈希字段.
+func (r *redisCache) HGet(ctx context.Context, key, field string) (string, error) {
+	result, err := r.client.HGet(ctx, key, field).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", r.fail("HGET", key, err)
+	}
+	return result, nil
+}
+
+// HGetAll 获取所有哈希字段.
+func (r *redisCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	result, err := r.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, r.fail("HGETALL", key, err)
+	}
+	return result, nil
+}
+
+// HDel 删除哈希字段.
+func (r *redisCache) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	result, err := r.client.HDel(ctx, key, fields...).Result()
+	if err != nil {
+		return 0, r.fail("HDEL", key, err)
+	}
+	return result, nil
+}
+
+// HIncrBy 增加哈希字段的值.
+func (r *redisCache) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	result, err := r.client.HIncrBy(ctx, key, field, incr).Result()
+	if err != nil {
+		return 0, r.fail("HINCRBY", key, err)
+	}
+	return result, nil
+}
+
+// HLen 返回哈希字段数量.
+func (r *redisCache) HLen(ctx context.Context, key string) (int64, error) {
+	result, err := r.client.HLen(ctx, key).Result()
+	if err != nil {
+		return 0, r.fail("HLEN", key, err)
+	}
+	return result, nil
+}
+
+// LPush 将值插入列表头部.
+func (r *redisCache) LPush(ctx context.Context, key string, values ...any) (int64, error) {
+	if len(values) == 0 {
+		return r.LLen(ctx, key)
+	}
+	data, err := r.serializeAll(values)
+	if err != nil {
+		return 0, err
+	}
+	result, err := r.client.LPush(ctx, key, data...).Result()
+	if err != nil {
+		return 0, r.fail("LPUSH", key, err)
+	}
+	return result, nil
+}
+
+// RPush 将值追加到列表尾部.
+func (r *redisCache) RPush(ctx context.Context, key string, values ...any) (int64, error) {
+	if len(values) == 0 {
+		return r.LLen(ctx, key)
+	}
+	data, err := r.serializeAll(values)
+	if err != nil {
+		return 0, err
+	}
+	result, err := r.client.RPush(ctx, key, data...).Result()
+	if err != nil {
+		return 0, r.fail("RPUSH", key, err)
+	}
+	return result, nil
+}
+
+// LPop 弹出列表头部的值.
+func (r *redisCache) LPop(ctx context.Context, key string) (string, error) {
+	result, err := r.client.LPop(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", r.fail("LPOP", key, err)
+	}
+	return result, nil
+}
+
+// RPop 弹出列表尾部的值.
+func (r *redisCache) RPop(ctx context.Context, key string) (string, error) {
+	result, err := r.client.RPop(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", r.fail("RPOP", key, err)
+	}
+	return result, nil
+}
+
+// LRange 返回列表指定范围的值.
+func (r *redisCache) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	result, err := r.client.LRange(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, r.fail("LRANGE", key, err)
+	}
+	return result, nil
+}
+
+// LTrim 裁剪列表.
+func (r *redisCache) LTrim(ctx context.Context, key string, start, stop int64) error {
+	if err := r.client.LTrim(ctx, key, start, stop).Err(); err != nil {
+		return r.fail("LTRIM", key, err)
+	}
+	return nil
+}
+
+// LLen 返回列表长度.
+func (r *redisCache) LLen(ctx context.Context, key string) (int64, error) {
+	result, err := r.client.LLen(ctx, key).Result()
+	if err != nil {
+		return 0, r.fail("LLEN", key, err)
+	}
+	return result, nil
+}
+
+// SAdd 添加集合成员.
+func (r *redisCache) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	result, err := r.client.SAdd(ctx, key, toAny(members)...).Result()
+	if err != nil {
+		return 0, r.fail("SADD", key, err)
+	}
+	return result, nil
+}
+
+// SRem 删除集合成员.
+func (r *redisCache) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	result, err := r.client.SRem(ctx, key, toAny(members)...).Result()
+	if err != nil {
+		return 0, r.fail("SREM", key, err)
+	}
+	return result, nil
+}
+
+// SMembers 返回所有集合成员.
+func (r *redisCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	result, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, r.fail("SMEMBERS", key, err)
+	}
+	return result, nil
+}
+
+// SIsMember 检查集合成员是否存在.
+func (r *redisCache) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	result, err := r.client.SIsMember(ctx, key, member).Result()
+	if err != nil {
+		return false, r.fail("SISMEMBER", key, err)
+	}
+	return result, nil
+}
+
+// SCard 返回集合成员数量.
+func (r *redisCache) SCard(ctx context.Context, key string) (int64, error) {
+	result, err := r.client.SCard(ctx, key).Result()
+	if err != nil {
+		return 0, r.fail("SCARD", key, err)
+	}
+	return result, nil
+}
+
+// SScan 增量遍历集合成员.
+func (r *redisCache) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	members, next, err := r.client.SScan(ctx, key, cursor, match, count).Result()
+	if err != nil {
+		return nil, 0, r.fail("SSCAN", key, err)
+	}
+	return members, next, nil
+}
+
+// ZAdd 添加有序集合成员.
+func (r *redisCache) ZAdd(ctx context.Context, key string, members ...Z) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	zs := make([]*redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = &redis.Z{Member: m.Member, Score: m.Score}
+	}
+	result, err := r.client.ZAdd(ctx, key, zs...).Result()
+	if err != nil {
+		return 0, r.fail("ZADD", key, err)
+	}
+	return result, nil
+}
+
+// ZIncrBy 增加有序集合成员的分数.
+func (r *redisCache) ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error) {
+	result, err := r.client.ZIncrBy(ctx, key, incr, member).Result()
+	if err != nil {
+		return 0, r.fail("ZINCRBY", key, err)
+	}
+	return result, nil
+}
+
+// ZScore 返回有序集合成员的分数.
+func (r *redisCache) ZScore(ctx context.Context, key, member string) (float64, error) {
+	result, err := r.client.ZScore(ctx, key, member).Result()
+	if err == redis.Nil {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, r.fail("ZSCORE", key, err)
+	}
+	return result, nil
+}
+
+// ZRevRank 返回有序集合成员从高到低的排名.
+func (r *redisCache) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	result, err := r.client.ZRevRank(ctx, key, member).Result()
+	if err == redis.Nil {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, r.fail("ZREVRANK", key, err)
+	}
+	return result, nil
+}
+
+// ZRange 返回有序集合从低到高指定范围的成员.
+func (r *redisCache) ZRange(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	result, err := r.client.ZRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, r.fail("ZRANGE", key, err)
+	}
+	return fromRedisZ(result), nil
+}
+
+// ZRevRange 返回有序集合从高到低指定范围的成员.
+func (r *redisCache) ZRevRange(ctx context.Context, key string, start, stop int64) ([]Z, error) {
+	result, err := r.client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, r.fail("ZREVRANGE", key, err)
+	}
+	return fromRedisZ(result), nil
+}
+
+// ZRangeByScore 返回分数在范围内的成员.
+func (r *redisCache) ZRangeByScore(ctx context.Context, key string, by ZRangeBy) ([]Z, error) {
+	opt := &redis.ZRangeBy{
+		Min:    formatScore(by.Min),
+		Max:    formatScore(by.Max),
+		Offset: by.Offset,
+		Count:  by.Count,
+	}
+	if opt.Count <= 0 && opt.Offset > 0 {
+		// LIMIT offset -1 返回 offset 之后的全部成员
+		opt.Count = -1
+	}
+	result, err := r.client.ZRangeByScoreWithScores(ctx, key, opt).Result()
+	if err != nil {
+		return nil, r.fail("ZRANGEBYSCORE", key, err)
+	}
+	return fromRedisZ(result), nil
+}
+
+// ZRem 删除有序集合成员.
+func (r *redisCache) ZRem(ctx context.Context, key string, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	result, err := r.client.ZRem(ctx, key, toAny(members)...).Result()
+	if err != nil {
+		return 0, r.fail("ZREM", key, err)
+	}
+	return result, nil
+}
+
+// ZCard 返回有序集合成员数量.
+func (r *redisCache) ZCard(ctx context.Context, key string) (int64, error) {
+	result, err := r.client.ZCard(ctx, key).Result()
+	if err != nil {
+		return 0, r.fail("ZCARD", key, err)
+	}
+	return result, nil
+}
+
+// XAdd 追加 Stream 消息.
+func (r *redisCache) XAdd(ctx context.Context, stream string, values map[string]any, maxLen int64) (string, error) {
+	data, err := r.serializeMap(values)
+	if err != nil {
+		return "", err
+	}
+	args := &redis.XAddArgs{Stream: stream, Values: data}
+	if maxLen > 0 {
+		args.MaxLen = maxLen
+		args.Approx = true
+	}
+	id, err := r.client.XAdd(ctx, args).Result()
+	if err != nil {
+		return "", r.fail("XADD", stream, err)
+	}
+	return id, nil
+}
+
+// XRange 返回 ID 范围内的 Stream 消息.
+func (r *redisCache) XRange(ctx context.Context, stream, start, stop string, count int64) ([]XMessage, error) {
+	var cmd *redis.XMessageSliceCmd
+	if count > 0 {
+		cmd = r.client.XRangeN(ctx, stream, start, stop, count)
+	} else {
+		cmd = r.client.XRange(ctx, stream, start, stop)
+	}
+	result, err := cmd.Result()
+	if err != nil {
+		return nil, r.fail("XRANGE", stream, err)
+	}
+	return fromRedisMessages(result), nil
+}
+
+// XRead 读取 Stream 消息.
+func (r *redisCache) XRead(ctx context.Context, stream, id string, count int64, block time.Duration) ([]XMessage, error) {
+	result, err := r.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{stream, id},
+		Count:   count,
+		Block:   blockArg(block),
+	}).Result()
+	if err == redis.Nil {
+		return []XMessage{}, nil
+	}
+	if err != nil {
+		return nil, r.fail("XREAD", stream, err)
+	}
+	return fromRedisStreams(result), nil
+}
+
+// XLen 返回 Stream 消息数量.
+func (r *redisCache) XLen(ctx context.Context, stream string) (int64, error) {
+	result, err := r.client.XLen(ctx, stream).Result()
+	if err != nil {
+		return 0, r.fail("XLEN", stream, err)
+	}
+	return result, nil
+}
+
+// XDel 删除 Stream 消息.
+func (r *redisCache) XDel(ctx context.Context, stream string, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := r.client.XDel(ctx, stream, ids...).Result()
+	if err != nil {
+		return 0, r.fail("XDEL", stream, err)
+	}
+	return result, nil
+}
+
+// XGroupCreate 创建消费者组.
+func (r *redisCache) XGroupCreate(ctx context.Context, stream, group, start string) error {
+	err := r.client.XGroupCreateMkStream(ctx, stream, group, start).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return r.fail("XGROUP CREATE", stream, err)
+	}
+	return nil
+}
+
+// XReadGroup 以消费者组读取 Stream 消息.
+func (r *redisCache) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]XMessage, error) {
+	result, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    blockArg(block),
+	}).Result()
+	if err == redis.Nil {
+		return []XMessage{}, nil
+	}
+	if err != nil {
+		return nil, r.fail("XREADGROUP", stream, err)
+	}
+	return fromRedisStreams(result), nil
+}
+
+// XAck 确认 Stream 消息.
+func (r *redisCache) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := r.client.XAck(ctx, stream, group, ids...).Result()
+	if err != nil {
+		return 0, r.fail("XACK", stream, err)
+	}
+	return result, nil
+}
+
+// fail 记录数据结构操作失败并转换错误.
+func (r *redisCache) fail(op, key string, err error) error {
+	err = redisError(err)
+	r.logger.With(
+		logger.String("key", key),
+		logger.Err(err),
+	).Error("[cache] " + op + " 操作失败")
+	return err
+}
+
+// redisError 将类型不匹配和消费者组不存在转换为预定义错误.
+func redisError(err error) error {
+	switch msg := err.Error(); {
+	case strings.HasPrefix(msg, "WRONGTYPE"):
+		return fmt.Errorf("%w: %w", ErrWrongType, err)
+	case strings.HasPrefix(msg, "NOGROUP"):
+		return fmt.Errorf("%w: %w", ErrGroupNotFound, err)
+	default:
+		return err
+	}
+}
+
+// serializeAll 序列化多个值.
+func (r *redisCache) serializeAll(values []any) ([]any, error) {
+	data := make([]any, len(values))
+	for i, value := range values {
+		s, err := r.serialize(value)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = s
+	}
+	return data, nil
+}
+
+// serializeMap 序列化 map 中的值.
+func (r *redisCache) serializeMap(values map[string]any) (map[string]any, error) {
+	data := make(map[string]any, len(values))
+	for field, value := range values {
+		s, err := r.serialize(value)
+		if err != nil {
+			return nil, err
+		}
+		data[field] = s
+	}
+	return data, nil
+}
+
+// blockArg 转换阻塞时间，go-redis 中 Block 为 0 表示永久阻塞，负数表示不阻塞.
+func blockArg(block time.Duration) time.Duration {
+	if block <= 0 {
+		return -1
+	}
+	return block
+}
+
+// formatScore 格式化分数边界，无穷大使用 Redis 的 -inf 和 +inf.
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, -1):
+		return "-inf"
+	case math.IsInf(score, 1):
+		return "+inf"
+	default:
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+}
+
+// toAny 转换为 []any.
+func toAny(values []string) []any {
+	result := make([]any, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// fromRedisZ 转换有序集合成员.
+func fromRedisZ(zs []redis.Z) []Z {
+	result := make([]Z, len(zs))
+	for i, z := range zs {
+		member, _ := z.Member.(string)
+		result[i] = Z{Member: member, Score: z.Score}
+	}
+	return result
+}
+
+// fromRedisStreams 转换单个 Stream 的读取结果.
+func fromRedisStreams(streams []redis.XStream) []XMessage {
+	if len(streams) == 0 {
+		return []XMessage{}
+	}
+	return fromRedisMessages(streams[0].Messages)
+}
+
+// fromRedisMessages 转换 Stream 消息.
+func fromRedisMessages(messages []redis.XMessage) []XMessage {
+	result := make([]XMessage, len(messages))
+	for i, msg := range messages {
+		values := make(map[string]string, len(msg.Values))
+		for field, value := range msg.Values {
+			values[field] = fmt.Sprint(value)
+		}
+		result[i] = XMessage{ID: msg.ID, Values: values}
+	}
+	return result
+}
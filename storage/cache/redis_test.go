@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// RedisCacheTestSuite Redis 缓存测试套件.
+type RedisCacheTestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	ctx    context.Context
+	logger logger.Logger
+}
+
+func TestRedisCacheSuite(t *testing.T) {
+	suite.Run(t, new(RedisCacheTestSuite))
+}
+
+func (s *RedisCacheTestSuite) SetupSuite() {
+	log, err := logger.NewLogger(logger.DefaultConfig())
+	s.Require().NoError(err)
+	s.logger = log
+}
+
+func (s *RedisCacheTestSuite) TearDownSuite() {
+	if s.logger != nil {
+		s.logger.Close()
+	}
+}
+
+func (s *RedisCacheTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.ctx = context.Background()
+}
+
+func (s *RedisCacheTestSuite) TestNew_Standalone() {
+	c, err := NewCache(NewRedisConfig(s.mr.Addr()), s.logger)
+	s.Require().NoError(err)
+	defer c.Close()
+
+	_, ok := c.Client().(*redis.Client)
+	s.True(ok)
+	_, ok = c.Client().(redis.UniversalClient)
+	s.True(ok)
+}
+
+func (s *RedisCacheTestSuite) TestNew_EmptyAddr() {
+	_, err := NewRedisCache(&Config{Mode: ModeCluster}, s.logger)
+	s.ErrorIs(err, ErrEmptyAddr)
+
+	_, err = NewRedisCache(&Config{Mode: ModeSentinel, Addrs: []string{"localhost:26379"}}, s.logger)
+	s.IsType(&ConfigError{}, err)
+}
+
+func (s *RedisCacheTestSuite) TestCluster_CrossSlot() {
+	c, err := NewCache(NewClusterConfig(s.mr.Addr()), s.logger)
+	s.Require().NoError(err)
+	defer c.Close()
+
+	_, ok := c.Client().(*redis.ClusterClient)
+	s.True(ok)
+
+	keys := []string{"{user:1}:name", "{user:2}:name", "{user:1}:email", "missing"}
+	s.NotEqual(KeySlot(keys[0]), KeySlot(keys[1]))
+
+	s.Require().NoError(c.MSet(s.ctx, map[string]any{
+		keys[0]: "alice",
+		keys[1]: "bob",
+		keys[2]: "alice@example.com",
+	}, time.Minute))
+
+	values, err := c.MGet(s.ctx, keys...)
+	s.NoError(err)
+	s.Equal([]string{"alice", "bob", "alice@example.com", ""}, values)
+
+	s.NoError(c.Del(s.ctx, keys[0], keys[1]))
+	values, err = c.MGet(s.ctx, keys...)
+	s.NoError(err)
+	s.Equal([]string{"", "", "alice@example.com", ""}, values)
+}
+
+func (s *RedisCacheTestSuite) TestCluster_Lock() {
+	c, err := NewCache(NewClusterConfig(s.mr.Addr()), s.logger)
+	s.Require().NoError(err)
+	defer c.Close()
+
+	ok, err := c.TryLock(s.ctx, "lock:order:1", "owner", time.Minute)
+	s.NoError(err)
+	s.True(ok)
+	s.ErrorIs(c.Unlock(s.ctx, "lock:order:1", "other"), ErrLockNotHeld)
+	s.NoError(c.Unlock(s.ctx, "lock:order:1", "owner"))
+}
@@ -0,0 +1,62 @@
+package cache
+
+import "strings"
+
+// clusterSlots Redis Cluster 哈希槽数量.
+const clusterSlots = 16384
+
+// crc16Table CRC16-CCITT (XModem) 查找表，Redis Cluster 用于计算哈希槽.
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// crc16 计算 CRC16-CCITT (XModem).
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^s[i]]
+	}
+	return crc
+}
+
+// KeySlot 返回键在 Redis Cluster 中的哈希槽.
+//
+// 键包含非空的哈希标签 {tag} 时只对标签计算，因此 "{user:1}:profile" 和 "{user:1}:orders"
+// 位于同一个槽，可以在 MGET、DEL 和 Lua 脚本中一起使用.
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key)) % clusterSlots
+}
+
+// groupBySlot 按哈希槽分组，返回每组键在 keys 中的下标，组的顺序为首次出现的顺序.
+func groupBySlot(keys []string) [][]int {
+	var groups [][]int
+	index := make(map[int]int)
+	for i, key := range keys {
+		slot := KeySlot(key)
+		g, ok := index[slot]
+		if !ok {
+			g = len(groups)
+			index[slot] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
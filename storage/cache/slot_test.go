@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySlot(t *testing.T) {
+	// 与 CLUSTER KEYSLOT 的结果一致
+	assert.Equal(t, 12739, KeySlot("123456789"))
+	assert.Equal(t, 12182, KeySlot("foo"))
+	assert.Equal(t, KeySlot("user:1"), KeySlot("{user:1}:profile"))
+	assert.Equal(t, KeySlot("{user:1}:profile"), KeySlot("{user:1}:orders"))
+	// 空标签时对整个键计算
+	assert.Equal(t, int(crc16("{}foo"))%clusterSlots, KeySlot("{}foo"))
+	assert.Equal(t, KeySlot("a{b"), int(crc16("a{b"))%clusterSlots)
+}
+
+func TestGroupBySlot(t *testing.T) {
+	keys := []string{"{a}1", "{b}1", "{a}2", "{b}2", "{c}1"}
+	groups := groupBySlot(keys)
+	assert.Equal(t, [][]int{{0, 2}, {1, 3}, {4}}, groups)
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Z 有序集合成员.
+type Z struct {
+	Member string
+	Score  float64
+}
+
+// ZRangeBy 按分数查询有序集合的范围.
+type ZRangeBy struct {
+	// Min 和 Max 为闭区间，可以使用 math.Inf 表示不限
+	Min, Max float64
+	// Offset 和 Count 用于分页，Count <= 0 表示返回 Offset 之后的全部成员
+	Offset, Count int64
+}
+
+// ScoreAll 返回包含所有分数的范围.
+func ScoreAll() ZRangeBy {
+	return ZRangeBy{Min: math.Inf(-1), Max: math.Inf(1)}
+}
+
+// XMessage Stream 消息.
+type XMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+// HashCache 哈希操作.
+type HashCache interface {
+	// HSet 设置字段，值的序列化方式与 Set 相同.
+	HSet(ctx context.Context, key string, values map[string]any) error
+
+	// HGet 获取字段，键或字段不存在时返回 ErrNotFound.
+	HGet(ctx context.Context, key, field string) (string, error)
+
+	// HGetAll 获取所有字段，键不存在时返回空 map.
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+
+	// HDel 删除字段，返回删除的数量.
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
+
+	// HIncrBy 将字段增加 incr，返回增加后的值.
+	HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error)
+
+	// HLen 返回字段数量.
+	HLen(ctx context.Context, key string) (int64, error)
+}
+
+// ListCache 列表操作.
+type ListCache interface {
+	// LPush 将值插入列表头部，返回插入后的长度.
+	LPush(ctx context.Context, key string, values ...any) (int64, error)
+
+	// RPush 将值追加到列表尾部，返回追加后的长度.
+	RPush(ctx context.Context, key string, values ...any) (int64, error)
+
+	// LPop 弹出列表头部的值，列表为空时返回 ErrNotFound.
+	LPop(ctx context.Context, key string) (string, error)
+
+	// RPop 弹出列表尾部的值，列表为空时返回 ErrNotFound.
+	RPop(ctx context.Context, key string) (string, error)
+
+	// LRange 返回下标 [start, stop] 之间的值，下标可以为负数，-1 表示最后一个.
+	LRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+
+	// LTrim 只保留下标 [start, stop] 之间的值.
+	LTrim(ctx context.Context, key string, start, stop int64) error
+
+	// LLen 返回列表长度.
+	LLen(ctx context.Context, key string) (int64, error)
+}
+
+// SetCache 集合操作.
+type SetCache interface {
+	// SAdd 添加成员，返回新增的数量.
+	SAdd(ctx context.Context, key string, members ...string) (int64, error)
+
+	// SRem 删除成员，返回删除的数量.
+	SRem(ctx context.Context, key string, members ...string) (int64, error)
+
+	// SMembers 返回所有成员，顺序不确定.
+	SMembers(ctx context.Context, key string) ([]string, error)
+
+	// SIsMember 检查成员是否存在.
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+
+	// SCard 返回成员数量.
+	SCard(ctx context.Context, key string) (int64, error)
+
+	// SScan 增量遍历匹配 glob 模式的成员，返回下一次遍历的游标，游标为 0 时遍历结束.
+	SScan(ctx context.Context, key string, cursor uint64, match string, count int64) ([]string, uint64, error)
+}
+
+// SortedSetCache 有序集合操作.
+type SortedSetCache interface {
+	// ZAdd 添加成员或更新分数，返回新增的数量.
+	ZAdd(ctx context.Context, key string, members ...Z) (int64, error)
+
+	// ZIncrBy 将成员的分数增加 incr，返回增加后的分数.
+	ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error)
+
+	// ZScore 返回成员的分数，成员不存在时返回 ErrNotFound.
+	ZScore(ctx context.Context, key, member string) (float64, error)
+
+	// ZRevRank 返回成员按分数从高到低的排名（从 0 开始），成员不存在时返回 ErrNotFound.
+	ZRevRank(ctx context.Context, key, member string) (int64, error)
+
+	// ZRange 返回按分数从低到高排序后下标 [start, stop] 之间的成员.
+	ZRange(ctx context.Context, key string, start, stop int64) ([]Z, error)
+
+	// ZRevRange 返回按分数从高到低排序后下标 [start, stop] 之间的成员，用于排行榜.
+	ZRevRange(ctx context.Context, key string, start, stop int64) ([]Z, error)
+
+	// ZRangeByScore 返回分数在范围内的成员，按分数从低到高排序.
+	ZRangeByScore(ctx context.Context, key string, by ZRangeBy) ([]Z, error)
+
+	// ZRem 删除成员，返回删除的数量.
+	ZRem(ctx context.Context, key string, members ...string) (int64, error)
+
+	// ZCard 返回成员数量.
+	ZCard(ctx context.Context, key string) (int64, error)
+}
+
+// StreamCache Stream 操作.
+type StreamCache interface {
+	// XAdd 追加消息，返回消息 ID.
+	//
+	// maxLen > 0 时裁剪 Stream 使长度约等于 maxLen，Redis 使用近似裁剪（MAXLEN ~）.
+	XAdd(ctx context.Context, stream string, values map[string]any, maxLen int64) (string, error)
+
+	// XRange 返回 ID 在 [start, stop] 之间的消息，"-" 和 "+" 表示最小和最大 ID，count <= 0 表示不限.
+	XRange(ctx context.Context, stream, start, stop string, count int64) ([]XMessage, error)
+
+	// XRead 读取 ID 大于 id 的消息，"$" 表示只读取调用之后追加的消息.
+	//
+	// block > 0 时最多等待 block，超时返回空结果；block <= 0 时不等待.
+	XRead(ctx context.Context, stream, id string, count int64, block time.Duration) ([]XMessage, error)
+
+	// XLen 返回消息数量.
+	XLen(ctx context.Context, stream string) (int64, error)
+
+	// XDel 删除消息，返回删除的数量.
+	XDel(ctx context.Context, stream string, ids ...string) (int64, error)
+
+	// XGroupCreate 创建消费者组，Stream 不存在时自动创建，组已存在时不报错.
+	//
+	// start 为组的起始 ID，"0" 表示从头消费，"$" 表示只消费之后追加的消息.
+	XGroupCreate(ctx context.Context, stream, group, start string) error
+
+	// XReadGroup 以消费者组读取未投递的消息，消息进入待确认列表直到 XAck.
+	//
+	// block 的含义与 XRead 相同，组不存在时返回 ErrGroupNotFound.
+	XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]XMessage, error)
+
+	// XAck 确认消息，返回确认的数量.
+	XAck(ctx context.Context, stream, group string, ids ...string) (int64, error)
+}
+
+// StructuredCache Redis 数据结构操作，内存缓存、Redis 缓存和多级缓存均实现.
+//
+// 与字符串键共享同一个键空间，Del、Exists、Expire 和 TTL 同样适用于数据结构键；
+// 对类型不匹配的键操作返回 ErrWrongType.
+//
+// 通过类型断言获取，代码依赖这些接口而不是底层客户端，测试时可以使用内存缓存:
+//
+//	board := c.(cache.SortedSetCache)
+//	board.ZIncrBy(ctx, "leaderboard:weekly", 10, "user:42")
+//	top, err := board.ZRevRange(ctx, "leaderboard:weekly", 0, 9)
+type StructuredCache interface {
+	HashCache
+	ListCache
+	SetCache
+	SortedSetCache
+	StreamCache
+}
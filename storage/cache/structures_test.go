@@ -0,0 +1,377 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// StructuredCacheTestSuite 数据结构操作测试套件，对每种缓存实现运行相同的用例.
+type StructuredCacheTestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	ctx    context.Context
+	logger logger.Logger
+}
+
+func TestStructuredCacheSuite(t *testing.T) {
+	suite.Run(t, new(StructuredCacheTestSuite))
+}
+
+func (s *StructuredCacheTestSuite) SetupSuite() {
+	log, err := logger.NewLogger(logger.DefaultConfig())
+	s.Require().NoError(err)
+	s.logger = log
+}
+
+func (s *StructuredCacheTestSuite) TearDownSuite() {
+	if s.logger != nil {
+		s.logger.Close()
+	}
+}
+
+func (s *StructuredCacheTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.ctx = context.Background()
+}
+
+// each 对每种缓存实现运行 fn.
+func (s *StructuredCacheTestSuite) each(fn func(c Cache, sc StructuredCache)) {
+	configs := map[string]*Config{
+		"memory": NewMemoryConfig(),
+		"redis":  NewRedisConfig(s.mr.Addr()),
+		"tiered": NewTieredConfig(s.mr.Addr()),
+	}
+	for name, config := range configs {
+		s.Run(name, func() {
+			s.mr.FlushAll()
+			c, err := NewCache(config, s.logger)
+			s.Require().NoError(err)
+			defer c.Close()
+
+			sc, ok := c.(StructuredCache)
+			s.Require().True(ok)
+			fn(c, sc)
+		})
+	}
+}
+
+func (s *StructuredCacheTestSuite) TestHash() {
+	s.each(func(c Cache, sc StructuredCache) {
+		s.NoError(sc.HSet(s.ctx, "user:1", map[string]any{
+			"name":  "alice",
+			"age":   30,
+			"roles": []string{"admin"},
+		}))
+
+		name, err := sc.HGet(s.ctx, "user:1", "name")
+		s.NoError(err)
+		s.Equal("alice", name)
+		_, err = sc.HGet(s.ctx, "user:1", "missing")
+		s.ErrorIs(err, ErrNotFound)
+		_, err = sc.HGet(s.ctx, "user:2", "name")
+		s.ErrorIs(err, ErrNotFound)
+
+		all, err := sc.HGetAll(s.ctx, "user:1")
+		s.NoError(err)
+		s.Equal(map[string]string{"name": "alice", "age": "30", "roles": `["admin"]`}, all)
+
+		age, err := sc.HIncrBy(s.ctx, "user:1", "age", 2)
+		s.NoError(err)
+		s.Equal(int64(32), age)
+
+		deleted, err := sc.HDel(s.ctx, "user:1", "roles", "missing")
+		s.NoError(err)
+		s.Equal(int64(1), deleted)
+		n, err := sc.HLen(s.ctx, "user:1")
+		s.NoError(err)
+		s.Equal(int64(2), n)
+
+		// 删除所有字段后键不存在
+		_, err = sc.HDel(s.ctx, "user:1", "name", "age")
+		s.NoError(err)
+		exists, err := c.Exists(s.ctx, "user:1")
+		s.NoError(err)
+		s.False(exists)
+
+		all, err = sc.HGetAll(s.ctx, "user:2")
+		s.NoError(err)
+		s.Empty(all)
+	})
+}
+
+func (s *StructuredCacheTestSuite) TestList() {
+	s.each(func(_ Cache, sc StructuredCache) {
+		n, err := sc.RPush(s.ctx, "queue", "b", "c")
+		s.NoError(err)
+		s.Equal(int64(2), n)
+		n, err = sc.LPush(s.ctx, "queue", "a", 0)
+		s.NoError(err)
+		s.Equal(int64(4), n)
+
+		values, err := sc.LRange(s.ctx, "queue", 0, -1)
+		s.NoError(err)
+		s.Equal([]string{"0", "a", "b", "c"}, values)
+		values, err = sc.LRange(s.ctx, "queue", -2, 10)
+		s.NoError(err)
+		s.Equal([]string{"b", "c"}, values)
+
+		value, err := sc.LPop(s.ctx, "queue")
+		s.NoError(err)
+		s.Equal("0", value)
+		value, err = sc.RPop(s.ctx, "queue")
+		s.NoError(err)
+		s.Equal("c", value)
+
+		s.NoError(sc.LTrim(s.ctx, "queue", 1, -1))
+		values, err = sc.LRange(s.ctx, "queue", 0, -1)
+		s.NoError(err)
+		s.Equal([]string{"b"}, values)
+
+		_, err = sc.LPop(s.ctx, "queue")
+		s.NoError(err)
+		_, err = sc.LPop(s.ctx, "queue")
+		s.ErrorIs(err, ErrNotFound)
+		n, err = sc.LLen(s.ctx, "queue")
+		s.NoError(err)
+		s.Zero(n)
+	})
+}
+
+func (s *StructuredCacheTestSuite) TestSet() {
+	s.each(func(_ Cache, sc StructuredCache) {
+		added, err := sc.SAdd(s.ctx, "online", "u1", "u2", "u3", "u1")
+		s.NoError(err)
+		s.Equal(int64(3), added)
+
+		ok, err := sc.SIsMember(s.ctx, "online", "u2")
+		s.NoError(err)
+		s.True(ok)
+
+		removed, err := sc.SRem(s.ctx, "online", "u2", "u9")
+		s.NoError(err)
+		s.Equal(int64(1), removed)
+
+		members, err := sc.SMembers(s.ctx, "online")
+		s.NoError(err)
+		s.ElementsMatch([]string{"u1", "u3"}, members)
+
+		n, err := sc.SCard(s.ctx, "online")
+		s.NoError(err)
+		s.Equal(int64(2), n)
+
+		var (
+			scanned []string
+			cursor  uint64
+		)
+		for {
+			page, next, err := sc.SScan(s.ctx, "online", cursor, "u*", 1)
+			s.Require().NoError(err)
+			scanned = append(scanned, page...)
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+		sort.Strings(scanned)
+		s.Equal([]string{"u1", "u3"}, scanned)
+	})
+}
+
+func (s *StructuredCacheTestSuite) TestSortedSet_Leaderboard() {
+	s.each(func(_ Cache, sc StructuredCache) {
+		added, err := sc.ZAdd(s.ctx, "board", Z{Member: "alice", Score: 10}, Z{Member: "bob", Score: 20}, Z{Member: "carol", Score: 15})
+		s.NoError(err)
+		s.Equal(int64(3), added)
+
+		score, err := sc.ZIncrBy(s.ctx, "board", 15, "alice")
+		s.NoError(err)
+		s.Equal(float64(25), score)
+
+		top, err := sc.ZRevRange(s.ctx, "board", 0, 1)
+		s.NoError(err)
+		s.Equal([]Z{{Member: "alice", Score: 25}, {Member: "bob", Score: 20}}, top)
+
+		rank, err := sc.ZRevRank(s.ctx, "board", "carol")
+		s.NoError(err)
+		s.Equal(int64(2), rank)
+		_, err = sc.ZRevRank(s.ctx, "board", "dave")
+		s.ErrorIs(err, ErrNotFound)
+
+		score, err = sc.ZScore(s.ctx, "board", "bob")
+		s.NoError(err)
+		s.Equal(float64(20), score)
+		_, err = sc.ZScore(s.ctx, "board", "dave")
+		s.ErrorIs(err, ErrNotFound)
+
+		all, err := sc.ZRange(s.ctx, "board", 0, -1)
+		s.NoError(err)
+		s.Equal([]Z{{"carol", 15}, {"bob", 20}, {"alice", 25}}, all)
+
+		ranged, err := sc.ZRangeByScore(s.ctx, "board", ZRangeBy{Min: 16, Max: math.Inf(1)})
+		s.NoError(err)
+		s.Equal([]Z{{"bob", 20}, {"alice", 25}}, ranged)
+		ranged, err = sc.ZRangeByScore(s.ctx, "board", ZRangeBy{Min: math.Inf(-1), Max: math.Inf(1), Offset: 1, Count: 1})
+		s.NoError(err)
+		s.Equal([]Z{{"bob", 20}}, ranged)
+		ranged, err = sc.ZRangeByScore(s.ctx, "board", func() ZRangeBy { by := ScoreAll(); by.Offset = 1; return by }())
+		s.NoError(err)
+		s.Equal([]Z{{"bob", 20}, {"alice", 25}}, ranged)
+
+		removed, err := sc.ZRem(s.ctx, "board", "carol")
+		s.NoError(err)
+		s.Equal(int64(1), removed)
+		n, err := sc.ZCard(s.ctx, "board")
+		s.NoError(err)
+		s.Equal(int64(2), n)
+	})
+}
+
+func (s *StructuredCacheTestSuite) TestStream() {
+	s.each(func(_ Cache, sc StructuredCache) {
+		var ids []string
+		for _, event := range []string{"created", "paid", "shipped"} {
+			id, err := sc.XAdd(s.ctx, "orders", map[string]any{"event": event, "order": 1}, 0)
+			s.Require().NoError(err)
+			ids = append(ids, id)
+		}
+
+		messages, err := sc.XRange(s.ctx, "orders", "-", "+", 0)
+		s.NoError(err)
+		s.Len(messages, 3)
+		s.Equal(ids[0], messages[0].ID)
+		s.Equal(map[string]string{"event": "created", "order": "1"}, messages[0].Values)
+
+		messages, err = sc.XRange(s.ctx, "orders", ids[1], "+", 1)
+		s.NoError(err)
+		s.Len(messages, 1)
+		s.Equal("paid", messages[0].Values["event"])
+
+		messages, err = sc.XRead(s.ctx, "orders", ids[0], 10, 0)
+		s.NoError(err)
+		s.Len(messages, 2)
+
+		// 没有新消息时不等待
+		messages, err = sc.XRead(s.ctx, "orders", ids[2], 10, 0)
+		s.NoError(err)
+		s.Empty(messages)
+
+		deleted, err := sc.XDel(s.ctx, "orders", ids[1])
+		s.NoError(err)
+		s.Equal(int64(1), deleted)
+		n, err := sc.XLen(s.ctx, "orders")
+		s.NoError(err)
+		s.Equal(int64(2), n)
+	})
+}
+
+func (s *StructuredCacheTestSuite) TestStream_ConsumerGroup() {
+	s.each(func(_ Cache, sc StructuredCache) {
+		s.NoError(sc.XGroupCreate(s.ctx, "jobs", "workers", "0"))
+		s.NoError(sc.XGroupCreate(s.ctx, "jobs", "workers", "0"), "组已存在时不报错")
+
+		_, err := sc.XReadGroup(s.ctx, "jobs", "missing", "w1", 10, 0)
+		s.ErrorIs(err, ErrGroupNotFound)
+
+		for i := range 3 {
+			_, err := sc.XAdd(s.ctx, "jobs", map[string]any{"n": i}, 0)
+			s.Require().NoError(err)
+		}
+
+		first, err := sc.XReadGroup(s.ctx, "jobs", "workers", "w1", 2, 0)
+		s.NoError(err)
+		s.Len(first, 2)
+		second, err := sc.XReadGroup(s.ctx, "jobs", "workers", "w2", 10, 0)
+		s.NoError(err)
+		s.Len(second, 1)
+		s.Equal("2", second[0].Values["n"])
+
+		acked, err := sc.XAck(s.ctx, "jobs", "workers", first[0].ID, first[1].ID)
+		s.NoError(err)
+		s.Equal(int64(2), acked)
+		acked, err = sc.XAck(s.ctx, "jobs", "workers", first[0].ID)
+		s.NoError(err)
+		s.Zero(acked)
+	})
+}
+
+func (s *StructuredCacheTestSuite) TestStream_MaxLen() {
+	c, err := NewMemoryCache(NewMemoryConfig(), s.logger)
+	s.Require().NoError(err)
+	defer c.Close()
+	sc := c.(StructuredCache)
+
+	for i := range 5 {
+		_, err := sc.XAdd(s.ctx, "events", map[string]any{"n": i}, 3)
+		s.Require().NoError(err)
+	}
+	messages, err := sc.XRange(s.ctx, "events", "-", "+", 0)
+	s.NoError(err)
+	s.Len(messages, 3)
+	s.Equal("2", messages[0].Values["n"])
+}
+
+func (s *StructuredCacheTestSuite) TestMemory_BlockingRead() {
+	c, err := NewMemoryCache(NewMemoryConfig(), s.logger)
+	s.Require().NoError(err)
+	defer c.Close()
+	sc := c.(StructuredCache)
+
+	s.NoError(sc.XGroupCreate(s.ctx, "events", "g", "$"))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		sc.XAdd(s.ctx, "events", map[string]any{"n": 1}, 0)
+	}()
+	messages, err := sc.XRead(s.ctx, "events", "$", 1, time.Second)
+	s.NoError(err)
+	s.Len(messages, 1)
+
+	messages, err = sc.XReadGroup(s.ctx, "events", "g", "c1", 10, time.Second)
+	s.NoError(err)
+	s.Len(messages, 1)
+
+	// 超时返回空结果
+	messages, err = sc.XRead(s.ctx, "events", "$", 1, 20*time.Millisecond)
+	s.NoError(err)
+	s.Empty(messages)
+
+	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = sc.XReadGroup(ctx, "events", "g", "c1", 10, time.Second)
+	s.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func (s *StructuredCacheTestSuite) TestWrongType() {
+	s.each(func(c Cache, sc StructuredCache) {
+		s.Require().NoError(c.Set(s.ctx, "string", "v", time.Minute))
+		_, err := sc.HGet(s.ctx, "string", "field")
+		s.ErrorIs(err, ErrWrongType)
+		_, err = sc.LPush(s.ctx, "string", "v")
+		s.ErrorIs(err, ErrWrongType)
+		_, err = sc.ZAdd(s.ctx, "string", Z{Member: "m", Score: 1})
+		s.ErrorIs(err, ErrWrongType)
+
+		_, err = sc.SAdd(s.ctx, "set", "m")
+		s.Require().NoError(err)
+		_, err = c.Get(s.ctx, "set")
+		s.ErrorIs(err, ErrWrongType)
+
+		// 数据结构键同样支持过期时间和删除
+		s.NoError(c.Expire(s.ctx, "set", time.Minute))
+		ttl, err := c.TTL(s.ctx, "set")
+		s.NoError(err)
+		s.Positive(ttl)
+		s.NoError(c.Del(s.ctx, "set"))
+		n, err := sc.SCard(s.ctx, "set")
+		s.NoError(err)
+		s.Zero(n)
+	})
+}
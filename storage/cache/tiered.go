@@ -0,0 +1,418 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+	"github.com/Tsukikage7/microservice-kit/util/collections/lrucache"
+)
+
+// localEntry 本地缓存项.
+type localEntry struct {
+	value    string
+	expireAt time.Time
+}
+
+// invalidation 失效广播消息.
+type invalidation struct {
+	// Source 发送实例，实例忽略自己发送的消息
+	Source string `json:"source"`
+	// Keys 失效的键
+	Keys []string `json:"keys"`
+}
+
+// tieredCache 多级缓存实现.
+//
+// 本地 LRU 缓存在 Redis 之前，读取优先命中本地，未命中时从 Redis 读取并写入本地.
+// 写入和删除先更新 Redis，再通过 Redis pub/sub 广播失效消息，其他实例收到后删除本地副本.
+// 广播消息可能在网络中断时丢失，本地缓存项最长保留 LocalTTL，因此 LocalTTL 决定了最大不一致时间.
+// 分布式锁、TTL 查询和数据结构操作直接访问 Redis.
+type tieredCache struct {
+	// StructuredCache 哈希、列表、集合、有序集合和 Stream 操作不经过本地缓存
+	StructuredCache
+
+	remote   *redisCache
+	local    *lrucache.LRUCache[string, localEntry]
+	localTTL time.Duration
+	channel  string
+	source   string
+	logger   logger.Logger
+
+	// generation 收到或发出失效时递增，读取 Redis 期间发生失效时不写入本地，避免写入旧值
+	generation atomic.Uint64
+
+	pubsub    *redis.PubSub
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewTieredCache 创建多级缓存.
+func NewTieredCache(config *Config, log logger.Logger) (Cache, error) {
+	if config == nil {
+		return nil, ErrNilConfig
+	}
+
+	config.ApplyDefaults()
+
+	remote, err := NewRedisCache(config, log)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := remote.(*redisCache)
+	t := &tieredCache{
+		StructuredCache: rc,
+		remote:          rc,
+		local:           lrucache.New[string, localEntry](config.MaxSize),
+		localTTL:        config.LocalTTL,
+		channel:         config.InvalidationChannel,
+		source:          uuid.NewString(),
+		logger:          log,
+		done:            make(chan struct{}),
+	}
+
+	// 订阅成功后再返回，避免错过创建后立即发生的失效
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	t.pubsub = t.remote.client.Subscribe(ctx, t.channel)
+	if _, err := t.pubsub.Receive(ctx); err != nil {
+		log.With(
+			logger.String("channel", t.channel),
+			logger.Err(err),
+		).Error("[cache] 订阅失效广播失败")
+		t.pubsub.Close()
+		t.remote.Close()
+		return nil, ErrConnect
+	}
+
+	go t.subscribeLoop()
+
+	log.With(
+		logger.String("channel", t.channel),
+		logger.Int("local_size", config.MaxSize),
+		logger.Duration("local_ttl", t.localTTL),
+	).Debug("[cache] 多级缓存初始化完成")
+
+	return t, nil
+}
+
+// subscribeLoop 接收失效广播并删除本地副本.
+func (t *tieredCache) subscribeLoop() {
+	defer close(t.done)
+
+	for msg := range t.pubsub.Channel() {
+		var inv invalidation
+		if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+			t.logger.With(logger.Err(err)).Warn("[cache] 忽略无法解析的失效广播")
+			continue
+		}
+		if inv.Source == t.source {
+			continue
+		}
+		t.evict(inv.Keys...)
+	}
+}
+
+// Set 设置键值对，写入 Redis 后更新本地副本并广播失效.
+func (t *tieredCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	data, err := t.remote.serialize(value)
+	if err != nil {
+		return err
+	}
+	if err := t.remote.Set(ctx, key, data, ttl); err != nil {
+		return err
+	}
+
+	t.evict(key)
+	t.store(key, data, ttl)
+	t.broadcast(ctx, key)
+	return nil
+}
+
+// Get 获取值，优先读取本地副本.
+func (t *tieredCache) Get(ctx context.Context, key string) (string, error) {
+	if value, ok := t.load(key); ok {
+		return value, nil
+	}
+
+	generation := t.generation.Load()
+	value, err := t.remote.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if t.generation.Load() == generation {
+		t.store(key, value, 0)
+	}
+	return value, nil
+}
+
+// Del 删除键.
+func (t *tieredCache) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := t.remote.Del(ctx, keys...); err != nil {
+		return err
+	}
+	t.invalidate(ctx, keys...)
+	return nil
+}
+
+// Exists 检查键是否存在.
+func (t *tieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if _, ok := t.load(key); ok {
+		return true, nil
+	}
+	return t.remote.Exists(ctx, key)
+}
+
+// SetNX 仅当键不存在时设置.
+func (t *tieredCache) SetNX(ctx context.Context, key string, value any, ttl time.Duration) (bool, error) {
+	ok, err := t.remote.SetNX(ctx, key, value, ttl)
+	if err != nil || !ok {
+		return ok, err
+	}
+	t.invalidate(ctx, key)
+	return true, nil
+}
+
+// Increment 递增.
+func (t *tieredCache) Increment(ctx context.Context, key string) (int64, error) {
+	return t.IncrementBy(ctx, key, 1)
+}
+
+// IncrementBy 增加指定值.
+func (t *tieredCache) IncrementBy(ctx context.Context, key string, value int64) (int64, error) {
+	result, err := t.remote.IncrementBy(ctx, key, value)
+	if err != nil {
+		return 0, err
+	}
+	t.invalidate(ctx, key)
+	return result, nil
+}
+
+// Decrement 递减.
+func (t *tieredCache) Decrement(ctx context.Context, key string) (int64, error) {
+	return t.IncrementBy(ctx, key, -1)
+}
+
+// Expire 设置过期时间.
+func (t *tieredCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if err := t.remote.Expire(ctx, key, ttl); err != nil {
+		return err
+	}
+	t.invalidate(ctx, key)
+	return nil
+}
+
+// TTL 获取 Redis 中的剩余过期时间.
+func (t *tieredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return t.remote.TTL(ctx, key)
+}
+
+// TryLock 尝试获取分布式锁.
+func (t *tieredCache) TryLock(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return t.remote.TryLock(ctx, key, value, ttl)
+}
+
+// Unlock 释放分布式锁.
+func (t *tieredCache) Unlock(ctx context.Context, key string, value string) error {
+	return t.remote.Unlock(ctx, key, value)
+}
+
+// MGet 批量获取，本地未命中的键从 Redis 批量读取.
+func (t *tieredCache) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	values := make([]string, len(keys))
+	var (
+		missing []string
+		indexes []int
+	)
+	for i, key := range keys {
+		if value, ok := t.load(key); ok {
+			values[i] = value
+			continue
+		}
+		missing = append(missing, key)
+		indexes = append(indexes, i)
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	generation := t.generation.Load()
+	results, err := t.remote.MGet(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+	fill := t.generation.Load() == generation
+	for i, value := range results {
+		values[indexes[i]] = value
+		// 空字符串无法区分不存在的键，不写入本地
+		if fill && value != "" {
+			t.store(missing[i], value, 0)
+		}
+	}
+	return values, nil
+}
+
+// MSet 批量设置.
+func (t *tieredCache) MSet(ctx context.Context, pairs map[string]any, ttl time.Duration) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	// 先序列化，本地副本与 Redis 中的值一致
+	data := make(map[string]any, len(pairs))
+	keys := make([]string, 0, len(pairs))
+	for key, value := range pairs {
+		serialized, err := t.remote.serialize(value)
+		if err != nil {
+			return err
+		}
+		data[key] = serialized
+		keys = append(keys, key)
+	}
+	if err := t.remote.MSet(ctx, data, ttl); err != nil {
+		return err
+	}
+
+	t.evict(keys...)
+	for key, value := range data {
+		t.store(key, value.(string), ttl)
+	}
+	t.broadcast(ctx, keys...)
+	return nil
+}
+
+// SetWithTags 设置键值对并关联标签，标签只保存在 Redis 中.
+func (t *tieredCache) SetWithTags(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	data, err := t.remote.serialize(value)
+	if err != nil {
+		return err
+	}
+	if err := t.remote.SetWithTags(ctx, key, data, ttl, tags...); err != nil {
+		return err
+	}
+
+	t.evict(key)
+	t.store(key, data, ttl)
+	t.broadcast(ctx, key)
+	return nil
+}
+
+// InvalidateTags 删除关联了任一标签的键，每批删除后广播失效.
+func (t *tieredCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	if err := t.remote.invalidateTags(ctx, tags, t.invalidateBatch(ctx)); err != nil {
+		t.logger.With(
+			logger.Any("tags", tags),
+			logger.Err(err),
+		).Error("[cache] 标签失效失败")
+		return err
+	}
+	return nil
+}
+
+// DeleteByPattern 删除匹配 glob 模式的键，每批删除后广播失效.
+func (t *tieredCache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	deleted, err := t.remote.deleteByPattern(ctx, pattern, t.invalidateBatch(ctx))
+	if err != nil {
+		t.logger.With(
+			logger.String("pattern", pattern),
+			logger.Int64("deleted", deleted),
+			logger.Err(err),
+		).Error("[cache] 按模式删除失败")
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+// Ping 测试连接.
+func (t *tieredCache) Ping(ctx context.Context) error {
+	return t.remote.Ping(ctx)
+}
+
+// Close 取消订阅并关闭 Redis 连接.
+func (t *tieredCache) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		t.pubsub.Close()
+		<-t.done
+		t.local.Clear()
+		err = t.remote.Close()
+	})
+	return err
+}
+
+// Client 返回底层 Redis 客户端.
+func (t *tieredCache) Client() any {
+	return t.remote.client
+}
+
+// load 读取未过期的本地副本.
+func (t *tieredCache) load(key string) (string, bool) {
+	entry, ok := t.local.Get(key)
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expireAt) {
+		t.local.Remove(key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+// store 写入本地副本，保留时间不超过 ttl 和 LocalTTL.
+func (t *tieredCache) store(key, value string, ttl time.Duration) {
+	keep := t.localTTL
+	if ttl > 0 && ttl < keep {
+		keep = ttl
+	}
+	t.local.Put(key, localEntry{value: value, expireAt: time.Now().Add(keep)})
+}
+
+// evict 删除本地副本.
+func (t *tieredCache) evict(keys ...string) {
+	t.generation.Add(1)
+	for _, key := range keys {
+		t.local.Remove(key)
+	}
+}
+
+// invalidate 删除本地副本并广播失效.
+func (t *tieredCache) invalidate(ctx context.Context, keys ...string) {
+	t.evict(keys...)
+	t.broadcast(ctx, keys...)
+}
+
+// invalidateBatch 返回删除一批键后调用的回调，删除本地副本并广播失效.
+//
+// Cluster 模式下各主节点并发遍历，回调可能被并发调用.
+func (t *tieredCache) invalidateBatch(ctx context.Context) func(keys []string) {
+	return func(keys []string) {
+		t.invalidate(ctx, keys...)
+	}
+}
+
+// broadcast 广播失效消息.
+//
+// Redis 已更新，广播失败只记录日志，其他实例的本地副本在 LocalTTL 后过期.
+func (t *tieredCache) broadcast(ctx context.Context, keys ...string) {
+	payload, err := json.Marshal(invalidation{Source: t.source, Keys: keys})
+	if err != nil {
+		return
+	}
+	if err := t.remote.client.Publish(ctx, t.channel, payload).Err(); err != nil {
+		t.logger.With(
+			logger.Any("keys", keys),
+			logger.Err(err),
+		).Warn("[cache] 广播失效失败")
+	}
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// TieredCacheTestSuite 多级缓存测试套件.
+type TieredCacheTestSuite struct {
+	suite.Suite
+	mr     *miniredis.Miniredis
+	a      Cache
+	b      Cache
+	ctx    context.Context
+	logger logger.Logger
+}
+
+func TestTieredCacheSuite(t *testing.T) {
+	suite.Run(t, new(TieredCacheTestSuite))
+}
+
+func (s *TieredCacheTestSuite) SetupSuite() {
+	log, err := logger.NewLogger(logger.DefaultConfig())
+	s.Require().NoError(err)
+	s.logger = log
+}
+
+func (s *TieredCacheTestSuite) TearDownSuite() {
+	if s.logger != nil {
+		s.logger.Close()
+	}
+}
+
+func (s *TieredCacheTestSuite) SetupTest() {
+	s.mr = miniredis.RunT(s.T())
+	s.ctx = context.Background()
+	s.a = s.newInstance()
+	s.b = s.newInstance()
+}
+
+func (s *TieredCacheTestSuite) TearDownTest() {
+	s.a.Close()
+	s.b.Close()
+}
+
+// newInstance 创建连接同一个 Redis 的实例，模拟多个服务进程.
+func (s *TieredCacheTestSuite) newInstance() Cache {
+	c, err := NewCache(NewTieredConfig(s.mr.Addr()), s.logger)
+	s.Require().NoError(err)
+	return c
+}
+
+func (s *TieredCacheTestSuite) TestGet_ServedLocally() {
+	s.Require().NoError(s.mr.Set("hot", "v1"))
+
+	value, err := s.a.Get(s.ctx, "hot")
+	s.NoError(err)
+	s.Equal("v1", value)
+
+	// 绕过缓存直接修改 Redis，本地副本仍然命中
+	s.Require().NoError(s.mr.Set("hot", "v2"))
+	value, err = s.a.Get(s.ctx, "hot")
+	s.NoError(err)
+	s.Equal("v1", value)
+
+	_, err = s.a.Get(s.ctx, "missing")
+	s.ErrorIs(err, ErrNotFound)
+}
+
+func (s *TieredCacheTestSuite) TestSet_InvalidatesOtherInstances() {
+	s.Require().NoError(s.a.Set(s.ctx, "user:1", "v1", time.Minute))
+	value, err := s.b.Get(s.ctx, "user:1")
+	s.NoError(err)
+	s.Equal("v1", value)
+
+	s.Require().NoError(s.a.Set(s.ctx, "user:1", "v2", time.Minute))
+	s.Eventually(func() bool {
+		value, err := s.b.Get(s.ctx, "user:1")
+		return err == nil && value == "v2"
+	}, time.Second, 10*time.Millisecond)
+
+	// 写入实例直接更新本地副本
+	value, err = s.a.Get(s.ctx, "user:1")
+	s.NoError(err)
+	s.Equal("v2", value)
+}
+
+func (s *TieredCacheTestSuite) TestDel_InvalidatesOtherInstances() {
+	s.Require().NoError(s.a.Set(s.ctx, "user:1", "v1", time.Minute))
+	_, err := s.b.Get(s.ctx, "user:1")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.a.Del(s.ctx, "user:1"))
+	s.Eventually(func() bool {
+		_, err := s.b.Get(s.ctx, "user:1")
+		return err == ErrNotFound
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *TieredCacheTestSuite) TestLocalTTL() {
+	config := NewTieredConfig(s.mr.Addr())
+	config.LocalTTL = 50 * time.Millisecond
+	c, err := NewCache(config, s.logger)
+	s.Require().NoError(err)
+	defer c.Close()
+
+	s.Require().NoError(s.mr.Set("key", "v1"))
+	_, err = c.Get(s.ctx, "key")
+	s.Require().NoError(err)
+	s.Require().NoError(s.mr.Set("key", "v2"))
+
+	s.Eventually(func() bool {
+		value, _ := c.Get(s.ctx, "key")
+		return value == "v2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *TieredCacheTestSuite) TestMGetMSet() {
+	s.Require().NoError(s.a.MSet(s.ctx, map[string]any{"k1": "v1", "k2": 2}, time.Minute))
+	_, err := s.b.Get(s.ctx, "k1")
+	s.Require().NoError(err)
+
+	values, err := s.b.MGet(s.ctx, "k1", "k2", "k3")
+	s.NoError(err)
+	s.Equal([]string{"v1", "2", ""}, values)
+
+	s.Require().NoError(s.a.MSet(s.ctx, map[string]any{"k1": "v1-new"}, time.Minute))
+	s.Eventually(func() bool {
+		values, err := s.b.MGet(s.ctx, "k1", "k2")
+		return err == nil && values[0] == "v1-new"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *TieredCacheTestSuite) TestIncrement_Invalidates() {
+	s.Require().NoError(s.a.Set(s.ctx, "counter", 1, 0))
+	_, err := s.b.Get(s.ctx, "counter")
+	s.Require().NoError(err)
+
+	result, err := s.a.Increment(s.ctx, "counter")
+	s.NoError(err)
+	s.Equal(int64(2), result)
+	s.Eventually(func() bool {
+		value, _ := s.b.Get(s.ctx, "counter")
+		return value == "2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *TieredCacheTestSuite) TestLock_BypassesLocal() {
+	ok, err := s.a.TryLock(s.ctx, "lock", "owner", time.Minute)
+	s.NoError(err)
+	s.True(ok)
+	ok, err = s.b.TryLock(s.ctx, "lock", "other", time.Minute)
+	s.NoError(err)
+	s.False(ok)
+	s.NoError(s.a.Unlock(s.ctx, "lock", "owner"))
+}
+
+func (s *TieredCacheTestSuite) TestConfig() {
+	s.Error((&Config{Type: TypeTiered}).Validate())
+	config := NewTieredConfig("localhost:6379")
+	s.Equal(DefaultLocalTTL, config.LocalTTL)
+	s.Equal(DefaultInvalidationChannel, config.InvalidationChannel)
+}
@@ -369,6 +369,11 @@ func NewObjectID() ObjectID {
 	return bson.NewObjectID()
 }
 
+// IsDuplicateKeyError 判断错误是否为唯一索引冲突.
+func IsDuplicateKeyError(err error) bool {
+	return mongo.IsDuplicateKeyError(err)
+}
+
 // ObjectIDFromHex 从十六进制字符串创建 ObjectID.
 func ObjectIDFromHex(hex string) (ObjectID, error) {
 	return bson.ObjectIDFromHex(hex)
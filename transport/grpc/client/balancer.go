@@ -0,0 +1,354 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// 负载均衡策略名称.
+const (
+	// BalancerRoundRobin 轮询（gRPC 内置）.
+	BalancerRoundRobin = roundrobin.Name
+	// BalancerWeighted 平滑加权轮询，权重取自地址属性.
+	BalancerWeighted = "weighted"
+	// BalancerLeastRequest 最少请求（P2C），选择进行中请求更少的实例.
+	BalancerLeastRequest = "least_request"
+	// BalancerConsistentHash 一致性哈希，哈希键取自请求元数据.
+	BalancerConsistentHash = "consistent_hash"
+)
+
+// DefaultWeight 未设置权重时的默认值.
+const DefaultWeight = 1
+
+// consistentHashReplicas 一致性哈希每个实例的虚拟节点数.
+const consistentHashReplicas = 160
+
+func init() {
+	balancer.Register(&weightedBuilder{})
+	balancer.Register(base.NewBalancerBuilder(BalancerLeastRequest, &leastRequestPickerBuilder{}, base.Config{HealthCheck: true}))
+	balancer.Register(&consistentHashBuilder{})
+}
+
+// weightKey 地址权重属性键.
+type weightKey struct{}
+
+// SetAddressWeight 设置地址权重，供 BalancerWeighted 使用.
+//
+// 权重保存在 BalancerAttributes 中，不影响连接复用. 已有连接的权重变化时，
+// BalancerWeighted 从最新的解析结果中读取权重并重建选择器.
+func SetAddressWeight(addr resolver.Address, weight int) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(weightKey{}, weight)
+	return addr
+}
+
+// AddressWeight 返回地址权重，未设置或非法时返回 DefaultWeight.
+func AddressWeight(addr resolver.Address) int {
+	if w, ok := addr.BalancerAttributes.Value(weightKey{}).(int); ok && w > 0 {
+		return w
+	}
+	return DefaultWeight
+}
+
+// serviceConfigJSON 生成指定负载均衡策略的服务配置.
+func serviceConfigJSON(policy, hashKey string) string {
+	cfg := map[string]any{}
+	if policy == BalancerConsistentHash {
+		cfg["hashKey"] = hashKey
+	}
+	data, _ := json.Marshal(map[string]any{
+		"loadBalancingConfig": []map[string]any{{policy: cfg}},
+	})
+	return string(data)
+}
+
+// weightedBuilder 平滑加权轮询负载均衡构建器.
+//
+// 在 base 负载均衡器外包装一层. base 负载均衡器按地址复用连接，忽略 BalancerAttributes，
+// 选择器收到的仍是创建连接时的地址，因此由包装层把最新的权重传递给选择器构建器.
+type weightedBuilder struct{}
+
+// Name 实现 balancer.Builder.
+func (*weightedBuilder) Name() string {
+	return BalancerWeighted
+}
+
+// Build 实现 balancer.Builder.
+func (*weightedBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &weightedPickerBuilder{}
+	return &weightedBalancer{
+		Balancer:      base.NewBalancerBuilder(BalancerWeighted, pb, base.Config{HealthCheck: true}).Build(cc, opts),
+		pickerBuilder: pb,
+	}
+}
+
+// weightedBalancer 将解析结果中的权重同步给选择器构建器的负载均衡器.
+type weightedBalancer struct {
+	balancer.Balancer
+	pickerBuilder *weightedPickerBuilder
+}
+
+// UpdateClientConnState 更新权重后交给 base 负载均衡器处理，base 负载均衡器随后重建选择器.
+func (b *weightedBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	weights := make(map[string]int, len(s.ResolverState.Addresses))
+	for _, addr := range s.ResolverState.Addresses {
+		weights[addr.Addr] = AddressWeight(addr)
+	}
+	b.pickerBuilder.weights = weights
+	return b.Balancer.UpdateClientConnState(s)
+}
+
+// weightedPickerBuilder 构建平滑加权轮询选择器.
+//
+// weights 为最新解析结果中各地址的权重，只在 gRPC 串行调用的负载均衡器方法中读写，无需加锁.
+// 地址不在 weights 中时使用连接地址上的权重.
+type weightedPickerBuilder struct {
+	weights map[string]int
+}
+
+// Build 实现 base.PickerBuilder.
+func (b *weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	nodes := make([]*weightedNode, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		weight, ok := b.weights[sci.Address.Addr]
+		if !ok {
+			weight = AddressWeight(sci.Address)
+		}
+		nodes = append(nodes, &weightedNode{
+			subConn: sc,
+			addr:    sci.Address.Addr,
+			weight:  weight,
+		})
+	}
+	// 固定顺序，保证相同权重下的选择序列可预期
+	slices.SortFunc(nodes, func(a, b *weightedNode) int {
+		switch {
+		case a.addr < b.addr:
+			return -1
+		case a.addr > b.addr:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return &weightedPicker{nodes: nodes}
+}
+
+// weightedNode 加权轮询节点.
+type weightedNode struct {
+	subConn balancer.SubConn
+	addr    string
+	weight  int
+	current int
+}
+
+// weightedPicker 平滑加权轮询（nginx 算法）.
+type weightedPicker struct {
+	mu    sync.Mutex
+	nodes []*weightedNode
+}
+
+// Pick 实现 balancer.Picker.
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	total := 0
+	var best *weightedNode
+	for _, n := range p.nodes {
+		n.current += n.weight
+		total += n.weight
+		if best == nil || n.current > best.current {
+			best = n
+		}
+	}
+	best.current -= total
+
+	return balancer.PickResult{SubConn: best.subConn}, nil
+}
+
+// leastRequestPickerBuilder 构建最少请求选择器.
+type leastRequestPickerBuilder struct{}
+
+// Build 实现 base.PickerBuilder.
+func (*leastRequestPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	nodes := make([]*leastRequestNode, 0, len(info.ReadySCs))
+	for sc := range info.ReadySCs {
+		nodes = append(nodes, &leastRequestNode{subConn: sc})
+	}
+	return &leastRequestPicker{nodes: nodes}
+}
+
+// leastRequestNode 最少请求节点.
+type leastRequestNode struct {
+	subConn  balancer.SubConn
+	inflight atomic.Int64
+}
+
+// leastRequestPicker 随机选取两个节点，取进行中请求较少者（Power of Two Choices）.
+type leastRequestPicker struct {
+	nodes []*leastRequestNode
+}
+
+// Pick 实现 balancer.Picker.
+func (p *leastRequestPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	node := p.nodes[0]
+	if n := len(p.nodes); n > 1 {
+		i := rand.IntN(n)
+		j := rand.IntN(n - 1)
+		if j >= i {
+			j++
+		}
+		a, b := p.nodes[i], p.nodes[j]
+		node = a
+		if b.inflight.Load() < a.inflight.Load() {
+			node = b
+		}
+	}
+
+	node.inflight.Add(1)
+	return balancer.PickResult{
+		SubConn: node.subConn,
+		Done: func(balancer.DoneInfo) {
+			node.inflight.Add(-1)
+		},
+	}, nil
+}
+
+// consistentHashConfig 一致性哈希策略配置.
+type consistentHashConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	// HashKey 用于计算哈希的请求元数据键
+	HashKey string `json:"hashKey"`
+}
+
+// consistentHashBuilder 一致性哈希负载均衡构建器.
+//
+// 在 base 负载均衡器外包装一层，用于把配置中的哈希键传递给选择器构建器.
+type consistentHashBuilder struct{}
+
+// Name 实现 balancer.Builder.
+func (*consistentHashBuilder) Name() string {
+	return BalancerConsistentHash
+}
+
+// Build 实现 balancer.Builder.
+func (*consistentHashBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &consistentHashPickerBuilder{}
+	return &consistentHashBalancer{
+		Balancer:      base.NewBalancerBuilder(BalancerConsistentHash, pb, base.Config{HealthCheck: true}).Build(cc, opts),
+		pickerBuilder: pb,
+	}
+}
+
+// ParseConfig 实现 balancer.ConfigParser.
+func (*consistentHashBuilder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	cfg := &consistentHashConfig{}
+	if err := json.Unmarshal(js, cfg); err != nil {
+		return nil, fmt.Errorf("grpc client: 解析一致性哈希配置失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// consistentHashBalancer 将配置中的哈希键同步给选择器构建器的负载均衡器.
+type consistentHashBalancer struct {
+	balancer.Balancer
+	pickerBuilder *consistentHashPickerBuilder
+}
+
+// UpdateClientConnState 更新哈希键后交给 base 负载均衡器处理，base 负载均衡器随后重建选择器.
+func (b *consistentHashBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	if cfg, ok := s.BalancerConfig.(*consistentHashConfig); ok {
+		b.pickerBuilder.hashKey = cfg.HashKey
+	}
+	return b.Balancer.UpdateClientConnState(s)
+}
+
+// consistentHashPickerBuilder 构建一致性哈希选择器.
+//
+// hashKey 只在 gRPC 串行调用的负载均衡器方法中读写，无需加锁.
+type consistentHashPickerBuilder struct {
+	hashKey string
+}
+
+// Build 实现 base.PickerBuilder.
+func (b *consistentHashPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &consistentHashPicker{
+		hashKey:  b.hashKey,
+		ring:     make([]uint32, 0, len(info.ReadySCs)*consistentHashReplicas),
+		nodes:    make(map[uint32]balancer.SubConn, len(info.ReadySCs)*consistentHashReplicas),
+		subConns: make([]balancer.SubConn, 0, len(info.ReadySCs)),
+	}
+	for sc, sci := range info.ReadySCs {
+		p.subConns = append(p.subConns, sc)
+		for i := range consistentHashReplicas {
+			h := crc32.ChecksumIEEE([]byte(sci.Address.Addr + "#" + strconv.Itoa(i)))
+			if _, exists := p.nodes[h]; exists {
+				continue
+			}
+			p.nodes[h] = sc
+			p.ring = append(p.ring, h)
+		}
+	}
+	slices.Sort(p.ring)
+
+	return p
+}
+
+// consistentHashPicker 基于哈希环的选择器.
+type consistentHashPicker struct {
+	hashKey  string
+	ring     []uint32
+	nodes    map[uint32]balancer.SubConn
+	subConns []balancer.SubConn
+}
+
+// Pick 实现 balancer.Picker.
+//
+// 请求元数据中缺少哈希键时随机选择实例.
+func (p *consistentHashPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	value := ""
+	if p.hashKey != "" {
+		if md, ok := metadata.FromOutgoingContext(info.Ctx); ok {
+			if vals := md.Get(p.hashKey); len(vals) > 0 {
+				value = vals[0]
+			}
+		}
+	}
+	if value == "" {
+		return balancer.PickResult{SubConn: p.subConns[rand.IntN(len(p.subConns))]}, nil
+	}
+
+	h := crc32.ChecksumIEEE([]byte(value))
+	idx, _ := slices.BinarySearch(p.ring, h)
+	if idx == len(p.ring) {
+		idx = 0
+	}
+	return balancer.PickResult{SubConn: p.nodes[p.ring[idx]]}, nil
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/Tsukikage7/microservice-kit/discovery"
+)
+
+// fakeSubConn 测试用 SubConn.
+type fakeSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func buildInfo(weights map[string]int) (base.PickerBuildInfo, map[string]balancer.SubConn) {
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	scs := make(map[string]balancer.SubConn)
+	for addr, w := range weights {
+		sc := &fakeSubConn{name: addr}
+		a := resolver.Address{Addr: addr}
+		if w > 0 {
+			a = SetAddressWeight(a, w)
+		}
+		info.ReadySCs[sc] = base.SubConnInfo{Address: a}
+		scs[addr] = sc
+	}
+	return info, scs
+}
+
+func TestAddressWeight(t *testing.T) {
+	addr := resolver.Address{Addr: "127.0.0.1:9090"}
+	if w := AddressWeight(addr); w != DefaultWeight {
+		t.Errorf("expected default weight, got %d", w)
+	}
+	if w := AddressWeight(SetAddressWeight(addr, 5)); w != 5 {
+		t.Errorf("expected weight 5, got %d", w)
+	}
+	if w := AddressWeight(SetAddressWeight(addr, -1)); w != DefaultWeight {
+		t.Errorf("expected default weight for invalid value, got %d", w)
+	}
+}
+
+func TestWeightedPicker(t *testing.T) {
+	info, _ := buildInfo(map[string]int{"a:1": 3, "b:1": 1})
+	p := (&weightedPickerBuilder{}).Build(info)
+
+	counts := make(map[string]int)
+	for range 400 {
+		res, err := p.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		counts[res.SubConn.(*fakeSubConn).name]++
+	}
+	if counts["a:1"] != 300 || counts["b:1"] != 100 {
+		t.Errorf("unexpected distribution: %v", counts)
+	}
+}
+
+func TestLeastRequestPicker(t *testing.T) {
+	info, scs := buildInfo(map[string]int{"a:1": 0, "b:1": 0})
+	p := (&leastRequestPickerBuilder{}).Build(info)
+
+	// 占住 a 的一个请求，之后的选择都应落到 b
+	var held balancer.PickResult
+	for {
+		res, _ := p.Pick(balancer.PickInfo{})
+		if res.SubConn == scs["a:1"] {
+			held = res
+			break
+		}
+		res.Done(balancer.DoneInfo{})
+	}
+
+	for range 20 {
+		res, _ := p.Pick(balancer.PickInfo{})
+		if res.SubConn != scs["b:1"] {
+			t.Fatal("expected pick on least loaded subconn")
+		}
+		res.Done(balancer.DoneInfo{})
+	}
+	held.Done(balancer.DoneInfo{})
+}
+
+func TestConsistentHashPicker(t *testing.T) {
+	info, _ := buildInfo(map[string]int{"a:1": 0, "b:1": 0, "c:1": 0})
+	p := (&consistentHashPickerBuilder{hashKey: "x-user-id"}).Build(info).(*consistentHashPicker)
+
+	pick := func(user string) string {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user-id", user)
+		res, err := p.Pick(balancer.PickInfo{Ctx: ctx})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res.SubConn.(*fakeSubConn).name
+	}
+
+	seen := make(map[string]bool)
+	for _, user := range []string{"u1", "u2", "u3", "u4", "u5", "u6", "u7", "u8"} {
+		first := pick(user)
+		for range 5 {
+			if got := pick(user); got != first {
+				t.Fatalf("user %s mapped to %s then %s", user, first, got)
+			}
+		}
+		seen[first] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expected keys spread across instances, got %v", seen)
+	}
+
+	// 缺少哈希键时仍能选出实例
+	if _, err := p.Pick(balancer.PickInfo{Ctx: context.Background()}); err != nil {
+		t.Errorf("unexpected error without hash key: %v", err)
+	}
+}
+
+func TestClient_ConsistentHash(t *testing.T) {
+	s1 := startTestServer(t)
+	s2 := startTestServer(t)
+
+	c, err := New(
+		WithServiceName("svc"),
+		WithDiscovery(&mockDiscovery{addrs: []string{s1.addr, s2.addr}}),
+		WithLogger(&mockLogger{}),
+		WithConsistentHash("x-user-id"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	// 等待两个实例都就绪
+	for i := 0; s1.count() == 0 || s2.count() == 0; i++ {
+		if i > 1000 {
+			t.Fatal("instances never became ready")
+		}
+		callN(t, c, 1, metadata.Pairs("x-user-id", fmt.Sprintf("warmup-%d", i)))
+	}
+
+	b1, b2 := s1.count(), s2.count()
+	callN(t, c, 10, metadata.Pairs("x-user-id", "user-42"))
+	d1, d2 := s1.count()-b1, s2.count()-b2
+	if !(d1 == 10 && d2 == 0) && !(d1 == 0 && d2 == 10) {
+		t.Errorf("expected sticky routing, got s1=%d s2=%d", d1, d2)
+	}
+}
+
+func TestClient_WeightChange(t *testing.T) {
+	s1 := startTestServer(t)
+	s2 := startTestServer(t)
+
+	weighted := func(w1, w2 int) []*discovery.ServiceInstance {
+		instances := toInstances([]string{s1.addr, s2.addr})
+		instances[0].Metadata = map[string]string{MetadataWeight: strconv.Itoa(w1)}
+		instances[1].Metadata = map[string]string{MetadataWeight: strconv.Itoa(w2)}
+		return instances
+	}
+	disc := &dynamicDiscovery{}
+	disc.setInstances(weighted(1, 1)...)
+
+	c, err := New(
+		WithServiceName("svc"),
+		WithDiscovery(disc),
+		WithLogger(&mockLogger{}),
+		WithBalancer(BalancerWeighted),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	// 等待两个实例都就绪
+	for i := 0; s1.count() == 0 || s2.count() == 0; i++ {
+		if i > 1000 {
+			t.Fatal("instances never became ready")
+		}
+		callN(t, c, 1, nil)
+	}
+
+	// 只修改权重，连接保持不变
+	disc.setInstances(weighted(9, 1)...)
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		b1, b2 := s1.count(), s2.count()
+		callN(t, c, 10, nil)
+		d1, d2 := s1.count()-b1, s2.count()-b2
+		if d1 == 9 && d2 == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("weight change never took effect, got s1=%d s2=%d", d1, d2)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServiceConfigJSON(t *testing.T) {
+	if got := serviceConfigJSON(BalancerRoundRobin, ""); got != `{"loadBalancingConfig":[{"round_robin":{}}]}` {
+		t.Errorf("unexpected config: %s", got)
+	}
+	if got := serviceConfigJSON(BalancerConsistentHash, "k"); got != `{"loadBalancingConfig":[{"consistent_hash":{"hashKey":"k"}}]}` {
+		t.Errorf("unexpected config: %s", got)
+	}
+}
@@ -37,18 +37,23 @@ func New(opts ...Option) (*Client, error) {
 		panic("grpc client: 必须设置 logger")
 	}
 
-	// 服务发现
+	// 服务发现，启动时没有实例不视为错误，解析器在实例上线后推送给 gRPC
 	addrs, err := o.discovery.Discover(context.Background(), o.serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrDiscoveryFailed, err)
 	}
 	if len(addrs) == 0 {
-		return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, o.serviceName)
+		o.logger.With(
+			logger.String("name", o.name),
+			logger.String("service", o.serviceName),
+		).Warn("[gRPC] 暂无可用实例，等待服务发现推送")
 	}
-	target := addrs[0]
+	target := Scheme + ":///" + o.serviceName
 
 	// 构建 dial 选项
 	dialOpts := []grpc.DialOption{
+		grpc.WithResolvers(newResolverBuilder(o.discovery, o.logger)),
+		grpc.WithDefaultServiceConfig(serviceConfigJSON(o.balancer, o.hashKey)),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                60 * time.Second,
@@ -77,6 +82,8 @@ func New(opts ...Option) (*Client, error) {
 		logger.String("name", o.name),
 		logger.String("service", o.serviceName),
 		logger.String("target", target),
+		logger.String("balancer", o.balancer),
+		logger.Int("instances", len(addrs)),
 	).Info("[gRPC] 客户端初始化成功")
 
 	return &Client{
@@ -3,8 +3,11 @@ package client
 import (
 	"context"
 	"errors"
+	"net"
+	"strconv"
 	"testing"
 
+	"github.com/Tsukikage7/microservice-kit/discovery"
 	"github.com/Tsukikage7/microservice-kit/logger"
 	"github.com/Tsukikage7/microservice-kit/transport"
 	"google.golang.org/grpc"
@@ -49,8 +52,43 @@ func (m *mockDiscovery) Unregister(ctx context.Context, serviceID string) error
 func (m *mockDiscovery) Discover(ctx context.Context, serviceName string) ([]string, error) {
 	return m.addrs, m.err
 }
+func (m *mockDiscovery) Instances(ctx context.Context, serviceName string) ([]*discovery.ServiceInstance, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return toInstances(m.addrs), nil
+}
+func (m *mockDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*discovery.ServiceInstance, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	ch := make(chan []*discovery.ServiceInstance, 1)
+	ch <- toInstances(m.addrs)
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch, nil
+}
 func (m *mockDiscovery) Close() error { return nil }
 
+// toInstances 将地址列表转换为健康的 gRPC 实例.
+func toInstances(addrs []string) []*discovery.ServiceInstance {
+	instances := make([]*discovery.ServiceInstance, 0, len(addrs))
+	for _, addr := range addrs {
+		host, portStr, _ := net.SplitHostPort(addr)
+		port, _ := strconv.Atoi(portStr)
+		instances = append(instances, &discovery.ServiceInstance{
+			ID:       addr,
+			Address:  host,
+			Port:     port,
+			Protocol: discovery.ProtocolGRPC,
+			Healthy:  true,
+		})
+	}
+	return instances
+}
+
 func TestNew(t *testing.T) {
 	t.Run("创建成功", func(t *testing.T) {
 		disc := &mockDiscovery{addrs: []string{"localhost:9090"}}
@@ -121,19 +159,17 @@ func TestNew(t *testing.T) {
 		}
 	})
 
-	t.Run("未找到服务实例", func(t *testing.T) {
+	t.Run("启动时没有服务实例", func(t *testing.T) {
 		disc := &mockDiscovery{addrs: []string{}}
-		_, err := New(
+		client, err := New(
 			WithServiceName("test-service"),
 			WithDiscovery(disc),
 			WithLogger(&mockLogger{}),
 		)
-		if err == nil {
-			t.Error("expected error when no service found")
-		}
-		if !errors.Is(err, ErrServiceNotFound) {
-			t.Errorf("expected ErrServiceNotFound, got %v", err)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
+		client.Close()
 	})
 }
 
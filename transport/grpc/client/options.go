@@ -17,12 +17,17 @@ type options struct {
 	logger       logger.Logger
 	interceptors []grpc.UnaryClientInterceptor
 	dialOptions  []grpc.DialOption
+
+	// 负载均衡
+	balancer string // 负载均衡策略
+	hashKey  string // 一致性哈希使用的元数据键
 }
 
 // defaultOptions 返回默认配置.
 func defaultOptions() *options {
 	return &options{
-		name: "gRPC-Client",
+		name:     "gRPC-Client",
+		balancer: BalancerRoundRobin,
 	}
 }
 
@@ -67,3 +72,18 @@ func WithDialOptions(opts ...grpc.DialOption) Option {
 		o.dialOptions = append(o.dialOptions, opts...)
 	}
 }
+
+// WithBalancer 设置负载均衡策略，默认 BalancerRoundRobin.
+func WithBalancer(name string) Option {
+	return func(o *options) {
+		o.balancer = name
+	}
+}
+
+// WithConsistentHash 使用一致性哈希负载均衡，按请求元数据 key 的值选择实例.
+func WithConsistentHash(key string) Option {
+	return func(o *options) {
+		o.balancer = BalancerConsistentHash
+		o.hashKey = key
+	}
+}
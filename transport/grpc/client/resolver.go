@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/resolver"
+
+	"github.com/Tsukikage7/microservice-kit/discovery"
+	"github.com/Tsukikage7/microservice-kit/logger"
+)
+
+// Scheme 服务发现解析器的 URI scheme.
+//
+// 目标地址格式为 discovery:///<serviceName>.
+const Scheme = "discovery"
+
+// MetadataWeight 实例元数据中的权重键，供 BalancerWeighted 使用.
+const MetadataWeight = "weight"
+
+// resolverBuilder 基于 discovery.Discovery 的 gRPC 解析器构建器.
+//
+// 每个 Client 持有独立的构建器，通过 grpc.WithResolvers 注入，不注册到全局.
+type resolverBuilder struct {
+	discovery discovery.Discovery
+	logger    logger.Logger
+}
+
+// newResolverBuilder 创建解析器构建器.
+func newResolverBuilder(d discovery.Discovery, log logger.Logger) *resolverBuilder {
+	return &resolverBuilder{
+		discovery: d,
+		logger:    log,
+	}
+}
+
+// Build 创建解析器并开始监听服务实例变化.
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	serviceName := target.Endpoint()
+	if serviceName == "" {
+		return nil, fmt.Errorf("%w: 目标地址缺少服务名称", ErrServiceNotFound)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := b.discovery.Watch(ctx, serviceName)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%w: %v", ErrDiscoveryFailed, err)
+	}
+
+	r := &discoveryResolver{
+		serviceName: serviceName,
+		logger:      b.logger,
+		cc:          cc,
+		cancel:      cancel,
+	}
+
+	r.wg.Add(1)
+	go r.watch(ctx, updates)
+
+	return r, nil
+}
+
+// Scheme 返回解析器 scheme.
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+// discoveryResolver 将服务发现推送的实例变化同步给 gRPC.
+type discoveryResolver struct {
+	serviceName string
+	logger      logger.Logger
+	cc          resolver.ClientConn
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// last 上一次推送的地址列表（已排序），仅在 watch 协程中访问
+	last []string
+}
+
+// ResolveNow 实例变化由服务发现主动推送，无需处理.
+func (r *discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+// Close 停止监听.
+func (r *discoveryResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// watch 消费实例变化.
+func (r *discoveryResolver) watch(ctx context.Context, updates <-chan []*discovery.ServiceInstance) {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case instances, ok := <-updates:
+			if !ok {
+				return
+			}
+			r.update(instances)
+		}
+	}
+}
+
+// update 过滤出健康的 gRPC 实例，有变化时更新连接状态.
+func (r *discoveryResolver) update(instances []*discovery.ServiceInstance) {
+	instances = discovery.FilterInstances(instances, discovery.ProtocolGRPC)
+	if len(instances) == 0 {
+		// 不推送空列表，保留已有连接直到服务发现恢复
+		r.cc.ReportError(fmt.Errorf("%w: %s", ErrServiceNotFound, r.serviceName))
+		return
+	}
+
+	state := resolver.State{Addresses: make([]resolver.Address, 0, len(instances))}
+	keys := make([]string, 0, len(instances))
+	for _, inst := range instances {
+		addr := resolver.Address{Addr: inst.Endpoint()}
+		weight := DefaultWeight
+		if w, err := strconv.Atoi(inst.Metadata[MetadataWeight]); err == nil && w > 0 {
+			weight = w
+		}
+		state.Addresses = append(state.Addresses, SetAddressWeight(addr, weight))
+		keys = append(keys, addr.Addr+"#"+strconv.Itoa(weight))
+	}
+	slices.Sort(keys)
+	if slices.Equal(keys, r.last) {
+		return
+	}
+
+	if err := r.cc.UpdateState(state); err != nil {
+		r.logger.With(
+			logger.String("service", r.serviceName),
+			logger.Err(err),
+		).Warn("[gRPC] 更新解析状态失败")
+		return
+	}
+
+	r.logger.With(
+		logger.String("service", r.serviceName),
+		logger.Any("addrs", keys),
+	).Debug("[gRPC] 服务实例已更新")
+	r.last = keys
+}
@@ -0,0 +1,306 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+
+	"github.com/Tsukikage7/microservice-kit/discovery"
+)
+
+// dynamicDiscovery 可在运行时修改实例列表的 mock discovery.
+type dynamicDiscovery struct {
+	mockDiscovery
+	mu        sync.Mutex
+	instances []*discovery.ServiceInstance
+	watchers  []chan []*discovery.ServiceInstance
+}
+
+func (d *dynamicDiscovery) set(addrs ...string) {
+	d.setInstances(toInstances(addrs)...)
+}
+
+func (d *dynamicDiscovery) setInstances(instances ...*discovery.ServiceInstance) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.instances = instances
+	for _, ch := range d.watchers {
+		ch <- instances
+	}
+}
+
+func (d *dynamicDiscovery) Discover(ctx context.Context, serviceName string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	addrs := make([]string, 0, len(d.instances))
+	for _, inst := range d.instances {
+		addrs = append(addrs, inst.Endpoint())
+	}
+	return addrs, nil
+}
+
+func (d *dynamicDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*discovery.ServiceInstance, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ch := make(chan []*discovery.ServiceInstance, 10)
+	ch <- d.instances
+	d.watchers = append(d.watchers, ch)
+	return ch, nil
+}
+
+// fakeClientConn 记录解析结果的 resolver.ClientConn.
+type fakeClientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+	errs   chan error
+}
+
+func newFakeClientConn() *fakeClientConn {
+	return &fakeClientConn{
+		states: make(chan resolver.State, 10),
+		errs:   make(chan error, 10),
+	}
+}
+
+func (c *fakeClientConn) UpdateState(s resolver.State) error {
+	c.states <- s
+	return nil
+}
+
+func (c *fakeClientConn) ReportError(err error) {
+	c.errs <- err
+}
+
+func (c *fakeClientConn) ParseServiceConfig(string) *serviceconfig.ParseResult {
+	return nil
+}
+
+// testServer 统计请求数的 gRPC 测试服务器.
+type testServer struct {
+	addr  string
+	srv   *grpc.Server
+	mu    sync.Mutex
+	calls int
+}
+
+func startTestServer(t *testing.T) *testServer {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ts := &testServer{addr: lis.Addr().String()}
+	ts.srv = grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ts.mu.Lock()
+		ts.calls++
+		ts.mu.Unlock()
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(ts.srv, health.NewServer())
+	go ts.srv.Serve(lis)
+	t.Cleanup(ts.srv.Stop)
+	return ts
+}
+
+func (s *testServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func callN(t *testing.T, c *Client, n int, md metadata.MD) {
+	t.Helper()
+	hc := healthpb.NewHealthClient(c.Conn())
+	for range n {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if md != nil {
+			ctx = metadata.NewOutgoingContext(ctx, md)
+		}
+		_, err := hc.Check(ctx, &healthpb.HealthCheckRequest{})
+		cancel()
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+}
+
+func buildResolver(t *testing.T, d discovery.Discovery, cc *fakeClientConn) resolver.Resolver {
+	t.Helper()
+	r, err := newResolverBuilder(d, &mockLogger{}).
+		Build(resolver.Target{URL: *mustParseTarget(t, "discovery:///svc")}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestResolver(t *testing.T) {
+	t.Run("推送健康的gRPC实例", func(t *testing.T) {
+		disc := &dynamicDiscovery{}
+		unhealthy := toInstances([]string{"10.0.0.3:9090"})[0]
+		unhealthy.Healthy = false
+		httpInst := toInstances([]string{"10.0.0.4:8080"})[0]
+		httpInst.Protocol = discovery.ProtocolHTTP
+		disc.setInstances(append(toInstances([]string{"10.0.0.2:9090", "10.0.0.1:9090"}), unhealthy, httpInst)...)
+		cc := newFakeClientConn()
+		buildResolver(t, disc, cc)
+
+		state := waitState(t, cc)
+		if len(state.Addresses) != 2 {
+			t.Fatalf("expected 2 addresses, got %d", len(state.Addresses))
+		}
+		for _, addr := range state.Addresses {
+			if addr.Addr != "10.0.0.1:9090" && addr.Addr != "10.0.0.2:9090" {
+				t.Errorf("unexpected address: %s", addr.Addr)
+			}
+		}
+	})
+
+	t.Run("实例变化时推送", func(t *testing.T) {
+		disc := &dynamicDiscovery{}
+		disc.set("10.0.0.1:9090")
+		cc := newFakeClientConn()
+		buildResolver(t, disc, cc)
+		waitState(t, cc)
+
+		// 列表未变化时不重复推送
+		disc.set("10.0.0.1:9090")
+		select {
+		case s := <-cc.states:
+			t.Fatalf("unexpected state update: %v", s)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		disc.set("10.0.0.1:9090", "10.0.0.3:9090")
+		if state := waitState(t, cc); len(state.Addresses) != 2 {
+			t.Errorf("expected 2 addresses, got %d", len(state.Addresses))
+		}
+	})
+
+	t.Run("从元数据读取权重", func(t *testing.T) {
+		disc := &dynamicDiscovery{}
+		inst := toInstances([]string{"10.0.0.1:9090"})[0]
+		inst.Metadata = map[string]string{MetadataWeight: "5"}
+		disc.setInstances(inst)
+		cc := newFakeClientConn()
+		buildResolver(t, disc, cc)
+
+		state := waitState(t, cc)
+		if w := AddressWeight(state.Addresses[0]); w != 5 {
+			t.Errorf("expected weight 5, got %d", w)
+		}
+	})
+
+	t.Run("实例为空时报告错误", func(t *testing.T) {
+		cc := newFakeClientConn()
+		buildResolver(t, &dynamicDiscovery{}, cc)
+
+		select {
+		case err := <-cc.errs:
+			if !errors.Is(err, ErrServiceNotFound) {
+				t.Errorf("expected ErrServiceNotFound, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected error report")
+		}
+	})
+
+	t.Run("监听失败时返回错误", func(t *testing.T) {
+		_, err := newResolverBuilder(&mockDiscovery{err: errors.New("boom")}, &mockLogger{}).
+			Build(resolver.Target{URL: *mustParseTarget(t, "discovery:///svc")}, newFakeClientConn(), resolver.BuildOptions{})
+		if !errors.Is(err, ErrDiscoveryFailed) {
+			t.Errorf("expected ErrDiscoveryFailed, got %v", err)
+		}
+	})
+}
+
+func TestClient_LiveResolve(t *testing.T) {
+	s1 := startTestServer(t)
+	s2 := startTestServer(t)
+
+	disc := &dynamicDiscovery{}
+	disc.set(s1.addr)
+
+	c, err := New(
+		WithServiceName("svc"),
+		WithDiscovery(disc),
+		WithLogger(&mockLogger{}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	callN(t, c, 4, nil)
+	if s1.count() != 4 || s2.count() != 0 {
+		t.Fatalf("expected all calls on s1, got s1=%d s2=%d", s1.count(), s2.count())
+	}
+
+	// 新实例上线后应被轮询到
+	disc.set(s1.addr, s2.addr)
+	deadline := time.Now().Add(3 * time.Second)
+	for s2.count() == 0 && time.Now().Before(deadline) {
+		callN(t, c, 1, nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s2.count() == 0 {
+		t.Fatal("new instance never received traffic")
+	}
+}
+
+func TestClient_StartWithoutInstances(t *testing.T) {
+	s1 := startTestServer(t)
+	disc := &dynamicDiscovery{}
+
+	c, err := New(
+		WithServiceName("svc"),
+		WithDiscovery(disc),
+		WithLogger(&mockLogger{}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Close()
+
+	// 实例稍后上线，等待中的请求在解析器推送后发出
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		disc.set(s1.addr)
+	}()
+	callN(t, c, 1, nil)
+	if s1.count() != 1 {
+		t.Fatalf("expected 1 call on s1, got %d", s1.count())
+	}
+}
+
+func mustParseTarget(t *testing.T, target string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatalf("parse target: %v", err)
+	}
+	return u
+}
+
+func waitState(t *testing.T, cc *fakeClientConn) resolver.State {
+	t.Helper()
+	select {
+	case s := <-cc.states:
+		return s
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for resolver state")
+	}
+	return resolver.State{}
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Tsukikage7/microservice-kit/discovery"
 	"github.com/Tsukikage7/microservice-kit/logger"
 	"github.com/Tsukikage7/microservice-kit/transport"
 )
@@ -53,6 +54,12 @@ func (m *mockDiscovery) Unregister(ctx context.Context, serviceID string) error
 func (m *mockDiscovery) Discover(ctx context.Context, serviceName string) ([]string, error) {
 	return m.addrs, m.err
 }
+func (m *mockDiscovery) Instances(ctx context.Context, serviceName string) ([]*discovery.ServiceInstance, error) {
+	return nil, m.err
+}
+func (m *mockDiscovery) Watch(ctx context.Context, serviceName string) (<-chan []*discovery.ServiceInstance, error) {
+	return nil, m.err
+}
 func (m *mockDiscovery) Close() error { return nil }
 
 func TestNew(t *testing.T) {